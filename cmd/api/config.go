@@ -44,6 +44,9 @@ var (
 	redisConnString = env.MustString("REDIS_DATABASE_URL")
 	redisPoolSize   = env.GetInt("REDIS_POOL_SIZE", 10)
 
+	// Event bus
+	eventsConsumerGroup = env.GetString("EVENTS_CONSUMER_GROUP", "listeners") // replicas of the group share the events
+
 	// Auth
 	oauthSigningKey = env.MustString("OAUTH_SIGNING_KEY")
 	accessTokenTTL  = env.GetDuration("ACCESS_TOKEN_TTL", time.Minute*5)
@@ -51,18 +54,40 @@ var (
 	clientID        = env.MustString("CLIENT_ID")
 	clientSecret    = env.MustString("CLIENT_SECRET")
 
+	// Websocket subscription tokens of the payment events
+	wsTokenSecret = env.GetString("WS_TOKEN_SECRET", "") // the OAuth signing key is used if empty
+	wsTokenTTL    = env.GetDuration("WS_TOKEN_TTL", 15*time.Minute)
+
+	// Events outbox
+	outboxPollInterval = env.GetDuration("OUTBOX_POLL_INTERVAL", 500*time.Millisecond)
+	outboxBatchSize    = env.GetInt("OUTBOX_BATCH_SIZE", 100)
+
+	// Bulk expiry of the payments and transactions
+	expiryBatchSize     = env.GetInt("EXPIRY_BATCH_SIZE", 100)
+	expiryBatchInterval = env.GetDuration("EXPIRY_BATCH_INTERVAL", time.Second)
+
 	// Worker
 	workerConcurrency = env.GetInt("WORKER_CONCURRENCY", 10)
 	queueName         = env.GetString("QUEUE_NAME", "default")
 
 	// Webhook
-	webhookSignatureSecret = env.MustBytes("WEBHOOK_SIGNATURE_SECRET")
-	webhookURI             = env.MustString("WEBHOOK_URI")
+	webhookSignatureSecret = env.GetBytes("WEBHOOK_SIGNATURE_SECRET", nil)          // signs payloads sent to WEBHOOK_URI
+	webhookURI             = env.GetString("WEBHOOK_URI", "")                       // optional default endpoint subscribed to all events
+	webhookPreviousSecret  = env.GetBytes("WEBHOOK_PREVIOUS_SIGNATURE_SECRET", nil) // set while rotating WEBHOOK_SIGNATURE_SECRET
+	webhookAPIVersion      = env.GetString("WEBHOOK_API_VERSION", "2023-03-10")     // payload version of WEBHOOK_URI
+	webhookFailureLimit    = env.GetInt("WEBHOOK_FAILURE_THRESHOLD", 5)             // consecutive failures to pause an endpoint
+	webhookPauseDuration   = env.GetDuration("WEBHOOK_PAUSE_DURATION", 5*time.Minute)
+	webhookDisableAfter    = env.GetDuration("WEBHOOK_DISABLE_AFTER", 72*time.Hour) // disable endpoints failing longer than this
 
 	// Solana
 	solanaRPCEndpoint = env.GetString("SOLANA_RPC_ENDPOINT", "https://api.devnet.solana.com")
 	solanaWSSEndpoint = env.GetString("SOLANA_WSS_ENDPOINT", "wss://api.devnet.solana.com")
 	solanaPayBaseURI  = env.GetString("SOLANA_PAY_BASE_URI", "https://checkout-api.easypmnt.com/payment/checkout/")
+	tokenListPath     = env.GetString("TOKEN_LIST_PATH", "https://raw.githubusercontent.com/solana-labs/token-list/main/src/tokens/solana.tokenlist.json")
+
+	// Token registry
+	tokenRegistryRefreshInterval = env.GetDuration("TOKEN_REGISTRY_REFRESH_INTERVAL", time.Hour)
+	supportedMints               = env.GetStrings("SUPPORTED_MINTS", ",", []string{})
 
 	// Merchant
 	merchantWalletAddress      = env.MustString("MERCHANT_WALLET_ADDRESS")
@@ -73,5 +98,28 @@ var (
 	bonusMintAddress           = env.GetString("BONUS_MINT_ADDRESS", "")
 	bonusMintAuthority         = env.GetString("BONUS_MINT_AUTHORITY", "")
 	bonusRate                  = env.GetInt[int64]("BONUS_RATE", 100)
+	bonusRates                 = env.GetString("BONUS_RATES", "")            // JSON object of accrual rates by destination mint, e.g. {"SOL":150,"USDC":100}
+	bonusValueBasis            = env.GetString("BONUS_VALUE_BASIS", "token") // token or usd
 	paymentTTL                 = env.GetDuration("PAYMENT_TTL", time.Minute*15)
+	simulateTransactions       = env.GetBool("SIMULATE_TRANSACTIONS", true)
+
+	// Gasless checkout
+	sponsorAccount       = env.GetString("SPONSOR_ACCOUNT", "")            // fee payer private key, sponsorship is disabled if empty
+	sponsorMaxPerPayment = env.GetInt[int64]("SPONSOR_MAX_PER_PAYMENT", 0) // lamports, 0 = unlimited
+	sponsorDailyBudget   = env.GetInt[int64]("SPONSOR_DAILY_BUDGET", 0)    // lamports, 0 = unlimited
+
+	// Affiliate program
+	affiliatePayoutAccount = env.GetString("AFFILIATE_PAYOUT_ACCOUNT", "") // private key to pay out accrued commissions, payouts are disabled if empty
+
+	// Loyalty program
+	loyaltyTiers = env.GetString("LOYALTY_TIERS", "") // JSON array of tiers ordered from the lowest level, e.g. [{"name":"bronze","accrue_bonus_rates":{"USDC":100}},{"name":"silver","min_spend":{"USDC":100000000},"accrue_bonus_rates":{"USDC":150},"max_apply_bonus_amounts":{"USDC":50000000}}]
+
+	// Receipt NFTs
+	receiptAuthority   = env.GetString("RECEIPT_AUTHORITY", "") // merchant private key to mint receipts, receipts are disabled if empty
+	receiptName        = env.GetString("RECEIPT_NAME", "Receipt")
+	receiptSymbol      = env.GetString("RECEIPT_SYMBOL", "RCPT")
+	receiptDescription = env.GetString("RECEIPT_DESCRIPTION", "")
+	receiptImageURI    = env.GetString("RECEIPT_IMAGE_URI", "") // absolute URI to receipt image
+	receiptExternalURL = env.GetString("RECEIPT_EXTERNAL_URL", "")
+	arweaveWalletKey   = env.GetString("ARWEAVE_WALLET_KEY", "") // JSON wallet key to upload receipt metadata
 )
@@ -3,22 +3,29 @@ package main
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"github.com/easypmnt/checkout-api/arweave"
 	"github.com/easypmnt/checkout-api/auth"
 	"github.com/easypmnt/checkout-api/events"
 	"github.com/easypmnt/checkout-api/internal/kitlog"
 	"github.com/easypmnt/checkout-api/jupiter"
+	"github.com/easypmnt/checkout-api/loyalty"
+	"github.com/easypmnt/checkout-api/outbox"
 	"github.com/easypmnt/checkout-api/payments"
+	"github.com/easypmnt/checkout-api/receipt"
 	"github.com/easypmnt/checkout-api/repository"
 	"github.com/easypmnt/checkout-api/server"
 	"github.com/easypmnt/checkout-api/solana"
+	"github.com/easypmnt/checkout-api/timeline"
 	"github.com/easypmnt/checkout-api/webhook"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/oauth"
+	"github.com/go-redis/redis/v8"
 	"github.com/hibiken/asynq"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/sync/errgroup"
@@ -64,15 +71,23 @@ func main() {
 		logger.WithError(err).Fatal("failed to init repository")
 	}
 
-	// Init event emitter
-	eventEmitter := events.NewEmitter(logger)
-
 	// Redis connect options for asynq client
 	redisConnOpt, err := asynq.ParseRedisURI(redisConnString)
 	if err != nil {
 		logger.WithError(err).Fatal("failed to parse redis connection string")
 	}
 
+	// Init event bus shared by all the replicas
+	redisClient, ok := redisConnOpt.MakeRedisClient().(redis.UniversalClient)
+	if !ok {
+		logger.Fatal("failed to init redis client")
+	}
+	defer redisClient.Close()
+	// Each event is handled by one of the replicas
+	eventEmitter := events.NewRedisEmitter(redisClient, logger, events.WithConsumerGroup(eventsConsumerGroup, ""))
+	// Every replica gets all the events to broadcast them to its websocket clients
+	broadcastEmitter := events.NewRedisEmitter(redisClient, logger)
+
 	// Init asynq client
 	asynqClient := asynq.NewClient(redisConnOpt)
 	defer asynqClient.Close()
@@ -80,11 +95,23 @@ func main() {
 	// Init Solana client
 	solClient := solana.NewClient(
 		solana.WithRPCEndpoint(solanaRPCEndpoint),
+		solana.WithTokenListPath(tokenListPath),
 	)
 
 	// Init Jupiter client
 	jupiterClient := jupiter.NewClient()
 
+	// Init token registry
+	tokenRegistry, err := payments.NewTokenRegistry(
+		solClient, jupiterClient, merchantDefaultMint,
+		payments.WithSupportedMints(supportedMints...),
+		payments.WithRefreshInterval(tokenRegistryRefreshInterval),
+		payments.WithRegistryLogger(logger),
+	)
+	if err != nil {
+		logger.WithError(err).Fatal("failed to init token registry")
+	}
+
 	// Init HTTP router
 	r := initRouter(logger)
 
@@ -103,41 +130,133 @@ func main() {
 	// 	websocketrpc.WithEventsEmitter(eventEmitter),
 	// )
 
+	// Loyalty ledger
+	var tiers []loyalty.Tier
+	if loyaltyTiers != "" {
+		if err := json.Unmarshal([]byte(loyaltyTiers), &tiers); err != nil {
+			logger.WithError(err).Fatal("failed to parse loyalty tiers")
+		}
+	}
+	// The spend thresholds, accrual rates and discount caps are set by symbol or mint address,
+	// the spend is counted and the rules are applied by mint address.
+	byMintAddress := func(tier string, amounts map[string]uint64) map[string]uint64 {
+		result := make(map[string]uint64, len(amounts))
+		for currency, amount := range amounts {
+			mint, err := tokenRegistry.MintAddress(currency, "")
+			if err != nil {
+				logger.WithError(err).Fatalf("failed to resolve mint of loyalty tier %s", tier)
+			}
+			result[mint] = amount
+		}
+		return result
+	}
+	for i, t := range tiers {
+		tiers[i].MinSpend = byMintAddress(t.Name, t.MinSpend)
+		tiers[i].AccrueBonusRates = byMintAddress(t.Name, t.AccrueBonusRates)
+		tiers[i].MaxApplyBonusAmounts = byMintAddress(t.Name, t.MaxApplyBonusAmounts)
+	}
+	loyaltyService := loyalty.NewService(
+		repo, solClient, bonusMintAddress,
+		loyalty.WithTiers(tiers...),
+		loyalty.WithEventEmitter(eventEmitter.Emit),
+	)
+
+	var accrueBonusRates map[string]uint64
+	if bonusRates != "" {
+		if err := json.Unmarshal([]byte(bonusRates), &accrueBonusRates); err != nil {
+			logger.WithError(err).Fatal("failed to parse bonus rates")
+		}
+	}
+
 	var paymentService payments.PaymentService
 	// Payment service
-	paymentService = payments.NewService(
+	paymentService, err = payments.NewService(
 		repo, solClient, jupiterClient,
 		payments.Config{
-			ApplyBonus:           merchantApplyBonus,
-			BonusMintAddress:     bonusMintAddress,
-			BonusAuthAccount:     bonusMintAuthority,
-			MaxApplyBonusAmount:  uint64(maxApplyBonusAmount),
-			MaxApplyBonusPercent: uint16(merchantMaxBonusPercentage),
-			AccrueBonus:          bonusRate > 0,
-			AccrueBonusRate:      uint64(bonusRate),
-			DestinationMint:      merchantDefaultMint,
-			DestinationWallet:    merchantWalletAddress,
-			PaymentTTL:           paymentTTL,
-			SolPayBaseURL:        solanaPayBaseURI,
+			ApplyBonus:             merchantApplyBonus,
+			BonusMintAddress:       bonusMintAddress,
+			BonusAuthAccount:       bonusMintAuthority,
+			MaxApplyBonusAmount:    uint64(maxApplyBonusAmount),
+			MaxApplyBonusPercent:   uint16(merchantMaxBonusPercentage),
+			AccrueBonus:            bonusRate > 0,
+			AccrueBonusRate:        uint64(bonusRate),
+			AccrueBonusRates:       accrueBonusRates,
+			BonusValueBasis:        payments.BonusValueBasis(bonusValueBasis),
+			DestinationMint:        merchantDefaultMint,
+			DestinationWallet:      merchantWalletAddress,
+			PaymentTTL:             paymentTTL,
+			SolPayBaseURL:          solanaPayBaseURI,
+			SimulateTransaction:    simulateTransactions,
+			SponsorAccount:         sponsorAccount,
+			SponsorMaxPerPayment:   uint64(sponsorMaxPerPayment),
+			SponsorDailyBudget:     uint64(sponsorDailyBudget),
+			AffiliatePayoutAccount: affiliatePayoutAccount,
 		},
+		payments.WithTokenRegistry(tokenRegistry),
+		payments.WithLoyaltyLedger(loyaltyService),
+		payments.WithLoyaltyTiers(loyaltyService),
+		payments.WithCoupons(db),
+		payments.WithGiftCards(db),
+		payments.WithAffiliates(db),
+		payments.WithOutbox(db),
+		payments.WithExpiryBatches(expiryBatchSize, expiryBatchInterval),
 	)
-	// Events decorator
-	paymentService = payments.NewServiceEvents(paymentService, eventEmitter.Emit)
+	if err != nil {
+		logger.WithError(err).Fatal("failed to init payments service")
+	}
 	// Logging decorator
 	paymentService = payments.NewServiceLogger(paymentService, logger)
 
+	// Webhook service
+	if webhookURI != "" && len(webhookSignatureSecret) == 0 {
+		logger.Fatal("WEBHOOK_SIGNATURE_SECRET is required when WEBHOOK_URI is set")
+	}
+	if !webhook.IsSupportedAPIVersion(webhookAPIVersion) {
+		logger.Fatalf("unsupported WEBHOOK_API_VERSION: %s", webhookAPIVersion)
+	}
+	webhookService := webhook.NewService(
+		webhook.WithSignatureSecret(webhookSignatureSecret),
+		webhook.WithPreviousSignatureSecret(webhookPreviousSecret),
+		webhook.WithWebhookURI(webhookURI),
+		webhook.WithAPIVersion(webhookAPIVersion),
+		webhook.WithRepository(repo),
+		webhook.WithPaymentSource(paymentService),
+		webhook.WithCircuitBreaker(webhookFailureLimit, webhookPauseDuration, webhookDisableAfter),
+		webhook.WithEventEmitter(eventEmitter.Emit),
+		webhook.WithLogger(logger),
+	)
+
 	// Init sse service
 	// sseService := sse.NewService(sse.NewMemStorage())
 
 	// Event listener
 	eventEmitter.On(events.TransactionUpdated, payments.UpdateTransactionStatusListener(paymentService))
 	eventEmitter.On(events.TransactionCreated, payments.TransactionCreatedListener(paymentService, paymentEnqueuer))
+	eventEmitter.On(events.TransactionSubmitted, payments.TransactionSubmittedListener(paymentEnqueuer))
 	eventEmitter.On(
 		events.TransactionReferenceNotification,
 		payments.ReferenceAccountNotificationListener(paymentService, paymentEnqueuer),
 	)
+	// Receipt NFTs are minted to the payer after the payment succeeds, if enabled
+	var receiptWorker *receipt.Worker
+	if receiptAuthority != "" {
+		receiptService := receipt.NewService(
+			repo, solClient,
+			arweave.NewClient(arweave.InitWalletWithPrivateKey([]byte(arweaveWalletKey))),
+			receipt.Config{
+				Authority:   receiptAuthority,
+				Name:        receiptName,
+				Symbol:      receiptSymbol,
+				Description: receiptDescription,
+				ImageURI:    receiptImageURI,
+				ExternalURL: receiptExternalURL,
+			},
+		)
+		receiptWorker = receipt.NewWorker(receiptService, logger)
+		eventEmitter.On(events.PaymentSucceeded, receipt.PaymentSucceededListener(receipt.NewEnqueuer(asynqClient)))
+	}
 	eventEmitter.ListenEvents(
-		webhook.TranslateEventsToWebhookEvents(webhookEnqueuer),
+		webhook.TranslateEventsToWebhookEvents(webhookEnqueuer, webhookService),
 		events.AllEvents...,
 	)
 	// eventEmitter.ListenEvents(
@@ -146,7 +265,15 @@ func main() {
 	// )
 
 	// Event broadcaster
-	eventBroadcaster := events.NewEventBroadcaster(eventEmitter, logger)
+	if wsTokenSecret == "" {
+		wsTokenSecret = oauthSigningKey
+	}
+	subscriptionTokens := events.NewSubscriptionTokens([]byte(wsTokenSecret), wsTokenTTL)
+	eventBroadcaster := events.NewEventBroadcaster(
+		broadcastEmitter, subscriptionTokens, logger,
+		events.WithAllowedOrigins(corsAllowedOrigins...),
+		events.WithSnapshot(payments.PaymentSnapshot(paymentService)),
+	)
 
 	// Mount HTTP endpoints
 	{
@@ -167,51 +294,86 @@ func main() {
 			))
 
 		// payment service
+		endpoints := server.MakeEndpoints(
+			paymentService,
+			jupiterClient,
+			tokenRegistry,
+			loyaltyService,
+			webhookService,
+			timeline.NewService(paymentService, webhookService),
+			subscriptionTokens,
+			server.Config{
+				AppName:    productName,
+				AppIconURI: productIconURI,
+			},
+		)
 		r.With(middleware.Timeout(httpRequestTimeout)).
-			Mount("/payment", server.MakeHTTPHandler(
-				server.MakeEndpoints(
-					paymentService,
-					jupiterClient,
-					server.Config{
-						AppName:    productName,
-						AppIconURI: productIconURI,
-					},
-				),
-				kitlog.NewLogger(logger),
-				oauthMdw,
-			))
+			Mount("/payment", server.MakeHTTPHandler(endpoints, kitlog.NewLogger(logger), oauthMdw))
+
+		// loyalty wallets
+		r.With(middleware.Timeout(httpRequestTimeout)).
+			Mount("/loyalty", server.MakeLoyaltyHTTPHandler(endpoints, kitlog.NewLogger(logger), oauthMdw))
 
-		// sse service
+		// webhook delivery log
+		r.With(middleware.Timeout(httpRequestTimeout)).
+			Mount("/webhooks/deliveries", server.MakeWebhookDeliveriesHTTPHandler(endpoints, kitlog.NewLogger(logger), oauthMdw))
+
+		// websocket events
 		r.With(middleware.Timeout(time.Hour)).
-			Mount("/ws", events.MakeHTTPHandler(eventBroadcaster))
+			Mount("/ws", events.MakeHTTPHandler(eventBroadcaster, oauthMdw))
 	}
 
 	// Run HTTP server
 	eg.Go(runServer(ctx, httpPort, r, logger))
 
 	// Run asynq worker
-	eg.Go(runQueueServer(
-		redisConnOpt,
-		logger,
+	taskHandlers := []taskHandler{
 		payments.NewWorker(paymentService, solClient, paymentEnqueuer),
-		webhook.NewWorker(webhook.NewService(
-			webhook.WithSignatureSecret(webhookSignatureSecret),
-			webhook.WithWebhookURI(webhookURI),
-		)),
-	))
+		loyalty.NewWorker(loyaltyService, logger),
+		webhook.NewWorker(webhookService),
+	}
+	if receiptWorker != nil {
+		taskHandlers = append(taskHandlers, receiptWorker)
+	}
+	eg.Go(runQueueServer(redisConnOpt, logger, taskHandlers...))
 
 	// Run asynq scheduler
 	eg.Go(runScheduler(
 		redisConnOpt,
 		logger,
 		payments.NewScheduler(),
+		loyalty.NewScheduler(),
+		webhook.NewScheduler(),
 	))
 
+	// Run events outbox relay
+	eg.Go(func() error {
+		return outbox.NewRelay(
+			db, repo, eventEmitter,
+			outbox.WithPollInterval(outboxPollInterval),
+			outbox.WithBatchSize(outboxBatchSize),
+			outbox.WithLogger(logger),
+		).Run(ctx)
+	})
+
+	// Run event bus consumers
+	eg.Go(func() error {
+		return eventEmitter.Run(ctx)
+	})
+	eg.Go(func() error {
+		return broadcastEmitter.Run(ctx)
+	})
+
 	// Run event broadcaster
 	eg.Go(func() error {
 		return eventBroadcaster.Run(ctx)
 	})
 
+	// Run token registry refresher
+	eg.Go(func() error {
+		return tokenRegistry.Run(ctx)
+	})
+
 	// Run event listener
 	// eg.Go(func() error {
 	// 	return websocketrpcClient.Run(ctx)
@@ -1,6 +1,9 @@
 package main
 
 import (
+	"context"
+
+	"github.com/easypmnt/checkout-api/events"
 	"github.com/hibiken/asynq"
 )
 
@@ -35,6 +38,13 @@ func runQueueServer(redisConnOpt asynq.RedisConnOpt, log asynq.Logger, handlers
 func registerQueueHandlers(handlers ...taskHandler) *asynq.ServeMux {
 	mux := asynq.NewServeMux()
 
+	// The events recorded by the task handlers are attributed to the task type
+	mux.Use(func(next asynq.Handler) asynq.Handler {
+		return asynq.HandlerFunc(func(ctx context.Context, t *asynq.Task) error {
+			return next.ProcessTask(events.WithSource(ctx, events.TaskSource(t.Type())), t)
+		})
+	})
+
 	// Register handlers
 	for _, h := range handlers {
 		h.Register(mux)
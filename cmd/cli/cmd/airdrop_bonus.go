@@ -0,0 +1,435 @@
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/easypmnt/checkout-api/internal/utils"
+	"github.com/easypmnt/checkout-api/payments"
+	"github.com/easypmnt/checkout-api/solana"
+	"github.com/fatih/color"
+	"github.com/portto/solana-go-sdk/types"
+	"github.com/spf13/cobra"
+)
+
+// airdropBonusCmd represents the airdropBonus command
+var airdropBonusCmd = &cobra.Command{
+	Use:     "airdrop-bonus",
+	Aliases: []string{"ab", "airdrop"},
+	Short:   "Mints bonus tokens to the wallets from a CSV file",
+	Long: `
+Mints bonus tokens to the wallets listed in the CSV file. Each line of the file
+contains the wallet address and the amount in base units of the token,
+e.g. "5YNmS1R9nNSCDzb5a7mMJ1dwK9uHeAAF4CmPEwKgVWr8,1000000000". The header line
+is optional. The output of the bonus-holders command can be used as is.
+
+The wallets are minted to in batches, one transaction per batch. A failed batch
+is retried, a batch is treated as failed only when its transaction can't land
+anymore, so nobody is paid twice. The progress is stored in the progress file
+after each step, so the interrupted airdrop is resumed by running the same command
+again. Don't remove the progress file until the airdrop is completed.
+	`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, mint, err := bonusToken(cmd)
+		if err != nil {
+			return err
+		}
+		batchSize, err := cmd.Flags().GetInt("batch-size")
+		if err != nil {
+			return fmt.Errorf("batch-size: %w", err)
+		}
+		if batchSize < 1 || batchSize > airdropMaxBatchSize {
+			return fmt.Errorf("batch-size must be between 1 and %d", airdropMaxBatchSize)
+		}
+		retries, err := cmd.Flags().GetInt("retries")
+		if err != nil {
+			return fmt.Errorf("retries: %w", err)
+		}
+
+		mintAuth, feePayer, err := signerAccounts(
+			cmd.Flag("mint-authority").Value.String(),
+			cmd.Flag("fee-payer").Value.String(),
+		)
+		if err != nil {
+			return err
+		}
+
+		file := cmd.Flag("file").Value.String()
+		if file == "" {
+			return fmt.Errorf("file is required")
+		}
+		rows, err := readAirdropFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read airdrop file: %w", err)
+		}
+
+		progressFile := cmd.Flag("progress-file").Value.String()
+		if progressFile == "" {
+			progressFile = file + ".progress.json"
+		}
+		progress, err := loadAirdropProgress(progressFile, mint)
+		if err != nil {
+			return err
+		}
+
+		a := &airdrop{
+			client:    client,
+			mint:      mint,
+			mintAuth:  mintAuth,
+			feePayer:  feePayer,
+			retries:   retries,
+			batchSize: batchSize,
+			progress:  progress,
+		}
+
+		return a.run(cmd.Context(), rows)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(airdropBonusCmd)
+
+	addBonusTokenFlags(airdropBonusCmd)
+	airdropBonusCmd.Flags().String("mint-authority", os.Getenv("BONUS_MINT_AUTHORITY"), "Base58 encoded private key of the mint authority (default is BONUS_MINT_AUTHORITY env).")
+	airdropBonusCmd.Flags().String("fee-payer", "", "Base58 encoded private key of the fee payer (optional), the mint authority pays if empty.")
+	airdropBonusCmd.Flags().String("file", "", "Path to the CSV file with wallets and amounts.")
+	airdropBonusCmd.Flags().String("progress-file", "", "Path to the progress file (default is the CSV file path with .progress.json suffix).")
+	airdropBonusCmd.Flags().Int("batch-size", 8, "Number of wallets minted to by a single transaction.")
+	airdropBonusCmd.Flags().Int("retries", 3, "Number of retries of a failed batch.")
+}
+
+// airdropMaxBatchSize is the max number of wallets which fit into a single transaction
+// with creation of the associated token accounts.
+const airdropMaxBatchSize = 10
+
+// airdropRow represents the single line of the airdrop file.
+type airdropRow struct {
+	Line   int
+	Wallet string
+	Amount uint64
+	key    string
+}
+
+// readAirdropFile reads and validates the airdrop CSV file.
+// Each row gets a key which doesn't depend on the line number,
+// so the progress is not lost if the file is sorted or extended.
+func readAirdropFile(path string) ([]airdropRow, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	var (
+		rows  []airdropRow
+		seen  = make(map[string]int)
+		total uint64
+	)
+	for line := 1; ; line++ {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(record) < 2 {
+			return nil, fmt.Errorf("line %d: wallet and amount are required", line)
+		}
+
+		wallet, value := strings.TrimSpace(record[0]), strings.TrimSpace(record[1])
+		amount, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			if line == 1 {
+				continue // header
+			}
+			return nil, fmt.Errorf("line %d: invalid amount %q", line, value)
+		}
+		if !payments.IsMintAddress(wallet) {
+			return nil, fmt.Errorf("line %d: invalid wallet address %q", line, wallet)
+		}
+		if amount == 0 {
+			continue
+		}
+
+		key := fmt.Sprintf("%s:%d", wallet, amount)
+		seen[key]++
+		rows = append(rows, airdropRow{
+			Line:   line,
+			Wallet: wallet,
+			Amount: amount,
+			key:    fmt.Sprintf("%s:%d", key, seen[key]),
+		})
+		total += amount
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("no wallets to airdrop to")
+	}
+
+	color.Yellow("Loaded %d wallets, %d tokens in total (base units)", len(rows), total)
+	return rows, nil
+}
+
+// airdropTransaction represents the sent airdrop batch transaction.
+type airdropTransaction struct {
+	Signature string    `json:"signature"`
+	Blockhash string    `json:"blockhash"` // the transaction can't land after the blockhash is expired
+	Confirmed bool      `json:"confirmed"`
+	SentAt    time.Time `json:"sent_at"`
+}
+
+// airdropProgress represents the state of the airdrop stored in the progress file.
+type airdropProgress struct {
+	path string
+
+	Mint         string                         `json:"mint"`
+	Transactions map[string]*airdropTransaction `json:"transactions"` // by signature
+	Rows         map[string]string              `json:"rows"`         // row key => transaction signature
+}
+
+// loadAirdropProgress loads the progress file or creates a new one if it doesn't exist.
+func loadAirdropProgress(path, mint string) (*airdropProgress, error) {
+	p := &airdropProgress{
+		path:         path,
+		Mint:         mint,
+		Transactions: make(map[string]*airdropTransaction),
+		Rows:         make(map[string]string),
+	}
+
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return p, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read progress file: %w", err)
+	}
+	if err := json.Unmarshal(b, p); err != nil {
+		return nil, fmt.Errorf("failed to parse progress file: %w", err)
+	}
+	if p.Mint != mint {
+		return nil, fmt.Errorf("progress file %s belongs to the airdrop of another mint: %s", path, p.Mint)
+	}
+
+	color.Yellow("Resuming the airdrop from %s", path)
+	return p, nil
+}
+
+// save writes the progress file atomically, so it's never left half-written.
+func (p *airdropProgress) save() error {
+	b, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode progress: %w", err)
+	}
+	tmp := p.path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o600); err != nil {
+		return fmt.Errorf("failed to write progress file: %w", err)
+	}
+	if err := os.Rename(tmp, p.path); err != nil {
+		return fmt.Errorf("failed to write progress file: %w", err)
+	}
+	return nil
+}
+
+// sent records the batch transaction before it's sent.
+func (p *airdropProgress) sent(tx *airdropTransaction, batch []airdropRow) error {
+	p.Transactions[tx.Signature] = tx
+	for _, row := range batch {
+		p.Rows[row.key] = tx.Signature
+	}
+	return p.save()
+}
+
+// settled records the final result of the batch transaction.
+// The rows of the dropped or failed transaction are released to be minted again.
+func (p *airdropProgress) settled(signature string, confirmed bool) error {
+	if confirmed {
+		p.Transactions[signature].Confirmed = true
+		return p.save()
+	}
+
+	delete(p.Transactions, signature)
+	for key, sig := range p.Rows {
+		if sig == signature {
+			delete(p.Rows, key)
+		}
+	}
+	return p.save()
+}
+
+// airdrop mints bonus tokens to the wallets batch by batch.
+type airdrop struct {
+	client    *solana.Client
+	mint      string
+	mintAuth  types.Account
+	feePayer  types.Account
+	retries   int
+	batchSize int
+	progress  *airdropProgress
+}
+
+// run settles the transactions left by the previous run and airdrops to the rest of the rows.
+func (a *airdrop) run(ctx context.Context, rows []airdropRow) error {
+	for sig, tx := range a.progress.Transactions {
+		if tx.Confirmed {
+			continue
+		}
+		color.Yellow("Checking transaction %s of the previous run...", sig)
+		if err := a.settle(ctx, tx); err != nil {
+			return err
+		}
+	}
+
+	pending := make([]airdropRow, 0, len(rows))
+	for _, row := range rows {
+		if _, ok := a.progress.Rows[row.key]; !ok {
+			pending = append(pending, row)
+		}
+	}
+	if len(pending) == 0 {
+		color.Green("All %d wallets have already received the airdrop", len(rows))
+		return nil
+	}
+	color.Yellow("%d of %d wallets are waiting for the airdrop", len(pending), len(rows))
+
+	for start := 0; start < len(pending); start += a.batchSize {
+		end := start + a.batchSize
+		if end > len(pending) {
+			end = len(pending)
+		}
+		batch := pending[start:end]
+
+		if err := a.airdropBatch(ctx, batch); err != nil {
+			return fmt.Errorf("lines %d-%d: %w, run the command again to resume the airdrop",
+				batch[0].Line, batch[len(batch)-1].Line, err)
+		}
+		color.Green("Airdropped %d of %d wallets", end, len(pending))
+	}
+
+	color.Green("Airdrop completed! Progress is stored in %s", a.progress.path)
+	return nil
+}
+
+// airdropBatch mints the tokens to the batch of wallets, retrying if the transaction didn't land.
+func (a *airdrop) airdropBatch(ctx context.Context, batch []airdropRow) error {
+	var lastErr error
+	for attempt := 0; attempt <= a.retries; attempt++ {
+		if attempt > 0 {
+			color.Yellow("Retrying the batch (%d/%d) after error: %v", attempt, a.retries, lastErr)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Duration(attempt) * 2 * time.Second):
+			}
+		}
+
+		tx, txSource, err := a.buildBatch(ctx, batch)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		// The transaction is recorded before it's sent, so it's checked on resume
+		// even if the process is killed right after sending.
+		if err := a.progress.sent(tx, batch); err != nil {
+			return err
+		}
+		if _, err := a.client.SendTransaction(ctx, txSource); err != nil {
+			color.Red("Failed to send transaction %s: %v", tx.Signature, err)
+		}
+
+		if err := a.settle(ctx, tx); err != nil {
+			return err
+		}
+		if tx.Confirmed {
+			color.Green("Transaction confirmed: https://explorer.solana.com/tx/%s", tx.Signature)
+			return nil
+		}
+		lastErr = fmt.Errorf("transaction %s didn't land", tx.Signature)
+	}
+
+	return lastErr
+}
+
+// buildBatch builds and signs the transaction which mints the tokens to the batch of wallets.
+func (a *airdrop) buildBatch(ctx context.Context, batch []airdropRow) (*airdropTransaction, string, error) {
+	builder := solana.NewTransactionBuilder(a.client).
+		SetFeePayer(a.feePayer.PublicKey.ToBase58()).
+		AddSigner(a.feePayer).
+		AddSigner(a.mintAuth)
+	for _, row := range batch {
+		builder.AddInstruction(solana.MintFungibleToken(solana.MintFungibleTokenParams{
+			Funder:    a.feePayer.PublicKey.ToBase58(),
+			Mint:      a.mint,
+			MintOwner: a.mintAuth.PublicKey.ToBase58(),
+			MintTo:    row.Wallet,
+			Amount:    row.Amount,
+		}))
+	}
+
+	txSource, err := builder.Build(ctx)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to build transaction: %w", err)
+	}
+	tx, err := solana.DecodeTransaction(txSource)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decode transaction: %w", err)
+	}
+	if len(tx.Signatures) == 0 {
+		return nil, "", fmt.Errorf("transaction is not signed")
+	}
+
+	return &airdropTransaction{
+		Signature: utils.BytesToBase58(tx.Signatures[0]),
+		Blockhash: tx.Message.RecentBlockHash,
+		SentAt:    time.Now(),
+	}, txSource, nil
+}
+
+// settle waits until the transaction is finalized, failed or can't land anymore
+// because of the expired blockhash, and records the result to the progress file.
+func (a *airdrop) settle(ctx context.Context, tx *airdropTransaction) error {
+	tick := time.NewTicker(5 * time.Second)
+	defer tick.Stop()
+
+	for {
+		status, err := a.client.GetTransactionStatus(ctx, tx.Signature)
+		switch {
+		case status == solana.TransactionStatusFailure:
+			color.Red("Transaction %s failed: %v", tx.Signature, err)
+			return a.progress.settled(tx.Signature, false)
+		case err != nil:
+			return fmt.Errorf("failed to get transaction status: %w", err)
+		case status == solana.TransactionStatusSuccess:
+			return a.progress.settled(tx.Signature, true)
+		case status == solana.TransactionStatusUnknown:
+			valid, err := a.client.IsBlockhashValid(ctx, tx.Blockhash)
+			if err != nil {
+				return err
+			}
+			if !valid {
+				// The transaction could land right before the blockhash expired.
+				if status, err = a.client.GetTransactionStatus(ctx, tx.Signature); err == nil && status == solana.TransactionStatusUnknown {
+					return a.progress.settled(tx.Signature, false)
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-tick.C:
+		}
+	}
+}
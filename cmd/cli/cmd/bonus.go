@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/easypmnt/checkout-api/payments"
+	"github.com/easypmnt/checkout-api/solana"
+	"github.com/fatih/color"
+	"github.com/portto/solana-go-sdk/types"
+	"github.com/spf13/cobra"
+)
+
+// addBonusTokenFlags registers the flags shared by the bonus token commands.
+func addBonusTokenFlags(cmd *cobra.Command) {
+	cmd.Flags().String("solana-rpc-endpoint", "https://api.devnet.solana.com", "Solana RPC endpoint URL.")
+	cmd.Flags().String("mint", os.Getenv("BONUS_MINT_ADDRESS"), "Bonus token mint address (default is BONUS_MINT_ADDRESS env).")
+}
+
+// bonusToken returns the solana client and the bonus token mint address from the command flags.
+func bonusToken(cmd *cobra.Command) (*solana.Client, string, error) {
+	endpoint := cmd.Flag("solana-rpc-endpoint").Value.String()
+	if endpoint == "" {
+		return nil, "", fmt.Errorf("solana-rpc-endpoint is required")
+	}
+	mint := cmd.Flag("mint").Value.String()
+	if !payments.IsMintAddress(mint) {
+		return nil, "", fmt.Errorf("mint must be a valid token mint address")
+	}
+
+	return solana.NewClient(solana.WithRPCEndpoint(endpoint)), mint, nil
+}
+
+// signerAccounts parses the base58 encoded private key of the signer and the fee payer.
+// The signer pays the fees if the fee payer is not set.
+func signerAccounts(signerKey, feePayerKey string) (signer, feePayer types.Account, err error) {
+	signer, err = types.AccountFromBase58(signerKey)
+	if err != nil {
+		return signer, feePayer, fmt.Errorf("failed to parse signer private key: %w", err)
+	}
+	if feePayerKey == "" {
+		return signer, signer, nil
+	}
+	feePayer, err = types.AccountFromBase58(feePayerKey)
+	if err != nil {
+		return signer, feePayer, fmt.Errorf("failed to parse fee payer: %w", err)
+	}
+
+	return signer, feePayer, nil
+}
+
+// sendAndConfirm sends the transaction built by the given builder and waits until it is finalized.
+// Returns the transaction signature or an error.
+func sendAndConfirm(ctx context.Context, client *solana.Client, builder *solana.TransactionBuilder) (string, error) {
+	color.Yellow("Building transaction...")
+	tx, err := builder.Build(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to build transaction: %w", err)
+	}
+
+	color.Yellow("Sending transaction...")
+	txSig, err := client.SendTransaction(ctx, tx)
+	if err != nil {
+		return "", fmt.Errorf("failed to send transaction: %w", err)
+	}
+
+	color.Yellow("Waiting for transaction to be confirmed...")
+	status, err := client.WaitForTransactionConfirmed(ctx, txSig, time.Minute)
+	if err != nil {
+		return txSig, fmt.Errorf("failed to wait for transaction to be confirmed: %w", err)
+	}
+	if status != solana.TransactionStatusSuccess {
+		return txSig, fmt.Errorf("transaction failed with status: %s", status)
+	}
+
+	return txSig, nil
+}
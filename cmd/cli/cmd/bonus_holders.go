@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/easypmnt/checkout-api/internal/utils"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// bonusHoldersCmd represents the bonusHolders command
+var bonusHoldersCmd = &cobra.Command{
+	Use:     "bonus-holders",
+	Aliases: []string{"bh", "holders"},
+	Short:   "Takes a snapshot of the bonus token holders",
+	Long: `
+Takes a snapshot of all the bonus token holders and their balances, sorted by
+balance in descending order. The snapshot is printed to the console or written
+as CSV (wallet,amount,ui_amount) to the output file, so it can be used as an
+input of the airdrop-bonus command.
+	`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, mint, err := bonusToken(cmd)
+		if err != nil {
+			return err
+		}
+		minBalance, err := cmd.Flags().GetUint64("min-balance")
+		if err != nil {
+			return fmt.Errorf("min-balance: %w", err)
+		}
+
+		supply, err := client.GetTokenSupply(cmd.Context(), mint)
+		if err != nil {
+			return fmt.Errorf("failed to get token supply: %w", err)
+		}
+
+		color.Yellow("Loading bonus token holders...")
+		balances, err := client.GetTokenHolders(cmd.Context(), mint)
+		if err != nil {
+			return fmt.Errorf("failed to get token holders: %w", err)
+		}
+
+		holders := make([]bonusHolder, 0, len(balances))
+		for wallet, amount := range balances {
+			if amount < minBalance {
+				continue
+			}
+			holders = append(holders, bonusHolder{Wallet: wallet, Amount: amount})
+		}
+		sort.Slice(holders, func(i, j int) bool {
+			if holders[i].Amount == holders[j].Amount {
+				return holders[i].Wallet < holders[j].Wallet
+			}
+			return holders[i].Amount > holders[j].Amount
+		})
+
+		if output := cmd.Flag("output").Value.String(); output != "" {
+			f, err := os.Create(output)
+			if err != nil {
+				return fmt.Errorf("failed to create output file: %w", err)
+			}
+			defer f.Close()
+
+			if err := writeBonusHoldersCSV(f, holders, supply.Decimals); err != nil {
+				return fmt.Errorf("failed to write output file: %w", err)
+			}
+			color.Green("Snapshot of %d holders is written to %s", len(holders), output)
+			return nil
+		}
+
+		bold := color.New(color.Bold).SprintFunc()
+		fmt.Println("---------------------------------------------------------------------------------")
+		fmt.Println(bold("Mint:    "), mint)
+		fmt.Println(bold("Supply:  "), supply.UIAmountString)
+		fmt.Println(bold("Holders: "), len(holders))
+		fmt.Println("---------------------------------------------------------------------------------")
+		for _, h := range holders {
+			fmt.Printf("%-44s  %20s\n", h.Wallet, utils.AmountToString(h.Amount, supply.Decimals))
+		}
+		fmt.Println("---------------------------------------------------------------------------------")
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(bonusHoldersCmd)
+
+	addBonusTokenFlags(bonusHoldersCmd)
+	bonusHoldersCmd.Flags().Uint64("min-balance", 1, "Skip holders with balance less than this amount in base units of the token.")
+	bonusHoldersCmd.Flags().String("output", "", "Path to the CSV file to write the snapshot to (optional), printed to the console if empty.")
+}
+
+// bonusHolder represents the bonus token holder in the snapshot.
+type bonusHolder struct {
+	Wallet string
+	Amount uint64
+}
+
+// writeBonusHoldersCSV writes the holders snapshot as CSV with header.
+func writeBonusHoldersCSV(w io.Writer, holders []bonusHolder, decimals uint8) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"wallet", "amount", "ui_amount"}); err != nil {
+		return err
+	}
+	for _, h := range holders {
+		if err := cw.Write([]string{
+			h.Wallet,
+			strconv.FormatUint(h.Amount, 10),
+			utils.AmountToString(h.Amount, decimals),
+		}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
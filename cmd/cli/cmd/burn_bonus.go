@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/easypmnt/checkout-api/solana"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// burnBonusCmd represents the burnBonus command
+var burnBonusCmd = &cobra.Command{
+	Use:     "burn-bonus",
+	Aliases: []string{"bb"},
+	Short:   "Burns bonus tokens from the wallet",
+	Long: `
+Burns the given amount of bonus tokens from the associated token account
+of the owner wallet. The amount is set in base units of the token,
+e.g. 1000000000 is 1 token with 9 decimals.
+The owner must sign the transaction, so its private key is required.
+By default the tokens are burned from the mint authority wallet.
+	`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, mint, err := bonusToken(cmd)
+		if err != nil {
+			return err
+		}
+		amount, err := cmd.Flags().GetUint64("amount")
+		if err != nil {
+			return fmt.Errorf("amount: %w", err)
+		}
+		if amount == 0 {
+			return fmt.Errorf("amount must be greater than 0")
+		}
+
+		owner, feePayer, err := signerAccounts(
+			cmd.Flag("owner").Value.String(),
+			cmd.Flag("fee-payer").Value.String(),
+		)
+		if err != nil {
+			return err
+		}
+
+		balance, err := client.GetTokenBalance(cmd.Context(), owner.PublicKey.ToBase58(), mint)
+		if err != nil {
+			return fmt.Errorf("failed to get owner balance: %w", err)
+		}
+		if balance.Amount < amount {
+			return fmt.Errorf("insufficient balance: %d, but %d is required", balance.Amount, amount)
+		}
+
+		color.Yellow("Burning %d bonus tokens of %s...", amount, owner.PublicKey.ToBase58())
+		txSig, err := sendAndConfirm(cmd.Context(), client, solana.NewTransactionBuilder(client).
+			SetFeePayer(feePayer.PublicKey.ToBase58()).
+			AddSigner(feePayer).
+			AddSigner(owner).
+			AddInstruction(solana.BurnToken(solana.BurnTokenParams{
+				Mint:              mint,
+				TokenAccountOwner: owner.PublicKey.ToBase58(),
+				Amount:            amount,
+			})),
+		)
+		if err != nil {
+			return fmt.Errorf("burn bonus: %w", err)
+		}
+
+		color.Green("Bonus tokens burned! Check it on Solana Explorer: https://explorer.solana.com/tx/%s", txSig)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(burnBonusCmd)
+
+	addBonusTokenFlags(burnBonusCmd)
+	burnBonusCmd.Flags().String("owner", os.Getenv("BONUS_MINT_AUTHORITY"), "Base58 encoded private key of the wallet to burn the tokens from (default is BONUS_MINT_AUTHORITY env).")
+	burnBonusCmd.Flags().String("fee-payer", "", "Base58 encoded private key of the fee payer (optional), the owner pays if empty.")
+	burnBonusCmd.Flags().Uint64("amount", 0, "Amount of tokens to burn in base units of the token.")
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/easypmnt/checkout-api/payments"
+	"github.com/easypmnt/checkout-api/repository"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	_ "github.com/lib/pq" // init pg driver
+)
+
+// issueGiftCardCmd represents the issueGiftCard command
+var issueGiftCardCmd = &cobra.Command{
+	Use:     "issue-gift-card",
+	Aliases: []string{"igc", "gift-card"},
+	Short:   "Issues a new gift card",
+	Long: `
+Issues a new gift card with the given balance and prints its code to the console.
+The balance is set in base units of the mint, e.g. 1000000 is 1 USDC.
+The gift card covers all or part of the payments in the same mint until its balance
+is spent or it expires. The code is generated if it's not provided.
+	`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		amount, err := cmd.Flags().GetUint64("amount")
+		if err != nil {
+			return fmt.Errorf("amount: %w", err)
+		}
+		expiresIn, err := cmd.Flags().GetDuration("expires-in")
+		if err != nil {
+			return fmt.Errorf("expires-in: %w", err)
+		}
+
+		dbURL := cmd.Flag("database-url").Value.String()
+		if dbURL == "" {
+			return fmt.Errorf("database-url is required")
+		}
+
+		db, err := sql.Open("postgres", dbURL)
+		if err != nil {
+			return fmt.Errorf("failed to open database connection: %w", err)
+		}
+		defer db.Close()
+
+		card := &payments.GiftCard{
+			Code:          cmd.Flag("code").Value.String(),
+			Mint:          cmd.Flag("mint").Value.String(),
+			InitialAmount: amount,
+			Active:        true,
+		}
+		if expiresIn > 0 {
+			expiresAt := time.Now().Add(expiresIn)
+			card.ExpiresAt = &expiresAt
+		}
+
+		svc, err := payments.NewService(repository.New(db), nil, nil, payments.Config{}, payments.WithGiftCards(db))
+		if err != nil {
+			return fmt.Errorf("init payments service: %w", err)
+		}
+		card, err = svc.IssueGiftCard(cmd.Context(), card)
+		if err != nil {
+			return fmt.Errorf("issue gift card: %w", err)
+		}
+
+		color.Green("\nNew gift card issued")
+		bold := color.New(color.Bold).SprintFunc()
+		fmt.Println("---------------------------------------------------------------------------------")
+		fmt.Println(bold("Code:    "), card.Code)
+		fmt.Println(bold("Mint:    "), card.Mint)
+		fmt.Println(bold("Balance: "), card.Balance)
+		if card.ExpiresAt != nil {
+			fmt.Println(bold("Expires: "), card.ExpiresAt.Format(time.RFC3339))
+		}
+		fmt.Println("---------------------------------------------------------------------------------")
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(issueGiftCardCmd)
+
+	issueGiftCardCmd.Flags().String("database-url", os.Getenv("DATABASE_URL"), "PostgreSQL connection string (default is DATABASE_URL env).")
+	issueGiftCardCmd.Flags().Uint64("amount", 0, "Initial balance of the gift card in base units of the mint.")
+	issueGiftCardCmd.Flags().String("mint", "USDC", "Token symbol or mint address of the gift card balance.")
+	issueGiftCardCmd.Flags().String("code", "", "Gift card code (optional), generated if empty.")
+	issueGiftCardCmd.Flags().Duration("expires-in", 0, "Gift card lifetime (optional), e.g. 720h. Never expires if 0.")
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/easypmnt/checkout-api/payments"
+	"github.com/easypmnt/checkout-api/solana"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// mintBonusCmd represents the mintBonus command
+var mintBonusCmd = &cobra.Command{
+	Use:     "mint-bonus",
+	Aliases: []string{"mb"},
+	Short:   "Mints bonus tokens to the wallet",
+	Long: `
+Mints the given amount of bonus tokens to the wallet. The amount is set in
+base units of the token, e.g. 1000000000 is 1 token with 9 decimals.
+The associated token account of the wallet is created if it doesn't exist,
+the fee payer pays for it.
+	`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, mint, err := bonusToken(cmd)
+		if err != nil {
+			return err
+		}
+		amount, err := cmd.Flags().GetUint64("amount")
+		if err != nil {
+			return fmt.Errorf("amount: %w", err)
+		}
+		if amount == 0 {
+			return fmt.Errorf("amount must be greater than 0")
+		}
+		to := cmd.Flag("to").Value.String()
+		if !payments.IsMintAddress(to) {
+			return fmt.Errorf("to must be a valid wallet address")
+		}
+
+		mintAuth, feePayer, err := signerAccounts(
+			cmd.Flag("mint-authority").Value.String(),
+			cmd.Flag("fee-payer").Value.String(),
+		)
+		if err != nil {
+			return err
+		}
+
+		color.Yellow("Minting %d bonus tokens to %s...", amount, to)
+		txSig, err := sendAndConfirm(cmd.Context(), client, solana.NewTransactionBuilder(client).
+			SetFeePayer(feePayer.PublicKey.ToBase58()).
+			AddSigner(feePayer).
+			AddSigner(mintAuth).
+			AddInstruction(solana.MintFungibleToken(solana.MintFungibleTokenParams{
+				Funder:    feePayer.PublicKey.ToBase58(),
+				Mint:      mint,
+				MintOwner: mintAuth.PublicKey.ToBase58(),
+				MintTo:    to,
+				Amount:    amount,
+			})),
+		)
+		if err != nil {
+			return fmt.Errorf("mint bonus: %w", err)
+		}
+
+		color.Green("Bonus tokens minted! Check it on Solana Explorer: https://explorer.solana.com/tx/%s", txSig)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(mintBonusCmd)
+
+	addBonusTokenFlags(mintBonusCmd)
+	mintBonusCmd.Flags().String("mint-authority", os.Getenv("BONUS_MINT_AUTHORITY"), "Base58 encoded private key of the mint authority (default is BONUS_MINT_AUTHORITY env).")
+	mintBonusCmd.Flags().String("fee-payer", "", "Base58 encoded private key of the fee payer (optional), the mint authority pays if empty.")
+	mintBonusCmd.Flags().String("to", "", "Wallet address to mint the tokens to.")
+	mintBonusCmd.Flags().Uint64("amount", 0, "Amount of tokens to mint in base units of the token.")
+}
@@ -0,0 +1,48 @@
+package events
+
+import "context"
+
+// Predefined event sources.
+const (
+	SourceAPI = "api" // the event is caused by the API request
+)
+
+type sourceContextKey struct{}
+
+// WithSource returns a copy of the context with the source of the events
+// recorded while handling it, e.g. the API request or the background task.
+func WithSource(ctx context.Context, source string) context.Context {
+	return context.WithValue(ctx, sourceContextKey{}, source)
+}
+
+// SourceFromContext returns the source of the events set with WithSource,
+// or an empty string if the source is unknown.
+func SourceFromContext(ctx context.Context) string {
+	source, _ := ctx.Value(sourceContextKey{}).(string)
+	return source
+}
+
+type eventIDContextKey struct{}
+
+// WithEventID returns a copy of the context with the ID of the recorded event passed to the listeners.
+// The ID is the same for every redelivery of the event, so the listeners can deduplicate them.
+func WithEventID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, eventIDContextKey{}, id)
+}
+
+// EventIDFromContext returns the ID of the event set with WithEventID,
+// or an empty string if the event is emitted without being recorded.
+func EventIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(eventIDContextKey{}).(string)
+	return id
+}
+
+// TaskSource returns the source of the events recorded by the background task of the given type.
+func TaskSource(taskType string) string {
+	return "task:" + taskType
+}
+
+// EventSource returns the source of the events recorded by the listener of the given event.
+func EventSource(name EventName) string {
+	return "event:" + string(name)
+}
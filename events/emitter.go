@@ -1,18 +1,27 @@
 package events
 
-import "sync"
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
 
 type (
 	// EventName is a string alias for event names.
 	EventName string
 
 	// Listener is a function that is called when an event is fired.
-	Listener func(EventName, interface{}) error
+	// The context carries the ID of the recorded event, see EventIDFromContext.
+	Listener func(context.Context, EventName, interface{}) error
 
 	// Emitter is an interface that allows to fire events.
 	Emitter interface {
 		// Emit fires an event with the given name and payload.
 		Emit(EventName, interface{})
+		// Dispatch hands the event to the listeners and returns an error if it's not handled,
+		// so the caller can retry it. The event ID set with WithEventID is passed to the listeners.
+		Dispatch(context.Context, EventName, interface{}) error
 		// On registers a listener for the given event name.
 		On(EventName, ...Listener)
 		// OnMany registers a listener for the given event names.
@@ -49,7 +58,7 @@ func (e *emitter) Emit(name EventName, payload interface{}) {
 	for _, listener := range e.listeners[name] {
 		if listener != nil {
 			go func(fn Listener, i interface{}) {
-				if err := fn(name, payload); err != nil {
+				if err := fn(context.Background(), name, payload); err != nil {
 					e.log.Errorf("failed to handle event %s: %s", name, err.Error())
 				}
 			}(listener, payload)
@@ -59,6 +68,35 @@ func (e *emitter) Emit(name EventName, payload interface{}) {
 	return
 }
 
+// Dispatch calls the listeners of the event one by one and returns their errors.
+// Unlike Emit, the caller knows if the event is handled, so it can retry the event:
+// all the listeners are called again, they must be idempotent.
+func (e *emitter) Dispatch(ctx context.Context, name EventName, payload interface{}) error {
+	e.RLock()
+	listeners := e.listeners[name]
+	e.RUnlock()
+
+	return callListeners(ctx, listeners, name, payload)
+}
+
+// callListeners calls the listeners one by one and returns their errors.
+func callListeners(ctx context.Context, listeners []Listener, name EventName, payload interface{}) error {
+	var errs []string
+	for _, listener := range listeners {
+		if listener == nil {
+			continue
+		}
+		if err := listener(ctx, name, payload); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to handle event %s: %s", name, strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
 // On registers a listener for the given event name.
 func (e *emitter) On(name EventName, listeners ...Listener) {
 	e.Lock()
@@ -1,5 +1,12 @@
 package events
 
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+)
+
 // Predefined
 const (
 	PaymentCreated                   EventName = "payment.created"
@@ -11,7 +18,10 @@ const (
 	PaymentLinkGenerated             EventName = "payment.link.generated"
 	TransactionCreated               EventName = "transaction.created"
 	TransactionUpdated               EventName = "transaction.updated"
+	TransactionSubmitted             EventName = "transaction.submitted"
 	TransactionReferenceNotification EventName = "transaction.reference.notification"
+	LoyaltyTierChanged               EventName = "loyalty.tier.changed"
+	WebhookEndpointDisabled          EventName = "webhook.endpoint.disabled"
 )
 
 var AllEvents = []EventName{
@@ -24,6 +34,8 @@ var AllEvents = []EventName{
 	PaymentLinkGenerated,
 	TransactionCreated,
 	TransactionUpdated,
+	LoyaltyTierChanged,
+	WebhookEndpointDisabled,
 }
 
 // Event payloads.
@@ -66,11 +78,67 @@ type (
 		Transaction interface{} `json:"transaction,omitempty"`
 	}
 
+	// TransactionSubmittedPayload is an internal event payload, it contains the signed transaction,
+	// so it must not be sent outside of the application.
+	TransactionSubmittedPayload struct {
+		PaymentID
+		Reference   string `json:"reference"`
+		Signature   string `json:"signature"`
+		Transaction string `json:"transaction"`
+	}
+
+	LoyaltyTierChangedPayload struct {
+		Wallet       string `json:"wallet"`
+		PreviousTier string `json:"previous_tier,omitempty"`
+		Tier         string `json:"tier"`
+	}
+
+	WebhookEndpointDisabledPayload struct {
+		EndpointID          string    `json:"endpoint_id"`
+		URL                 string    `json:"url"`
+		Reason              string    `json:"reason"`
+		ConsecutiveFailures int       `json:"consecutive_failures"`
+		FailingSince        time.Time `json:"failing_since"`
+	}
+
 	ReferencePayload struct {
 		Reference string `json:"reference"`
 	}
 )
 
+// payloadTypes maps the events to their payload types, so the payloads stored
+// as JSON are decoded to the types the listeners expect.
+var payloadTypes = map[EventName]reflect.Type{
+	PaymentCreated:                   reflect.TypeOf(PaymentCreatedPayload{}),
+	PaymentProcessing:                reflect.TypeOf(PaymentStatusUpdatedPayload{}),
+	PaymentCancelled:                 reflect.TypeOf(PaymentStatusUpdatedPayload{}),
+	PaymentFailed:                    reflect.TypeOf(PaymentStatusUpdatedPayload{}),
+	PaymentExpired:                   reflect.TypeOf(PaymentStatusUpdatedPayload{}),
+	PaymentSucceeded:                 reflect.TypeOf(PaymentStatusUpdatedPayload{}),
+	PaymentLinkGenerated:             reflect.TypeOf(PaymentLinkGeneratedPayload{}),
+	TransactionCreated:               reflect.TypeOf(TransactionCreatedPayload{}),
+	TransactionUpdated:               reflect.TypeOf(TransactionUpdatedPayload{}),
+	TransactionSubmitted:             reflect.TypeOf(TransactionSubmittedPayload{}),
+	TransactionReferenceNotification: reflect.TypeOf(ReferencePayload{}),
+	LoyaltyTierChanged:               reflect.TypeOf(LoyaltyTierChangedPayload{}),
+	WebhookEndpointDisabled:          reflect.TypeOf(WebhookEndpointDisabledPayload{}),
+}
+
+// DecodePayload decodes the JSON-encoded payload of the event to its payload type.
+func DecodePayload(name EventName, data []byte) (interface{}, error) {
+	t, ok := payloadTypes[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown event: %s", name)
+	}
+
+	v := reflect.New(t)
+	if err := json.Unmarshal(data, v.Interface()); err != nil {
+		return nil, fmt.Errorf("failed to decode payload of event %s: %w", name, err)
+	}
+
+	return v.Elem().Interface(), nil
+}
+
 // GetPaymentID returns payment_id from event payload.
 // This method is required for PaymentIDGetter interface.
 func (p PaymentID) GetPaymentID() string {
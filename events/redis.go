@@ -0,0 +1,362 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Default Redis emitter settings.
+const (
+	DefaultStream       = "events"
+	DefaultStreamMaxLen = 10000 // approximate number of the latest events kept in the stream
+
+	redisReadCount = 100
+	redisReadBlock = 5 * time.Second
+)
+
+// Predefined errors.
+var (
+	ErrInvalidEventID = errors.New("invalid event id")
+	ErrEventTrimmed   = errors.New("event is trimmed from the stream")
+)
+
+type (
+	// RedisEmitter is an Emitter backed by a Redis stream, so the events are shared by all the replicas.
+	// Without a consumer group every replica calls its listeners for every event, e.g. to broadcast
+	// the event to the websocket clients connected to the replica.
+	// With a consumer group the events are split into partitions by payment, and each partition
+	// is handled by one replica of the group at a time, so the events of a payment are handled in order.
+	// A failed event is retried before the later events of its partition are handled.
+	RedisEmitter struct {
+		sync.RWMutex
+		rdb         redis.UniversalClient
+		stream      string
+		maxLen      int64
+		group       string
+		consumer    string
+		partitions  int
+		maxAttempts int
+		listeners   map[EventName][]Listener
+		handlers    []StreamHandler
+		log         Logger
+	}
+
+	// StreamEvent is the event read from the Redis stream, the ID is the ID of the stream entry.
+	StreamEvent struct {
+		ID      string
+		Name    EventName
+		Payload interface{}
+	}
+
+	// StreamHandler is a function that is called with every event read from the stream.
+	StreamHandler func(StreamEvent)
+
+	// RedisEmitterOption is a function that configures the Redis emitter.
+	RedisEmitterOption func(*RedisEmitter)
+)
+
+// NewRedisEmitter creates a new emitter backed by the Redis stream.
+// Call Run to consume the events.
+func NewRedisEmitter(rdb redis.UniversalClient, log Logger, opts ...RedisEmitterOption) *RedisEmitter {
+	hostname, _ := os.Hostname()
+
+	e := &RedisEmitter{
+		rdb:         rdb,
+		stream:      DefaultStream,
+		maxLen:      DefaultStreamMaxLen,
+		consumer:    fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+		partitions:  DefaultPartitions,
+		maxAttempts: DefaultMaxAttempts,
+		listeners:   make(map[EventName][]Listener),
+		log:         log,
+	}
+
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	return e
+}
+
+// WithStream configures the Redis stream key.
+func WithStream(key string) RedisEmitterOption {
+	return func(e *RedisEmitter) {
+		e.stream = key
+	}
+}
+
+// WithStreamMaxLen configures the approximate max length of the stream.
+func WithStreamMaxLen(n int64) RedisEmitterOption {
+	return func(e *RedisEmitter) {
+		e.maxLen = n
+	}
+}
+
+// WithConsumerGroup configures the consumer group, so each event is handled by one replica of the group.
+// The consumer name must be unique within the group, it's the hostname and the process ID by default.
+// See WithPartitions for how the events are split between the replicas.
+func WithConsumerGroup(group, consumer string) RedisEmitterOption {
+	return func(e *RedisEmitter) {
+		e.group = group
+		if consumer != "" {
+			e.consumer = consumer
+		}
+	}
+}
+
+// WithPartitions configures the number of partitions the events of the consumer group are split into.
+// The events of a payment always go to the same partition, a partition is handled by one replica at a time.
+// All the replicas of the group must use the same number of partitions.
+func WithPartitions(n int) RedisEmitterOption {
+	return func(e *RedisEmitter) {
+		if n > 0 {
+			e.partitions = n
+		}
+	}
+}
+
+// WithMaxAttempts configures the number of attempts to handle the event by the consumer group,
+// the event still failing after them is moved to the dead-letter stream.
+func WithMaxAttempts(n int) RedisEmitterOption {
+	return func(e *RedisEmitter) {
+		if n > 0 {
+			e.maxAttempts = n
+		}
+	}
+}
+
+// Emit publishes the event to the stream. The error is only logged, use Dispatch to handle it.
+func (e *RedisEmitter) Emit(name EventName, payload interface{}) {
+	if err := e.Dispatch(context.Background(), name, payload); err != nil {
+		e.log.Errorf("failed to emit event %s: %s", name, err.Error())
+	}
+}
+
+// Dispatch publishes the event to the stream. The event is handled once it's published,
+// the listeners are called by the consumers of the stream.
+// The event ID set with WithEventID and the payment ID of the payload are published along with the event.
+func (e *RedisEmitter) Dispatch(ctx context.Context, name EventName, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode payload of event %s: %w", name, err)
+	}
+
+	values := map[string]interface{}{
+		"name":    string(name),
+		"payload": string(data),
+	}
+	if id := EventIDFromContext(ctx); id != "" {
+		values["event_id"] = id
+	}
+	if p, ok := payload.(PaymentIDGetter); ok && p.GetPaymentID() != "" {
+		values["payment_id"] = p.GetPaymentID()
+	}
+
+	if err := e.rdb.XAdd(ctx, &redis.XAddArgs{
+		Stream: e.stream,
+		MaxLen: e.maxLen,
+		Approx: true,
+		Values: values,
+	}).Err(); err != nil {
+		return fmt.Errorf("failed to publish event %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// On registers a listener for the given event name.
+func (e *RedisEmitter) On(name EventName, listeners ...Listener) {
+	e.Lock()
+	defer e.Unlock()
+
+	e.listeners[name] = append(e.listeners[name], listeners...)
+}
+
+// ListenEvents registers a listener for the given event names.
+func (e *RedisEmitter) ListenEvents(listener Listener, names ...EventName) {
+	e.Lock()
+	defer e.Unlock()
+
+	for _, name := range names {
+		e.listeners[name] = append(e.listeners[name], listener)
+	}
+}
+
+// OnStreamEvent registers a handler called with every event read from the stream along with its ID,
+// e.g. to let the websocket clients resume from the last received event.
+func (e *RedisEmitter) OnStreamEvent(handler StreamHandler) {
+	e.Lock()
+	defer e.Unlock()
+
+	e.handlers = append(e.handlers, handler)
+}
+
+// LastEventID returns the ID of the latest event in the stream, or "0-0" if the stream is empty.
+func (e *RedisEmitter) LastEventID(ctx context.Context) (string, error) {
+	msgs, err := e.rdb.XRevRangeN(ctx, e.stream, "+", "-", 1).Result()
+	if err != nil {
+		return "", fmt.Errorf("failed to get last event id: %w", err)
+	}
+	if len(msgs) == 0 {
+		return "0-0", nil
+	}
+
+	return msgs[0].ID, nil
+}
+
+// EventsAfter returns up to count events published after the event with the given ID, oldest first.
+// Returns ErrEventTrimmed if the event is older than the events kept in the stream,
+// so the events published after it could be lost.
+func (e *RedisEmitter) EventsAfter(ctx context.Context, id string, count int64) ([]StreamEvent, error) {
+	if _, err := parseStreamID(id); err != nil {
+		return nil, err
+	}
+
+	first, err := e.rdb.XRangeN(ctx, e.stream, "-", "+", 1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get first event: %w", err)
+	}
+	if len(first) == 0 || compareStreamIDs(first[0].ID, id) > 0 {
+		return nil, ErrEventTrimmed
+	}
+
+	msgs, err := e.rdb.XRangeN(ctx, e.stream, "("+id, "+", count).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get events after %s: %w", id, err)
+	}
+
+	result := make([]StreamEvent, 0, len(msgs))
+	for _, msg := range msgs {
+		name, _ := msg.Values["name"].(string)
+		data, _ := msg.Values["payload"].(string)
+		payload, err := DecodePayload(EventName(name), []byte(data))
+		if err != nil {
+			continue // unknown to the replica
+		}
+		result = append(result, StreamEvent{ID: msg.ID, Name: EventName(name), Payload: payload})
+	}
+
+	return result, nil
+}
+
+// Run consumes the events published since the start and calls the listeners until the context is canceled.
+func (e *RedisEmitter) Run(ctx context.Context) error {
+	if e.group != "" {
+		return e.runGroup(ctx)
+	}
+
+	lastID := "$"
+	for ctx.Err() == nil {
+		streams, err := e.rdb.XRead(ctx, &redis.XReadArgs{
+			Streams: []string{e.stream, lastID},
+			Count:   redisReadCount,
+			Block:   redisReadBlock,
+		}).Result()
+		if err != nil {
+			e.readFailed(ctx, err)
+			continue
+		}
+
+		for _, s := range streams {
+			for _, msg := range s.Messages {
+				lastID = msg.ID
+				if err := e.handle(ctx, msg); err != nil {
+					e.log.Errorf("event %s: %s", msg.ID, err.Error())
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// handle decodes the event and calls the listeners with the published event ID.
+// The events unknown to the replica, e.g. published by a newer version, are skipped.
+func (e *RedisEmitter) handle(ctx context.Context, msg redis.XMessage) error {
+	name, _ := msg.Values["name"].(string)
+	data, _ := msg.Values["payload"].(string)
+	if id, _ := msg.Values["event_id"].(string); id != "" {
+		ctx = WithEventID(ctx, id)
+	}
+
+	e.RLock()
+	listeners := e.listeners[EventName(name)]
+	handlers := e.handlers
+	e.RUnlock()
+	if len(listeners) == 0 && len(handlers) == 0 {
+		return nil
+	}
+
+	payload, err := DecodePayload(EventName(name), []byte(data))
+	if err != nil {
+		e.log.Errorf("skip event %s: %s", msg.ID, err.Error())
+		return nil
+	}
+
+	for _, h := range handlers {
+		h(StreamEvent{ID: msg.ID, Name: EventName(name), Payload: payload})
+	}
+
+	return callListeners(ctx, listeners, EventName(name), payload)
+}
+
+// readFailed logs the error of reading the stream and waits before the next attempt.
+func (e *RedisEmitter) readFailed(ctx context.Context, err error) {
+	if errors.Is(err, redis.Nil) || ctx.Err() != nil {
+		return // no new events or the consumer is stopped
+	}
+
+	e.log.Errorf("failed to read events: %s", err.Error())
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+	}
+}
+
+// parseStreamID parses the Redis stream entry ID: <milliseconds>-<sequence>.
+func parseStreamID(id string) ([2]uint64, error) {
+	var result [2]uint64
+	ms, seq, ok := strings.Cut(id, "-")
+	if !ok {
+		return result, fmt.Errorf("%w: %q", ErrInvalidEventID, id)
+	}
+
+	var err error
+	if result[0], err = strconv.ParseUint(ms, 10, 64); err != nil {
+		return result, fmt.Errorf("%w: %q", ErrInvalidEventID, id)
+	}
+	if result[1], err = strconv.ParseUint(seq, 10, 64); err != nil {
+		return result, fmt.Errorf("%w: %q", ErrInvalidEventID, id)
+	}
+
+	return result, nil
+}
+
+// compareStreamIDs compares the stream entry IDs, the invalid IDs are the oldest ones.
+func compareStreamIDs(a, b string) int {
+	x, _ := parseStreamID(a)
+	y, _ := parseStreamID(b)
+	switch {
+	case x[0] != y[0]:
+		if x[0] < y[0] {
+			return -1
+		}
+		return 1
+	case x[1] != y[1]:
+		if x[1] < y[1] {
+			return -1
+		}
+		return 1
+	}
+	return 0
+}
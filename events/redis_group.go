@@ -0,0 +1,324 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Consumer group settings.
+const (
+	DefaultPartitions  = 8
+	DefaultMaxAttempts = 12 // about 3 minutes of retries before the event is dead-lettered
+
+	redisLeaseTTL      = 15 * time.Second // the partition lease is renewed every third of it
+	redisRetryMinDelay = 100 * time.Millisecond
+	redisRetryMaxDelay = 30 * time.Second
+)
+
+var (
+	// renewLeaseScript extends the lease if it's still held by the consumer.
+	renewLeaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+
+	// releaseLeaseScript removes the lease if it's still held by the consumer.
+	releaseLeaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+	// saveOffsetScript saves the offset of the partition if its lease is still held by the consumer.
+	saveOffsetScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	redis.call("SET", KEYS[2], ARGV[2])
+	return 1
+end
+return 0`)
+)
+
+// partitionWorker is the running consumer of the leased partition.
+// The stopped worker is kept until it's done, so the partition isn't consumed twice by the replica.
+type partitionWorker struct {
+	stop    context.CancelFunc
+	done    chan struct{}
+	stopped bool
+}
+
+// runGroup consumes the events with the consumer group. The partitions are leased by the replicas
+// of the group, every replica takes its share of the partitions and consumes each of them
+// from the offset saved by the previous owner. The events of a partition are handled one by one,
+// the failed event is retried before the later events are handled, so they never overtake it.
+// The event still failing after the max attempts is moved to the dead-letter stream, see DeadLetterStream.
+func (e *RedisEmitter) runGroup(ctx context.Context) error {
+	lastID, err := e.LastEventID(ctx)
+	if err != nil {
+		return err
+	}
+	for p := 0; p < e.partitions; p++ {
+		if err := e.rdb.SetNX(ctx, e.groupKey("offset", p), lastID, 0).Err(); err != nil {
+			return fmt.Errorf("failed to init offset of partition %d: %w", p, err)
+		}
+	}
+
+	workers := make(map[int]*partitionWorker)
+	defer func() {
+		for _, w := range workers {
+			w.stop()
+			<-w.done
+		}
+		e.rdb.ZRem(context.Background(), e.consumersKey(), e.consumer) //nolint:errcheck
+	}()
+
+	ticker := time.NewTicker(redisLeaseTTL / 3)
+	defer ticker.Stop()
+
+	for {
+		e.balance(ctx, workers)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// balance renews the leases of the partitions consumed by the replica
+// and takes or releases the partitions to get the replica's share of them.
+func (e *RedisEmitter) balance(ctx context.Context, workers map[int]*partitionWorker) {
+	active := 0
+	for p, w := range workers {
+		select {
+		case <-w.done:
+			delete(workers, p)
+			continue
+		default:
+		}
+		if w.stopped {
+			continue
+		}
+
+		ok, err := renewLeaseScript.Run(ctx, e.rdb, []string{e.groupKey("lease", p)},
+			e.consumer, redisLeaseTTL.Milliseconds()).Bool()
+		if err != nil || !ok {
+			e.log.Errorf("lost lease of partition %d", p)
+			w.stop()
+			w.stopped = true
+			continue
+		}
+		active++
+	}
+
+	share, err := e.partitionShare(ctx)
+	if err != nil {
+		e.log.Errorf("failed to get partition share: %s", err.Error())
+		return
+	}
+
+	for _, w := range workers {
+		if active <= share {
+			break
+		}
+		if !w.stopped {
+			w.stop() // the lease is released once the worker is done
+			w.stopped = true
+			active--
+		}
+	}
+
+	for p := 0; p < e.partitions && active < share; p++ {
+		if _, ok := workers[p]; ok {
+			continue
+		}
+
+		ok, err := e.rdb.SetNX(ctx, e.groupKey("lease", p), e.consumer, redisLeaseTTL).Result()
+		if err != nil {
+			e.log.Errorf("failed to lease partition %d: %s", p, err.Error())
+			return
+		}
+		if !ok {
+			continue // leased by another replica
+		}
+
+		wctx, stop := context.WithCancel(ctx)
+		w := &partitionWorker{stop: stop, done: make(chan struct{})}
+		workers[p] = w
+		active++
+		go func(p int) {
+			defer close(w.done)
+			e.consumePartition(wctx, p)
+		}(p)
+	}
+}
+
+// partitionShare registers the replica as alive and returns the number of partitions it should consume.
+func (e *RedisEmitter) partitionShare(ctx context.Context) (int, error) {
+	now := time.Now()
+	key := e.consumersKey()
+
+	var count *redis.IntCmd
+	if _, err := e.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZAdd(ctx, key, &redis.Z{Score: float64(now.UnixMilli()), Member: e.consumer})
+		pipe.ZRemRangeByScore(ctx, key, "-inf", fmt.Sprintf("(%d", now.Add(-redisLeaseTTL).UnixMilli()))
+		count = pipe.ZCard(ctx, key)
+		return nil
+	}); err != nil {
+		return 0, err
+	}
+
+	alive := int(count.Val())
+	if alive < 1 {
+		alive = 1
+	}
+
+	return (e.partitions + alive - 1) / alive, nil
+}
+
+// consumePartition handles the events of the partition from its saved offset until the context is canceled
+// or the lease is lost. The lease is released on return.
+func (e *RedisEmitter) consumePartition(ctx context.Context, p int) {
+	leaseKey, offsetKey := e.groupKey("lease", p), e.groupKey("offset", p)
+	defer releaseLeaseScript.Run(context.Background(), e.rdb, []string{leaseKey}, e.consumer) //nolint:errcheck
+
+	offset, err := e.rdb.Get(ctx, offsetKey).Result()
+	if err != nil {
+		e.log.Errorf("failed to get offset of partition %d: %s", p, err.Error())
+		return
+	}
+
+	for ctx.Err() == nil {
+		if trimmed, err := e.trimmedAfter(ctx, offset); err != nil {
+			e.readFailed(ctx, err)
+			continue
+		} else if trimmed {
+			e.log.Errorf("events of partition %d after %s may be lost: trimmed from the stream", p, offset)
+		}
+
+		streams, err := e.rdb.XRead(ctx, &redis.XReadArgs{
+			Streams: []string{e.stream, offset},
+			Count:   redisReadCount,
+			Block:   redisReadBlock,
+		}).Result()
+		if err != nil {
+			e.readFailed(ctx, err)
+			continue
+		}
+
+		for _, s := range streams {
+			for _, msg := range s.Messages {
+				if e.partition(msg) == p && !e.handleWithRetry(ctx, msg) {
+					return
+				}
+				offset = msg.ID
+			}
+		}
+
+		ok, err := saveOffsetScript.Run(ctx, e.rdb, []string{leaseKey, offsetKey}, e.consumer, offset).Bool()
+		switch {
+		case err != nil:
+			e.log.Errorf("failed to save offset of partition %d: %s", p, err.Error())
+		case !ok:
+			e.log.Errorf("lost lease of partition %d", p)
+			return
+		}
+	}
+}
+
+// handleWithRetry handles the event until it succeeds, waiting longer after each failure.
+// The event failing after the max attempts is moved to the dead-letter stream.
+// Returns false if the context is canceled before the event is handled or dead-lettered.
+func (e *RedisEmitter) handleWithRetry(ctx context.Context, msg redis.XMessage) bool {
+	delay := redisRetryMinDelay
+	for attempt := 1; ctx.Err() == nil; attempt++ {
+		err := e.handle(ctx, msg)
+		if err == nil {
+			return true
+		}
+		if errors.Is(err, context.Canceled) {
+			return false
+		}
+		if attempt >= e.maxAttempts {
+			derr := e.deadLetter(ctx, msg, err)
+			if derr == nil {
+				e.log.Errorf("event %s: %s, moved to %s after %d attempts", msg.ID, err.Error(), e.DeadLetterStream(), attempt)
+				return true
+			}
+			e.log.Errorf("event %s: failed to move to %s: %s", msg.ID, e.DeadLetterStream(), derr.Error())
+		}
+		e.log.Errorf("event %s: %s, retry in %s", msg.ID, err.Error(), delay)
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(delay):
+		}
+		if delay *= 2; delay > redisRetryMaxDelay {
+			delay = redisRetryMaxDelay
+		}
+	}
+
+	return false
+}
+
+// deadLetter adds the failed event to the dead-letter stream along with its ID and the error.
+func (e *RedisEmitter) deadLetter(ctx context.Context, msg redis.XMessage, cause error) error {
+	values := make(map[string]interface{}, len(msg.Values)+2)
+	for k, v := range msg.Values {
+		values[k] = v
+	}
+	values["stream_id"] = msg.ID
+	values["error"] = cause.Error()
+
+	return e.rdb.XAdd(ctx, &redis.XAddArgs{
+		Stream: e.DeadLetterStream(),
+		MaxLen: e.maxLen,
+		Approx: true,
+		Values: values,
+	}).Err()
+}
+
+// DeadLetterStream returns the key of the stream the events failed after the max attempts are moved to,
+// e.g. to inspect and publish them again.
+func (e *RedisEmitter) DeadLetterStream() string {
+	return fmt.Sprintf("%s:%s:dead", e.stream, e.group)
+}
+
+// trimmedAfter checks if the events after the given one could be trimmed from the stream:
+// the first event kept in the stream is newer than it.
+func (e *RedisEmitter) trimmedAfter(ctx context.Context, id string) (bool, error) {
+	if id == "0-0" {
+		return false, nil // the stream was empty
+	}
+
+	first, err := e.rdb.XRangeN(ctx, e.stream, "-", "+", 1).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to get first event: %w", err)
+	}
+
+	return len(first) > 0 && compareStreamIDs(first[0].ID, id) > 0, nil
+}
+
+// partition returns the partition of the event: the events of a payment always go to the same partition.
+func (e *RedisEmitter) partition(msg redis.XMessage) int {
+	paymentID, _ := msg.Values["payment_id"].(string)
+	h := fnv.New32a()
+	h.Write([]byte(paymentID))
+	return int(h.Sum32() % uint32(e.partitions))
+}
+
+// groupKey returns the key of the partition state of the consumer group.
+func (e *RedisEmitter) groupKey(kind string, p int) string {
+	return fmt.Sprintf("%s:%s:%s:%d", e.stream, e.group, kind, p)
+}
+
+// consumersKey returns the key of the replicas of the consumer group.
+func (e *RedisEmitter) consumersKey() string {
+	return fmt.Sprintf("%s:%s:consumers", e.stream, e.group)
+}
@@ -0,0 +1,245 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/require"
+)
+
+type testLogger struct{}
+
+func (testLogger) Debugf(string, ...interface{}) {}
+func (testLogger) Infof(string, ...interface{})  {}
+func (testLogger) Errorf(string, ...interface{}) {}
+
+func newTestRedis(t *testing.T) redis.UniversalClient {
+	srv := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: srv.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	return rdb
+}
+
+func runEmitter(t *testing.T, e *RedisEmitter) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go e.Run(ctx) //nolint:errcheck
+	// let the consumer start reading the stream
+	time.Sleep(100 * time.Millisecond)
+}
+
+func TestRedisEmitterBroadcast(t *testing.T) {
+	rdb := newTestRedis(t)
+
+	var mu sync.Mutex
+	received := map[string]interface{}{}
+	eventIDs := map[string]string{}
+	for _, replica := range []string{"a", "b"} {
+		replica := replica
+		e := NewRedisEmitter(rdb, testLogger{})
+		e.On(PaymentSucceeded, func(ctx context.Context, _ EventName, payload interface{}) error {
+			mu.Lock()
+			defer mu.Unlock()
+			received[replica] = payload
+			eventIDs[replica] = EventIDFromContext(ctx)
+			return nil
+		})
+		runEmitter(t, e)
+	}
+
+	payload := PaymentStatusUpdatedPayload{PaymentID: PaymentID{PaymentID: "1"}, Status: "completed"}
+	require.NoError(t, NewRedisEmitter(rdb, testLogger{}).Dispatch(WithEventID(context.Background(), "42"), PaymentSucceeded, payload))
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(received) == 2
+	}, 2*time.Second, 10*time.Millisecond)
+
+	// every replica gets the typed payload and the event ID
+	require.Equal(t, payload, received["a"])
+	require.Equal(t, payload, received["b"])
+	require.Equal(t, map[string]string{"a": "42", "b": "42"}, eventIDs)
+}
+
+func TestRedisEmitterConsumerGroup(t *testing.T) {
+	rdb := newTestRedis(t)
+
+	var handled int32
+	for _, consumer := range []string{"a", "b"} {
+		e := NewRedisEmitter(rdb, testLogger{}, WithConsumerGroup("listeners", consumer))
+		e.On(PaymentSucceeded, func(_ context.Context, _ EventName, _ interface{}) error {
+			atomic.AddInt32(&handled, 1)
+			return nil
+		})
+		runEmitter(t, e)
+	}
+
+	e := NewRedisEmitter(rdb, testLogger{})
+	for i := 0; i < 10; i++ {
+		require.NoError(t, e.Dispatch(context.Background(), PaymentSucceeded, PaymentStatusUpdatedPayload{
+			PaymentID: PaymentID{PaymentID: strconv.Itoa(i)},
+			Status:    "completed",
+		}))
+	}
+
+	require.Eventually(t, func() bool {
+		return atomic.LoadInt32(&handled) == 10
+	}, 2*time.Second, 10*time.Millisecond)
+
+	// each event is handled once
+	time.Sleep(100 * time.Millisecond)
+	require.EqualValues(t, 10, atomic.LoadInt32(&handled))
+}
+
+func TestRedisEmitterConsumerGroupOrder(t *testing.T) {
+	rdb := newTestRedis(t)
+
+	var (
+		mu       sync.Mutex
+		statuses []string
+		calls    int
+	)
+	listener := func(_ context.Context, _ EventName, payload interface{}) error {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		statuses = append(statuses, payload.(PaymentStatusUpdatedPayload).Status)
+		if calls == 1 {
+			return errors.New("listener failed")
+		}
+		return nil
+	}
+	for _, consumer := range []string{"a", "b"} {
+		e := NewRedisEmitter(rdb, testLogger{}, WithConsumerGroup("listeners", consumer))
+		e.ListenEvents(listener, PaymentProcessing, PaymentSucceeded)
+		runEmitter(t, e)
+	}
+
+	e := NewRedisEmitter(rdb, testLogger{})
+	for _, status := range []string{"pending", "completed"} {
+		name := PaymentProcessing
+		if status == "completed" {
+			name = PaymentSucceeded
+		}
+		require.NoError(t, e.Dispatch(context.Background(), name, PaymentStatusUpdatedPayload{
+			PaymentID: PaymentID{PaymentID: "p1"},
+			Status:    status,
+		}))
+	}
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(statuses) == 3
+	}, 2*time.Second, 10*time.Millisecond)
+
+	// the failed event is retried before the later event of the payment is handled
+	time.Sleep(100 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(t, []string{"pending", "pending", "completed"}, statuses)
+}
+
+// recordingLogger records the logged errors.
+type recordingLogger struct {
+	testLogger
+	mu     sync.Mutex
+	errors []string
+}
+
+func (l *recordingLogger) Errorf(format string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.errors = append(l.errors, fmt.Sprintf(format, args...))
+}
+
+func (l *recordingLogger) logged(substr string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, e := range l.errors {
+		if strings.Contains(e, substr) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestRedisEmitterConsumerGroupDeadLetter(t *testing.T) {
+	rdb := newTestRedis(t)
+
+	var (
+		mu       sync.Mutex
+		statuses []string
+	)
+	e := NewRedisEmitter(rdb, testLogger{}, WithConsumerGroup("listeners", "a"), WithMaxAttempts(3))
+	e.ListenEvents(func(_ context.Context, _ EventName, payload interface{}) error {
+		mu.Lock()
+		defer mu.Unlock()
+		status := payload.(PaymentStatusUpdatedPayload).Status
+		statuses = append(statuses, status)
+		if status == "pending" {
+			return errors.New("listener failed")
+		}
+		return nil
+	}, PaymentProcessing, PaymentSucceeded)
+	runEmitter(t, e)
+
+	require.NoError(t, e.Dispatch(context.Background(), PaymentProcessing, paymentEvent("p1", "pending")))
+	require.NoError(t, e.Dispatch(context.Background(), PaymentSucceeded, paymentEvent("p1", "completed")))
+
+	// the event failing after the max attempts doesn't block the partition
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(statuses) == 4
+	}, 3*time.Second, 10*time.Millisecond)
+	mu.Lock()
+	require.Equal(t, []string{"pending", "pending", "pending", "completed"}, statuses)
+	mu.Unlock()
+
+	dead, err := rdb.XRange(context.Background(), e.DeadLetterStream(), "-", "+").Result()
+	require.NoError(t, err)
+	require.Len(t, dead, 1)
+	require.Equal(t, string(PaymentProcessing), dead[0].Values["name"])
+	require.Contains(t, dead[0].Values["error"], "listener failed")
+	require.NotEmpty(t, dead[0].Values["stream_id"])
+}
+
+func TestRedisEmitterConsumerGroupTrimmed(t *testing.T) {
+	rdb := newTestRedis(t)
+	ctx := context.Background()
+
+	publisher := NewRedisEmitter(rdb, testLogger{})
+	require.NoError(t, publisher.Dispatch(ctx, PaymentProcessing, paymentEvent("p1", "pending")))
+	offset, err := publisher.LastEventID(ctx)
+	require.NoError(t, err)
+	require.NoError(t, publisher.Dispatch(ctx, PaymentProcessing, paymentEvent("p2", "pending")))
+	require.NoError(t, publisher.Dispatch(ctx, PaymentSucceeded, paymentEvent("p3", "completed")))
+
+	// the partitions are stuck at the first event, while the stream is trimmed
+	e := NewRedisEmitter(rdb, &recordingLogger{}, WithConsumerGroup("listeners", "a"), WithPartitions(1))
+	require.NoError(t, rdb.Set(ctx, e.groupKey("offset", 0), offset, 0).Err())
+	require.NoError(t, rdb.XTrimMaxLen(ctx, DefaultStream, 1).Err())
+
+	var handled int32
+	e.On(PaymentSucceeded, func(_ context.Context, _ EventName, _ interface{}) error {
+		atomic.AddInt32(&handled, 1)
+		return nil
+	})
+	runEmitter(t, e)
+
+	require.Eventually(t, func() bool {
+		return atomic.LoadInt32(&handled) == 1
+	}, 2*time.Second, 10*time.Millisecond)
+	require.True(t, e.log.(*recordingLogger).logged("trimmed from the stream"))
+}
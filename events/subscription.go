@@ -0,0 +1,85 @@
+package events
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultSubscriptionTokenTTL is the lifetime of the subscription token:
+// it's only checked on connect, so it must be long enough to open the websocket.
+const DefaultSubscriptionTokenTTL = 15 * time.Minute
+
+// Predefined subscription token errors.
+var (
+	ErrInvalidSubscriptionToken = errors.New("invalid subscription token")
+	ErrSubscriptionTokenExpired = errors.New("subscription token expired")
+)
+
+// SubscriptionTokens issues and verifies short-lived tokens to subscribe to the events of a payment,
+// so the checkout widget gets the events of its payment only.
+// Token scheme: <payment id>.<expiration unix timestamp>.<base64url hmac-sha256 of "<payment id>.<expiration>">
+type SubscriptionTokens struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+// NewSubscriptionTokens creates a new issuer of the subscription tokens signed with the secret.
+func NewSubscriptionTokens(secret []byte, ttl time.Duration) *SubscriptionTokens {
+	if ttl <= 0 {
+		ttl = DefaultSubscriptionTokenTTL
+	}
+	return &SubscriptionTokens{secret: secret, ttl: ttl}
+}
+
+// Issue returns the token to subscribe to the events of the payment and its expiration time.
+func (t *SubscriptionTokens) Issue(paymentID string) (string, time.Time, error) {
+	if paymentID == "" || strings.Contains(paymentID, ".") {
+		return "", time.Time{}, fmt.Errorf("%w: invalid payment id", ErrInvalidSubscriptionToken)
+	}
+
+	expiresAt := time.Now().Add(t.ttl).Truncate(time.Second)
+	claims := paymentID + "." + strconv.FormatInt(expiresAt.Unix(), 10)
+
+	return claims + "." + base64.RawURLEncoding.EncodeToString(t.sign(claims)), expiresAt, nil
+}
+
+// Verify checks the token and returns the payment ID it's issued for.
+func (t *SubscriptionTokens) Verify(token string) (string, error) {
+	i := strings.LastIndex(token, ".")
+	if i < 0 {
+		return "", ErrInvalidSubscriptionToken
+	}
+	claims, sig := token[:i], token[i+1:]
+
+	signature, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(signature, t.sign(claims)) {
+		return "", ErrInvalidSubscriptionToken
+	}
+
+	paymentID, exp, ok := strings.Cut(claims, ".")
+	if !ok || paymentID == "" {
+		return "", ErrInvalidSubscriptionToken
+	}
+	expiresAt, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil {
+		return "", ErrInvalidSubscriptionToken
+	}
+	if time.Now().Unix() >= expiresAt {
+		return "", ErrSubscriptionTokenExpired
+	}
+
+	return paymentID, nil
+}
+
+// sign returns the hmac-sha256 signature of the claims.
+func (t *SubscriptionTokens) sign(claims string) []byte {
+	mac := hmac.New(sha256.New, t.secret)
+	mac.Write([]byte(claims))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,47 @@
+package events
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscriptionTokens(t *testing.T) {
+	tokens := NewSubscriptionTokens([]byte("secret"), time.Minute)
+
+	token, expiresAt, err := tokens.Issue("payment-1")
+	require.NoError(t, err)
+	require.WithinDuration(t, time.Now().Add(time.Minute), expiresAt, time.Second)
+
+	paymentID, err := tokens.Verify(token)
+	require.NoError(t, err)
+	require.Equal(t, "payment-1", paymentID)
+
+	// signed with another secret
+	_, err = NewSubscriptionTokens([]byte("other"), time.Minute).Verify(token)
+	require.ErrorIs(t, err, ErrInvalidSubscriptionToken)
+
+	// issued for another payment
+	_, err = tokens.Verify(strings.Replace(token, "payment-1", "payment-2", 1))
+	require.ErrorIs(t, err, ErrInvalidSubscriptionToken)
+
+	for _, token := range []string{"", "payment-1", "payment-1.123", "payment-1.123.!!!"} {
+		_, err = tokens.Verify(token)
+		require.ErrorIs(t, err, ErrInvalidSubscriptionToken, token)
+	}
+
+	_, _, err = tokens.Issue("")
+	require.ErrorIs(t, err, ErrInvalidSubscriptionToken)
+}
+
+func TestSubscriptionTokenExpired(t *testing.T) {
+	tokens := NewSubscriptionTokens([]byte("secret"), time.Minute)
+	claims := "payment-1.1"
+	token := claims + "." + base64.RawURLEncoding.EncodeToString(tokens.sign(claims))
+
+	_, err := tokens.Verify(token)
+	require.ErrorIs(t, err, ErrSubscriptionTokenExpired)
+}
@@ -2,180 +2,483 @@ package events
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
 	"net/http"
 	"sync"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/gorilla/websocket"
-	"golang.org/x/sync/errgroup"
 )
 
+// Websocket connection settings.
+const (
+	wsWriteWait      = 10 * time.Second    // time allowed to write a message to the client
+	wsPongWait       = 60 * time.Second    // time allowed to read the next pong message from the client
+	wsPingPeriod     = wsPongWait * 9 / 10 // must be less than wsPongWait
+	wsMaxMessageSize = 512                 // clients don't send anything but control messages
+	wsSendBuffer     = 64                  // events queued for the client, the slow client is disconnected
+	wsResumeLimit    = 1000                // max events replayed on resume, the snapshot is sent instead
+
+	// merchantChannel is the channel of all the events, payment channels are payment IDs.
+	merchantChannel = "*"
+)
+
+// publicEvents are the events sent to the websocket clients.
+// The internal events, e.g. transaction.submitted with the signed transaction, are never sent.
+var publicEvents = map[EventName]bool{
+	PaymentCreated:       true,
+	PaymentProcessing:    true,
+	PaymentCancelled:     true,
+	PaymentFailed:        true,
+	PaymentExpired:       true,
+	PaymentSucceeded:     true,
+	PaymentLinkGenerated: true,
+	TransactionCreated:   true,
+	TransactionUpdated:   true,
+	LoyaltyTierChanged:   true,
+}
+
+// SnapshotEvent is the name of the first message sent to the payment channel subscriber:
+// the current state of the payment, the events after it follow.
+const SnapshotEvent = "snapshot"
+
 type (
+	// Event is the message sent to the websocket clients.
+	// The ID is the ID of the event in the stream, the client passes the ID of the last
+	// received event to resume the subscription after the reconnect.
 	Event struct {
+		ID      string      `json:"id,omitempty"`
 		Channel string      `json:"-"`
 		Name    string      `json:"name"`
 		Data    interface{} `json:"data"`
 	}
 
+	// EventBroadcaster sends the events to the websocket clients connected to the replica.
+	// The payment channel requires the subscription token of the payment,
+	// the merchant channel gets the events of all the payments and must be protected by the auth middleware.
 	EventBroadcaster struct {
 		clients   *channelHub
 		broadcast chan Event
 		upgrader  websocket.Upgrader
-		emitter   Emitter
+		stream    eventStream
+		tokens    tokenVerifier
+		snapshot  SnapshotFunc
 		log       Logger
 	}
+
+	// EventBroadcasterOption is a function that configures the event broadcaster.
+	EventBroadcasterOption func(*EventBroadcaster)
+
+	// SnapshotFunc returns the current state of the payment sent to the client on connect.
+	SnapshotFunc func(ctx context.Context, paymentID string) (interface{}, error)
+
+	// eventStream is the source of the events with their IDs, e.g. RedisEmitter without a consumer group.
+	eventStream interface {
+		OnStreamEvent(handler StreamHandler)
+		LastEventID(ctx context.Context) (string, error)
+		EventsAfter(ctx context.Context, id string, count int64) ([]StreamEvent, error)
+	}
+
+	tokenVerifier interface {
+		Verify(token string) (string, error)
+	}
 )
 
-func NewEventBroadcaster(emitter Emitter, log Logger) *EventBroadcaster {
+// NewEventBroadcaster creates a new event broadcaster of the events read from the stream.
+func NewEventBroadcaster(stream eventStream, tokens tokenVerifier, log Logger, opts ...EventBroadcasterOption) *EventBroadcaster {
 	b := &EventBroadcaster{
 		clients:   newChannelHub(),
 		broadcast: make(chan Event, 100),
-		upgrader: websocket.Upgrader{
-			CheckOrigin: func(r *http.Request) bool {
-				return true
-			},
-		},
-		emitter: emitter,
-		log:     log,
+		stream:    stream,
+		tokens:    tokens,
+		log:       log,
 	}
 
-	b.emitter.ListenEvents(b.RetranslateEvents, AllEvents...)
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	b.stream.OnStreamEvent(b.RetranslateEvent)
 
 	return b
 }
 
-// RetranslateEvents retranslates events from the emitter to the event Broadcaster.
-func (b *EventBroadcaster) RetranslateEvents(event EventName, payload interface{}) error {
-	data, ok := payload.(PaymentIDGetter)
-	if !ok {
-		return fmt.Errorf("event Broadcaster: retranslate events: invalid payload: %T", payload)
+// WithAllowedOrigins configures the origins of the pages allowed to connect, "*" allows any origin.
+// Only the same origin requests are allowed by default.
+func WithAllowedOrigins(origins ...string) EventBroadcasterOption {
+	return func(b *EventBroadcaster) {
+		allowed := make(map[string]bool, len(origins))
+		for _, o := range origins {
+			allowed[o] = true
+		}
+		if allowed["*"] {
+			b.upgrader.CheckOrigin = func(*http.Request) bool { return true }
+			return
+		}
+		b.upgrader.CheckOrigin = func(r *http.Request) bool {
+			origin := r.Header.Get("Origin")
+			return origin == "" || allowed[origin]
+		}
 	}
+}
 
-	b.broadcast <- Event{
-		Channel: data.GetPaymentID(),
-		Name:    string(event),
-		Data:    payload,
+// WithSnapshot configures the function to get the current state of the payment
+// sent to the payment channel subscribers on connect.
+func WithSnapshot(fn SnapshotFunc) EventBroadcasterOption {
+	return func(b *EventBroadcaster) {
+		b.snapshot = fn
+	}
+}
+
+// RetranslateEvent retranslates the public event from the stream to the payment channel and the merchant channel.
+// It never blocks the stream consumer: the event is dropped if the broadcaster doesn't keep up,
+// the clients get it when they resume from the last received event.
+func (b *EventBroadcaster) RetranslateEvent(e StreamEvent) {
+	if !publicEvents[e.Name] {
+		return
 	}
 
-	return nil
+	select {
+	case b.broadcast <- Event{
+		ID:      e.ID,
+		Channel: paymentIDOf(e.Payload),
+		Name:    string(e.Name),
+		Data:    e.Payload,
+	}:
+	default:
+		b.log.Errorf("event broadcaster: queue is full, event %s dropped", e.ID)
+	}
 }
 
-// Run starts the event Broadcaster.
+// Run starts the event broadcaster.
 func (b *EventBroadcaster) Run(ctx context.Context) error {
-	eg, ctx := errgroup.WithContext(ctx)
-
-	eg.Go(func() error {
-		for {
-			select {
-			case <-ctx.Done():
-				b.log.Infof("event Broadcaster: stopped")
-				if ctx.Err() == context.Canceled {
-					return nil
-				} else if ctx.Err() == context.DeadlineExceeded {
-					return ctx.Err()
-				}
-				return nil
-			case event := <-b.broadcast:
-				clients := b.clients.Get(event.Channel)
-				for _, client := range clients {
-					if err := client.WriteJSON(event); err != nil {
-						client.Close()
-						b.clients.Remove(event.Channel, client)
-					}
-				}
+	for {
+		select {
+		case <-ctx.Done():
+			b.log.Infof("event broadcaster: stopped")
+			b.clients.CloseAll()
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return fmt.Errorf("event broadcaster: run: %w", ctx.Err())
+			}
+			return nil
+		case event := <-b.broadcast:
+			if event.Channel != "" {
+				b.send(event.Channel, event)
 			}
+			b.send(merchantChannel, event)
 		}
-	})
-
-	if err := eg.Wait(); err != nil {
-		return fmt.Errorf("event Broadcaster: run: %w", err)
 	}
+}
 
-	return nil
+// send queues the event to the clients of the channel. The client which doesn't keep up is disconnected,
+// it can reconnect and resume from the last received event.
+func (b *EventBroadcaster) send(channel string, event Event) {
+	for _, c := range b.clients.Get(channel) {
+		if !c.enqueue(event) {
+			b.log.Infof("event broadcaster: slow client of channel %s disconnected", channel)
+			c.close()
+		}
+	}
 }
 
-func (b *EventBroadcaster) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+// handlePaymentChannel subscribes the client to the events of the payment.
+// Query parameters: token is the subscription token of the payment, required;
+// last_event_id is the ID of the last received event to resume from, optional.
+func (b *EventBroadcaster) handlePaymentChannel(w http.ResponseWriter, r *http.Request) {
 	channelID := chi.URLParam(r, "channel")
-	if channelID == "" {
+	if channelID == "" || channelID == merchantChannel {
 		http.Error(w, "channel id is required", http.StatusBadRequest)
 		return
 	}
 
+	paymentID, err := b.tokens.Verify(r.URL.Query().Get("token"))
+	if err != nil || paymentID != channelID {
+		http.Error(w, "invalid subscription token", http.StatusUnauthorized)
+		return
+	}
+
+	b.serve(w, r, channelID)
+}
+
+// handleMerchantChannel subscribes the client to the events of all the payments.
+// Query parameters: last_event_id is the ID of the last received event to resume from, optional.
+func (b *EventBroadcaster) handleMerchantChannel(w http.ResponseWriter, r *http.Request) {
+	b.serve(w, r, merchantChannel)
+}
+
+// serve upgrades the connection and sends the events of the channel until the client disconnects.
+// The client is registered before the initial messages are built, so no event is missed in between,
+// and the events already covered by the initial messages are skipped.
+func (b *EventBroadcaster) serve(w http.ResponseWriter, r *http.Request, channel string) {
+	lastEventID := r.URL.Query().Get("last_event_id")
+	if lastEventID != "" {
+		if _, err := parseStreamID(lastEventID); err != nil {
+			http.Error(w, "invalid last event id", http.StatusBadRequest)
+			return
+		}
+	}
+
 	conn, err := b.upgrader.Upgrade(w, r, nil)
 	if err != nil {
-		log.Printf("error upgrading connection to websocket: %v", err)
+		b.log.Errorf("event broadcaster: failed to upgrade connection to websocket: %v", err)
 		return
 	}
 
-	b.clients.Add(channelID, conn)
+	c := newClient(conn)
+	b.clients.Add(channel, c)
 	defer func() {
-		b.clients.Remove(channelID, conn)
-		conn.Close()
+		b.clients.Remove(channel, c)
+		c.close()
 	}()
 
-	<-r.Context().Done()
-	b.log.Infof("event Broadcaster: websocket connection closed by client")
+	initial, err := b.initialEvents(r.Context(), channel, lastEventID)
+	if err != nil {
+		b.log.Errorf("event broadcaster: channel %s: %v", channel, err)
+		c.closeWithMessage(websocket.CloseInternalServerErr, "failed to get the current state")
+		return
+	}
+	for _, e := range initial {
+		if err := c.write(e); err != nil {
+			return
+		}
+	}
 
-	// for {
-	// 	select {
-	// 	case <-r.Context().Done():
-	// 		b.log.Infof("event Broadcaster: websocket connection closed by client")
-	// 		return
-	// 	default:
-	// 		if _, _, err := conn.ReadMessage(); err != nil {
-	// 			log.Printf("error reading message from websocket: %v", err)
-	// 			break
-	// 		}
-	// 	}
-	// }
+	go c.writePump()
+	c.readPump()
+}
+
+// initialEvents returns the events published after the last received one, or the snapshot
+// of the current state if the client connects for the first time or the events can't be replayed.
+func (b *EventBroadcaster) initialEvents(ctx context.Context, channel, lastEventID string) ([]Event, error) {
+	if lastEventID != "" {
+		missed, err := b.stream.EventsAfter(ctx, lastEventID, wsResumeLimit+1)
+		if err != nil && !errors.Is(err, ErrEventTrimmed) {
+			return nil, err
+		}
+		if err == nil && len(missed) <= wsResumeLimit {
+			result := make([]Event, 0, len(missed))
+			for _, e := range missed {
+				if !publicEvents[e.Name] || (channel != merchantChannel && paymentIDOf(e.Payload) != channel) {
+					continue
+				}
+				result = append(result, Event{ID: e.ID, Name: string(e.Name), Data: e.Payload})
+			}
+			if len(result) == 0 {
+				// nothing missed, the live events after the last stream event follow
+				return []Event{{ID: lastStreamID(missed, lastEventID)}}, nil
+			}
+			return result, nil
+		}
+	}
+
+	// The state is read after the stream position, so the events after it may be already applied;
+	// the clients handle them idempotently.
+	position, err := b.stream.LastEventID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if channel == merchantChannel || b.snapshot == nil {
+		return []Event{{ID: position}}, nil
+	}
+
+	state, err := b.snapshot(ctx, channel)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get snapshot: %w", err)
+	}
+
+	return []Event{{ID: position, Name: SnapshotEvent, Data: state}}, nil
+}
+
+// paymentIDOf returns the payment ID of the event payload, or an empty string
+// if the event isn't related to a payment.
+func paymentIDOf(payload interface{}) string {
+	if p, ok := payload.(PaymentIDGetter); ok {
+		return p.GetPaymentID()
+	}
+	return ""
+}
+
+// lastStreamID returns the ID of the last event read from the stream.
+func lastStreamID(events []StreamEvent, fallback string) string {
+	if len(events) == 0 {
+		return fallback
+	}
+	return events[len(events)-1].ID
+}
+
+type (
+	// client is the websocket connection subscribed to the channel.
+	client struct {
+		conn   *websocket.Conn
+		send   chan Event
+		done   chan struct{}
+		once   sync.Once
+		lastID string // the events up to the ID are already sent by the initial messages
+	}
+)
+
+func newClient(conn *websocket.Conn) *client {
+	return &client{
+		conn: conn,
+		send: make(chan Event, wsSendBuffer),
+		done: make(chan struct{}),
+	}
+}
+
+// enqueue queues the event to send it to the client.
+// Returns false if the queue is full.
+func (c *client) enqueue(e Event) bool {
+	select {
+	case <-c.done:
+		return true
+	case c.send <- e:
+		return true
+	default:
+		return false
+	}
+}
+
+// write sends the initial message to the client. The position messages without the name
+// are not sent, they only mark the events already covered.
+func (c *client) write(e Event) error {
+	if e.ID != "" {
+		c.lastID = e.ID
+	}
+	if e.Name == "" {
+		return nil
+	}
+
+	c.conn.SetWriteDeadline(time.Now().Add(wsWriteWait)) //nolint:errcheck
+	return c.conn.WriteJSON(e)
+}
+
+// writePump sends the queued events and the pings to the client until the connection is closed.
+func (c *client) writePump() {
+	ticker := time.NewTicker(wsPingPeriod)
+	defer func() {
+		ticker.Stop()
+		c.close()
+	}()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case e := <-c.send:
+			if c.lastID != "" && e.ID != "" && compareStreamIDs(e.ID, c.lastID) <= 0 {
+				continue
+			}
+			c.conn.SetWriteDeadline(time.Now().Add(wsWriteWait)) //nolint:errcheck
+			if err := c.conn.WriteJSON(e); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteWait)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// readPump reads the connection to handle the pongs and the close message.
+// The connection is considered dead if no pong is received within wsPongWait.
+func (c *client) readPump() {
+	c.conn.SetReadLimit(wsMaxMessageSize)
+	c.conn.SetReadDeadline(time.Now().Add(wsPongWait)) //nolint:errcheck
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+
+	for {
+		if _, _, err := c.conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
+
+// closeWithMessage sends the close message to the client and closes the connection.
+func (c *client) closeWithMessage(code int, text string) {
+	c.conn.WriteControl( //nolint:errcheck
+		websocket.CloseMessage,
+		websocket.FormatCloseMessage(code, text),
+		time.Now().Add(wsWriteWait),
+	)
+	c.close()
+}
+
+// close closes the connection, it's safe to call it several times.
+func (c *client) close() {
+	c.once.Do(func() {
+		close(c.done)
+		c.conn.Close()
+	})
 }
 
 func newChannelHub() *channelHub {
 	return &channelHub{
-		clients: make(map[string][]*websocket.Conn),
+		clients: make(map[string][]*client),
 	}
 }
 
 type channelHub struct {
 	sync.RWMutex
-	clients map[string][]*websocket.Conn
+	clients map[string][]*client
 }
 
-func (h *channelHub) Add(channel string, conn *websocket.Conn) {
+func (h *channelHub) Add(channel string, c *client) {
 	h.Lock()
 	defer h.Unlock()
 
-	h.clients[channel] = append(h.clients[channel], conn)
+	h.clients[channel] = append(h.clients[channel], c)
 }
 
-func (h *channelHub) Remove(channel string, conn *websocket.Conn) {
+func (h *channelHub) Remove(channel string, c *client) {
 	h.Lock()
 	defer h.Unlock()
 
 	clients := h.clients[channel]
-	for i, c := range clients {
-		if c == conn {
-			clients = append(clients[:i], clients[i+1:]...)
+	for i, cc := range clients {
+		if cc == c {
+			// copy, so the slices returned by Get are not modified
+			clients = append(clients[:i:i], clients[i+1:]...)
 			break
 		}
 	}
+	if len(clients) == 0 {
+		delete(h.clients, channel)
+		return
+	}
+	h.clients[channel] = clients
 }
 
-func (h *channelHub) Get(channel string) []*websocket.Conn {
+func (h *channelHub) Get(channel string) []*client {
 	h.RLock()
 	defer h.RUnlock()
 
 	return h.clients[channel]
 }
 
+// CloseAll disconnects all the clients.
+func (h *channelHub) CloseAll() {
+	h.RLock()
+	defer h.RUnlock()
+
+	for _, clients := range h.clients {
+		for _, c := range clients {
+			c.close()
+		}
+	}
+}
+
 // MakeHTTPHandler returns a handler that makes a set of endpoints available on
-// predefined paths.
-func MakeHTTPHandler(b *EventBroadcaster) http.Handler {
+// predefined paths. The merchant channel is protected by the auth middleware.
+func MakeHTTPHandler(b *EventBroadcaster, authMdw func(http.Handler) http.Handler) http.Handler {
 	r := chi.NewRouter()
 
-	r.HandleFunc("/channel/{channel}", b.handleWebSocket)
+	r.HandleFunc("/channel/{channel}", b.handlePaymentChannel)
+	r.With(authMdw).HandleFunc("/merchant", b.handleMerchantChannel)
 
 	return r
 }
@@ -0,0 +1,152 @@
+package events
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestBroadcaster(t *testing.T) (*RedisEmitter, *SubscriptionTokens, string) {
+	rdb := newTestRedis(t)
+	e := NewRedisEmitter(rdb, testLogger{})
+	tokens := NewSubscriptionTokens([]byte("secret"), time.Minute)
+	b := NewEventBroadcaster(e, tokens, testLogger{}, WithSnapshot(func(_ context.Context, paymentID string) (interface{}, error) {
+		return map[string]string{"payment_id": paymentID, "status": "new"}, nil
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go b.Run(ctx) //nolint:errcheck
+	runEmitter(t, e)
+
+	authMdw := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+	srv := httptest.NewServer(MakeHTTPHandler(b, authMdw))
+	t.Cleanup(srv.Close)
+
+	return e, tokens, "ws" + strings.TrimPrefix(srv.URL, "http")
+}
+
+func dial(t *testing.T, url string, header http.Header) (*websocket.Conn, int) {
+	conn, resp, err := websocket.DefaultDialer.Dial(url, header)
+	if err != nil {
+		require.NotNil(t, resp, err)
+		return nil, resp.StatusCode
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn, resp.StatusCode
+}
+
+func readEvent(t *testing.T, conn *websocket.Conn) Event {
+	var e Event
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+	require.NoError(t, conn.ReadJSON(&e))
+	return e
+}
+
+func paymentEvent(paymentID, status string) PaymentStatusUpdatedPayload {
+	return PaymentStatusUpdatedPayload{PaymentID: PaymentID{PaymentID: paymentID}, Status: status}
+}
+
+func TestBroadcasterPaymentChannel(t *testing.T) {
+	e, tokens, url := newTestBroadcaster(t)
+
+	token, _, err := tokens.Issue("p1")
+	require.NoError(t, err)
+	otherToken, _, err := tokens.Issue("p2")
+	require.NoError(t, err)
+
+	_, status := dial(t, url+"/channel/p1", nil)
+	require.Equal(t, http.StatusUnauthorized, status)
+	_, status = dial(t, url+"/channel/p1?token="+otherToken, nil)
+	require.Equal(t, http.StatusUnauthorized, status)
+
+	conn, _ := dial(t, url+"/channel/p1?token="+token, nil)
+	snapshot := readEvent(t, conn)
+	require.Equal(t, SnapshotEvent, snapshot.Name)
+	require.Equal(t, map[string]interface{}{"payment_id": "p1", "status": "new"}, snapshot.Data)
+
+	require.NoError(t, e.Dispatch(context.Background(), PaymentProcessing, paymentEvent("p2", "pending")))
+	require.NoError(t, e.Dispatch(context.Background(), PaymentProcessing, paymentEvent("p1", "pending")))
+
+	received := readEvent(t, conn)
+	require.Equal(t, string(PaymentProcessing), received.Name)
+	require.Equal(t, "p1", received.Data.(map[string]interface{})["payment_id"])
+	require.NotEmpty(t, received.ID)
+	conn.Close()
+
+	// the events published while the client is disconnected are replayed instead of the snapshot,
+	// the internal events are skipped
+	require.NoError(t, e.Dispatch(context.Background(), TransactionSubmitted, TransactionSubmittedPayload{
+		PaymentID:   PaymentID{PaymentID: "p1"},
+		Transaction: "signed",
+	}))
+	require.NoError(t, e.Dispatch(context.Background(), PaymentSucceeded, paymentEvent("p1", "completed")))
+	conn, _ = dial(t, url+"/channel/p1?token="+token+"&last_event_id="+received.ID, nil)
+	missed := readEvent(t, conn)
+	require.Equal(t, string(PaymentSucceeded), missed.Name)
+	require.Equal(t, 1, compareStreamIDs(missed.ID, received.ID))
+
+	_, status = dial(t, url+"/channel/p1?token="+token+"&last_event_id=latest", nil)
+	require.Equal(t, http.StatusBadRequest, status)
+}
+
+func TestBroadcasterMerchantChannel(t *testing.T) {
+	e, _, url := newTestBroadcaster(t)
+
+	_, status := dial(t, url+"/merchant", nil)
+	require.Equal(t, http.StatusUnauthorized, status)
+
+	conn, _ := dial(t, url+"/merchant", http.Header{"Authorization": {"Bearer token"}})
+	// no snapshot on the merchant channel, let the server register the client
+	time.Sleep(100 * time.Millisecond)
+
+	require.NoError(t, e.Dispatch(context.Background(), TransactionSubmitted, TransactionSubmittedPayload{
+		PaymentID:   PaymentID{PaymentID: "p1"},
+		Transaction: "signed",
+	}))
+	require.NoError(t, e.Dispatch(context.Background(), WebhookEndpointDisabled, WebhookEndpointDisabledPayload{URL: "https://example.com"}))
+	require.NoError(t, e.Dispatch(context.Background(), PaymentProcessing, paymentEvent("p1", "pending")))
+	require.NoError(t, e.Dispatch(context.Background(), PaymentProcessing, paymentEvent("p2", "pending")))
+
+	// the internal events are not sent
+	first := readEvent(t, conn)
+	require.Equal(t, string(PaymentProcessing), first.Name)
+	require.Equal(t, "p1", first.Data.(map[string]interface{})["payment_id"])
+	require.Equal(t, "p2", readEvent(t, conn).Data.(map[string]interface{})["payment_id"])
+}
+
+func TestEventsAfterTrimmed(t *testing.T) {
+	rdb := newTestRedis(t)
+	e := NewRedisEmitter(rdb, testLogger{}, WithStreamMaxLen(1))
+	ctx := context.Background()
+
+	last, err := e.LastEventID(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "0-0", last)
+
+	require.NoError(t, e.Dispatch(context.Background(), PaymentProcessing, paymentEvent("p1", "pending")))
+	first, err := e.LastEventID(ctx)
+	require.NoError(t, err)
+	require.NoError(t, e.Dispatch(context.Background(), PaymentSucceeded, paymentEvent("p1", "completed")))
+	require.NoError(t, e.Dispatch(context.Background(), PaymentSucceeded, paymentEvent("p2", "completed")))
+
+	_, err = e.EventsAfter(ctx, first, 10)
+	require.ErrorIs(t, err, ErrEventTrimmed)
+
+	_, err = e.EventsAfter(ctx, "latest", 10)
+	require.ErrorIs(t, err, ErrInvalidEventID)
+}
@@ -4,6 +4,7 @@ go 1.19
 
 require (
 	filippo.io/edwards25519 v1.0.0-rc.1
+	github.com/alicebob/miniredis/v2 v2.31.1
 	github.com/dmitrymomot/go-env v1.0.2
 	github.com/dmitrymomot/random v1.0.6
 	github.com/dustin/go-broadcast v0.0.0-20211018055107-71439988bd91
@@ -14,6 +15,7 @@ require (
 	github.com/go-chi/cors v1.2.1
 	github.com/go-chi/oauth v0.0.0-20210913085627-d937e221b3ef
 	github.com/go-kit/kit v0.10.0
+	github.com/go-redis/redis/v8 v8.11.5
 	github.com/google/go-querystring v1.1.0
 	github.com/google/uuid v1.3.0
 	github.com/gookit/validate v1.4.6
@@ -37,6 +39,7 @@ require (
 )
 
 require (
+	github.com/alicebob/gopher-json v0.0.0-20200520072559-a9ecdc9d1d3a // indirect
 	github.com/btcsuite/btcd/btcec/v2 v2.3.2 // indirect
 	github.com/btcsuite/btcd/btcutil v1.1.3 // indirect
 	github.com/cespare/xxhash/v2 v2.2.0 // indirect
@@ -52,7 +55,6 @@ require (
 	github.com/everFinance/ttcrsa v1.1.3 // indirect
 	github.com/go-gorp/gorp/v3 v3.1.0 // indirect
 	github.com/go-logfmt/logfmt v0.5.1 // indirect
-	github.com/go-redis/redis/v8 v8.11.5 // indirect
 	github.com/go-stack/stack v1.8.1 // indirect
 	github.com/gofrs/uuid v4.0.0+incompatible // indirect
 	github.com/golang/protobuf v1.5.3 // indirect
@@ -79,6 +81,7 @@ require (
 	github.com/tidwall/gjson v1.14.1 // indirect
 	github.com/tidwall/match v1.1.1 // indirect
 	github.com/tidwall/pretty v1.2.0 // indirect
+	github.com/yuin/gopher-lua v1.1.0 // indirect
 	golang.org/x/sys v0.6.0 // indirect
 	golang.org/x/text v0.7.0 // indirect
 	golang.org/x/time v0.3.0 // indirect
@@ -113,3 +113,18 @@ func TestExchangeRate(t *testing.T) {
 	assert.Equal(t, usdcMint, exchangeRate.OutputMint)
 	assert.EqualValues(t, amount, exchangeRate.OutAmount)
 }
+
+func TestGetInputMintsForMint(t *testing.T) {
+	routesMap := jupiter.IndexedRoutesMap{
+		MintKeys: []string{wSolMint, usdcMint, "mint3"},
+		IndexedRouteMap: map[string][]int{
+			"0": {1},
+			"1": {0, 2},
+			"2": {0},
+		},
+	}
+
+	assert.ElementsMatch(t, []string{wSolMint}, routesMap.GetInputMintsForMint(usdcMint))
+	assert.ElementsMatch(t, []string{usdcMint, "mint3"}, routesMap.GetInputMintsForMint(wSolMint))
+	assert.Empty(t, routesMap.GetInputMintsForMint("unknown"))
+}
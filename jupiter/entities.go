@@ -141,6 +141,39 @@ func (r *IndexedRoutesMap) GetRoutesForMint(mint string) []string {
 	return result
 }
 
+// GetInputMintsForMint returns all the mints that can be swapped into the given output mint.
+func (r *IndexedRoutesMap) GetInputMintsForMint(mint string) []string {
+	// Find index of mint in mintKeys.
+	outputKey := -1
+	for key, val := range r.MintKeys {
+		if val == mint {
+			outputKey = key
+			break
+		}
+	}
+	if outputKey < 0 {
+		return []string{}
+	}
+
+	// Find all the input mints which have the output mint in their routes.
+	result := make([]string, 0)
+	for inputKey, outputKeys := range r.IndexedRouteMap {
+		for _, key := range outputKeys {
+			if key != outputKey {
+				continue
+			}
+			idx, err := strconv.Atoi(inputKey)
+			if err != nil || idx < 0 || idx >= len(r.MintKeys) {
+				break
+			}
+			result = append(result, r.MintKeys[idx])
+			break
+		}
+	}
+
+	return result
+}
+
 // BestSwapParams contains the parameters for the best swap route.
 type BestSwapParams struct {
 	UserPublicKey        string // user base58 encoded public key
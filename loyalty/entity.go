@@ -0,0 +1,68 @@
+package loyalty
+
+import (
+	"time"
+
+	"github.com/easypmnt/checkout-api/repository"
+	"github.com/google/uuid"
+)
+
+// EntryType represents the type of a loyalty ledger entry.
+type EntryType string
+
+// Predefined ledger entry types.
+const (
+	EntryTypeAccrual    EntryType = "accrual"    // bonus tokens minted for the payment
+	EntryTypeRedemption EntryType = "redemption" // bonus tokens burned to get a discount
+	EntryTypeAdjustment EntryType = "adjustment" // manual correction, can be positive or negative
+	EntryTypeExpiry     EntryType = "expiry"     // expired bonus tokens
+)
+
+// Entry represents a single loyalty ledger entry.
+// Amount is signed: accruals are positive, redemptions and expiries are negative.
+type Entry struct {
+	ID            uuid.UUID  `json:"id"`
+	Wallet        string     `json:"wallet"`
+	Mint          string     `json:"mint"`
+	Type          EntryType  `json:"type"`
+	Amount        int64      `json:"amount"`
+	TransactionID *uuid.UUID `json:"transaction_id,omitempty"`
+	Note          string     `json:"note,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// Wallet represents the loyalty balance and history of the customer wallet.
+type Wallet struct {
+	Wallet  string  `json:"wallet"`
+	Mint    string  `json:"mint"`
+	Balance int64   `json:"balance"`
+	Tier    string  `json:"tier,omitempty"`
+	History []Entry `json:"history"`
+}
+
+// Discrepancy represents the difference between the ledger and on-chain bonus balances.
+type Discrepancy struct {
+	Wallet         string `json:"wallet"`
+	LedgerBalance  int64  `json:"ledger_balance"`
+	OnChainBalance uint64 `json:"on_chain_balance"`
+	Difference     int64  `json:"difference"` // on-chain balance minus ledger balance
+}
+
+// cast repository.LoyaltyLedger to loyalty.Entry
+func castFromRepositoryEntry(e repository.LoyaltyLedger) Entry {
+	result := Entry{
+		ID:        e.ID,
+		Wallet:    e.Wallet,
+		Mint:      e.Mint,
+		Type:      EntryType(e.EntryType),
+		Amount:    e.Amount,
+		Note:      e.Note.String,
+		CreatedAt: e.CreatedAt,
+	}
+
+	if e.TransactionID.Valid {
+		result.TransactionID = &e.TransactionID.UUID
+	}
+
+	return result
+}
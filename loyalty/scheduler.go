@@ -0,0 +1,16 @@
+package loyalty
+
+import "github.com/hibiken/asynq"
+
+// Scheduler is a task scheduler for the loyalty ledger.
+type Scheduler struct{}
+
+// NewScheduler creates a new task scheduler for the loyalty ledger.
+func NewScheduler() *Scheduler {
+	return &Scheduler{}
+}
+
+// Schedule tasks for the loyalty ledger.
+func (s *Scheduler) Schedule(scheduler *asynq.Scheduler) {
+	scheduler.Register("@daily", asynq.NewTask(TaskReconcileLedger, nil))
+}
@@ -0,0 +1,249 @@
+package loyalty
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/easypmnt/checkout-api/events"
+	"github.com/easypmnt/checkout-api/repository"
+	"github.com/easypmnt/checkout-api/solana"
+	"github.com/google/uuid"
+)
+
+type (
+	// Service is an off-chain ledger of bonus tokens accrued and redeemed by customer wallets.
+	Service struct {
+		repo      LedgerRepository
+		sol       solanaClient
+		bonusMint string
+		tiers     []Tier
+		fireEvent fireEventFunc
+	}
+
+	// ServiceOption is a function that configures a loyalty service.
+	ServiceOption func(*Service)
+
+	fireEventFunc func(event events.EventName, payload interface{})
+
+	// LedgerRepository stores the ledger entries and the wallet tiers.
+	LedgerRepository interface {
+		CreateLoyaltyLedgerEntry(ctx context.Context, arg repository.CreateLoyaltyLedgerEntryParams) error
+		GetLoyaltyBalance(ctx context.Context, arg repository.GetLoyaltyBalanceParams) (int64, error)
+		GetLoyaltyLedgerEntries(ctx context.Context, arg repository.GetLoyaltyLedgerEntriesParams) ([]repository.LoyaltyLedger, error)
+		GetLoyaltyBalances(ctx context.Context, mint string) ([]repository.GetLoyaltyBalancesRow, error)
+		GetLoyaltyWalletTier(ctx context.Context, wallet string) (string, error)
+		UpsertLoyaltyWalletTier(ctx context.Context, arg repository.UpsertLoyaltyWalletTierParams) error
+		GetWalletSpendStats(ctx context.Context, sourceWallet string) ([]repository.GetWalletSpendStatsRow, error)
+	}
+
+	solanaClient interface {
+		GetTokenBalance(ctx context.Context, base58Addr, base58MintAddr string) (solana.Balance, error)
+	}
+)
+
+// NewService creates a new loyalty ledger service for the given bonus mint.
+func NewService(repo LedgerRepository, sol solanaClient, bonusMint string, opts ...ServiceOption) *Service {
+	s := &Service{
+		repo:      repo,
+		sol:       sol,
+		bonusMint: bonusMint,
+		fireEvent: func(events.EventName, interface{}) {},
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// WithTiers configures the loyalty tiers, ordered from the lowest to the highest level.
+// If no tiers are set, the global bonus rules of the payment service are used.
+func WithTiers(tiers ...Tier) ServiceOption {
+	return func(s *Service) {
+		s.tiers = append(s.tiers, tiers...)
+	}
+}
+
+// WithEventEmitter configures the function to emit loyalty events, e.g. tier changes.
+func WithEventEmitter(fn fireEventFunc) ServiceOption {
+	return func(s *Service) {
+		s.fireEvent = fn
+	}
+}
+
+// WithRepository returns a copy of the service on the given repository,
+// e.g. bound to the database transaction of the completed payment transaction.
+// The tier change event is emitted right away, before the database transaction is committed.
+func (s *Service) WithRepository(repo LedgerRepository) *Service {
+	c := *s
+	c.repo = repo
+	return &c
+}
+
+// RecordTransaction writes accrual and redemption entries for the completed payment transaction.
+// It is safe to call it several times for the same transaction, duplicates are ignored.
+func (s *Service) RecordTransaction(ctx context.Context, txID uuid.UUID, wallet string, accrued, redeemed uint64) error {
+	if redeemed > 0 {
+		if err := s.addEntry(ctx, wallet, EntryTypeRedemption, -int64(redeemed), &txID, ""); err != nil {
+			return err
+		}
+	}
+	if accrued > 0 {
+		if err := s.addEntry(ctx, wallet, EntryTypeAccrual, int64(accrued), &txID, ""); err != nil {
+			return err
+		}
+	}
+
+	return s.updateTier(ctx, wallet)
+}
+
+// Tier returns the loyalty tier of the given wallet based on its completed transactions.
+// Returns nil if no tiers are configured or the wallet does not reach any tier.
+func (s *Service) Tier(ctx context.Context, wallet string) (*Tier, error) {
+	if len(s.tiers) == 0 {
+		return nil, nil
+	}
+
+	stats, err := s.repo.GetWalletSpendStats(ctx, wallet)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallet spend stats: %w", err)
+	}
+
+	var purchases uint64
+	spend := make(map[string]uint64, len(stats))
+	for _, row := range stats {
+		spend[row.DestinationMint] = uint64(row.Spend)
+		purchases += uint64(row.Purchases)
+	}
+
+	return resolveTier(s.tiers, spend, purchases), nil
+}
+
+// AddEntry writes an entry which is not related to a payment transaction, e.g. adjustment or expiry.
+// Amount is signed: positive amount increases the wallet balance, negative amount decreases it.
+func (s *Service) AddEntry(ctx context.Context, wallet string, entryType EntryType, amount int64, note string) error {
+	return s.addEntry(ctx, wallet, entryType, amount, nil, note)
+}
+
+// GetWallet returns the ledger balance and the latest entries of the given wallet.
+func (s *Service) GetWallet(ctx context.Context, wallet string, limit, offset int32) (*Wallet, error) {
+	balance, err := s.repo.GetLoyaltyBalance(ctx, repository.GetLoyaltyBalanceParams{
+		Wallet: wallet,
+		Mint:   s.bonusMint,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get loyalty balance: %w", err)
+	}
+
+	entries, err := s.repo.GetLoyaltyLedgerEntries(ctx, repository.GetLoyaltyLedgerEntriesParams{
+		Wallet: wallet,
+		Mint:   s.bonusMint,
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get loyalty ledger entries: %w", err)
+	}
+
+	tier, err := s.Tier(ctx, wallet)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Wallet{
+		Wallet:  wallet,
+		Mint:    s.bonusMint,
+		Balance: balance,
+		History: make([]Entry, 0, len(entries)),
+	}
+	for _, e := range entries {
+		result.History = append(result.History, castFromRepositoryEntry(e))
+	}
+	if tier != nil {
+		result.Tier = tier.Name
+	}
+
+	return result, nil
+}
+
+// Reconcile compares the ledger balances with on-chain bonus token balances
+// and returns wallets whose balances differ.
+// Wallets whose on-chain balance can't be fetched are skipped.
+func (s *Service) Reconcile(ctx context.Context) ([]Discrepancy, error) {
+	balances, err := s.repo.GetLoyaltyBalances(ctx, s.bonusMint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get loyalty balances: %w", err)
+	}
+
+	result := make([]Discrepancy, 0)
+	for _, b := range balances {
+		onChain, err := s.sol.GetTokenBalance(ctx, b.Wallet, s.bonusMint)
+		if err != nil {
+			continue
+		}
+		if int64(onChain.Amount) == b.Balance {
+			continue
+		}
+		result = append(result, Discrepancy{
+			Wallet:         b.Wallet,
+			LedgerBalance:  b.Balance,
+			OnChainBalance: onChain.Amount,
+			Difference:     int64(onChain.Amount) - b.Balance,
+		})
+	}
+
+	return result, nil
+}
+
+func (s *Service) addEntry(ctx context.Context, wallet string, entryType EntryType, amount int64, txID *uuid.UUID, note string) error {
+	params := repository.CreateLoyaltyLedgerEntryParams{
+		Wallet:    wallet,
+		Mint:      s.bonusMint,
+		EntryType: repository.LoyaltyEntryType(entryType),
+		Amount:    amount,
+		Note:      sql.NullString{String: note, Valid: note != ""},
+	}
+	if txID != nil {
+		params.TransactionID = uuid.NullUUID{UUID: *txID, Valid: true}
+	}
+
+	if err := s.repo.CreateLoyaltyLedgerEntry(ctx, params); err != nil {
+		return fmt.Errorf("failed to create loyalty ledger %s entry: %w", entryType, err)
+	}
+
+	return nil
+}
+
+// updateTier stores the current tier of the wallet and emits an event if it has changed.
+func (s *Service) updateTier(ctx context.Context, wallet string) error {
+	tier, err := s.Tier(ctx, wallet)
+	if err != nil || tier == nil {
+		return err
+	}
+
+	prevTier, err := s.repo.GetLoyaltyWalletTier(ctx, wallet)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to get loyalty wallet tier: %w", err)
+	}
+	if prevTier == tier.Name {
+		return nil
+	}
+
+	if err := s.repo.UpsertLoyaltyWalletTier(ctx, repository.UpsertLoyaltyWalletTierParams{
+		Wallet: wallet,
+		Tier:   tier.Name,
+	}); err != nil {
+		return fmt.Errorf("failed to update loyalty wallet tier: %w", err)
+	}
+
+	s.fireEvent(events.LoyaltyTierChanged, events.LoyaltyTierChangedPayload{
+		Wallet:       wallet,
+		PreviousTier: prevTier,
+		Tier:         tier.Name,
+	})
+
+	return nil
+}
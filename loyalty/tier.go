@@ -0,0 +1,45 @@
+package loyalty
+
+// Tier represents a loyalty program level with its own bonus rules.
+// A wallet reaches the tier when its cumulative spend in any of the mints or its purchase count
+// reaches the threshold, a tier without thresholds is the base level available to everyone.
+// The spend, the accrual rates and the discount caps are set per destination mint,
+// since amounts of different mints are in different base units. The merchant's rules apply to the mints not listed.
+type Tier struct {
+	Name                 string            `json:"name"`
+	MinSpend             map[string]uint64 `json:"min_spend"`               // cumulative amount of completed transactions by destination mint address, in base units of the mint
+	MinPurchases         uint64            `json:"min_purchases"`           // number of completed transactions
+	AccrueBonusRates     map[string]uint64 `json:"accrue_bonus_rates"`      // accrual rates by destination mint address, 10000 = 100%, 100 = 1%, 0 = no accrual
+	MaxApplyBonusPercent uint16            `json:"max_apply_bonus_percent"` // 10000 = 100%, 0 = unlimited
+	MaxApplyBonusAmounts map[string]uint64 `json:"max_apply_bonus_amounts"` // max discount per payment by destination mint address, in base units of the mint, 0 = unlimited
+}
+
+// qualifies checks if the wallet with the given spend by mint and purchase count reaches the tier.
+func (t Tier) qualifies(spend map[string]uint64, purchases uint64) bool {
+	hasMinSpend := false
+	for mint, min := range t.MinSpend {
+		if min == 0 {
+			continue
+		}
+		if spend[mint] >= min {
+			return true
+		}
+		hasMinSpend = true
+	}
+	if t.MinPurchases > 0 {
+		return purchases >= t.MinPurchases
+	}
+	return !hasMinSpend
+}
+
+// resolveTier returns the highest tier the wallet reaches.
+// Tiers must be ordered from the lowest to the highest level.
+func resolveTier(tiers []Tier, spend map[string]uint64, purchases uint64) *Tier {
+	var result *Tier
+	for i := range tiers {
+		if tiers[i].qualifies(spend, purchases) {
+			result = &tiers[i]
+		}
+	}
+	return result
+}
@@ -0,0 +1,58 @@
+package loyalty
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hibiken/asynq"
+)
+
+// Task names.
+const (
+	TaskReconcileLedger = "reconcile_loyalty_ledger"
+)
+
+type (
+	// Worker is a task handler for the loyalty ledger.
+	Worker struct {
+		svc service
+		log logger
+	}
+
+	service interface {
+		Reconcile(ctx context.Context) ([]Discrepancy, error)
+	}
+
+	logger interface {
+		Infof(format string, args ...interface{})
+		Errorf(format string, args ...interface{})
+	}
+)
+
+// NewWorker creates a new loyalty ledger task handler.
+func NewWorker(svc service, log logger) *Worker {
+	return &Worker{svc: svc, log: log}
+}
+
+// Register registers task handlers for the loyalty ledger.
+func (w *Worker) Register(mux *asynq.ServeMux) {
+	mux.HandleFunc(TaskReconcileLedger, w.ReconcileLedger)
+}
+
+// ReconcileLedger compares the ledger with on-chain bonus balances and reports discrepancies.
+func (w *Worker) ReconcileLedger(ctx context.Context, t *asynq.Task) error {
+	discrepancies, err := w.svc.Reconcile(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to reconcile loyalty ledger: %w", err)
+	}
+
+	for _, d := range discrepancies {
+		w.log.Errorf(
+			"loyalty ledger discrepancy: wallet=%s, ledger_balance=%d, on_chain_balance=%d, difference=%d",
+			d.Wallet, d.LedgerBalance, d.OnChainBalance, d.Difference,
+		)
+	}
+	w.log.Infof("loyalty ledger reconciled: %d discrepancies found", len(discrepancies))
+
+	return nil
+}
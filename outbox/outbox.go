@@ -0,0 +1,54 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/easypmnt/checkout-api/events"
+	"github.com/easypmnt/checkout-api/repository"
+	"github.com/google/uuid"
+)
+
+// writer stores the events in the outbox table.
+type writer interface {
+	CreateOutboxEvent(ctx context.Context, arg repository.CreateOutboxEventParams) error
+	GetPaymentForUpdate(ctx context.Context, id uuid.UUID) (repository.Payment, error)
+}
+
+// Record stores the event in the outbox, the relay publishes it to the listeners.
+// Call it with the repository bound to the database transaction of the state change,
+// so the event is stored if and only if the change is committed.
+// The payment row of the event is locked until the transaction ends, so the events of the payment
+// get their ids in the commit order and are published in the order they are recorded.
+// The source of the event is taken from the context, see events.WithSource.
+func Record(ctx context.Context, w writer, name events.EventName, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode payload of event %s: %w", name, err)
+	}
+
+	var paymentID uuid.NullUUID
+	if p, ok := payload.(events.PaymentIDGetter); ok {
+		id, err := uuid.Parse(p.GetPaymentID())
+		if err != nil {
+			return fmt.Errorf("failed to parse payment id of event %s: %w", name, err)
+		}
+		paymentID = uuid.NullUUID{UUID: id, Valid: true}
+
+		if _, err := w.GetPaymentForUpdate(ctx, id); err != nil {
+			return fmt.Errorf("failed to lock payment of event %s: %w", name, err)
+		}
+	}
+
+	if err := w.CreateOutboxEvent(ctx, repository.CreateOutboxEventParams{
+		Event:     string(name),
+		Payload:   data,
+		PaymentID: paymentID,
+		Source:    events.SourceFromContext(ctx),
+	}); err != nil {
+		return fmt.Errorf("failed to record event %s: %w", name, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,223 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/easypmnt/checkout-api/events"
+	"github.com/easypmnt/checkout-api/repository"
+	"github.com/google/uuid"
+)
+
+// Default relay settings.
+const (
+	DefaultPollInterval = 500 * time.Millisecond
+	DefaultBatchSize    = 100
+	maxRetryDelay       = 10 * time.Minute
+
+	// relayLockKey is the key of the advisory lock held by the relay,
+	// so only one replica publishes the events at a time.
+	relayLockKey int64 = 0x6f7574626f78 // "outbox"
+)
+
+type (
+	// Relay publishes the events recorded in the outbox to the dispatcher with at-least-once semantics.
+	// An event is marked as published once the dispatcher accepts it: the in-memory emitter calls
+	// the listeners, the Redis emitter adds the event to the stream. Otherwise it's retried with exponential backoff.
+	// The events of the same payment are published in order: an event waits until all the earlier
+	// events of the payment are published.
+	Relay struct {
+		db           txBeginner
+		repo         relayRepository
+		dispatcher   dispatcher
+		pollInterval time.Duration
+		batchSize    int32
+		log          logger
+	}
+
+	// RelayOption is a function that configures the relay.
+	RelayOption func(*Relay)
+
+	// txBeginner starts database transactions.
+	txBeginner interface {
+		BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+	}
+
+	relayRepository interface {
+		MarkOutboxEventPublished(ctx context.Context, id int64) error
+		RecordOutboxEventFailure(ctx context.Context, arg repository.RecordOutboxEventFailureParams) error
+		WithTx(tx *sql.Tx) *repository.Queries
+	}
+
+	// dispatcher calls the event listeners synchronously.
+	dispatcher interface {
+		Dispatch(ctx context.Context, name events.EventName, payload interface{}) error
+	}
+
+	logger interface {
+		Errorf(format string, args ...interface{})
+	}
+)
+
+// NewRelay creates a new outbox relay.
+func NewRelay(db txBeginner, repo relayRepository, d dispatcher, opts ...RelayOption) *Relay {
+	r := &Relay{
+		db:           db,
+		repo:         repo,
+		dispatcher:   d,
+		pollInterval: DefaultPollInterval,
+		batchSize:    DefaultBatchSize,
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// WithPollInterval configures how often the relay checks the outbox for new events.
+func WithPollInterval(d time.Duration) RelayOption {
+	return func(r *Relay) {
+		r.pollInterval = d
+	}
+}
+
+// WithBatchSize configures the max number of events published in one run.
+func WithBatchSize(n int) RelayOption {
+	return func(r *Relay) {
+		r.batchSize = int32(n)
+	}
+}
+
+// WithLogger configures the relay logger.
+func WithLogger(l logger) RelayOption {
+	return func(r *Relay) {
+		r.log = l
+	}
+}
+
+// Run publishes the events until the context is canceled.
+// A full batch is followed by the next one right away, so the backlog is published
+// without waiting for the poll interval.
+func (r *Relay) Run(ctx context.Context) error {
+	ticker := time.NewTicker(r.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			for {
+				n, err := r.Publish(ctx)
+				if err != nil {
+					r.errorf("failed to publish outbox events: %v", err)
+				}
+				if err != nil || n < int(r.batchSize) || ctx.Err() != nil {
+					break
+				}
+			}
+		}
+	}
+}
+
+// Publish publishes a batch of due events and returns the number of processed events.
+// Does nothing if another replica holds the relay lock.
+func (r *Relay) Publish(ctx context.Context) (int, error) {
+	dbTx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin db transaction: %w", err)
+	}
+	// The transaction only holds the lock, the events are marked as published one by one,
+	// so the published events are not dispatched again if the relay dies in the middle of the batch.
+	defer dbTx.Rollback() //nolint:errcheck
+
+	q := r.repo.WithTx(dbTx)
+
+	locked, err := q.LockOutboxRelay(ctx, relayLockKey)
+	if err != nil {
+		return 0, fmt.Errorf("failed to lock outbox relay: %w", err)
+	}
+	if !locked {
+		return 0, nil
+	}
+
+	batch, err := q.GetDueOutboxEvents(ctx, r.batchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get outbox events: %w", err)
+	}
+
+	if err := r.publish(ctx, batch); err != nil {
+		return 0, err
+	}
+
+	return len(batch), nil
+}
+
+// publish dispatches the events in order. Once an event of a payment fails,
+// the rest of the events of the payment are skipped till the next run.
+func (r *Relay) publish(ctx context.Context, batch []repository.EventsOutbox) error {
+	blocked := make(map[uuid.UUID]bool)
+
+	for _, e := range batch {
+		if e.PaymentID.Valid && blocked[e.PaymentID.UUID] {
+			continue
+		}
+
+		if err := r.dispatch(ctx, e); err != nil {
+			if e.PaymentID.Valid {
+				blocked[e.PaymentID.UUID] = true
+			}
+			if err := r.repo.RecordOutboxEventFailure(ctx, repository.RecordOutboxEventFailureParams{
+				ID:            e.ID,
+				LastError:     sql.NullString{String: err.Error(), Valid: true},
+				NextAttemptAt: time.Now().Add(retryDelay(int(e.Attempts) + 1)),
+			}); err != nil {
+				return fmt.Errorf("failed to record failure of outbox event %d: %w", e.ID, err)
+			}
+			r.errorf("outbox event %d %s: %v", e.ID, e.Event, err)
+			continue
+		}
+
+		if err := r.repo.MarkOutboxEventPublished(ctx, e.ID); err != nil {
+			return fmt.Errorf("failed to mark outbox event %d as published: %w", e.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// dispatch decodes the event payload and calls the listeners.
+// The outbox ID is the event ID, so it's the same for every redelivery of the event.
+func (r *Relay) dispatch(ctx context.Context, e repository.EventsOutbox) error {
+	name := events.EventName(e.Event)
+	payload, err := events.DecodePayload(name, e.Payload)
+	if err != nil {
+		return err
+	}
+
+	return r.dispatcher.Dispatch(events.WithEventID(ctx, strconv.FormatInt(e.ID, 10)), name, payload)
+}
+
+func (r *Relay) errorf(format string, args ...interface{}) {
+	if r.log != nil {
+		r.log.Errorf(format, args...)
+	}
+}
+
+// retryDelay returns the delay before the given attempt: 1s, 2s, 4s, ... up to maxRetryDelay.
+// The event is never dropped, it's retried until the listeners succeed.
+func retryDelay(attempt int) time.Duration {
+	if attempt > 20 {
+		return maxRetryDelay
+	}
+	d := time.Second << (attempt - 1)
+	if d > maxRetryDelay {
+		return maxRetryDelay
+	}
+	return d
+}
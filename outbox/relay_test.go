@@ -0,0 +1,92 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/easypmnt/checkout-api/events"
+	"github.com/easypmnt/checkout-api/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeRepo struct {
+	published []int64
+	failed    []int64
+}
+
+func (r *fakeRepo) MarkOutboxEventPublished(_ context.Context, id int64) error {
+	r.published = append(r.published, id)
+	return nil
+}
+
+func (r *fakeRepo) RecordOutboxEventFailure(_ context.Context, arg repository.RecordOutboxEventFailureParams) error {
+	r.failed = append(r.failed, arg.ID)
+	return nil
+}
+
+func (r *fakeRepo) WithTx(*sql.Tx) *repository.Queries { return nil }
+
+type fakeDispatcher struct {
+	failStatus string
+	dispatched []interface{}
+	ids        []string
+}
+
+func (d *fakeDispatcher) Dispatch(ctx context.Context, _ events.EventName, payload interface{}) error {
+	d.dispatched = append(d.dispatched, payload)
+	d.ids = append(d.ids, events.EventIDFromContext(ctx))
+	if p, ok := payload.(events.PaymentStatusUpdatedPayload); ok && p.Status == d.failStatus {
+		return errors.New("listener failed")
+	}
+	return nil
+}
+
+func outboxEvent(t *testing.T, id int64, paymentID uuid.UUID, status string) repository.EventsOutbox {
+	payload, err := json.Marshal(events.PaymentStatusUpdatedPayload{
+		PaymentID: events.PaymentID{PaymentID: paymentID.String()},
+		Status:    status,
+	})
+	require.NoError(t, err)
+
+	return repository.EventsOutbox{
+		ID:        id,
+		Event:     string(events.PaymentProcessing),
+		Payload:   payload,
+		PaymentID: uuid.NullUUID{UUID: paymentID, Valid: true},
+	}
+}
+
+func TestRelayPublishOrder(t *testing.T) {
+	p1, p2 := uuid.New(), uuid.New()
+	repo := &fakeRepo{}
+	d := &fakeDispatcher{failStatus: "pending"}
+	r := NewRelay(nil, repo, d)
+
+	err := r.publish(context.Background(), []repository.EventsOutbox{
+		outboxEvent(t, 1, p1, "pending"),
+		outboxEvent(t, 2, p2, "pending"),
+		outboxEvent(t, 3, p1, "completed"), // must wait for event 1
+		outboxEvent(t, 4, uuid.New(), "completed"),
+	})
+	require.NoError(t, err)
+	require.Equal(t, []int64{1, 2}, repo.failed)
+	require.Equal(t, []int64{4}, repo.published)
+
+	// the payload is decoded to the type the listeners expect
+	require.IsType(t, events.PaymentStatusUpdatedPayload{}, d.dispatched[0])
+	require.Len(t, d.dispatched, 3)
+	// the listeners get the outbox ID as the event ID
+	require.Equal(t, []string{"1", "2", "4"}, d.ids)
+}
+
+func TestRetryDelay(t *testing.T) {
+	require.Equal(t, time.Second, retryDelay(1))
+	require.Equal(t, 8*time.Second, retryDelay(4))
+	require.Equal(t, maxRetryDelay, retryDelay(15))
+	require.Equal(t, maxRetryDelay, retryDelay(1000))
+}
@@ -0,0 +1,186 @@
+package payments
+
+import (
+	"strings"
+	"time"
+
+	"github.com/easypmnt/checkout-api/repository"
+	"github.com/google/uuid"
+)
+
+// AffiliateCommissionType represents how the affiliate commission is calculated.
+type AffiliateCommissionType string
+
+// Predefined affiliate commission types.
+const (
+	AffiliateCommissionTypePercent AffiliateCommissionType = "percent" // commission of the sale amount, 10000 = 100%
+	AffiliateCommissionTypeFixed   AffiliateCommissionType = "fixed"   // commission in base units of the payment destination mint
+)
+
+// IsValid checks if the commission type is supported.
+func (t AffiliateCommissionType) IsValid() bool {
+	return t == AffiliateCommissionTypePercent || t == AffiliateCommissionTypeFixed
+}
+
+// AffiliatePayoutMode represents how the affiliate commission is paid.
+type AffiliatePayoutMode string
+
+// Predefined affiliate payout modes.
+const (
+	AffiliatePayoutModeInline  AffiliatePayoutMode = "inline"  // transferred to the affiliate wallet by the payment transaction
+	AffiliatePayoutModeAccrued AffiliatePayoutMode = "accrued" // accrued and paid later by the batch payout
+)
+
+// IsValid checks if the payout mode is supported.
+func (m AffiliatePayoutMode) IsValid() bool {
+	return m == AffiliatePayoutModeInline || m == AffiliatePayoutModeAccrued
+}
+
+// AffiliateCommissionStatus represents the payout status of the affiliate commission.
+type AffiliateCommissionStatus string
+
+// Predefined affiliate commission statuses.
+const (
+	AffiliateCommissionStatusAccrued    AffiliateCommissionStatus = "accrued"    // waiting for the batch payout
+	AffiliateCommissionStatusProcessing AffiliateCommissionStatus = "processing" // payout transaction is sent, but not confirmed yet
+	AffiliateCommissionStatusPaid       AffiliateCommissionStatus = "paid"
+)
+
+// Affiliate represents a partner who refers customers with the `ref` code of the payment link.
+type Affiliate struct {
+	ID              uuid.UUID               `json:"id"`
+	Name            string                  `json:"name"`
+	RefCode         string                  `json:"ref_code"`
+	Wallet          string                  `json:"wallet"` // commissions are paid to this wallet
+	CommissionType  AffiliateCommissionType `json:"commission_type"`
+	CommissionValue uint64                  `json:"commission_value"`
+	PayoutMode      AffiliatePayoutMode     `json:"payout_mode"`
+	Active          bool                    `json:"active"`
+	CreatedAt       time.Time               `json:"created_at"`
+}
+
+// AffiliateCommission represents the commission earned by the affiliate for the completed payment.
+type AffiliateCommission struct {
+	ID              uuid.UUID                 `json:"id"`
+	AffiliateID     uuid.UUID                 `json:"affiliate_id"`
+	TransactionID   uuid.UUID                 `json:"transaction_id"`
+	PaymentID       uuid.UUID                 `json:"payment_id"`
+	Mint            string                    `json:"mint"`
+	SaleAmount      uint64                    `json:"sale_amount"` // payment amount after discounts
+	Amount          uint64                    `json:"amount"`
+	Status          AffiliateCommissionStatus `json:"status"`
+	PayoutSignature string                    `json:"payout_signature,omitempty"`
+	PayoutAt        *time.Time                `json:"payout_at,omitempty"`
+	CreatedAt       time.Time                 `json:"created_at"`
+}
+
+// AffiliatePayout represents the batch payout of the accrued affiliate commissions.
+type AffiliatePayout struct {
+	AffiliateID uuid.UUID              `json:"affiliate_id"`
+	Wallet      string                 `json:"wallet"`
+	Signature   string                 `json:"signature"`
+	Amounts     []AffiliatePayoutTotal `json:"amounts"`
+	Commissions []AffiliateCommission  `json:"commissions"`
+}
+
+// AffiliatePayoutTotal represents the amount paid out in a single mint.
+type AffiliatePayoutTotal struct {
+	Mint   string `json:"mint"`
+	Amount uint64 `json:"amount"`
+}
+
+// AffiliateReport represents the affiliate sales and commissions for the period.
+type AffiliateReport struct {
+	Affiliate   *Affiliate             `json:"affiliate"`
+	Since       time.Time              `json:"since"`
+	Until       time.Time              `json:"until"`
+	Totals      []AffiliateReportTotal `json:"totals"`
+	Commissions []AffiliateCommission  `json:"commissions"`
+}
+
+// AffiliateReportTotal represents the affiliate totals in a single mint.
+type AffiliateReportTotal struct {
+	Mint             string `json:"mint"`
+	Payments         uint64 `json:"payments"`
+	SalesAmount      uint64 `json:"sales_amount"`
+	CommissionAmount uint64 `json:"commission_amount"`
+	AccruedAmount    uint64 `json:"accrued_amount"`
+	ProcessingAmount uint64 `json:"processing_amount"`
+	PaidAmount       uint64 `json:"paid_amount"`
+}
+
+// NewRefCode generates a random affiliate ref code, e.g. "7KQM-X2RD".
+func NewRefCode() (string, error) {
+	return randomCode(8)
+}
+
+// NormalizeRefCode returns the ref code in the form it is stored, codes are case-insensitive.
+func NormalizeRefCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// commission returns the affiliate commission for the given sale amount.
+// The fixed commission is capped by the sale amount.
+func (a *Affiliate) commission(amount uint64) uint64 {
+	var result uint64
+	switch a.CommissionType {
+	case AffiliateCommissionTypePercent:
+		result = amount * a.CommissionValue / 10000
+	case AffiliateCommissionTypeFixed:
+		result = a.CommissionValue
+	}
+	if result > amount {
+		result = amount
+	}
+	return result
+}
+
+// cast repository.Affiliate to payments.Affiliate
+func castFromRepositoryAffiliate(a repository.Affiliate) *Affiliate {
+	return &Affiliate{
+		ID:              a.ID,
+		Name:            a.Name,
+		RefCode:         a.RefCode,
+		Wallet:          a.Wallet,
+		CommissionType:  AffiliateCommissionType(a.CommissionType),
+		CommissionValue: uint64(a.CommissionValue),
+		PayoutMode:      AffiliatePayoutMode(a.PayoutMode),
+		Active:          a.Active,
+		CreatedAt:       a.CreatedAt,
+	}
+}
+
+// cast repository.AffiliateCommission to payments.AffiliateCommission
+func castFromRepositoryAffiliateCommission(c repository.AffiliateCommission) AffiliateCommission {
+	result := AffiliateCommission{
+		ID:              c.ID,
+		AffiliateID:     c.AffiliateID,
+		TransactionID:   c.TransactionID,
+		PaymentID:       c.PaymentID,
+		Mint:            c.Mint,
+		SaleAmount:      uint64(c.SaleAmount),
+		Amount:          uint64(c.Amount),
+		Status:          AffiliateCommissionStatus(c.Status),
+		PayoutSignature: c.PayoutSignature.String,
+		CreatedAt:       c.CreatedAt,
+	}
+
+	if c.PayoutAt.Valid {
+		result.PayoutAt = &c.PayoutAt.Time
+	}
+
+	return result
+}
+
+// cast repository.GetAffiliateReportRow to payments.AffiliateReportTotal
+func castFromRepositoryAffiliateReportRow(r repository.GetAffiliateReportRow) AffiliateReportTotal {
+	return AffiliateReportTotal{
+		Mint:             r.Mint,
+		Payments:         uint64(r.Payments),
+		SalesAmount:      uint64(r.SalesAmount),
+		CommissionAmount: uint64(r.CommissionAmount),
+		AccruedAmount:    uint64(r.AccruedAmount),
+		ProcessingAmount: uint64(r.ProcessingAmount),
+		PaidAmount:       uint64(r.PaidAmount),
+	}
+}
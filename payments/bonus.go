@@ -0,0 +1,107 @@
+package payments
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+)
+
+// BonusValueBasis represents the value the bonus accrual rate is applied to.
+type BonusValueBasis string
+
+// Predefined bonus value bases.
+const (
+	BonusValueBasisToken BonusValueBasis = "token" // tokens of the payment destination mint: 1 token paid = 1 bonus token at 100% rate
+	BonusValueBasisUSD   BonusValueBasis = "usd"   // USD value of the payment quoted in USDC: 1 USD paid = 1 bonus token at 100% rate
+)
+
+// IsValid checks if the bonus value basis is supported.
+func (v BonusValueBasis) IsValid() bool {
+	return v == BonusValueBasisToken || v == BonusValueBasisUSD
+}
+
+// decimalsResolver resolves the number of decimals of the token mint.
+type decimalsResolver interface {
+	Decimals(ctx context.Context, mint string) (uint8, error)
+}
+
+// mintDecimals resolves the number of decimals of the token mints using default tokens,
+// the token registry and on-chain token supply, in that order.
+// Decimals never change, so resolved values are cached forever.
+type mintDecimals struct {
+	sol    solanaClient
+	tokens tokenRegistry
+
+	mu    sync.RWMutex
+	cache map[string]uint8
+}
+
+// newMintDecimals creates a new mint decimals resolver, the token registry is optional.
+func newMintDecimals(sol solanaClient, tokens tokenRegistry) *mintDecimals {
+	d := &mintDecimals{
+		sol:    sol,
+		tokens: tokens,
+		cache:  make(map[string]uint8),
+	}
+	for _, t := range defaultTokens {
+		d.cache[t.Mint] = t.Decimals
+	}
+	return d
+}
+
+// Decimals returns the number of decimals of the given mint address.
+func (d *mintDecimals) Decimals(ctx context.Context, mint string) (uint8, error) {
+	d.mu.RLock()
+	decimals, ok := d.cache[mint]
+	d.mu.RUnlock()
+	if ok {
+		return decimals, nil
+	}
+
+	// Zero decimals in the token list may mean the field is missing, so such tokens are checked on-chain.
+	if t, ok := d.tokenFromRegistry(mint); ok && t.Decimals > 0 {
+		decimals = t.Decimals
+	} else {
+		supply, err := d.sol.GetTokenSupply(ctx, mint)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get decimals of %s: %w", mint, err)
+		}
+		decimals = supply.Decimals
+	}
+
+	d.mu.Lock()
+	d.cache[mint] = decimals
+	d.mu.Unlock()
+
+	return decimals, nil
+}
+
+// tokenFromRegistry returns the token from the registry if it is set.
+func (d *mintDecimals) tokenFromRegistry(mint string) (Token, bool) {
+	if d.tokens == nil {
+		return Token{}, false
+	}
+	return d.tokens.Token(mint)
+}
+
+// scaleAmount converts the amount in base units of the mint with the given decimals
+// into base units of the mint with the other decimals, e.g. 1 SOL (9) = 1000000 in base units of USDC (6).
+// The result is rounded down and capped by math.MaxUint64.
+func scaleAmount(amount uint64, from, to uint8) uint64 {
+	if from == to || amount == 0 {
+		return amount
+	}
+
+	result := new(big.Int).SetUint64(amount)
+	if to > from {
+		result.Mul(result, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(to-from)), nil))
+	} else {
+		result.Quo(result, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(from-to)), nil))
+	}
+	if !result.IsUint64() {
+		return ^uint64(0)
+	}
+
+	return result.Uint64()
+}
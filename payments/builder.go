@@ -4,9 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
+	"time"
 
 	"github.com/easypmnt/checkout-api/jupiter"
+	"github.com/easypmnt/checkout-api/loyalty"
 	"github.com/easypmnt/checkout-api/solana"
+	"github.com/portto/solana-go-sdk/program/token"
 	"github.com/portto/solana-go-sdk/types"
 )
 
@@ -21,6 +25,31 @@ type (
 		availableBonusAmount uint64
 		referenceAccount     types.Account
 		bonusAuthAccount     *types.Account
+
+		tiers    tierResolver
+		decimals decimalsResolver
+		tier     *loyalty.Tier // loyalty tier of the payer, overrides the global bonus rules
+
+		rules             []BonusRule
+		purchases         uint64 // number of completed purchases of the payer
+		accrualMultiplier uint64 // 10000 = x1, 0 = no bonus rules applied
+		flatBonus         uint64
+		bonusValue        uint64 // value of the payment in base units of the bonus mint, the accrual rate is applied to it
+
+		coupon      *Coupon
+		gatingRules []GatingRule
+
+		giftCard          *GiftCard
+		giftCardAvailable uint64 // gift card balance excluding amounts held by other pending checkouts
+
+		affiliate *Affiliate
+
+		sponsorAccount  *types.Account // pays network fees and rent instead of the customer
+		sponsorLimit    uint64         // max sponsored amount in lamports, 0 = unlimited
+		sponsored       bool
+		sponsoredAmount uint64
+
+		err error
 	}
 )
 
@@ -42,6 +71,9 @@ func NewPaymentTransactionBuilder(sc solanaClient, jc jupiterClient, config Conf
 	if b.config.AccrueBonusRate == 0 {
 		b.config.AccrueBonusRate = 100
 	}
+	if b.config.BonusValueBasis == "" {
+		b.config.BonusValueBasis = BonusValueBasisToken
+	}
 
 	mintAuth, err := types.AccountFromBase58(config.BonusAuthAccount)
 	if err != nil {
@@ -60,11 +92,18 @@ func (b *PaymentBuilder) SetTransaction(tx *Transaction, p *Payment) *PaymentBui
 	tx.Amount = p.Amount
 	tx.Message = p.Message
 	tx.Memo = p.ExternalID
-	tx.DestinationMint = MintAddress(tx.DestinationMint, b.config.DestinationMint)
-	tx.SourceMint = MintAddress(tx.SourceMint, tx.DestinationMint)
+	if tx.DestinationMint, b.err = MintAddress(tx.DestinationMint, b.config.DestinationMint); b.err != nil {
+		return b
+	}
+	if tx.SourceMint, b.err = MintAddress(tx.SourceMint, tx.DestinationMint); b.err != nil {
+		return b
+	}
 	if tx.DestinationWallet == "" {
 		tx.DestinationWallet = b.config.DestinationWallet
 	}
+	if rate, ok := b.config.AccrueBonusRates[tx.DestinationMint]; ok {
+		b.config.AccrueBonusRate = rate
+	}
 	if tx.TotalAmount == 0 {
 		tx.TotalAmount = tx.Amount - tx.DiscountAmount
 	}
@@ -72,6 +111,66 @@ func (b *PaymentBuilder) SetTransaction(tx *Transaction, p *Payment) *PaymentBui
 	return b
 }
 
+// SetTierResolver sets the loyalty tier resolver,
+// so the bonus rules depend on the payer's tier instead of the global config.
+func (b *PaymentBuilder) SetTierResolver(r tierResolver) *PaymentBuilder {
+	b.tiers = r
+	return b
+}
+
+// SetDecimalsResolver sets the resolver of the mint decimals, so the bonus accrual
+// is normalised by decimals of the payment and bonus mints.
+// Without it, the accrual rate is applied to the payment amount in base units as is.
+func (b *PaymentBuilder) SetDecimalsResolver(r decimalsResolver) *PaymentBuilder {
+	b.decimals = r
+	return b
+}
+
+// SetBonusRules sets the active bonus rules and the number of completed purchases of the payer,
+// which is required to evaluate "every Nth purchase" rules.
+func (b *PaymentBuilder) SetBonusRules(rules []BonusRule, purchases uint64) *PaymentBuilder {
+	b.rules = rules
+	b.purchases = purchases
+	return b
+}
+
+// SetCoupon sets the coupon which discount is applied to the payment.
+// The coupon availability and usage limits must be checked by the caller.
+func (b *PaymentBuilder) SetCoupon(c *Coupon) *PaymentBuilder {
+	b.coupon = c
+	return b
+}
+
+// SetGiftCard sets the gift card which covers the rest of the payment amount up to the available balance.
+// The gift card availability and balance must be checked by the caller.
+func (b *PaymentBuilder) SetGiftCard(c *GiftCard, available uint64) *PaymentBuilder {
+	b.giftCard = c
+	b.giftCardAvailable = available
+	return b
+}
+
+// SetAffiliate sets the affiliate who referred the customer, the commission is calculated per transaction.
+// The affiliate must be active, it's checked by the caller.
+func (b *PaymentBuilder) SetAffiliate(a *Affiliate) *PaymentBuilder {
+	b.affiliate = a
+	return b
+}
+
+// SetGatingRules sets the active gating rules, the best discount of the rules matched
+// by the payer's assets is applied to the payment.
+func (b *PaymentBuilder) SetGatingRules(rules []GatingRule) *PaymentBuilder {
+	b.gatingRules = rules
+	return b
+}
+
+// SetSponsor sets the account which pays network fees and rent for the customer.
+// The transaction is not sponsored if its estimated cost exceeds the limit (in lamports, 0 = unlimited).
+func (b *PaymentBuilder) SetSponsor(account types.Account, limit uint64) *PaymentBuilder {
+	b.sponsorAccount = &account
+	b.sponsorLimit = limit
+	return b
+}
+
 // GetReferenceAddress returns the reference address.
 func (b *PaymentBuilder) GetReferenceAddress() string {
 	return b.referenceAccount.PublicKey.ToBase58()
@@ -83,11 +182,37 @@ func (b *PaymentBuilder) Build(ctx context.Context) (string, *Transaction, error
 		return "", nil, fmt.Errorf("failed to validate builder parameters: %w", err)
 	}
 
+	if err := b.resolveTier(ctx); err != nil {
+		return "", nil, err
+	}
+
 	bonusBalance, _ := b.sol.GetTokenBalance(ctx, b.tx.SourceWallet, b.config.BonusMintAddress)
 	b.availableBonusAmount = bonusBalance.Amount
 	b.tx = b.recalculateTotalAmount(b.tx)
+	b.applyRules()
+	if err := b.applyGatingRules(ctx); err != nil {
+		return "", nil, err
+	}
+	b.applyCoupon()
+	b.applyGiftCard()
+	if b.tx.TotalAmount == 0 && b.tx.GiftCardAmount > 0 {
+		return "", nil, ErrGiftCardCovered
+	}
+	b.applyAffiliate()
+	if err := b.valueBonus(ctx); err != nil {
+		return "", nil, err
+	}
+	if err := b.sponsor(ctx); err != nil {
+		return "", nil, err
+	}
+
+	builder := solana.NewTransactionBuilder(b.sol).SetFeePayer(b.feePayer())
+	if b.sponsored {
+		builder = builder.AddSigner(*b.sponsorAccount)
+	}
+	b.tx.FeePayer = b.feePayer()
+	b.tx.SponsoredAmount = b.sponsoredAmount
 
-	builder := solana.NewTransactionBuilder(b.sol).SetFeePayer(b.tx.SourceWallet)
 	builder = b.burnBonus(builder)
 	builder, err := b.swap(builder)
 	if err != nil {
@@ -98,6 +223,7 @@ func (b *PaymentBuilder) Build(ctx context.Context) (string, *Transaction, error
 	} else {
 		builder = b.transferToken(builder)
 	}
+	builder = b.payAffiliate(builder)
 	builder = b.mintBonus(builder)
 	base64Tx, err := builder.Build(ctx)
 	if err != nil {
@@ -107,11 +233,463 @@ func (b *PaymentBuilder) Build(ctx context.Context) (string, *Transaction, error
 	return base64Tx, b.tx, nil
 }
 
+// Preview calculates the payment transaction breakdown: discount, swap quote, bonus accrual and fees.
+// It neither builds the transaction nor requires the source wallet.
+func (b *PaymentBuilder) Preview(ctx context.Context) (*TransactionPreview, error) {
+	if err := b.validatePreview(); err != nil {
+		return nil, fmt.Errorf("failed to validate builder parameters: %w", err)
+	}
+
+	if err := b.resolveTier(ctx); err != nil {
+		return nil, err
+	}
+	if b.tx.SourceWallet != "" && b.tx.ApplyBonus {
+		bonusBalance, _ := b.sol.GetTokenBalance(ctx, b.tx.SourceWallet, b.config.BonusMintAddress)
+		b.availableBonusAmount = bonusBalance.Amount
+	}
+	b.tx = b.recalculateTotalAmount(b.tx)
+	b.applyRules()
+	if err := b.applyGatingRules(ctx); err != nil {
+		return nil, err
+	}
+	b.applyCoupon()
+	b.applyGiftCard()
+	b.applyAffiliate()
+	if err := b.valueBonus(ctx); err != nil {
+		return nil, err
+	}
+	b.tx.AccruedBonusAmount = b.accruedBonusAmount()
+	if err := b.sponsor(ctx); err != nil {
+		return nil, err
+	}
+
+	preview := &TransactionPreview{
+		PaymentID:            b.tx.PaymentID,
+		SourceWallet:         b.tx.SourceWallet,
+		SourceMint:           b.tx.SourceMint,
+		SourceAmount:         b.tx.TotalAmount,
+		DestinationMint:      b.tx.DestinationMint,
+		Amount:               b.tx.Amount,
+		DiscountAmount:       b.tx.DiscountAmount,
+		PromoDiscountAmount:  b.tx.PromoDiscountAmount,
+		CouponDiscountAmount: b.tx.CouponDiscountAmount,
+		GatingDiscountAmount: b.tx.GatingDiscountAmount,
+		GatingAsset:          b.tx.GatingAsset,
+		GiftCardAmount:       b.tx.GiftCardAmount,
+		TotalAmount:          b.tx.TotalAmount,
+		AccruedBonusAmount:   b.tx.AccruedBonusAmount,
+		AppliedRules:         b.tx.AppliedRules,
+	}
+	if b.tier != nil {
+		preview.Tier = b.tier.Name
+	}
+
+	preview.LineItems = append(preview.LineItems, LineItem{
+		Type:   LineItemTypeAmount,
+		Mint:   b.tx.DestinationMint,
+		Amount: b.tx.Amount,
+	})
+	if b.tx.DiscountAmount > 0 {
+		preview.LineItems = append(preview.LineItems, LineItem{
+			Type:   LineItemTypeBonusDiscount,
+			Mint:   b.config.BonusMintAddress,
+			Amount: b.tx.DiscountAmount,
+		})
+	}
+	if b.tx.PromoDiscountAmount > 0 {
+		preview.LineItems = append(preview.LineItems, LineItem{
+			Type:   LineItemTypePromoDiscount,
+			Mint:   b.tx.DestinationMint,
+			Amount: b.tx.PromoDiscountAmount,
+		})
+	}
+	if b.tx.GatingDiscountAmount > 0 {
+		preview.LineItems = append(preview.LineItems, LineItem{
+			Type:   LineItemTypeGatingDiscount,
+			Mint:   b.tx.DestinationMint,
+			Amount: b.tx.GatingDiscountAmount,
+		})
+	}
+	if b.tx.CouponDiscountAmount > 0 {
+		preview.LineItems = append(preview.LineItems, LineItem{
+			Type:   LineItemTypeCouponDiscount,
+			Mint:   b.tx.DestinationMint,
+			Amount: b.tx.CouponDiscountAmount,
+		})
+	}
+	if b.tx.GiftCardAmount > 0 {
+		preview.LineItems = append(preview.LineItems, LineItem{
+			Type:   LineItemTypeGiftCard,
+			Mint:   b.tx.DestinationMint,
+			Amount: b.tx.GiftCardAmount,
+		})
+	}
+	preview.LineItems = append(preview.LineItems, LineItem{
+		Type:   LineItemTypeTotal,
+		Mint:   b.tx.DestinationMint,
+		Amount: b.tx.TotalAmount,
+	})
+
+	if b.tx.SourceMint != b.tx.DestinationMint && b.tx.TotalAmount > 0 {
+		rate, err := b.jup.ExchangeRate(jupiter.ExchangeRateParams{
+			InputMint:  b.tx.SourceMint,
+			OutputMint: b.tx.DestinationMint,
+			Amount:     b.tx.TotalAmount,
+			SwapMode:   jupiter.SwapModeExactOut,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get swap quote: %w", err)
+		}
+		preview.SourceAmount = rate.InAmount
+		preview.LineItems = append(preview.LineItems, LineItem{
+			Type:   LineItemTypeSwap,
+			Mint:   b.tx.SourceMint,
+			Amount: rate.InAmount,
+		})
+	}
+
+	networkFee, rent, err := b.estimateFee(ctx)
+	if err != nil {
+		return nil, err
+	}
+	preview.EstimatedFee = networkFee + rent
+	preview.LineItems = append(preview.LineItems, LineItem{
+		Type:   LineItemTypeNetworkFee,
+		Mint:   SOL,
+		Amount: networkFee,
+	})
+	if rent > 0 {
+		preview.LineItems = append(preview.LineItems, LineItem{
+			Type:   LineItemTypeAccountRent,
+			Mint:   SOL,
+			Amount: rent,
+		})
+	}
+	if b.sponsored {
+		preview.SponsoredFee = b.sponsoredAmount
+		preview.LineItems = append(preview.LineItems, LineItem{
+			Type:   LineItemTypeSponsoredFee,
+			Mint:   SOL,
+			Amount: b.sponsoredAmount,
+		})
+	}
+
+	if b.tx.AccruedBonusAmount > 0 {
+		preview.LineItems = append(preview.LineItems, LineItem{
+			Type:   LineItemTypeBonusAccrual,
+			Mint:   b.config.BonusMintAddress,
+			Amount: b.tx.AccruedBonusAmount,
+		})
+	}
+
+	return preview, nil
+}
+
+// estimateFee returns the estimated signature fees and rent for the token accounts
+// which would be created by the payment transaction, both in lamports.
+// Token accounts created by the swap instructions are not taken into account.
+func (b *PaymentBuilder) estimateFee(ctx context.Context) (uint64, uint64, error) {
+	signatures := uint64(1)
+	if b.accruedBonusAmount() > 0 {
+		signatures++
+	}
+	if b.sponsored {
+		signatures++
+	}
+
+	var accounts uint64
+	if !IsSOL(b.tx.DestinationMint) {
+		exists, err := b.tokenAccountExists(ctx, b.tx.DestinationWallet, b.tx.DestinationMint)
+		if err != nil {
+			return 0, 0, err
+		}
+		if !exists {
+			accounts++
+		}
+	}
+	if b.tx.AffiliateInline && !IsSOL(b.tx.DestinationMint) {
+		exists, err := b.tokenAccountExists(ctx, b.affiliate.Wallet, b.tx.DestinationMint)
+		if err != nil {
+			return 0, 0, err
+		}
+		if !exists {
+			accounts++
+		}
+	}
+	if b.accruedBonusAmount() > 0 {
+		exists := false
+		if b.tx.SourceWallet != "" {
+			var err error
+			if exists, err = b.tokenAccountExists(ctx, b.tx.SourceWallet, b.config.BonusMintAddress); err != nil {
+				return 0, 0, err
+			}
+		}
+		if !exists {
+			accounts++
+		}
+	}
+
+	var rent uint64
+	if accounts > 0 {
+		rentPerAccount, err := b.sol.GetMinimumBalanceForRentExemption(ctx, token.TokenAccountSize)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to get minimum balance for rent exemption: %w", err)
+		}
+		rent = rentPerAccount * accounts
+	}
+
+	return signatures * solana.LamportsPerSignature, rent, nil
+}
+
+// applyRules applies the matched bonus rules to the transaction:
+// discounts reduce the total amount, accrual multipliers and flat bonuses increase the accrued bonus.
+// Multipliers are compounded, discounts and flat bonuses are summed up.
+func (b *PaymentBuilder) applyRules() {
+	b.tx.AppliedRules, b.tx.PromoDiscountAmount = nil, 0
+	b.accrualMultiplier, b.flatBonus = 0, 0
+	if len(b.rules) == 0 {
+		return
+	}
+
+	rc := ruleContext{
+		Now:        time.Now(),
+		Purchases:  b.purchases,
+		SourceMint: b.tx.SourceMint,
+		Amount:     b.tx.Amount,
+		ExternalID: b.tx.Memo,
+	}
+
+	var discount uint64
+	for _, r := range b.rules {
+		if !r.Active || !r.Conditions.match(rc) {
+			continue
+		}
+
+		switch r.Action {
+		case BonusRuleActionAccrualMultiplier:
+			if b.accrualMultiplier == 0 {
+				b.accrualMultiplier = 10000
+			}
+			b.accrualMultiplier = b.accrualMultiplier * r.Value / 10000
+		case BonusRuleActionFlatBonus:
+			b.flatBonus += r.Value
+		case BonusRuleActionDiscountPercent:
+			discount += b.tx.Amount * r.Value / 10000
+		case BonusRuleActionFreeItem:
+			discount += r.Value
+		}
+
+		b.tx.AppliedRules = append(b.tx.AppliedRules, AppliedRule{
+			ID:     r.ID,
+			Name:   r.Name,
+			Action: r.Action,
+			Value:  r.Value,
+		})
+	}
+
+	if discount > b.tx.TotalAmount {
+		discount = b.tx.TotalAmount
+	}
+	b.tx.PromoDiscountAmount = discount
+	b.tx.TotalAmount -= discount
+}
+
+// applyGatingRules applies the best discount of the gating rules matched by the payer's assets.
+// NFTs are recognized as tokens with the balance of 1 base unit, their collection and creators
+// are loaded only if there are collection or creator rules.
+func (b *PaymentBuilder) applyGatingRules(ctx context.Context) error {
+	b.tx.GatingRuleID, b.tx.GatingAsset, b.tx.GatingDiscountAmount = nil, "", 0
+	if len(b.gatingRules) == 0 || b.tx.SourceWallet == "" {
+		return nil
+	}
+
+	tokens, err := b.sol.GetWalletTokens(ctx, b.tx.SourceWallet)
+	if err != nil {
+		return fmt.Errorf("failed to get wallet tokens: %w", err)
+	}
+
+	var origins []solana.AssetOrigin
+	if usesAssetOrigin(b.gatingRules) {
+		nfts := make([]string, 0, len(tokens))
+		for mint, amount := range tokens {
+			if amount == 1 {
+				nfts = append(nfts, mint)
+			}
+		}
+		sort.Strings(nfts)
+		if len(nfts) > 0 {
+			if origins, err = b.sol.GetAssetsOrigin(ctx, nfts); err != nil {
+				return fmt.Errorf("failed to get wallet NFTs metadata: %w", err)
+			}
+		}
+	}
+
+	var discount uint64
+	for _, r := range b.gatingRules {
+		if !r.Active {
+			continue
+		}
+		asset := r.match(tokens, origins)
+		if asset == "" {
+			continue
+		}
+		if d := b.tx.Amount * r.DiscountPercent / 10000; b.tx.GatingRuleID == nil || d > discount {
+			ruleID := r.ID
+			b.tx.GatingRuleID = &ruleID
+			b.tx.GatingAsset = asset
+			discount = d
+		}
+	}
+
+	if discount > b.tx.TotalAmount {
+		discount = b.tx.TotalAmount
+	}
+	b.tx.GatingDiscountAmount = discount
+	b.tx.TotalAmount -= discount
+
+	return nil
+}
+
+// applyCoupon applies the coupon discount to the transaction total amount.
+// The discount is calculated from the payment amount and capped by the rest of the total amount.
+func (b *PaymentBuilder) applyCoupon() {
+	b.tx.CouponDiscountAmount = 0
+	if b.coupon == nil {
+		return
+	}
+
+	discount := b.coupon.discount(b.tx.Amount)
+	if discount > b.tx.TotalAmount {
+		discount = b.tx.TotalAmount
+	}
+	b.tx.CouponID = &b.coupon.ID
+	b.tx.CouponCode = b.coupon.Code
+	b.tx.CouponDiscountAmount = discount
+	b.tx.TotalAmount -= discount
+}
+
+// applyGiftCard pays the rest of the total amount with the gift card balance.
+// It's applied after all the discounts, so bonus tokens accrue only for the amount paid on-chain.
+func (b *PaymentBuilder) applyGiftCard() {
+	b.tx.GiftCardAmount = 0
+	if b.giftCard == nil {
+		return
+	}
+
+	amount := b.giftCardAvailable
+	if amount > b.tx.TotalAmount {
+		amount = b.tx.TotalAmount
+	}
+	b.tx.GiftCardID = &b.giftCard.ID
+	b.tx.GiftCardCode = b.giftCard.Code
+	b.tx.GiftCardAmount = amount
+	b.tx.TotalAmount -= amount
+}
+
+// applyAffiliate calculates the commission of the referring affiliate from the payment amount after discounts,
+// including the part paid with the gift card. The commission is paid inline only if the affiliate prefers it
+// and it's covered by the amount paid on-chain, otherwise it's accrued for the batch payout.
+// Customers and merchants can't refer themselves.
+func (b *PaymentBuilder) applyAffiliate() {
+	b.tx.AffiliateID, b.tx.AffiliateCommission, b.tx.AffiliateInline = nil, 0, false
+	if b.affiliate == nil || b.affiliate.Wallet == b.tx.SourceWallet || b.affiliate.Wallet == b.tx.DestinationWallet {
+		return
+	}
+
+	commission := b.affiliate.commission(b.tx.TotalAmount + b.tx.GiftCardAmount)
+	if commission == 0 {
+		return
+	}
+	b.tx.AffiliateID = &b.affiliate.ID
+	b.tx.AffiliateCommission = commission
+	b.tx.AffiliateInline = b.affiliate.PayoutMode == AffiliatePayoutModeInline && commission < b.tx.TotalAmount
+}
+
+// resolveTier applies the bonus rules of the payer's loyalty tier for the destination mint.
+// The global bonus rules are used if the payer is unknown or does not reach any tier,
+// as well as for the rate or cap the tier does not set for the destination mint.
+func (b *PaymentBuilder) resolveTier(ctx context.Context) error {
+	if b.tiers == nil || b.tx.SourceWallet == "" {
+		return nil
+	}
+
+	tier, err := b.tiers.Tier(ctx, b.tx.SourceWallet)
+	if err != nil {
+		return fmt.Errorf("failed to resolve loyalty tier: %w", err)
+	}
+	if tier == nil {
+		return nil
+	}
+
+	b.tier = tier
+	if rate, ok := tier.AccrueBonusRates[b.tx.DestinationMint]; ok {
+		b.config.AccrueBonusRate = rate
+	}
+	if amount, ok := tier.MaxApplyBonusAmounts[b.tx.DestinationMint]; ok {
+		b.config.MaxApplyBonusAmount = amount
+	}
+	b.config.MaxApplyBonusPercent = tier.MaxApplyBonusPercent
+
+	return nil
+}
+
+// sponsor decides whether the transaction is sponsored, the estimated cost is charged to the sponsor.
+// Token accounts created by the swap instructions are still funded by the customer.
+func (b *PaymentBuilder) sponsor(ctx context.Context) error {
+	b.sponsored, b.sponsoredAmount = false, 0
+	if b.sponsorAccount == nil {
+		return nil
+	}
+
+	b.sponsored = true
+	networkFee, rent, err := b.estimateFee(ctx)
+	if err != nil {
+		return err
+	}
+	if b.sponsorLimit > 0 && networkFee+rent > b.sponsorLimit {
+		b.sponsored = false
+		return nil
+	}
+	b.sponsoredAmount = networkFee + rent
+
+	return nil
+}
+
+// feePayer returns the base58 encoded public key of the transaction fee payer.
+func (b *PaymentBuilder) feePayer() string {
+	if b.sponsored {
+		return b.sponsorAccount.PublicKey.ToBase58()
+	}
+	return b.tx.SourceWallet
+}
+
+// tokenAccountExists checks if the associated token account exists for the given owner and mint.
+func (b *PaymentBuilder) tokenAccountExists(ctx context.Context, owner, mint string) (bool, error) {
+	ata, err := solana.FindAssociatedTokenAddress(owner, mint)
+	if err != nil {
+		return false, err
+	}
+	exists, _ := b.sol.DoesTokenAccountExist(ctx, ata)
+	return exists, nil
+}
+
 // validate builder parameters.
 func (b *PaymentBuilder) validate() error {
+	if b.err != nil {
+		return b.err
+	}
 	if b.tx.SourceWallet == "" {
 		return errors.New("source wallet address is required")
 	}
+	return b.validatePreview()
+}
+
+// validatePreview validates builder parameters required to preview the transaction.
+// Source wallet is optional, since the customer may not have connected the wallet yet.
+func (b *PaymentBuilder) validatePreview() error {
+	if b.err != nil {
+		return b.err
+	}
 	if b.tx.SourceMint == "" {
 		return errors.New("source mint address is required")
 	}
@@ -130,9 +708,6 @@ func (b *PaymentBuilder) validate() error {
 	if b.config.AccrueBonus && b.bonusAuthAccount == nil {
 		return errors.New("bonus auth account is required")
 	}
-	if b.config.AccrueBonus && b.config.AccrueBonusRate == 0 {
-		return errors.New("accrue bonus rate is required")
-	}
 	return nil
 }
 
@@ -191,7 +766,7 @@ func (b *PaymentBuilder) mintBonus(builder *solana.TransactionBuilder) *solana.T
 		return builder
 	}
 
-	bonusAmount := b.tx.TotalAmount * b.config.AccrueBonusRate / 10000
+	bonusAmount := b.accruedBonusAmount()
 	if bonusAmount == 0 {
 		return builder
 	}
@@ -199,7 +774,7 @@ func (b *PaymentBuilder) mintBonus(builder *solana.TransactionBuilder) *solana.T
 	b.tx.AccruedBonusAmount = bonusAmount
 
 	return builder.AddInstruction(solana.MintFungibleToken(solana.MintFungibleTokenParams{
-		Funder:    b.tx.SourceWallet,
+		Funder:    b.feePayer(),
 		Mint:      b.config.BonusMintAddress,
 		MintOwner: b.bonusAuthAccount.PublicKey.ToBase58(),
 		MintTo:    b.tx.SourceWallet,
@@ -207,13 +782,69 @@ func (b *PaymentBuilder) mintBonus(builder *solana.TransactionBuilder) *solana.T
 	})).AddSigner(*b.bonusAuthAccount)
 }
 
+// valueBonus calculates the value of the payment in base units of the bonus mint,
+// so the same accrual rate gives the same bonus whatever mint the merchant settles in.
+// The value is based on the destination mint tokens or on the USD value of the payment,
+// depending on the configured basis. It must be called after the total amount is calculated.
+func (b *PaymentBuilder) valueBonus(ctx context.Context) error {
+	b.bonusValue = 0
+	if !b.config.AccrueBonus || b.config.AccrueBonusRate == 0 || b.tx.TotalAmount == 0 {
+		return nil
+	}
+	if b.decimals == nil {
+		b.bonusValue = b.tx.TotalAmount
+		return nil
+	}
+
+	amount, mint := b.tx.TotalAmount, b.tx.DestinationMint
+	if b.config.BonusValueBasis == BonusValueBasisUSD && mint != USDC {
+		rate, err := b.jup.ExchangeRate(jupiter.ExchangeRateParams{
+			InputMint:  mint,
+			OutputMint: USDC,
+			Amount:     amount,
+			SwapMode:   jupiter.SwapModeExactIn,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to get USD value of the payment: %w", err)
+		}
+		amount, mint = rate.OutAmount, USDC
+	}
+
+	from, err := b.decimals.Decimals(ctx, mint)
+	if err != nil {
+		return err
+	}
+	to, err := b.decimals.Decimals(ctx, b.config.BonusMintAddress)
+	if err != nil {
+		return err
+	}
+	b.bonusValue = scaleAmount(amount, from, to)
+
+	return nil
+}
+
+// accruedBonusAmount returns the amount of bonus tokens to be minted for the payment.
+func (b *PaymentBuilder) accruedBonusAmount() uint64 {
+	if !b.config.AccrueBonus {
+		return 0
+	}
+
+	amount := b.bonusValue * b.config.AccrueBonusRate / 10000
+	if b.accrualMultiplier > 0 {
+		amount = amount * b.accrualMultiplier / 10000
+	}
+
+	return amount + b.flatBonus
+}
+
 func (b *PaymentBuilder) transferToken(builder *solana.TransactionBuilder) *solana.TransactionBuilder {
 	return builder.AddInstruction(solana.TransferToken(solana.TransferTokenParam{
 		Sender:    b.tx.SourceWallet,
 		Recipient: b.tx.DestinationWallet,
 		Mint:      b.tx.DestinationMint,
 		Reference: b.tx.Reference,
-		Amount:    b.tx.TotalAmount,
+		Amount:    b.tx.MerchantAmount(),
+		Funder:    b.feePayer(),
 	}))
 }
 
@@ -222,7 +853,31 @@ func (b *PaymentBuilder) transferSOL(builder *solana.TransactionBuilder) *solana
 		Sender:    b.tx.SourceWallet,
 		Recipient: b.tx.DestinationWallet,
 		Reference: b.tx.Reference,
-		Amount:    b.tx.TotalAmount,
+		Amount:    b.tx.MerchantAmount(),
+	}))
+}
+
+// payAffiliate transfers the inline affiliate commission from the customer to the affiliate wallet.
+// The transfer has no reference, so it's not mistaken for the payment transfer.
+func (b *PaymentBuilder) payAffiliate(builder *solana.TransactionBuilder) *solana.TransactionBuilder {
+	if !b.tx.AffiliateInline {
+		return builder
+	}
+
+	if IsSOL(b.tx.DestinationMint) {
+		return builder.AddInstruction(solana.TransferSOL(solana.TransferSOLParams{
+			Sender:    b.tx.SourceWallet,
+			Recipient: b.affiliate.Wallet,
+			Amount:    b.tx.AffiliateCommission,
+		}))
+	}
+
+	return builder.AddInstruction(solana.TransferToken(solana.TransferTokenParam{
+		Sender:    b.tx.SourceWallet,
+		Recipient: b.affiliate.Wallet,
+		Mint:      b.tx.DestinationMint,
+		Amount:    b.tx.AffiliateCommission,
+		Funder:    b.feePayer(),
 	}))
 }
 
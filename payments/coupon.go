@@ -0,0 +1,89 @@
+package payments
+
+import (
+	"strings"
+	"time"
+
+	"github.com/easypmnt/checkout-api/repository"
+	"github.com/google/uuid"
+)
+
+// CouponDiscountType represents how the coupon discount is calculated.
+type CouponDiscountType string
+
+// Predefined coupon discount types.
+const (
+	CouponDiscountTypePercent CouponDiscountType = "percent" // discount of the payment amount, 10000 = 100%
+	CouponDiscountTypeFixed   CouponDiscountType = "fixed"   // discount in base units of the payment destination mint
+)
+
+// IsValid checks if the discount type is supported.
+func (t CouponDiscountType) IsValid() bool {
+	return t == CouponDiscountTypePercent || t == CouponDiscountTypeFixed
+}
+
+// Coupon represents a promo code which gives a discount at checkout.
+type Coupon struct {
+	ID               uuid.UUID          `json:"id"`
+	Code             string             `json:"code"`
+	DiscountType     CouponDiscountType `json:"discount_type"`
+	DiscountValue    uint64             `json:"discount_value"`
+	MinAmount        uint64             `json:"min_amount,omitempty"`         // min payment amount in base units
+	UsageLimit       uint64             `json:"usage_limit,omitempty"`        // max number of payments, 0 = unlimited
+	WalletUsageLimit uint64             `json:"wallet_usage_limit,omitempty"` // max number of payments per wallet, 0 = unlimited
+	ExpiresAt        *time.Time         `json:"expires_at,omitempty"`
+	Active           bool               `json:"active"`
+	CreatedAt        time.Time          `json:"created_at"`
+}
+
+// NormalizeCouponCode returns the coupon code in the form it is stored, codes are case-insensitive.
+func NormalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// available checks if the coupon can be applied to the payment amount at the given time.
+// Usage limits are checked separately, since they depend on the stored transactions.
+func (c *Coupon) available(amount uint64, now time.Time) bool {
+	if !c.Active {
+		return false
+	}
+	if c.ExpiresAt != nil && !now.Before(*c.ExpiresAt) {
+		return false
+	}
+	if c.MinAmount > 0 && amount < c.MinAmount {
+		return false
+	}
+	return true
+}
+
+// discount returns the coupon discount for the given payment amount.
+func (c *Coupon) discount(amount uint64) uint64 {
+	switch c.DiscountType {
+	case CouponDiscountTypePercent:
+		return amount * c.DiscountValue / 10000
+	case CouponDiscountTypeFixed:
+		return c.DiscountValue
+	}
+	return 0
+}
+
+// cast repository.Coupon to payments.Coupon
+func castFromRepositoryCoupon(c repository.Coupon) *Coupon {
+	result := &Coupon{
+		ID:               c.ID,
+		Code:             c.Code,
+		DiscountType:     CouponDiscountType(c.DiscountType),
+		DiscountValue:    uint64(c.DiscountValue),
+		MinAmount:        uint64(c.MinAmount),
+		UsageLimit:       uint64(c.UsageLimit),
+		WalletUsageLimit: uint64(c.WalletUsageLimit),
+		Active:           c.Active,
+		CreatedAt:        c.CreatedAt,
+	}
+
+	if c.ExpiresAt.Valid {
+		result.ExpiresAt = &c.ExpiresAt.Time
+	}
+
+	return result
+}
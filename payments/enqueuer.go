@@ -101,3 +101,27 @@ func (e *Enqueuer) CheckPaymentByReference(ctx context.Context, reference string
 
 	return nil
 }
+
+// rebroadcastTimeout is the max duration of the rebroadcast transaction task,
+// it must be longer than the blockhash lifetime (150 blocks, about 1-2 minutes).
+const rebroadcastTimeout = 3 * time.Minute
+
+// RebroadcastTransaction enqueues a task to rebroadcast the submitted transaction.
+// This function returns an error if the task could not be enqueued.
+func (e *Enqueuer) RebroadcastTransaction(ctx context.Context, payload RebroadcastPayload) error {
+	task, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("RebroadcastTransaction: failed to marshal task payload: %w", err)
+	}
+
+	if err := e.enqueueTask(ctx, asynq.NewTask(TaskRebroadcastTransaction, task),
+		asynq.Queue(e.queueName),
+		asynq.Deadline(time.Now().Add(rebroadcastTimeout)),
+		asynq.MaxRetry(0),
+		asynq.Unique(rebroadcastTimeout),
+	); err != nil {
+		return fmt.Errorf("RebroadcastTransaction: %w", err)
+	}
+
+	return nil
+}
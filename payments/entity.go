@@ -1,8 +1,10 @@
 package payments
 
 import (
+	"encoding/json"
 	"time"
 
+	"github.com/easypmnt/checkout-api/events"
 	"github.com/easypmnt/checkout-api/repository"
 	"github.com/google/uuid"
 )
@@ -28,6 +30,7 @@ const (
 	TransactionStatusPending   TransactionStatus = "pending"
 	TransactionStatusCompleted TransactionStatus = "completed"
 	TransactionStatusFailed    TransactionStatus = "failed"
+	TransactionStatusExpired   TransactionStatus = "expired" // the payment expired before the transaction was completed
 )
 
 // Payment represents an initial payment request.
@@ -40,26 +43,117 @@ type Payment struct {
 	Status            PaymentStatus `json:"status,omitempty"`
 	Message           string        `json:"message,omitempty"`
 	ExpiresAt         *time.Time    `json:"expires_at,omitempty"`
+	ReceiptMint       string        `json:"receipt_mint,omitempty"` // mint address of the receipt NFT sent to the payer
+	CreatedAt         time.Time     `json:"created_at,omitempty"`
+	UpdatedAt         *time.Time    `json:"updated_at,omitempty"`
 }
 
 type Transaction struct {
-	ID                 uuid.UUID         `json:"id,omitempty"`
-	PaymentID          uuid.UUID         `json:"payment_id,omitempty"`
-	Reference          string            `json:"reference,omitempty"`
-	SourceWallet       string            `json:"source_wallet,omitempty"`
-	SourceMint         string            `json:"source_mint,omitempty"`
-	DestinationWallet  string            `json:"destination_wallet,omitempty"`
-	DestinationMint    string            `json:"destination_mint,omitempty"`
-	Amount             uint64            `json:"amount,omitempty"`
-	DiscountAmount     uint64            `json:"discount_amount,omitempty"`
-	TotalAmount        uint64            `json:"total_amount,omitempty"`
-	AccruedBonusAmount uint64            `json:"accrued_bonus_amount,omitempty"`
-	Message            string            `json:"message,omitempty"`
-	Memo               string            `json:"memo,omitempty"`
-	ApplyBonus         bool              `json:"apply_bonus,omitempty"`
-	Transaction        string            `json:"transaction,omitempty"`
-	Status             TransactionStatus `json:"status,omitempty"`
-	Signature          string            `json:"signature,omitempty"`
+	ID                   uuid.UUID         `json:"id,omitempty"`
+	PaymentID            uuid.UUID         `json:"payment_id,omitempty"`
+	Reference            string            `json:"reference,omitempty"`
+	SourceWallet         string            `json:"source_wallet,omitempty"`
+	SourceMint           string            `json:"source_mint,omitempty"`
+	DestinationWallet    string            `json:"destination_wallet,omitempty"`
+	DestinationMint      string            `json:"destination_mint,omitempty"`
+	Amount               uint64            `json:"amount,omitempty"`
+	DiscountAmount       uint64            `json:"discount_amount,omitempty"`
+	PromoDiscountAmount  uint64            `json:"promo_discount_amount,omitempty"` // discount given by bonus rules, not paid with bonus tokens
+	CouponCode           string            `json:"coupon_code,omitempty"`
+	CouponID             *uuid.UUID        `json:"coupon_id,omitempty"`
+	CouponDiscountAmount uint64            `json:"coupon_discount_amount,omitempty"` // discount given by the coupon, not paid with bonus tokens
+	GatingRuleID         *uuid.UUID        `json:"gating_rule_id,omitempty"`
+	GatingAsset          string            `json:"gating_asset,omitempty"`           // mint address of the payer's asset matched by the gating rule
+	GatingDiscountAmount uint64            `json:"gating_discount_amount,omitempty"` // discount given to the holder of the gating asset
+	GiftCardCode         string            `json:"gift_card_code,omitempty"`
+	GiftCardID           *uuid.UUID        `json:"gift_card_id,omitempty"`
+	GiftCardAmount       uint64            `json:"gift_card_amount,omitempty"` // part of the amount paid with the gift card balance
+	RefCode              string            `json:"ref_code,omitempty"`         // ref parameter of the payment link
+	AffiliateID          *uuid.UUID        `json:"affiliate_id,omitempty"`
+	AffiliateCommission  uint64            `json:"affiliate_commission,omitempty"` // commission of the referring affiliate
+	AffiliateInline      bool              `json:"affiliate_inline,omitempty"`     // the commission is transferred to the affiliate by this transaction
+	TotalAmount          uint64            `json:"total_amount,omitempty"`
+	AccruedBonusAmount   uint64            `json:"accrued_bonus_amount,omitempty"`
+	Message              string            `json:"message,omitempty"`
+	Memo                 string            `json:"memo,omitempty"`
+	ApplyBonus           bool              `json:"apply_bonus,omitempty"`
+	Transaction          string            `json:"transaction,omitempty"`
+	Status               TransactionStatus `json:"status,omitempty"`
+	Signature            string            `json:"signature,omitempty"`
+	FeePayer             string            `json:"fee_payer,omitempty"`
+	SponsoredAmount      uint64            `json:"sponsored_amount,omitempty"` // network fees and rent paid by the merchant in lamports
+	AppliedRules         []AppliedRule     `json:"applied_rules,omitempty"`
+	CreatedAt            time.Time         `json:"created_at,omitempty"`
+	UpdatedAt            *time.Time        `json:"updated_at,omitempty"`
+}
+
+// MerchantAmount returns the amount transferred to the destination wallet.
+// The inline affiliate commission is transferred from the total amount to the affiliate wallet.
+func (t *Transaction) MerchantAmount() uint64 {
+	if t.AffiliateInline && t.AffiliateCommission < t.TotalAmount {
+		return t.TotalAmount - t.AffiliateCommission
+	}
+	return t.TotalAmount
+}
+
+// LineItemType represents the type of a transaction preview line item.
+type LineItemType string
+
+// Predefined line item types.
+const (
+	LineItemTypeAmount         LineItemType = "amount"          // payment amount in the destination mint
+	LineItemTypeBonusDiscount  LineItemType = "bonus_discount"  // discount paid with bonus tokens
+	LineItemTypePromoDiscount  LineItemType = "promo_discount"  // discount given by bonus rules
+	LineItemTypeCouponDiscount LineItemType = "coupon_discount" // discount given by the coupon
+	LineItemTypeGatingDiscount LineItemType = "gating_discount" // discount given to the holder of the NFT or token
+	LineItemTypeGiftCard       LineItemType = "gift_card"       // part of the amount paid with the gift card balance
+	LineItemTypeTotal          LineItemType = "total"           // amount received by the merchant
+	LineItemTypeSwap           LineItemType = "swap"            // amount of the source token to be swapped
+	LineItemTypeNetworkFee     LineItemType = "network_fee"     // transaction signature fees in lamports
+	LineItemTypeAccountRent    LineItemType = "account_rent"    // rent for the token accounts created by the transaction in lamports
+	LineItemTypeSponsoredFee   LineItemType = "sponsored_fee"   // network fees and rent paid by the merchant in lamports
+	LineItemTypeBonusAccrual   LineItemType = "bonus_accrual"   // bonus tokens earned for the payment
+)
+
+// LineItem represents a single line of the transaction preview.
+type LineItem struct {
+	Type   LineItemType `json:"type"`
+	Mint   string       `json:"mint"`
+	Amount uint64       `json:"amount"`
+}
+
+// TransactionPreview represents the breakdown of a payment transaction
+// calculated without building and storing the transaction.
+type TransactionPreview struct {
+	PaymentID            uuid.UUID     `json:"payment_id"`
+	SourceWallet         string        `json:"source_wallet,omitempty"`
+	SourceMint           string        `json:"source_mint"`
+	SourceAmount         uint64        `json:"source_amount"` // amount of the source token the customer pays, excluding fees
+	DestinationMint      string        `json:"destination_mint"`
+	Amount               uint64        `json:"amount"`
+	DiscountAmount       uint64        `json:"discount_amount"`
+	PromoDiscountAmount  uint64        `json:"promo_discount_amount"`
+	CouponDiscountAmount uint64        `json:"coupon_discount_amount"`
+	GatingDiscountAmount uint64        `json:"gating_discount_amount"`
+	GatingAsset          string        `json:"gating_asset,omitempty"` // mint address of the payer's asset matched by the gating rule
+	GiftCardAmount       uint64        `json:"gift_card_amount"`
+	TotalAmount          uint64        `json:"total_amount"`
+	AccruedBonusAmount   uint64        `json:"accrued_bonus_amount"`
+	Tier                 string        `json:"tier,omitempty"` // loyalty tier of the payer which bonus rules are applied
+	EstimatedFee         uint64        `json:"estimated_fee"`  // network fees and account rent in lamports
+	SponsoredFee         uint64        `json:"sponsored_fee"`  // part of the estimated fee paid by the merchant in lamports
+	LineItems            []LineItem    `json:"line_items"`
+	AppliedRules         []AppliedRule `json:"applied_rules,omitempty"`
+}
+
+// PaymentEvent represents the event recorded for the payment.
+type PaymentEvent struct {
+	ID          int64           `json:"id"`
+	Name        string          `json:"name"`
+	Payload     json.RawMessage `json:"payload"`
+	Source      string          `json:"source,omitempty"` // api, task:<type> or event:<name>
+	CreatedAt   time.Time       `json:"created_at"`
+	PublishedAt *time.Time      `json:"published_at,omitempty"` // nil until the event is handed to the listeners
 }
 
 // cast repository.Payment to payments.Payment
@@ -72,11 +166,19 @@ func castFromRepositoryPayment(p repository.Payment) *Payment {
 		Amount:            uint64(p.Amount),
 		Status:            castFromRepositoryPaymentStatus(p.Status),
 		Message:           p.Message.String,
+		CreatedAt:         p.CreatedAt,
 	}
 
 	if p.ExpiresAt.Valid {
 		result.ExpiresAt = &p.ExpiresAt.Time
 	}
+	if p.ReceiptConfirmed {
+		// the pending receipt mint may never be created
+		result.ReceiptMint = p.ReceiptMint.String
+	}
+	if p.UpdatedAt.Valid {
+		result.UpdatedAt = &p.UpdatedAt.Time
+	}
 
 	return result
 }
@@ -124,23 +226,54 @@ func castToRepositoryPaymentStatus(status PaymentStatus) repository.PaymentStatu
 // cast repository.Transaction to payments.Transaction
 func castFromRepositoryTransaction(t repository.Transaction, conf Config) *Transaction {
 	result := &Transaction{
-		ID:                 t.ID,
-		PaymentID:          t.PaymentID,
-		Reference:          t.Reference,
-		SourceWallet:       t.SourceWallet,
-		SourceMint:         t.SourceMint,
-		DestinationWallet:  t.DestinationWallet,
-		DestinationMint:    t.DestinationMint,
-		Amount:             uint64(t.Amount),
-		DiscountAmount:     uint64(t.DiscountAmount),
-		TotalAmount:        uint64(t.TotalAmount),
-		AccruedBonusAmount: uint64(t.AccruedBonusAmount),
-		Message:            t.Message.String,
-		Memo:               t.Memo.String,
-		Status:             castFromRepositoryTransactionStatus(t.Status),
-		Signature:          t.TxSignature.String,
+		ID:                   t.ID,
+		PaymentID:            t.PaymentID,
+		Reference:            t.Reference,
+		SourceWallet:         t.SourceWallet,
+		SourceMint:           t.SourceMint,
+		DestinationWallet:    t.DestinationWallet,
+		DestinationMint:      t.DestinationMint,
+		Amount:               uint64(t.Amount),
+		DiscountAmount:       uint64(t.DiscountAmount),
+		TotalAmount:          uint64(t.TotalAmount),
+		AccruedBonusAmount:   uint64(t.AccruedBonusAmount),
+		Message:              t.Message.String,
+		Memo:                 t.Memo.String,
+		Status:               castFromRepositoryTransactionStatus(t.Status),
+		Signature:            t.TxSignature.String,
+		FeePayer:             t.FeePayer.String,
+		SponsoredAmount:      uint64(t.SponsoredAmount),
+		PromoDiscountAmount:  uint64(t.PromoDiscountAmount),
+		CouponDiscountAmount: uint64(t.CouponDiscountAmount),
+		GatingAsset:          t.GatingAsset.String,
+		GatingDiscountAmount: uint64(t.GatingDiscountAmount),
+		GiftCardAmount:       uint64(t.GiftCardAmount),
+		RefCode:              t.RefCode.String,
+		AffiliateCommission:  uint64(t.AffiliateCommission),
+		AffiliateInline:      t.AffiliateCommissionInline,
+		CreatedAt:            t.CreatedAt,
+	}
+
+	if t.UpdatedAt.Valid {
+		result.UpdatedAt = &t.UpdatedAt.Time
+	}
+
+	if t.CouponID.Valid {
+		result.CouponID = &t.CouponID.UUID
+	}
+	if t.GatingRuleID.Valid {
+		result.GatingRuleID = &t.GatingRuleID.UUID
+	}
+	if t.GiftCardID.Valid {
+		result.GiftCardID = &t.GiftCardID.UUID
+	}
+	if t.AffiliateID.Valid {
+		result.AffiliateID = &t.AffiliateID.UUID
 	}
 
+	// Applied rules are stored by the service, so the error is not expected here.
+	_ = json.Unmarshal(t.AppliedRules, &result.AppliedRules)
+
 	if t.ApplyBonus.Valid {
 		result.ApplyBonus = t.ApplyBonus.Bool
 	} else {
@@ -155,7 +288,7 @@ func castFromRepositoryTransaction(t repository.Transaction, conf Config) *Trans
 	}
 
 	if t.TotalAmount == 0 && result.Amount > 0 {
-		result.TotalAmount = result.Amount - result.DiscountAmount
+		result.TotalAmount = result.Amount - result.DiscountAmount - result.PromoDiscountAmount - result.CouponDiscountAmount - result.GatingDiscountAmount - result.GiftCardAmount
 	} else if result.Amount == 0 && result.TotalAmount > 0 {
 		result.Amount = result.TotalAmount + result.DiscountAmount
 	} else if result.Amount == 0 && result.TotalAmount == 0 {
@@ -173,12 +306,16 @@ func castToRepositoryTransactionStatus(status TransactionStatus) repository.Tran
 		return repository.TransactionStatusCompleted
 	case TransactionStatusFailed:
 		return repository.TransactionStatusFailed
+	case TransactionStatusExpired:
+		return repository.TransactionStatusExpired
 	}
 
 	return repository.TransactionStatusPending
 }
 
 // cast from repository.TransactionStatus to payments.TransactionStatus
+// The expired transaction is read as pending, so it's still checked by reference
+// and the payment completed after the expiry is not lost.
 func castFromRepositoryTransactionStatus(status repository.TransactionStatus) TransactionStatus {
 	switch status {
 	case repository.TransactionStatusPending:
@@ -191,3 +328,31 @@ func castFromRepositoryTransactionStatus(status repository.TransactionStatus) Tr
 
 	return TransactionStatusPending
 }
+
+// cast repository.EventsOutbox to payments.PaymentEvent
+func castFromRepositoryEvent(e repository.EventsOutbox) *PaymentEvent {
+	result := &PaymentEvent{
+		ID:        e.ID,
+		Name:      e.Event,
+		Payload:   e.Payload,
+		Source:    e.Source,
+		CreatedAt: e.CreatedAt,
+	}
+
+	if e.PublishedAt.Valid {
+		result.PublishedAt = &e.PublishedAt.Time
+	}
+
+	// The signed transaction is internal, it must not be exposed through the API.
+	if events.EventName(e.Event) == events.TransactionSubmitted {
+		var payload map[string]json.RawMessage
+		if err := json.Unmarshal(e.Payload, &payload); err == nil {
+			delete(payload, "transaction")
+			if redacted, err := json.Marshal(payload); err == nil {
+				result.Payload = redacted
+			}
+		}
+	}
+
+	return result
+}
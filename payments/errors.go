@@ -0,0 +1,23 @@
+package payments
+
+import "errors"
+
+// Predefined package errors.
+var (
+	ErrUnknownToken         = errors.New("unknown token")
+	ErrTransactionMismatch  = errors.New("signed transaction does not match the payment transaction")
+	ErrInvalidBonusRule     = errors.New("invalid bonus rule")
+	ErrInvalidCoupon        = errors.New("invalid coupon")
+	ErrInvalidGatingRule    = errors.New("invalid gating rule")
+	ErrCouponUnavailable    = errors.New("coupon is not available")
+	ErrCouponUsageLimit     = errors.New("coupon usage limit reached")
+	ErrInvalidGiftCard      = errors.New("invalid gift card")
+	ErrGiftCardUnavailable  = errors.New("gift card is not available")
+	ErrGiftCardBalance      = errors.New("insufficient gift card balance")
+	ErrGiftCardCovered      = errors.New("payment is fully covered by the gift card")
+	ErrInvalidAffiliate     = errors.New("invalid affiliate")
+	ErrAffiliateUnavailable = errors.New("affiliate is not available")
+	ErrPayoutDisabled       = errors.New("affiliate payouts are disabled")
+	ErrNothingToPayout      = errors.New("no accrued commissions to pay out")
+	ErrSponsorBudgetSpent   = errors.New("sponsor daily budget is exhausted")
+)
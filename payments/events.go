@@ -30,7 +30,7 @@ func getEventName(status PaymentStatus) events.EventName {
 
 // UpdateTransactionStatusListener is a listener for the transaction.updated event.
 func UpdateTransactionStatusListener(service PaymentService) events.Listener {
-	return func(event events.EventName, payload interface{}) error {
+	return func(ctx context.Context, event events.EventName, payload interface{}) error {
 		if payload == nil {
 			return nil
 		}
@@ -55,14 +55,12 @@ func UpdateTransactionStatusListener(service PaymentService) events.Listener {
 			status = PaymentStatusCompleted
 		case TransactionStatusFailed:
 			status = PaymentStatusFailed
-		case TransactionStatusPending:
-			status = PaymentStatusPending
+		case TransactionStatusExpired:
+			// the transaction is expired with its payment
+			return nil
 		}
 
-		ctx, cancel := context.WithCancel(context.Background())
-		defer cancel()
-
-		return service.UpdatePaymentStatus(ctx, pid, status)
+		return service.UpdatePaymentStatus(events.WithSource(ctx, events.EventSource(event)), pid, status)
 	}
 }
 
@@ -72,7 +70,7 @@ type eventsEnqueuer interface {
 
 // TransactionCreatedListener is a listener for the transaction.created event.
 func TransactionCreatedListener(service PaymentService, enq eventsEnqueuer) events.Listener {
-	return func(event events.EventName, payload interface{}) error {
+	return func(ctx context.Context, event events.EventName, payload interface{}) error {
 		if payload == nil {
 			return nil
 		}
@@ -82,13 +80,13 @@ func TransactionCreatedListener(service PaymentService, enq eventsEnqueuer) even
 			return nil
 		}
 
-		return enq.CheckPaymentByReference(context.Background(), p.Reference)
+		return enq.CheckPaymentByReference(ctx, p.Reference)
 	}
 }
 
 // ReferenceAccountNotificationListener is a listener for the transaction.reference.notification event.
 func ReferenceAccountNotificationListener(service PaymentService, enq eventsEnqueuer) events.Listener {
-	return func(event events.EventName, payload interface{}) error {
+	return func(ctx context.Context, event events.EventName, payload interface{}) error {
 		if payload == nil || event != events.TransactionReferenceNotification {
 			return nil
 		}
@@ -98,6 +96,64 @@ func ReferenceAccountNotificationListener(service PaymentService, enq eventsEnqu
 			return nil
 		}
 
-		return enq.CheckPaymentByReference(context.Background(), p.Reference)
+		return enq.CheckPaymentByReference(ctx, p.Reference)
+	}
+}
+
+type transactionEnqueuer interface {
+	RebroadcastTransaction(ctx context.Context, payload RebroadcastPayload) error
+}
+
+// TransactionSubmittedListener is a listener for the transaction.submitted event.
+func TransactionSubmittedListener(enq transactionEnqueuer) events.Listener {
+	return func(ctx context.Context, event events.EventName, payload interface{}) error {
+		if payload == nil {
+			return nil
+		}
+
+		p, ok := payload.(events.TransactionSubmittedPayload)
+		if !ok {
+			return nil
+		}
+
+		return enq.RebroadcastTransaction(ctx, RebroadcastPayload{
+			Reference:   p.Reference,
+			Signature:   p.Signature,
+			Transaction: p.Transaction,
+		})
+	}
+}
+
+// Snapshot is the current state of the payment sent to the websocket clients on connect.
+type Snapshot struct {
+	Payment     *Payment     `json:"payment"`
+	Transaction *Transaction `json:"transaction,omitempty"` // the latest transaction of the payment
+}
+
+// PaymentSnapshot returns the function to get the current state of the payment
+// for the websocket clients subscribed to the payment events.
+func PaymentSnapshot(service PaymentService) events.SnapshotFunc {
+	return func(ctx context.Context, paymentID string) (interface{}, error) {
+		pid, err := uuid.Parse(paymentID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse payment id: %s", err.Error())
+		}
+
+		payment, err := service.GetPayment(ctx, pid)
+		if err != nil {
+			return nil, err
+		}
+
+		txs, err := service.GetPaymentTransactions(ctx, pid)
+		if err != nil {
+			return nil, err
+		}
+
+		result := Snapshot{Payment: payment}
+		if len(txs) > 0 {
+			result.Transaction = txs[0]
+		}
+
+		return result, nil
 	}
 }
@@ -0,0 +1,51 @@
+package payments
+
+import (
+	"context"
+	"time"
+)
+
+// Default settings of the bulk expiry of the payments and transactions.
+const (
+	DefaultExpiryBatchSize     = 100         // payments or transactions expired at once
+	DefaultExpiryBatchInterval = time.Second // pause between the batches
+)
+
+// WithExpiryBatches configures the bulk expiry of the payments and transactions:
+// up to size rows are expired in a database transaction together with their events,
+// and the next batch is started after the interval, so a large backlog of expired payments
+// doesn't flood the event listeners and the webhook endpoints.
+func WithExpiryBatches(size int, interval time.Duration) ServiceOption {
+	return func(s *Service) {
+		if size > 0 {
+			s.expiryBatchSize = size
+		}
+		if interval >= 0 {
+			s.expiryBatchInterval = interval
+		}
+	}
+}
+
+// expireInBatches runs the batch in a database transaction until it returns less rows than the batch size.
+// The rows left after the context is done are expired by the next run.
+func (s *Service) expireInBatches(ctx context.Context, batch func(ctx context.Context, repo paymentRepository) (int, error)) error {
+	for {
+		var n int
+		if err := s.inTx(ctx, func(ctx context.Context, repo paymentRepository) error {
+			var err error
+			n, err = batch(ctx, repo)
+			return err
+		}); err != nil {
+			return err
+		}
+		if n < s.expiryBatchSize {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(s.expiryBatchInterval):
+		}
+	}
+}
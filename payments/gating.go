@@ -0,0 +1,91 @@
+package payments
+
+import (
+	"time"
+
+	"github.com/easypmnt/checkout-api/repository"
+	"github.com/easypmnt/checkout-api/solana"
+	"github.com/google/uuid"
+)
+
+// GatingRuleType represents the kind of asset the payer must hold to get the discount.
+type GatingRuleType string
+
+// Predefined gating rule types.
+const (
+	GatingRuleTypeCollection   GatingRuleType = "collection"    // NFT of the verified collection, address is the collection mint
+	GatingRuleTypeCreator      GatingRuleType = "creator"       // NFT with the verified creator, address is the creator wallet
+	GatingRuleTypeTokenBalance GatingRuleType = "token_balance" // min balance of the fungible token, address is the token mint
+)
+
+// IsValid checks if the rule type is supported.
+func (t GatingRuleType) IsValid() bool {
+	switch t {
+	case GatingRuleTypeCollection, GatingRuleTypeCreator, GatingRuleTypeTokenBalance:
+		return true
+	}
+	return false
+}
+
+// GatingRule represents a discount for holders of the NFT collection or the fungible token.
+type GatingRule struct {
+	ID              uuid.UUID      `json:"id"`
+	Name            string         `json:"name"`
+	Type            GatingRuleType `json:"type"`
+	Address         string         `json:"address"`
+	MinBalance      uint64         `json:"min_balance,omitempty"` // in base units, only for token_balance rules
+	DiscountPercent uint64         `json:"discount_percent"`      // 10000 = 100%
+	Active          bool           `json:"active"`
+	CreatedAt       time.Time      `json:"created_at"`
+}
+
+// match returns the mint address of the payer's asset which matches the rule,
+// or an empty string if the payer does not hold such asset.
+// tokens are the payer's token balances mapped by mint, origins are the payer's NFTs.
+func (r GatingRule) match(tokens map[string]uint64, origins []solana.AssetOrigin) string {
+	switch r.Type {
+	case GatingRuleTypeTokenBalance:
+		if balance, ok := tokens[r.Address]; ok && balance >= r.MinBalance && balance > 0 {
+			return r.Address
+		}
+	case GatingRuleTypeCollection:
+		for _, o := range origins {
+			if o.Collection == r.Address {
+				return o.Mint
+			}
+		}
+	case GatingRuleTypeCreator:
+		for _, o := range origins {
+			for _, c := range o.Creators {
+				if c == r.Address {
+					return o.Mint
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// usesAssetOrigin checks if any of the rules requires the NFT metadata.
+func usesAssetOrigin(rules []GatingRule) bool {
+	for _, r := range rules {
+		if r.Type == GatingRuleTypeCollection || r.Type == GatingRuleTypeCreator {
+			return true
+		}
+	}
+	return false
+}
+
+// cast repository.GatingRule to payments.GatingRule
+func castFromRepositoryGatingRule(r repository.GatingRule) GatingRule {
+	return GatingRule{
+		ID:              r.ID,
+		Name:            r.Name,
+		Type:            GatingRuleType(r.RuleType),
+		Address:         r.Address,
+		MinBalance:      uint64(r.MinBalance),
+		DiscountPercent: uint64(r.DiscountPercent),
+		Active:          r.Active,
+		CreatedAt:       r.CreatedAt,
+	}
+}
@@ -0,0 +1,121 @@
+package payments
+
+import (
+	"crypto/rand"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/easypmnt/checkout-api/repository"
+	"github.com/google/uuid"
+)
+
+// GiftCardEntryType represents the type of the gift card balance change.
+type GiftCardEntryType string
+
+// Predefined gift card entry types.
+const (
+	GiftCardEntryTypeIssue      GiftCardEntryType = "issue"      // initial balance of the issued card
+	GiftCardEntryTypeRedemption GiftCardEntryType = "redemption" // amount spent on the completed payment
+)
+
+// GiftCard represents a prepaid store credit which covers all or part of the payment amount.
+type GiftCard struct {
+	ID            uuid.UUID       `json:"id"`
+	Code          string          `json:"code"`
+	Mint          string          `json:"mint"`           // currency of the balance, the card covers only payments in this mint
+	InitialAmount uint64          `json:"initial_amount"` // in base units of the mint
+	Balance       uint64          `json:"balance"`        // in base units of the mint, amounts held by pending checkouts are not subtracted
+	ExpiresAt     *time.Time      `json:"expires_at,omitempty"`
+	Active        bool            `json:"active"`
+	CreatedAt     time.Time       `json:"created_at"`
+	Entries       []GiftCardEntry `json:"entries,omitempty"`
+}
+
+// GiftCardEntry represents a single change of the gift card balance.
+type GiftCardEntry struct {
+	ID            uuid.UUID         `json:"id"`
+	Type          GiftCardEntryType `json:"type"`
+	Amount        int64             `json:"amount"` // positive for issues, negative for redemptions
+	TransactionID *uuid.UUID        `json:"transaction_id,omitempty"`
+	CreatedAt     time.Time         `json:"created_at"`
+}
+
+// codeAlphabet excludes characters which are easy to confuse, e.g. 0 and O, 1 and I.
+const codeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// NewGiftCardCode generates a random gift card code, e.g. "7KQM-X2RD-PW9F-C4NH".
+func NewGiftCardCode() (string, error) {
+	return randomCode(16)
+}
+
+// randomCode generates a random code of the given length, split into groups of 4 characters.
+func randomCode(length int) (string, error) {
+	var sb strings.Builder
+	max := big.NewInt(int64(len(codeAlphabet)))
+	for i := 0; i < length; i++ {
+		if i > 0 && i%4 == 0 {
+			sb.WriteByte('-')
+		}
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteByte(codeAlphabet[n.Int64()])
+	}
+	return sb.String(), nil
+}
+
+// NormalizeGiftCardCode returns the gift card code in the form it is stored, codes are case-insensitive.
+func NormalizeGiftCardCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// available checks if the gift card can be applied to the payment in the given mint at the given time.
+// The balance is checked separately, since it depends on the amounts held by pending checkouts.
+func (c *GiftCard) available(mint string, now time.Time) bool {
+	if !c.Active || c.Mint != mint {
+		return false
+	}
+	if c.ExpiresAt != nil && !now.Before(*c.ExpiresAt) {
+		return false
+	}
+	return true
+}
+
+// cast repository.GiftCard to payments.GiftCard
+func castFromRepositoryGiftCard(c repository.GiftCard) *GiftCard {
+	result := &GiftCard{
+		ID:            c.ID,
+		Code:          c.Code,
+		Mint:          c.Mint,
+		InitialAmount: uint64(c.InitialAmount),
+		Active:        c.Active,
+		CreatedAt:     c.CreatedAt,
+	}
+
+	if c.Balance > 0 {
+		result.Balance = uint64(c.Balance)
+	}
+	if c.ExpiresAt.Valid {
+		result.ExpiresAt = &c.ExpiresAt.Time
+	}
+
+	return result
+}
+
+// cast repository.GiftCardLedger to payments.GiftCardEntry
+func castFromRepositoryGiftCardEntry(e repository.GiftCardLedger) GiftCardEntry {
+	result := GiftCardEntry{
+		ID:        e.ID,
+		Type:      GiftCardEntryType(e.EntryType),
+		Amount:    e.Amount,
+		CreatedAt: e.CreatedAt,
+	}
+
+	if e.TransactionID.Valid {
+		result.TransactionID = &e.TransactionID.UUID
+	}
+
+	return result
+}
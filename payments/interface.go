@@ -2,6 +2,7 @@ package payments
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -15,7 +16,7 @@ type PaymentService interface {
 	// GetPaymentByExternalID returns the payment with the given external ID.
 	GetPaymentByExternalID(ctx context.Context, externalID string) (*Payment, error)
 	// GeneratePaymentLink generates a new payment link for the given payment.
-	GeneratePaymentLink(ctx context.Context, paymentID uuid.UUID, mint string, applyBonus bool) (string, error)
+	GeneratePaymentLink(ctx context.Context, paymentID uuid.UUID, mint string, applyBonus bool, coupon, ref string) (string, error)
 	// UpdatePaymentStatus updates the status of the payment with the given ID.
 	UpdatePaymentStatus(ctx context.Context, id uuid.UUID, status PaymentStatus) error
 	// CancelPayment cancels the payment with the given ID.
@@ -26,12 +27,66 @@ type PaymentService interface {
 	MarkPaymentsAsExpired(ctx context.Context) error
 	// BuildTransaction builds a new transaction for the given payment.
 	BuildTransaction(ctx context.Context, tx *Transaction) (*Transaction, error)
+	// PreviewTransaction calculates the payment breakdown without building and storing a transaction.
+	PreviewTransaction(ctx context.Context, tx *Transaction) (*TransactionPreview, error)
+	// SubmitTransaction verifies the transaction signed by the customer and sends it to the network.
+	SubmitTransaction(ctx context.Context, reference, signedTx string) (*Transaction, error)
 	// GetTransactionByReference returns the transaction with the given reference.
 	GetTransactionByReference(ctx context.Context, reference string) (*Transaction, error)
+	// GetPaymentTransactions returns all the transactions of the payment, newest first.
+	GetPaymentTransactions(ctx context.Context, paymentID uuid.UUID) ([]*Transaction, error)
+	// GetPaymentEvents returns the events recorded for the payment, oldest first.
+	GetPaymentEvents(ctx context.Context, paymentID uuid.UUID) ([]*PaymentEvent, error)
 	// UpdateTransaction updates the status and signature of the transaction with the given reference.
 	UpdateTransaction(ctx context.Context, reference string, status TransactionStatus, signature string) error
 	// GetPendingTransactions returns all pending transactions.
 	GetPendingTransactions(ctx context.Context) ([]*Transaction, error)
 	// MarkTransactionsAsExpired marks all transactions that are expired as expired.
 	MarkTransactionsAsExpired(ctx context.Context) error
+	// CreateBonusRule creates a new bonus rule.
+	CreateBonusRule(ctx context.Context, rule *BonusRule) (*BonusRule, error)
+	// GetBonusRules returns all the bonus rules ordered by priority.
+	GetBonusRules(ctx context.Context) ([]BonusRule, error)
+	// UpdateBonusRuleStatus enables or disables the bonus rule with the given ID.
+	UpdateBonusRuleStatus(ctx context.Context, id uuid.UUID, active bool) (*BonusRule, error)
+	// DeleteBonusRule deletes the bonus rule with the given ID.
+	DeleteBonusRule(ctx context.Context, id uuid.UUID) error
+	// CreateCoupon creates a new coupon.
+	CreateCoupon(ctx context.Context, coupon *Coupon) (*Coupon, error)
+	// GetCoupons returns all the coupons, newest first.
+	GetCoupons(ctx context.Context) ([]*Coupon, error)
+	// UpdateCouponStatus enables or disables the coupon with the given ID.
+	UpdateCouponStatus(ctx context.Context, id uuid.UUID, active bool) (*Coupon, error)
+	// DeleteCoupon deletes the coupon with the given ID.
+	DeleteCoupon(ctx context.Context, id uuid.UUID) error
+	// CreateGatingRule creates a new discount rule for holders of the NFT collection or the token.
+	CreateGatingRule(ctx context.Context, rule *GatingRule) (*GatingRule, error)
+	// GetGatingRules returns all the gating rules.
+	GetGatingRules(ctx context.Context) ([]GatingRule, error)
+	// UpdateGatingRuleStatus enables or disables the gating rule with the given ID.
+	UpdateGatingRuleStatus(ctx context.Context, id uuid.UUID, active bool) (*GatingRule, error)
+	// DeleteGatingRule deletes the gating rule with the given ID.
+	DeleteGatingRule(ctx context.Context, id uuid.UUID) error
+	// IssueGiftCard issues a new gift card with the initial balance.
+	IssueGiftCard(ctx context.Context, card *GiftCard) (*GiftCard, error)
+	// GetGiftCards returns all the gift cards, newest first.
+	GetGiftCards(ctx context.Context) ([]*GiftCard, error)
+	// GetGiftCard returns the gift card with the given ID and its balance history.
+	GetGiftCard(ctx context.Context, id uuid.UUID) (*GiftCard, error)
+	// UpdateGiftCardStatus enables or disables the gift card with the given ID.
+	UpdateGiftCardStatus(ctx context.Context, id uuid.UUID, active bool) (*GiftCard, error)
+	// PayWithGiftCard pays the whole payment amount with the gift card and completes the payment.
+	PayWithGiftCard(ctx context.Context, paymentID uuid.UUID, code string) (*Transaction, error)
+	// CreateAffiliate creates a new affiliate.
+	CreateAffiliate(ctx context.Context, affiliate *Affiliate) (*Affiliate, error)
+	// GetAffiliates returns all the affiliates, newest first.
+	GetAffiliates(ctx context.Context) ([]*Affiliate, error)
+	// GetAffiliate returns the affiliate with the given ID.
+	GetAffiliate(ctx context.Context, id uuid.UUID) (*Affiliate, error)
+	// UpdateAffiliateStatus enables or disables the affiliate with the given ID.
+	UpdateAffiliateStatus(ctx context.Context, id uuid.UUID, active bool) (*Affiliate, error)
+	// GetAffiliateReport returns the affiliate sales and commissions created in the given period.
+	GetAffiliateReport(ctx context.Context, id uuid.UUID, since, until time.Time) (*AffiliateReport, error)
+	// PayoutAffiliateCommissions pays out all the accrued commissions of the affiliate.
+	PayoutAffiliateCommissions(ctx context.Context, id uuid.UUID) (*AffiliatePayout, error)
 }
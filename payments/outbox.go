@@ -0,0 +1,102 @@
+package payments
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/easypmnt/checkout-api/events"
+	"github.com/easypmnt/checkout-api/outbox"
+)
+
+type (
+	fireEventFunc func(events.EventName, interface{})
+
+	// pendingEvent is the event emitted once the database transaction it's recorded in is committed.
+	pendingEvent struct {
+		name    events.EventName
+		payload interface{}
+	}
+
+	pendingEventsKey struct{}
+)
+
+// WithOutbox enables the outbox for the events of the payments and transactions.
+// The events are recorded in the outbox in the database transaction of the state change,
+// and published by the outbox relay, so they're not lost if the process dies right after the change.
+func WithOutbox(db txBeginner) ServiceOption {
+	return func(s *Service) {
+		s.db = db
+		s.outbox = true
+	}
+}
+
+// WithEventEmitter configures the function to emit the events directly when the outbox is disabled.
+// The events are emitted after the database transaction of the state change is committed,
+// so they're lost if the process dies in between. Ignored if the outbox is enabled.
+func WithEventEmitter(fn fireEventFunc) ServiceOption {
+	return func(s *Service) {
+		s.fireEvent = fn
+	}
+}
+
+// inTx runs fn in a database transaction, so the events recorded by fn are committed
+// together with the state change. Without the database fn runs on the repository as is.
+// The events emitted directly are emitted once fn succeeds and the transaction is committed.
+func (s *Service) inTx(ctx context.Context, fn func(ctx context.Context, repo paymentRepository) error) error {
+	var pending []pendingEvent
+	if !s.outbox {
+		ctx = context.WithValue(ctx, pendingEventsKey{}, &pending)
+	}
+
+	if s.db == nil {
+		if err := fn(ctx, s.repo); err != nil {
+			return err
+		}
+		s.emitPending(pending)
+		return nil
+	}
+
+	dbTx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin db transaction: %w", err)
+	}
+	defer dbTx.Rollback() //nolint:errcheck
+
+	if err := fn(ctx, s.repo.WithTx(dbTx)); err != nil {
+		return err
+	}
+
+	if err := dbTx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit db transaction: %w", err)
+	}
+	s.emitPending(pending)
+
+	return nil
+}
+
+// recordEvent records the event in the outbox with the given repository.
+// If the outbox is disabled, the event is emitted directly: after the commit when it's
+// recorded within inTx, right away otherwise. Does nothing if neither is configured.
+func (s *Service) recordEvent(ctx context.Context, repo paymentRepository, name events.EventName, payload interface{}) error {
+	if s.outbox {
+		return outbox.Record(ctx, repo, name, payload)
+	}
+	if s.fireEvent == nil {
+		return nil
+	}
+
+	if pending, ok := ctx.Value(pendingEventsKey{}).(*[]pendingEvent); ok {
+		*pending = append(*pending, pendingEvent{name: name, payload: payload})
+		return nil
+	}
+	s.fireEvent(name, payload)
+
+	return nil
+}
+
+// emitPending emits the events recorded while the outbox is disabled.
+func (s *Service) emitPending(pending []pendingEvent) {
+	for _, e := range pending {
+		s.fireEvent(e.name, e.payload)
+	}
+}
@@ -0,0 +1,312 @@
+package payments
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/easypmnt/checkout-api/jupiter"
+	"github.com/easypmnt/checkout-api/solana"
+)
+
+type (
+	// Token represents a token which can be used to pay for a payment.
+	Token struct {
+		Mint     string `json:"mint"`
+		Symbol   string `json:"symbol"`
+		Name     string `json:"name"`
+		Decimals uint8  `json:"decimals"`
+		LogoURI  string `json:"logo_uri,omitempty"`
+		Swap     bool   `json:"swap"` // true if the token is swapped into the merchant's mint while paying
+	}
+
+	// TokenRegistry is a catalog of tokens which can be used to pay for a payment.
+	// It is built from the token list, on-chain metadata and the Jupiter routes map,
+	// so it contains only tokens which can be routed to the merchant's mint.
+	TokenRegistry struct {
+		sol registrySolanaClient
+		jup registryJupiterClient
+		log Logger
+
+		destinationMint string
+		supportedMints  []string
+		refreshInterval time.Duration
+
+		mu       sync.RWMutex
+		byMint   map[string]Token
+		bySymbol map[string]Token
+	}
+
+	// TokenRegistryOption is a function that configures a token registry.
+	TokenRegistryOption func(*TokenRegistry)
+
+	// registrySolanaClient is an RPC client for Solana.
+	registrySolanaClient interface {
+		GetTokenList(ctx context.Context) (*solana.TokenList, error)
+		GetFungibleTokenMetadata(ctx context.Context, base58MintAddr string) (*solana.FungibleTokenMetadata, error)
+	}
+
+	// registryJupiterClient is an REST API client for Jupiter.
+	registryJupiterClient interface {
+		RoutesMap(onlyDirectRoutes bool) (jupiter.IndexedRoutesMap, error)
+	}
+)
+
+// NewTokenRegistry creates a new token registry for the given merchant's mint.
+// The registry contains only default tokens (SOL, USDC, USDT) until it is loaded.
+// Default refresh interval: 1 hour.
+func NewTokenRegistry(sol registrySolanaClient, jup registryJupiterClient, destinationMint string, opts ...TokenRegistryOption) (*TokenRegistry, error) {
+	mint, err := MintAddress(destinationMint, SOL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve destination mint: %w", err)
+	}
+
+	r := &TokenRegistry{
+		sol:             sol,
+		jup:             jup,
+		destinationMint: mint,
+		refreshInterval: time.Hour,
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	for _, m := range r.supportedMints {
+		if !IsMintAddress(m) {
+			return nil, fmt.Errorf("%w: supported mint %s", ErrUnknownToken, m)
+		}
+	}
+
+	r.setTokens(r.defaultTokens())
+
+	return r, nil
+}
+
+// WithSupportedMints adds mints which are always available in the registry,
+// e.g. the merchant's own token which is not listed in the token list.
+func WithSupportedMints(mints ...string) TokenRegistryOption {
+	return func(r *TokenRegistry) {
+		r.supportedMints = append(r.supportedMints, mints...)
+	}
+}
+
+// WithRefreshInterval configures the registry refresh interval.
+func WithRefreshInterval(d time.Duration) TokenRegistryOption {
+	return func(r *TokenRegistry) {
+		r.refreshInterval = d
+	}
+}
+
+// WithRegistryLogger configures the registry logger.
+func WithRegistryLogger(log Logger) TokenRegistryOption {
+	return func(r *TokenRegistry) {
+		r.log = log
+	}
+}
+
+// Load loads the token list and the routes map and rebuilds the registry.
+// On-chain metadata is merged for the merchant's mint and supported mints only,
+// the rest of the tokens rely on the token list.
+// Default tokens are always kept, even if they are missing in the token list or the routes map.
+func (r *TokenRegistry) Load(ctx context.Context) error {
+	tokenList, err := r.sol.GetTokenList(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load token list: %w", err)
+	}
+
+	routesMap, err := r.jup.RoutesMap(false)
+	if err != nil {
+		return fmt.Errorf("failed to load routes map: %w", err)
+	}
+
+	routable := make(map[string]bool)
+	for _, mint := range routesMap.GetInputMintsForMint(r.destinationMint) {
+		routable[mint] = true
+	}
+
+	tokens := r.defaultTokens()
+	for _, t := range tokenList.Tokens {
+		if t.ChainID != solana.ChainIdMainnet {
+			continue
+		}
+		if t.Address != r.destinationMint && !routable[t.Address] {
+			continue
+		}
+		tokens = append(tokens, Token{
+			Mint:     t.Address,
+			Symbol:   t.Symbol,
+			Name:     t.Name,
+			Decimals: uint8(t.Decimals),
+			LogoURI:  t.LogoURI,
+			Swap:     t.Address != r.destinationMint,
+		})
+	}
+
+	for _, mint := range append([]string{r.destinationMint}, r.supportedMints...) {
+		md, err := r.sol.GetFungibleTokenMetadata(ctx, mint)
+		if err != nil || md == nil {
+			r.errorf("failed to get on-chain metadata of mint %s: %v", mint, err)
+			continue
+		}
+		tokens = append(tokens, Token{
+			Mint:     mint,
+			Symbol:   md.Symbol,
+			Name:     md.Name,
+			Decimals: md.Decimals,
+			LogoURI:  md.LogoURI,
+			Swap:     mint != r.destinationMint,
+		})
+	}
+
+	r.setTokens(tokens)
+
+	return nil
+}
+
+// Run loads the registry and refreshes it periodically until the context is canceled.
+// Loading errors are logged and the previously loaded catalog is kept.
+func (r *TokenRegistry) Run(ctx context.Context) error {
+	if err := r.Load(ctx); err != nil {
+		r.errorf("failed to load token registry: %v", err)
+	}
+
+	ticker := time.NewTicker(r.refreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := r.Load(ctx); err != nil {
+				r.errorf("failed to refresh token registry: %v", err)
+			}
+		}
+	}
+}
+
+// Tokens returns all the tokens which can be used to pay for a payment, sorted by symbol.
+func (r *TokenRegistry) Tokens() []Token {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]Token, 0, len(r.byMint))
+	for _, t := range r.byMint {
+		result = append(result, t)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Symbol == result[j].Symbol {
+			return result[i].Mint < result[j].Mint
+		}
+		return result[i].Symbol < result[j].Symbol
+	})
+
+	return result
+}
+
+// Token returns the token by symbol or mint address.
+func (r *TokenRegistry) Token(symbolOrMint string) (Token, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if t, ok := r.byMint[symbolOrMint]; ok {
+		return t, true
+	}
+	t, ok := r.bySymbol[strings.ToUpper(symbolOrMint)]
+	return t, ok
+}
+
+// MintAddress returns the mint address by symbol or mint address.
+// If the currency is empty, the fallback is used instead.
+// Only tokens of the registry, the merchant's mint and supported mints are accepted.
+// Returns ErrUnknownToken if the token is not found in the registry.
+func (r *TokenRegistry) MintAddress(currency string, fallback string) (string, error) {
+	if currency == "" {
+		currency = fallback
+	}
+	if t, ok := r.Token(currency); ok {
+		return t.Mint, nil
+	}
+	if currency == r.destinationMint {
+		return currency, nil
+	}
+	for _, m := range r.supportedMints {
+		if currency == m {
+			return currency, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s", ErrUnknownToken, currency)
+}
+
+// defaultTokens returns the default tokens marked to be swapped into the merchant's mint.
+func (r *TokenRegistry) defaultTokens() []Token {
+	tokens := make([]Token, 0, len(defaultTokens))
+	for _, t := range defaultTokens {
+		t.Swap = t.Mint != r.destinationMint
+		tokens = append(tokens, t)
+	}
+	return tokens
+}
+
+// setTokens rebuilds the registry indexes.
+func (r *TokenRegistry) setTokens(tokens []Token) {
+	byMint := make(map[string]Token, len(tokens))
+	for _, t := range tokens {
+		if existing, ok := byMint[t.Mint]; ok {
+			t = mergeTokens(existing, t)
+		}
+		byMint[t.Mint] = t
+	}
+
+	// Symbols are not unique, so the first token wins:
+	// default tokens, the merchant's mint and supported mints, then the token list.
+	bySymbol := make(map[string]Token, len(byMint))
+	addSymbol := func(t Token) {
+		symbol := strings.ToUpper(t.Symbol)
+		if _, ok := bySymbol[symbol]; ok || symbol == "" {
+			return
+		}
+		bySymbol[symbol] = t
+	}
+	for _, mint := range append([]string{SOL, USDC, USDT, r.destinationMint}, r.supportedMints...) {
+		if t, ok := byMint[mint]; ok {
+			addSymbol(t)
+		}
+	}
+	for _, t := range tokens {
+		addSymbol(byMint[t.Mint])
+	}
+
+	r.mu.Lock()
+	r.byMint = byMint
+	r.bySymbol = bySymbol
+	r.mu.Unlock()
+}
+
+// mergeTokens fills the base token with non-empty fields of the given token.
+func mergeTokens(base, t Token) Token {
+	if t.Symbol != "" {
+		base.Symbol = t.Symbol
+	}
+	if t.Name != "" {
+		base.Name = t.Name
+	}
+	if t.Decimals > 0 {
+		base.Decimals = t.Decimals
+	}
+	if t.LogoURI != "" {
+		base.LogoURI = t.LogoURI
+	}
+	base.Swap = t.Swap
+	return base
+}
+
+func (r *TokenRegistry) errorf(format string, args ...interface{}) {
+	if r.log != nil {
+		r.log.Errorf(format, args...)
+	}
+}
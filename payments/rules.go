@@ -0,0 +1,137 @@
+package payments
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/easypmnt/checkout-api/repository"
+	"github.com/google/uuid"
+)
+
+// BonusRuleAction represents the effect of a bonus rule on the payment.
+type BonusRuleAction string
+
+// Predefined bonus rule actions.
+const (
+	BonusRuleActionAccrualMultiplier BonusRuleAction = "accrual_multiplier" // multiplies accrued bonus, 20000 = x2
+	BonusRuleActionFlatBonus         BonusRuleAction = "flat_bonus"         // extra bonus tokens in base units
+	BonusRuleActionDiscountPercent   BonusRuleAction = "discount_percent"   // discount of the payment amount, 10000 = 100%
+	BonusRuleActionFreeItem          BonusRuleAction = "free_item"          // discount of the item price in base units
+)
+
+// IsValid checks if the action is supported.
+func (a BonusRuleAction) IsValid() bool {
+	switch a {
+	case BonusRuleActionAccrualMultiplier,
+		BonusRuleActionFlatBonus,
+		BonusRuleActionDiscountPercent,
+		BonusRuleActionFreeItem:
+		return true
+	}
+	return false
+}
+
+// BonusRuleConditions defines when a bonus rule is applied, all set conditions must match.
+// Empty conditions match any payment.
+type BonusRuleConditions struct {
+	EveryNthPurchase uint64         `json:"every_nth_purchase,omitempty"` // e.g. 5 for every 5th completed purchase of the wallet
+	StartsAt         *time.Time     `json:"starts_at,omitempty"`
+	EndsAt           *time.Time     `json:"ends_at,omitempty"`
+	Weekdays         []time.Weekday `json:"weekdays,omitempty"` // UTC weekdays, 0 = Sunday
+	SourceMint       string         `json:"source_mint,omitempty"`
+	MinAmount        uint64         `json:"min_amount,omitempty"` // min payment amount in base units
+	ExternalIDPrefix string         `json:"external_id_prefix,omitempty"`
+}
+
+// BonusRule represents a promotion, e.g. "5th coffee free" or "double bonus on weekends".
+type BonusRule struct {
+	ID         uuid.UUID           `json:"id"`
+	Name       string              `json:"name"`
+	Priority   int32               `json:"priority"` // rules with higher priority are applied first
+	Conditions BonusRuleConditions `json:"conditions"`
+	Action     BonusRuleAction     `json:"action"`
+	Value      uint64              `json:"value"`
+	Active     bool                `json:"active"`
+	CreatedAt  time.Time           `json:"created_at"`
+}
+
+// AppliedRule is a snapshot of the bonus rule applied to the transaction, stored for auditing.
+type AppliedRule struct {
+	ID     uuid.UUID       `json:"id"`
+	Name   string          `json:"name"`
+	Action BonusRuleAction `json:"action"`
+	Value  uint64          `json:"value"`
+}
+
+// ruleContext is the payment data the bonus rule conditions are evaluated against.
+type ruleContext struct {
+	Now        time.Time
+	Purchases  uint64 // number of completed purchases of the wallet before this one
+	SourceMint string
+	Amount     uint64
+	ExternalID string
+}
+
+// usesPurchaseCount checks if any of the rules depends on the wallet's purchase count.
+func usesPurchaseCount(rules []BonusRule) bool {
+	for _, r := range rules {
+		if r.Conditions.EveryNthPurchase > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// match checks if the payment matches all the conditions.
+func (c BonusRuleConditions) match(rc ruleContext) bool {
+	if c.EveryNthPurchase > 0 && (rc.Purchases+1)%c.EveryNthPurchase != 0 {
+		return false
+	}
+	if c.StartsAt != nil && rc.Now.Before(*c.StartsAt) {
+		return false
+	}
+	if c.EndsAt != nil && !rc.Now.Before(*c.EndsAt) {
+		return false
+	}
+	if len(c.Weekdays) > 0 {
+		weekday, found := rc.Now.UTC().Weekday(), false
+		for _, d := range c.Weekdays {
+			if d == weekday {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if c.SourceMint != "" && c.SourceMint != rc.SourceMint {
+		return false
+	}
+	if c.MinAmount > 0 && rc.Amount < c.MinAmount {
+		return false
+	}
+	if c.ExternalIDPrefix != "" && !strings.HasPrefix(rc.ExternalID, c.ExternalIDPrefix) {
+		return false
+	}
+	return true
+}
+
+// cast repository.BonusRule to payments.BonusRule
+func castFromRepositoryBonusRule(r repository.BonusRule) BonusRule {
+	result := BonusRule{
+		ID:        r.ID,
+		Name:      r.Name,
+		Priority:  r.Priority,
+		Action:    BonusRuleAction(r.Action),
+		Value:     uint64(r.ActionValue),
+		Active:    r.Active,
+		CreatedAt: r.CreatedAt,
+	}
+
+	// Conditions are validated before storing, so the error is not expected here.
+	_ = json.Unmarshal(r.Conditions, &result.Conditions)
+
+	return result
+}
@@ -3,33 +3,152 @@ package payments
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/easypmnt/checkout-api/events"
 	"github.com/easypmnt/checkout-api/internal/utils"
 	"github.com/easypmnt/checkout-api/repository"
+	"github.com/easypmnt/checkout-api/solana"
 	"github.com/google/uuid"
+	"github.com/portto/solana-go-sdk/types"
 )
 
+// sponsorBudgetLockKey is the key of the advisory lock held while the sponsored transaction is stored,
+// so the daily budget is not overspent by concurrent builds.
+const sponsorBudgetLockKey int64 = 0x73706f6e736f72 // "sponsor"
+
 type (
 	Service struct {
-		repo paymentRepository
-		sol  solanaClient
-		jup  jupiterClient
-		conf Config
+		repo     paymentRepository
+		sol      solanaClient
+		jup      jupiterClient
+		tokens   tokenRegistry
+		decimals *mintDecimals
+		loyalty  loyaltyLedger
+		tiers    tierResolver
+		db       txBeginner
+		sponsor  *types.Account
+		payout   *types.Account
+		conf     Config
+
+		coupons    bool
+		giftCards  bool
+		affiliates bool
+		outbox     bool
+		fireEvent  fireEventFunc
+
+		expiryBatchSize     int
+		expiryBatchInterval time.Duration
 	}
+
+	// ServiceOption is a function that configures a payment service.
+	ServiceOption func(*Service)
 )
 
 // NewService creates a new payment service instance.
-func NewService(repo paymentRepository, sol solanaClient, jup jupiterClient, conf Config) *Service {
-	return &Service{
-		repo: repo,
-		sol:  sol,
-		jup:  jup,
-		conf: conf,
+func NewService(repo paymentRepository, sol solanaClient, jup jupiterClient, conf Config, opts ...ServiceOption) (*Service, error) {
+	s := &Service{
+		repo:                repo,
+		sol:                 sol,
+		jup:                 jup,
+		conf:                conf,
+		expiryBatchSize:     DefaultExpiryBatchSize,
+		expiryBatchInterval: DefaultExpiryBatchInterval,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if conf.SponsorAccount != "" {
+		sponsor, err := types.AccountFromBase58(conf.SponsorAccount)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse sponsor account: %w", err)
+		}
+		s.sponsor = &sponsor
+	}
+	if conf.AffiliatePayoutAccount != "" {
+		payout, err := types.AccountFromBase58(conf.AffiliatePayoutAccount)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse affiliate payout account: %w", err)
+		}
+		s.payout = &payout
+	}
+	if conf.BonusValueBasis != "" && !conf.BonusValueBasis.IsValid() {
+		return nil, fmt.Errorf("invalid bonus value basis: %s", conf.BonusValueBasis)
+	}
+	if len(conf.AccrueBonusRates) > 0 {
+		// The rates are set by symbols or mint addresses, the builder looks them up by mint address.
+		rates := make(map[string]uint64, len(conf.AccrueBonusRates))
+		for currency, rate := range conf.AccrueBonusRates {
+			mint, err := s.mintAddress(currency, "")
+			if err != nil {
+				return nil, fmt.Errorf("failed to resolve mint of the bonus rate: %w", err)
+			}
+			rates[mint] = rate
+		}
+		s.conf.AccrueBonusRates = rates
+	}
+	s.decimals = newMintDecimals(sol, s.tokens)
+
+	return s, nil
+}
+
+// WithTokenRegistry configures the token registry to resolve token symbols.
+// If it is not set, only default tokens and mint addresses are supported.
+func WithTokenRegistry(r tokenRegistry) ServiceOption {
+	return func(s *Service) {
+		s.tokens = r
+	}
+}
+
+// WithLoyaltyLedger configures the ledger to record bonus accrual and redemption
+// when a transaction completes.
+func WithLoyaltyLedger(l loyaltyLedger) ServiceOption {
+	return func(s *Service) {
+		s.loyalty = l
+	}
+}
+
+// WithLoyaltyTiers configures the resolver of the payer's loyalty tier,
+// so the bonus accrual and redemption rules depend on the tier.
+func WithLoyaltyTiers(r tierResolver) ServiceOption {
+	return func(s *Service) {
+		s.tiers = r
+	}
+}
+
+// WithCoupons enables coupon codes at checkout.
+// The database is used to redeem coupons atomically, so concurrent checkouts can't exceed the usage limits.
+func WithCoupons(db txBeginner) ServiceOption {
+	return func(s *Service) {
+		s.db = db
+		s.coupons = true
+	}
+}
+
+// WithGiftCards enables gift cards as a payment source.
+// The database is used to hold and redeem gift card balances atomically.
+func WithGiftCards(db txBeginner) ServiceOption {
+	return func(s *Service) {
+		s.db = db
+		s.giftCards = true
+	}
+}
+
+// WithAffiliates enables affiliate referrals with the `ref` parameter of the payment link.
+// The database is used to pay out accrued commissions atomically.
+func WithAffiliates(db txBeginner) ServiceOption {
+	return func(s *Service) {
+		s.db = db
+		s.affiliates = true
 	}
 }
 
@@ -39,19 +158,32 @@ func (s *Service) CreatePayment(ctx context.Context, payment *Payment) (*Payment
 	if payment.Amount == 0 {
 		return nil, fmt.Errorf("payment amount must be greater than 0")
 	}
-	payment.DestinationMint = MintAddress(payment.DestinationMint, s.conf.DestinationMint)
-
-	result, err := s.repo.CreatePayment(ctx, repository.CreatePaymentParams{
-		ExternalID:        sql.NullString{String: payment.ExternalID, Valid: payment.ExternalID != ""},
-		DestinationWallet: payment.DestinationWallet,
-		DestinationMint:   payment.DestinationMint,
-		Amount:            int64(payment.Amount),
-		Status:            repository.PaymentStatusNew,
-		Message:           sql.NullString{String: payment.Message, Valid: payment.Message != ""},
-		ExpiresAt:         sql.NullTime{Time: *payment.ExpiresAt, Valid: payment.ExpiresAt != nil},
-	})
+	mint, err := s.mintAddress(payment.DestinationMint, s.conf.DestinationMint)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create payment: %w", err)
+		return nil, err
+	}
+	payment.DestinationMint = mint
+
+	var result repository.Payment
+	if err := s.inTx(ctx, func(ctx context.Context, repo paymentRepository) error {
+		result, err = repo.CreatePayment(ctx, repository.CreatePaymentParams{
+			ExternalID:        sql.NullString{String: payment.ExternalID, Valid: payment.ExternalID != ""},
+			DestinationWallet: payment.DestinationWallet,
+			DestinationMint:   payment.DestinationMint,
+			Amount:            int64(payment.Amount),
+			Status:            repository.PaymentStatusNew,
+			Message:           sql.NullString{String: payment.Message, Valid: payment.Message != ""},
+			ExpiresAt:         sql.NullTime{Time: *payment.ExpiresAt, Valid: payment.ExpiresAt != nil},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create payment: %w", err)
+		}
+
+		return s.recordEvent(ctx, repo, events.PaymentCreated, events.PaymentCreatedPayload{
+			PaymentID: events.PaymentID{PaymentID: result.ID.String()},
+		})
+	}); err != nil {
+		return nil, err
 	}
 
 	return castFromRepositoryPayment(result), nil
@@ -78,7 +210,9 @@ func (s *Service) GetPaymentByExternalID(ctx context.Context, externalID string)
 }
 
 // GeneratePaymentLink generates a new payment link for the given payment.
-func (s *Service) GeneratePaymentLink(ctx context.Context, paymentID uuid.UUID, mint string, applyBonus bool) (string, error) {
+// The coupon code and the affiliate ref code are optional, they're passed to the checkout URL
+// as the `coupon` and `ref` query parameters.
+func (s *Service) GeneratePaymentLink(ctx context.Context, paymentID uuid.UUID, mint string, applyBonus bool, coupon, ref string) (string, error) {
 	payment, err := s.GetPayment(ctx, paymentID)
 	if err != nil {
 		return "", fmt.Errorf("failed to get payment: %w", err)
@@ -87,7 +221,10 @@ func (s *Service) GeneratePaymentLink(ctx context.Context, paymentID uuid.UUID,
 		return "", fmt.Errorf("payment already %s", payment.Status)
 	}
 
-	mint = MintAddress(mint, payment.DestinationMint)
+	mint, err = s.mintAddress(mint, payment.DestinationMint)
+	if err != nil {
+		return "", err
+	}
 
 	uri := strings.Join([]string{
 		strings.TrimRight(s.conf.SolPayBaseURL, "/"),
@@ -96,48 +233,107 @@ func (s *Service) GeneratePaymentLink(ctx context.Context, paymentID uuid.UUID,
 		strconv.FormatBool(applyBonus),
 	}, "/")
 
-	return fmt.Sprintf("solana:%s", uri), nil
+	query := url.Values{}
+	if coupon != "" {
+		c, err := s.getCoupon(ctx, coupon, payment.Amount)
+		if err != nil {
+			return "", err
+		}
+		query.Set("coupon", c.Code)
+	}
+	if ref != "" {
+		a, err := s.getAffiliate(ctx, ref)
+		if err != nil {
+			return "", err
+		}
+		query.Set("ref", a.RefCode)
+	}
+	if len(query) > 0 {
+		// Transaction request URL with query parameters must be URL-encoded, see Solana Pay spec.
+		uri = url.QueryEscape(uri + "?" + query.Encode())
+	}
+
+	link := fmt.Sprintf("solana:%s", uri)
+
+	// Nothing is stored with the link, the event is recorded in its own transaction
+	// to hold the payment lock until it's committed.
+	if err := s.inTx(ctx, func(ctx context.Context, repo paymentRepository) error {
+		return s.recordEvent(ctx, repo, events.PaymentLinkGenerated, events.PaymentLinkGeneratedPayload{
+			PaymentID: events.PaymentID{PaymentID: paymentID.String()},
+			Link:      link,
+		})
+	}); err != nil {
+		return "", err
+	}
+
+	return link, nil
 }
 
 // UpdatePaymentStatus updates the status of the payment with the given ID.
+// The event of the new status is recorded only if the status is changed.
 func (s *Service) UpdatePaymentStatus(ctx context.Context, id uuid.UUID, status PaymentStatus) error {
-	if _, err := s.repo.UpdatePaymentStatus(ctx, repository.UpdatePaymentStatusParams{
-		ID:     id,
-		Status: castToRepositoryPaymentStatus(status),
-	}); err != nil {
-		return fmt.Errorf("failed to update payment status: %w", err)
+	eventName := getEventName(status)
+	if eventName == "" {
+		return fmt.Errorf("unknown payment status %s", status)
 	}
 
-	return nil
+	return s.inTx(ctx, func(ctx context.Context, repo paymentRepository) error {
+		// The payment is locked, so concurrent updates to the same status record the event once.
+		prev, err := repo.GetPaymentForUpdate(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to get payment: %w", err)
+		}
+
+		if _, err := repo.UpdatePaymentStatus(ctx, repository.UpdatePaymentStatusParams{
+			ID:     id,
+			Status: castToRepositoryPaymentStatus(status),
+		}); err != nil {
+			return fmt.Errorf("failed to update payment status: %w", err)
+		}
+
+		if castFromRepositoryPaymentStatus(prev.Status) == status {
+			return nil
+		}
+
+		return s.recordEvent(ctx, repo, eventName, events.PaymentStatusUpdatedPayload{
+			PaymentID: events.PaymentID{PaymentID: id.String()},
+			Status:    string(status),
+		})
+	})
 }
 
 // CancelPayment cancels the payment with the given ID.
 func (s *Service) CancelPayment(ctx context.Context, id uuid.UUID) error {
-	if _, err := s.repo.UpdatePaymentStatus(ctx, repository.UpdatePaymentStatusParams{
-		ID:     id,
-		Status: repository.PaymentStatusCanceled,
-	}); err != nil {
-		return fmt.Errorf("failed to update payment status: %w", err)
-	}
-
-	return nil
+	return s.inTx(ctx, func(ctx context.Context, repo paymentRepository) error {
+		return s.cancelPayment(ctx, repo, id)
+	})
 }
 
 // CancelPaymentByExternalID cancels the payment with the given external ID.
 func (s *Service) CancelPaymentByExternalID(ctx context.Context, externalID string) error {
-	payment, err := s.GetPaymentByExternalID(ctx, externalID)
-	if err != nil {
-		return err
-	}
+	return s.inTx(ctx, func(ctx context.Context, repo paymentRepository) error {
+		payment, err := repo.GetPaymentByExternalID(ctx, externalID)
+		if err != nil {
+			return fmt.Errorf("failed to get payment: %w", err)
+		}
+
+		return s.cancelPayment(ctx, repo, payment.ID)
+	})
+}
 
-	if _, err := s.repo.UpdatePaymentStatus(ctx, repository.UpdatePaymentStatusParams{
-		ID:     payment.ID,
+// cancelPayment updates the payment status to canceled and records the event.
+func (s *Service) cancelPayment(ctx context.Context, repo paymentRepository, id uuid.UUID) error {
+	if _, err := repo.UpdatePaymentStatus(ctx, repository.UpdatePaymentStatusParams{
+		ID:     id,
 		Status: repository.PaymentStatusCanceled,
 	}); err != nil {
 		return fmt.Errorf("failed to update payment status: %w", err)
 	}
 
-	return nil
+	return s.recordEvent(ctx, repo, events.PaymentCancelled, events.PaymentStatusUpdatedPayload{
+		PaymentID: events.PaymentID{PaymentID: id.String()},
+		Status:    string(PaymentStatusCanceled),
+	})
 }
 
 // BuildTransaction builds a new transaction for the given payment.
@@ -155,34 +351,116 @@ func (s *Service) BuildTransaction(ctx context.Context, tx *Transaction) (*Trans
 	if payment.Status != PaymentStatusNew && payment.Status != PaymentStatusPending {
 		return nil, fmt.Errorf("payment already %s", payment.Status)
 	}
-	payment.DestinationMint = MintAddress(payment.DestinationMint, s.conf.DestinationMint)
-	tx.SourceMint = MintAddress(tx.SourceMint, payment.DestinationMint)
+	if payment.DestinationMint, err = s.mintAddress(payment.DestinationMint, s.conf.DestinationMint); err != nil {
+		return nil, err
+	}
+	if tx.SourceMint, err = s.mintAddress(tx.SourceMint, payment.DestinationMint); err != nil {
+		return nil, err
+	}
+
+	coupon, err := s.checkoutCoupon(ctx, tx, payment)
+	if err != nil {
+		return nil, err
+	}
+	giftCard, giftCardAvailable, err := s.checkoutGiftCard(ctx, tx, payment)
+	if err != nil {
+		return nil, err
+	}
+	affiliate, err := s.checkoutAffiliate(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.buildTransaction(ctx, *tx, payment, coupon, giftCard, giftCardAvailable, affiliate)
+	if errors.Is(err, ErrSponsorBudgetSpent) {
+		// The budget is spent by concurrent transactions since the sponsor limit was read,
+		// so the transaction is built again with the rest of the budget or without the sponsor.
+		result, err = s.buildTransaction(ctx, *tx, payment, coupon, giftCard, giftCardAvailable, affiliate)
+	}
+
+	return result, err
+}
 
-	base64Tx, tx, err := NewPaymentTransactionBuilder(s.sol, s.jup, s.conf).
-		SetTransaction(tx, payment).
+// buildTransaction builds the transaction on a copy of the given one and stores it as pending.
+func (s *Service) buildTransaction(ctx context.Context, t Transaction, payment *Payment, coupon *Coupon, giftCard *GiftCard, giftCardAvailable uint64, affiliate *Affiliate) (*Transaction, error) {
+	builder, err := s.newPaymentBuilder(ctx, t.SourceWallet)
+	if err != nil {
+		return nil, err
+	}
+	base64Tx, tx, err := builder.SetTransaction(&t, payment).
+		SetCoupon(coupon).
+		SetGiftCard(giftCard, giftCardAvailable).
+		SetAffiliate(affiliate).
 		Build(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to build transaction: %w", err)
 	}
 
-	repoTx, err := s.repo.CreateTransaction(ctx, repository.CreateTransactionParams{
-		PaymentID:          tx.PaymentID,
-		Reference:          tx.Reference,
-		SourceWallet:       tx.SourceWallet,
-		SourceMint:         tx.SourceMint,
-		DestinationWallet:  tx.DestinationWallet,
-		DestinationMint:    tx.DestinationMint,
-		Amount:             int64(tx.Amount),
-		DiscountAmount:     int64(tx.DiscountAmount),
-		TotalAmount:        int64(tx.TotalAmount),
-		Message:            sql.NullString{String: tx.Message, Valid: tx.Message != ""},
-		Memo:               sql.NullString{String: tx.Memo, Valid: tx.Memo != ""},
-		ApplyBonus:         sql.NullBool{Bool: tx.ApplyBonus, Valid: true},
-		AccruedBonusAmount: int64(tx.AccruedBonusAmount),
-		Status:             repository.TransactionStatusPending,
+	if err := s.simulateTransaction(ctx, base64Tx); err != nil {
+		return nil, err
+	}
+
+	if tx.AppliedRules == nil {
+		tx.AppliedRules = []AppliedRule{}
+	}
+	appliedRules, err := json.Marshal(tx.AppliedRules)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode applied rules: %w", err)
+	}
+
+	var couponID uuid.NullUUID
+	if tx.CouponID != nil {
+		couponID = uuid.NullUUID{UUID: *tx.CouponID, Valid: true}
+	}
+
+	var gatingRuleID uuid.NullUUID
+	if tx.GatingRuleID != nil {
+		gatingRuleID = uuid.NullUUID{UUID: *tx.GatingRuleID, Valid: true}
+	}
+
+	var giftCardID uuid.NullUUID
+	if tx.GiftCardID != nil {
+		giftCardID = uuid.NullUUID{UUID: *tx.GiftCardID, Valid: true}
+	}
+
+	var affiliateID uuid.NullUUID
+	if tx.AffiliateID != nil {
+		affiliateID = uuid.NullUUID{UUID: *tx.AffiliateID, Valid: true}
+	}
+
+	repoTx, err := s.createTransaction(ctx, coupon, giftCard, repository.CreateTransactionParams{
+		PaymentID:                 tx.PaymentID,
+		Reference:                 tx.Reference,
+		SourceWallet:              tx.SourceWallet,
+		SourceMint:                tx.SourceMint,
+		DestinationWallet:         tx.DestinationWallet,
+		DestinationMint:           tx.DestinationMint,
+		Amount:                    int64(tx.Amount),
+		DiscountAmount:            int64(tx.DiscountAmount),
+		TotalAmount:               int64(tx.TotalAmount),
+		Message:                   sql.NullString{String: tx.Message, Valid: tx.Message != ""},
+		Memo:                      sql.NullString{String: tx.Memo, Valid: tx.Memo != ""},
+		ApplyBonus:                sql.NullBool{Bool: tx.ApplyBonus, Valid: true},
+		AccruedBonusAmount:        int64(tx.AccruedBonusAmount),
+		FeePayer:                  sql.NullString{String: tx.FeePayer, Valid: tx.FeePayer != ""},
+		SponsoredAmount:           int64(tx.SponsoredAmount),
+		PromoDiscountAmount:       int64(tx.PromoDiscountAmount),
+		AppliedRules:              appliedRules,
+		CouponID:                  couponID,
+		CouponDiscountAmount:      int64(tx.CouponDiscountAmount),
+		GatingRuleID:              gatingRuleID,
+		GatingAsset:               sql.NullString{String: tx.GatingAsset, Valid: tx.GatingAsset != ""},
+		GatingDiscountAmount:      int64(tx.GatingDiscountAmount),
+		GiftCardID:                giftCardID,
+		GiftCardAmount:            int64(tx.GiftCardAmount),
+		RefCode:                   sql.NullString{String: tx.RefCode, Valid: tx.RefCode != ""},
+		AffiliateID:               affiliateID,
+		AffiliateCommission:       int64(tx.AffiliateCommission),
+		AffiliateCommissionInline: tx.AffiliateInline,
+		Status:                    repository.TransactionStatusPending,
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create transaction: %w", err)
+		return nil, err
 	}
 
 	result := castFromRepositoryTransaction(repoTx, s.conf)
@@ -191,75 +469,1442 @@ func (s *Service) BuildTransaction(ctx context.Context, tx *Transaction) (*Trans
 	return result, nil
 }
 
-// GetTransactionByReference returns the transaction with the given reference.
-func (s *Service) GetTransactionByReference(ctx context.Context, reference string) (*Transaction, error) {
-	result, err := s.repo.GetTransactionByReference(ctx, reference)
+// PreviewTransaction calculates the payment breakdown for the given transaction parameters.
+// Nothing is stored, so it can be used before the customer connects the wallet.
+func (s *Service) PreviewTransaction(ctx context.Context, tx *Transaction) (*TransactionPreview, error) {
+	if tx.PaymentID == uuid.Nil {
+		return nil, fmt.Errorf("payment ID is required")
+	}
+	payment, err := s.GetPayment(ctx, tx.PaymentID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get transaction by reference=%s: %w", reference, err)
+		return nil, fmt.Errorf("failed to get payment: %w", err)
+	}
+	if payment.Status != PaymentStatusNew && payment.Status != PaymentStatusPending {
+		return nil, fmt.Errorf("payment already %s", payment.Status)
+	}
+	if payment.DestinationMint, err = s.mintAddress(payment.DestinationMint, s.conf.DestinationMint); err != nil {
+		return nil, err
+	}
+	if tx.SourceMint, err = s.mintAddress(tx.SourceMint, payment.DestinationMint); err != nil {
+		return nil, err
 	}
 
-	return castFromRepositoryTransaction(result, s.conf), nil
+	coupon, err := s.checkoutCoupon(ctx, tx, payment)
+	if err != nil {
+		return nil, err
+	}
+	giftCard, giftCardAvailable, err := s.checkoutGiftCard(ctx, tx, payment)
+	if err != nil {
+		return nil, err
+	}
+	affiliate, err := s.checkoutAffiliate(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	builder, err := s.newPaymentBuilder(ctx, tx.SourceWallet)
+	if err != nil {
+		return nil, err
+	}
+	preview, err := builder.SetTransaction(tx, payment).
+		SetCoupon(coupon).
+		SetGiftCard(giftCard, giftCardAvailable).
+		SetAffiliate(affiliate).
+		Preview(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to preview transaction: %w", err)
+	}
+
+	return preview, nil
 }
 
-// MarkPaymentsAsExpired marks all payments that are expired as expired.
-func (s *Service) MarkPaymentsAsExpired(ctx context.Context) error {
-	if err := s.repo.MarkPaymentsExpired(ctx); err != nil {
-		if !errors.Is(err, sql.ErrNoRows) {
-			return fmt.Errorf("failed to mark payments as expired: %w", err)
+// SubmitTransaction verifies the transaction signed by the customer against the stored one
+// and sends it to the network. Returns the transaction with the signature.
+func (s *Service) SubmitTransaction(ctx context.Context, reference, signedTx string) (*Transaction, error) {
+	tx, err := s.GetTransactionByReference(ctx, reference)
+	if err != nil {
+		return nil, err
+	}
+	if tx.Status != TransactionStatusPending {
+		return nil, fmt.Errorf("transaction already %s", tx.Status)
+	}
+
+	mint := tx.DestinationMint
+	if IsSOL(mint) {
+		mint = ""
+	}
+	feePayer := tx.FeePayer
+	if feePayer == "" {
+		feePayer = tx.SourceWallet
+	}
+	if err := solana.CheckPaymentTransaction(signedTx, solana.CheckPaymentTransactionParams{
+		FeePayer:  feePayer,
+		Reference: tx.Reference,
+		Recipient: tx.DestinationWallet,
+		Mint:      mint,
+		Amount:    tx.MerchantAmount(),
+	}); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrTransactionMismatch, err.Error())
+	}
+
+	if tx.SponsoredAmount > 0 {
+		if err := s.reserveSponsorBudget(ctx, reference, signedTx, tx.SponsoredAmount); err != nil {
+			return nil, err
 		}
 	}
 
-	return nil
-}
+	signature, err := s.sol.SendTransaction(ctx, signedTx)
+	if err != nil {
+		if tx.SponsoredAmount > 0 {
+			// The transaction is not sent, so it must not hold the budget.
+			if _, rerr := s.repo.UpdateTransactionByReference(ctx, repository.UpdateTransactionByReferenceParams{
+				Reference: reference,
+				Status:    repository.TransactionStatusPending,
+			}); rerr != nil {
+				return nil, fmt.Errorf("failed to send transaction: %w; failed to release sponsor budget: %v", err, rerr)
+			}
+		}
+		return nil, fmt.Errorf("failed to send transaction: %w", err)
+	}
 
-// UpdateTransaction updates the status and signature of the transaction with the given reference.
-func (s *Service) UpdateTransaction(ctx context.Context, reference string, status TransactionStatus, signature string) error {
-	if _, err := s.repo.UpdateTransactionByReference(ctx, repository.UpdateTransactionByReferenceParams{
-		Reference:   reference,
-		Status:      castToRepositoryTransactionStatus(status),
-		TxSignature: sql.NullString{String: signature, Valid: signature != ""},
+	if err := s.UpdateTransaction(ctx, reference, TransactionStatusPending, signature); err != nil {
+		return nil, err
+	}
+
+	tx.Signature = signature
+	tx.Transaction = signedTx
+
+	// The transaction is already sent, so the event is recorded after the fact.
+	if err := s.inTx(ctx, func(ctx context.Context, repo paymentRepository) error {
+		return s.recordEvent(ctx, repo, events.TransactionSubmitted, events.TransactionSubmittedPayload{
+			PaymentID:   events.PaymentID{PaymentID: tx.PaymentID.String()},
+			Reference:   tx.Reference,
+			Signature:   tx.Signature,
+			Transaction: tx.Transaction,
+		})
 	}); err != nil {
-		return fmt.Errorf("failed to update transaction status: %w", err)
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+// CreateBonusRule creates a new bonus rule.
+func (s *Service) CreateBonusRule(ctx context.Context, rule *BonusRule) (*BonusRule, error) {
+	if rule.Name == "" || !rule.Action.IsValid() || rule.Value == 0 {
+		return nil, ErrInvalidBonusRule
+	}
+	if rule.Action == BonusRuleActionDiscountPercent && rule.Value > 10000 {
+		return nil, fmt.Errorf("%w: discount percent must not exceed 10000", ErrInvalidBonusRule)
+	}
+	if rule.Conditions.SourceMint != "" {
+		mint, err := s.mintAddress(rule.Conditions.SourceMint, "")
+		if err != nil {
+			return nil, err
+		}
+		rule.Conditions.SourceMint = mint
+	}
+
+	conditions, err := json.Marshal(rule.Conditions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode bonus rule conditions: %w", err)
+	}
+
+	result, err := s.repo.CreateBonusRule(ctx, repository.CreateBonusRuleParams{
+		Name:        rule.Name,
+		Priority:    rule.Priority,
+		Conditions:  conditions,
+		Action:      repository.BonusRuleAction(rule.Action),
+		ActionValue: int64(rule.Value),
+		Active:      rule.Active,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create bonus rule: %w", err)
+	}
+
+	return utils.Pointer(castFromRepositoryBonusRule(result)), nil
+}
+
+// GetBonusRules returns all the bonus rules ordered by priority.
+func (s *Service) GetBonusRules(ctx context.Context) ([]BonusRule, error) {
+	rules, err := s.repo.GetBonusRules(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bonus rules: %w", err)
+	}
+
+	result := make([]BonusRule, 0, len(rules))
+	for _, r := range rules {
+		result = append(result, castFromRepositoryBonusRule(r))
+	}
+
+	return result, nil
+}
+
+// UpdateBonusRuleStatus enables or disables the bonus rule with the given ID.
+func (s *Service) UpdateBonusRuleStatus(ctx context.Context, id uuid.UUID, active bool) (*BonusRule, error) {
+	result, err := s.repo.UpdateBonusRuleStatus(ctx, repository.UpdateBonusRuleStatusParams{
+		ID:     id,
+		Active: active,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update bonus rule status: %w", err)
+	}
+
+	return utils.Pointer(castFromRepositoryBonusRule(result)), nil
+}
+
+// DeleteBonusRule deletes the bonus rule with the given ID.
+// Rules applied to transactions are kept in the transactions for auditing.
+func (s *Service) DeleteBonusRule(ctx context.Context, id uuid.UUID) error {
+	if err := s.repo.DeleteBonusRule(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete bonus rule: %w", err)
 	}
 
 	return nil
 }
 
-// GetPendingTransactions returns all pending transactions.
-func (s *Service) GetPendingTransactions(ctx context.Context) ([]*Transaction, error) {
-	pendingTxs, err := s.repo.GetPendingTransactions(ctx)
+// CreateCoupon creates a new coupon.
+func (s *Service) CreateCoupon(ctx context.Context, coupon *Coupon) (*Coupon, error) {
+	coupon.Code = NormalizeCouponCode(coupon.Code)
+	if coupon.Code == "" || !coupon.DiscountType.IsValid() || coupon.DiscountValue == 0 {
+		return nil, ErrInvalidCoupon
+	}
+	if coupon.DiscountType == CouponDiscountTypePercent && coupon.DiscountValue > 10000 {
+		return nil, fmt.Errorf("%w: discount percent must not exceed 10000", ErrInvalidCoupon)
+	}
+	if coupon.WalletUsageLimit > 0 && coupon.UsageLimit > 0 && coupon.WalletUsageLimit > coupon.UsageLimit {
+		return nil, fmt.Errorf("%w: wallet usage limit must not exceed usage limit", ErrInvalidCoupon)
+	}
+
+	var expiresAt sql.NullTime
+	if coupon.ExpiresAt != nil {
+		expiresAt = sql.NullTime{Time: *coupon.ExpiresAt, Valid: true}
+	}
+
+	result, err := s.repo.CreateCoupon(ctx, repository.CreateCouponParams{
+		Code:             coupon.Code,
+		DiscountType:     repository.CouponDiscountType(coupon.DiscountType),
+		DiscountValue:    int64(coupon.DiscountValue),
+		MinAmount:        int64(coupon.MinAmount),
+		UsageLimit:       int64(coupon.UsageLimit),
+		WalletUsageLimit: int64(coupon.WalletUsageLimit),
+		ExpiresAt:        expiresAt,
+		Active:           coupon.Active,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get pending transactions: %w", err)
+		return nil, fmt.Errorf("failed to create coupon: %w", err)
 	}
 
-	result := make([]*Transaction, 0, len(pendingTxs))
-	for _, tx := range pendingTxs {
-		result = append(result, castFromRepositoryTransaction(tx, s.conf))
+	return castFromRepositoryCoupon(result), nil
+}
+
+// GetCoupons returns all the coupons, newest first.
+func (s *Service) GetCoupons(ctx context.Context) ([]*Coupon, error) {
+	coupons, err := s.repo.GetCoupons(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get coupons: %w", err)
+	}
+
+	result := make([]*Coupon, 0, len(coupons))
+	for _, c := range coupons {
+		result = append(result, castFromRepositoryCoupon(c))
 	}
 
 	return result, nil
 }
 
-// MarkTransactionsAsExpired marks all transactions that are expired as expired.
-func (s *Service) MarkTransactionsAsExpired(ctx context.Context) error {
-	if err := s.repo.MarkTransactionsAsExpired(ctx); err != nil {
-		if !errors.Is(err, sql.ErrNoRows) {
-			return fmt.Errorf("failed to mark transactions as expired: %w", err)
+// UpdateCouponStatus enables or disables the coupon with the given ID.
+func (s *Service) UpdateCouponStatus(ctx context.Context, id uuid.UUID, active bool) (*Coupon, error) {
+	result, err := s.repo.UpdateCouponStatus(ctx, repository.UpdateCouponStatusParams{
+		ID:     id,
+		Active: active,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update coupon status: %w", err)
+	}
+
+	return castFromRepositoryCoupon(result), nil
+}
+
+// DeleteCoupon deletes the coupon with the given ID.
+// Transactions keep the coupon discount amount, but lose the reference to the coupon.
+func (s *Service) DeleteCoupon(ctx context.Context, id uuid.UUID) error {
+	if err := s.repo.DeleteCoupon(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete coupon: %w", err)
+	}
+
+	return nil
+}
+
+// CreateGatingRule creates a new discount rule for holders of the NFT collection or the token.
+func (s *Service) CreateGatingRule(ctx context.Context, rule *GatingRule) (*GatingRule, error) {
+	if rule.Name == "" || !rule.Type.IsValid() || rule.DiscountPercent == 0 || rule.DiscountPercent > 10000 {
+		return nil, ErrInvalidGatingRule
+	}
+	if rule.Type == GatingRuleTypeTokenBalance {
+		mint, err := s.mintAddress(rule.Address, "")
+		if err != nil {
+			return nil, err
 		}
+		rule.Address = mint
+	} else if !IsMintAddress(rule.Address) {
+		return nil, fmt.Errorf("%w: invalid %s address", ErrInvalidGatingRule, rule.Type)
+	}
+
+	result, err := s.repo.CreateGatingRule(ctx, repository.CreateGatingRuleParams{
+		Name:            rule.Name,
+		RuleType:        repository.GatingRuleType(rule.Type),
+		Address:         rule.Address,
+		MinBalance:      int64(rule.MinBalance),
+		DiscountPercent: int64(rule.DiscountPercent),
+		Active:          rule.Active,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gating rule: %w", err)
+	}
+
+	return utils.Pointer(castFromRepositoryGatingRule(result)), nil
+}
+
+// GetGatingRules returns all the gating rules.
+func (s *Service) GetGatingRules(ctx context.Context) ([]GatingRule, error) {
+	rules, err := s.repo.GetGatingRules(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get gating rules: %w", err)
+	}
+
+	result := make([]GatingRule, 0, len(rules))
+	for _, r := range rules {
+		result = append(result, castFromRepositoryGatingRule(r))
+	}
+
+	return result, nil
+}
+
+// UpdateGatingRuleStatus enables or disables the gating rule with the given ID.
+func (s *Service) UpdateGatingRuleStatus(ctx context.Context, id uuid.UUID, active bool) (*GatingRule, error) {
+	result, err := s.repo.UpdateGatingRuleStatus(ctx, repository.UpdateGatingRuleStatusParams{
+		ID:     id,
+		Active: active,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update gating rule status: %w", err)
+	}
+
+	return utils.Pointer(castFromRepositoryGatingRule(result)), nil
+}
+
+// DeleteGatingRule deletes the gating rule with the given ID.
+// Transactions keep the matched asset and the discount amount for auditing.
+func (s *Service) DeleteGatingRule(ctx context.Context, id uuid.UUID) error {
+	if err := s.repo.DeleteGatingRule(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete gating rule: %w", err)
 	}
 
 	return nil
 }
 
-func (s *Service) mergePaymentWithDefaultConfig(payment *Payment) *Payment {
-	if payment.DestinationWallet == "" {
-		payment.DestinationWallet = s.conf.DestinationWallet
+// IssueGiftCard issues a new gift card with the initial balance in the given mint.
+// The code is generated if it's empty.
+func (s *Service) IssueGiftCard(ctx context.Context, card *GiftCard) (*GiftCard, error) {
+	if !s.giftCards {
+		return nil, fmt.Errorf("%w: gift cards are disabled", ErrGiftCardUnavailable)
 	}
-	if payment.DestinationMint == "" {
-		payment.DestinationMint = s.conf.DestinationMint
+	if card.InitialAmount == 0 || card.InitialAmount > math.MaxInt64 {
+		return nil, fmt.Errorf("%w: initial amount must be greater than 0", ErrInvalidGiftCard)
 	}
-	if payment.ExpiresAt == nil {
-		payment.ExpiresAt = utils.Pointer(time.Now().Add(s.conf.PaymentTTL))
+	if card.ExpiresAt != nil && !card.ExpiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("%w: expiration time must be in the future", ErrInvalidGiftCard)
 	}
-	return payment
+
+	card.Code = NormalizeGiftCardCode(card.Code)
+	if card.Code == "" {
+		code, err := NewGiftCardCode()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate gift card code: %w", err)
+		}
+		card.Code = code
+	}
+
+	mint, err := s.mintAddress(card.Mint, s.conf.DestinationMint)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidGiftCard, err.Error())
+	}
+
+	var expiresAt sql.NullTime
+	if card.ExpiresAt != nil {
+		expiresAt = sql.NullTime{Time: *card.ExpiresAt, Valid: true}
+	}
+
+	dbTx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin db transaction: %w", err)
+	}
+	defer dbTx.Rollback() //nolint:errcheck
+
+	repo := s.repo.WithTx(dbTx)
+
+	c, err := repo.CreateGiftCard(ctx, repository.CreateGiftCardParams{
+		Code:          card.Code,
+		Mint:          mint,
+		InitialAmount: int64(card.InitialAmount),
+		Balance:       int64(card.InitialAmount),
+		ExpiresAt:     expiresAt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gift card: %w", err)
+	}
+
+	entry, err := repo.CreateGiftCardLedgerEntry(ctx, repository.CreateGiftCardLedgerEntryParams{
+		GiftCardID: c.ID,
+		EntryType:  repository.GiftCardEntryTypeIssue,
+		Amount:     c.InitialAmount,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gift card ledger entry: %w", err)
+	}
+
+	if err := dbTx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit db transaction: %w", err)
+	}
+
+	result := castFromRepositoryGiftCard(c)
+	result.Entries = []GiftCardEntry{castFromRepositoryGiftCardEntry(entry)}
+
+	return result, nil
+}
+
+// GetGiftCards returns all the gift cards, newest first.
+func (s *Service) GetGiftCards(ctx context.Context) ([]*GiftCard, error) {
+	cards, err := s.repo.GetGiftCards(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get gift cards: %w", err)
+	}
+
+	result := make([]*GiftCard, 0, len(cards))
+	for _, c := range cards {
+		result = append(result, castFromRepositoryGiftCard(c))
+	}
+
+	return result, nil
+}
+
+// GetGiftCard returns the gift card with the given ID and its balance history, newest first.
+func (s *Service) GetGiftCard(ctx context.Context, id uuid.UUID) (*GiftCard, error) {
+	c, err := s.repo.GetGiftCard(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get gift card: %w", err)
+	}
+
+	entries, err := s.repo.GetGiftCardLedgerEntries(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get gift card ledger entries: %w", err)
+	}
+
+	result := castFromRepositoryGiftCard(c)
+	result.Entries = make([]GiftCardEntry, 0, len(entries))
+	for _, e := range entries {
+		result.Entries = append(result.Entries, castFromRepositoryGiftCardEntry(e))
+	}
+
+	return result, nil
+}
+
+// UpdateGiftCardStatus enables or disables the gift card with the given ID.
+func (s *Service) UpdateGiftCardStatus(ctx context.Context, id uuid.UUID, active bool) (*GiftCard, error) {
+	result, err := s.repo.UpdateGiftCardStatus(ctx, repository.UpdateGiftCardStatusParams{
+		ID:     id,
+		Active: active,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update gift card status: %w", err)
+	}
+
+	return castFromRepositoryGiftCard(result), nil
+}
+
+// PayWithGiftCard pays the whole payment amount with the gift card balance
+// and completes the payment without an on-chain transaction.
+// Returns the stored transaction.
+func (s *Service) PayWithGiftCard(ctx context.Context, paymentID uuid.UUID, code string) (*Transaction, error) {
+	payment, err := s.GetPayment(ctx, paymentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payment: %w", err)
+	}
+	if payment.Status != PaymentStatusNew && payment.Status != PaymentStatusPending {
+		return nil, fmt.Errorf("payment already %s", payment.Status)
+	}
+	if payment.DestinationMint, err = s.mintAddress(payment.DestinationMint, s.conf.DestinationMint); err != nil {
+		return nil, err
+	}
+	if payment.DestinationWallet == "" {
+		payment.DestinationWallet = s.conf.DestinationWallet
+	}
+
+	card, err := s.getGiftCard(ctx, code, payment.DestinationMint)
+	if err != nil {
+		return nil, err
+	}
+
+	var tx repository.Transaction
+	if err := s.inTx(ctx, func(ctx context.Context, repo paymentRepository) error {
+		if _, err := lockGiftCard(ctx, repo, card.ID, payment.DestinationMint, payment.ID, payment.Amount); err != nil {
+			return err
+		}
+
+		var err error
+		tx, err = repo.CreateTransaction(ctx, repository.CreateTransactionParams{
+			PaymentID:         payment.ID,
+			Reference:         types.NewAccount().PublicKey.ToBase58(), // unique, there is no on-chain transaction to refer to
+			SourceMint:        payment.DestinationMint,
+			DestinationWallet: payment.DestinationWallet,
+			DestinationMint:   payment.DestinationMint,
+			Amount:            int64(payment.Amount),
+			Message:           sql.NullString{String: payment.Message, Valid: payment.Message != ""},
+			Memo:              sql.NullString{String: payment.ExternalID, Valid: payment.ExternalID != ""},
+			ApplyBonus:        sql.NullBool{Bool: false, Valid: true},
+			AppliedRules:      json.RawMessage("[]"),
+			GiftCardID:        uuid.NullUUID{UUID: card.ID, Valid: true},
+			GiftCardAmount:    int64(payment.Amount),
+			Status:            repository.TransactionStatusCompleted,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create transaction: %w", err)
+		}
+
+		if err := redeemGiftCard(ctx, repo, tx); err != nil {
+			return err
+		}
+
+		if _, err := repo.UpdatePaymentStatus(ctx, repository.UpdatePaymentStatusParams{
+			ID:     payment.ID,
+			Status: repository.PaymentStatusCompleted,
+		}); err != nil {
+			return fmt.Errorf("failed to update payment status: %w", err)
+		}
+
+		return s.recordEvent(ctx, repo, events.PaymentSucceeded, events.PaymentStatusUpdatedPayload{
+			PaymentID: events.PaymentID{PaymentID: payment.ID.String()},
+			Status:    string(PaymentStatusCompleted),
+		})
+	}); err != nil {
+		return nil, err
+	}
+
+	return castFromRepositoryTransaction(tx, s.conf), nil
+}
+
+// CreateAffiliate creates a new affiliate. The ref code is generated if it's empty.
+func (s *Service) CreateAffiliate(ctx context.Context, affiliate *Affiliate) (*Affiliate, error) {
+	if affiliate.Name == "" || !affiliate.CommissionType.IsValid() || affiliate.CommissionValue == 0 {
+		return nil, ErrInvalidAffiliate
+	}
+	if affiliate.CommissionType == AffiliateCommissionTypePercent && affiliate.CommissionValue > 10000 {
+		return nil, fmt.Errorf("%w: commission percent must not exceed 10000", ErrInvalidAffiliate)
+	}
+	if affiliate.CommissionValue > math.MaxInt64 {
+		return nil, fmt.Errorf("%w: commission value is too big", ErrInvalidAffiliate)
+	}
+	if !IsMintAddress(affiliate.Wallet) {
+		return nil, fmt.Errorf("%w: invalid wallet address", ErrInvalidAffiliate)
+	}
+	if affiliate.Wallet == s.conf.DestinationWallet {
+		return nil, fmt.Errorf("%w: wallet must not be the merchant wallet", ErrInvalidAffiliate)
+	}
+	if affiliate.PayoutMode == "" {
+		affiliate.PayoutMode = AffiliatePayoutModeAccrued
+	}
+	if !affiliate.PayoutMode.IsValid() {
+		return nil, fmt.Errorf("%w: unsupported payout mode", ErrInvalidAffiliate)
+	}
+
+	affiliate.RefCode = NormalizeRefCode(affiliate.RefCode)
+	if affiliate.RefCode == "" {
+		code, err := NewRefCode()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate ref code: %w", err)
+		}
+		affiliate.RefCode = code
+	}
+
+	result, err := s.repo.CreateAffiliate(ctx, repository.CreateAffiliateParams{
+		Name:            affiliate.Name,
+		RefCode:         affiliate.RefCode,
+		Wallet:          affiliate.Wallet,
+		CommissionType:  repository.AffiliateCommissionType(affiliate.CommissionType),
+		CommissionValue: int64(affiliate.CommissionValue),
+		PayoutMode:      repository.AffiliatePayoutMode(affiliate.PayoutMode),
+		Active:          affiliate.Active,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create affiliate: %w", err)
+	}
+
+	return castFromRepositoryAffiliate(result), nil
+}
+
+// GetAffiliates returns all the affiliates, newest first.
+func (s *Service) GetAffiliates(ctx context.Context) ([]*Affiliate, error) {
+	affiliates, err := s.repo.GetAffiliates(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get affiliates: %w", err)
+	}
+
+	result := make([]*Affiliate, 0, len(affiliates))
+	for _, a := range affiliates {
+		result = append(result, castFromRepositoryAffiliate(a))
+	}
+
+	return result, nil
+}
+
+// GetAffiliate returns the affiliate with the given ID.
+func (s *Service) GetAffiliate(ctx context.Context, id uuid.UUID) (*Affiliate, error) {
+	result, err := s.repo.GetAffiliate(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get affiliate: %w", err)
+	}
+
+	return castFromRepositoryAffiliate(result), nil
+}
+
+// UpdateAffiliateStatus enables or disables the affiliate with the given ID.
+// Commissions which are already accrued are still paid out to the disabled affiliate.
+func (s *Service) UpdateAffiliateStatus(ctx context.Context, id uuid.UUID, active bool) (*Affiliate, error) {
+	result, err := s.repo.UpdateAffiliateStatus(ctx, repository.UpdateAffiliateStatusParams{
+		ID:     id,
+		Active: active,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update affiliate status: %w", err)
+	}
+
+	return castFromRepositoryAffiliate(result), nil
+}
+
+// GetAffiliateReport returns the affiliate sales and commissions created in the given period.
+func (s *Service) GetAffiliateReport(ctx context.Context, id uuid.UUID, since, until time.Time) (*AffiliateReport, error) {
+	affiliate, err := s.GetAffiliate(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.reconcileAffiliatePayouts(ctx, id); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.repo.GetAffiliateReport(ctx, repository.GetAffiliateReportParams{
+		AffiliateID: id,
+		Since:       since,
+		Until:       until,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get affiliate report: %w", err)
+	}
+
+	commissions, err := s.repo.GetAffiliateCommissions(ctx, repository.GetAffiliateCommissionsParams{
+		AffiliateID: id,
+		Since:       since,
+		Until:       until,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get affiliate commissions: %w", err)
+	}
+
+	report := &AffiliateReport{
+		Affiliate:   affiliate,
+		Since:       since,
+		Until:       until,
+		Totals:      make([]AffiliateReportTotal, 0, len(rows)),
+		Commissions: make([]AffiliateCommission, 0, len(commissions)),
+	}
+	for _, r := range rows {
+		report.Totals = append(report.Totals, castFromRepositoryAffiliateReportRow(r))
+	}
+	for _, c := range commissions {
+		report.Commissions = append(report.Commissions, castFromRepositoryAffiliateCommission(c))
+	}
+
+	return report, nil
+}
+
+// PayoutAffiliateCommissions pays out all the accrued commissions of the affiliate by a single transaction
+// from the payout wallet, one transfer per mint. The commissions are marked as processing before the transaction
+// is sent, so they can't be paid twice. They're marked as paid, or as accrued again if the transaction
+// has not landed, on the next payout or report of the affiliate.
+func (s *Service) PayoutAffiliateCommissions(ctx context.Context, id uuid.UUID) (*AffiliatePayout, error) {
+	if s.payout == nil || s.db == nil {
+		return nil, ErrPayoutDisabled
+	}
+
+	affiliate, err := s.GetAffiliate(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.reconcileAffiliatePayouts(ctx, id); err != nil {
+		return nil, err
+	}
+
+	dbTx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin db transaction: %w", err)
+	}
+	defer dbTx.Rollback() //nolint:errcheck
+
+	repo := s.repo.WithTx(dbTx)
+
+	commissions, err := repo.GetAccruedAffiliateCommissionsForUpdate(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get accrued affiliate commissions: %w", err)
+	}
+	if len(commissions) == 0 {
+		return nil, ErrNothingToPayout
+	}
+
+	payout := &AffiliatePayout{
+		AffiliateID: affiliate.ID,
+		Wallet:      affiliate.Wallet,
+		Commissions: make([]AffiliateCommission, 0, len(commissions)),
+	}
+	totals := make(map[string]uint64)
+	for _, c := range commissions {
+		if _, ok := totals[c.Mint]; !ok {
+			payout.Amounts = append(payout.Amounts, AffiliatePayoutTotal{Mint: c.Mint})
+		}
+		totals[c.Mint] += uint64(c.Amount)
+	}
+
+	sender := s.payout.PublicKey.ToBase58()
+	builder := solana.NewTransactionBuilder(s.sol).SetFeePayer(sender).AddSigner(*s.payout)
+	for i, t := range payout.Amounts {
+		payout.Amounts[i].Amount = totals[t.Mint]
+		if IsSOL(t.Mint) {
+			builder = builder.AddInstruction(solana.TransferSOL(solana.TransferSOLParams{
+				Sender:    sender,
+				Recipient: affiliate.Wallet,
+				Amount:    totals[t.Mint],
+			}))
+			continue
+		}
+		builder = builder.AddInstruction(solana.TransferToken(solana.TransferTokenParam{
+			Sender:    sender,
+			Recipient: affiliate.Wallet,
+			Mint:      t.Mint,
+			Amount:    totals[t.Mint],
+		}))
+	}
+
+	txSource, err := builder.Build(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build payout transaction: %w", err)
+	}
+	if err := s.sol.SimulateTransaction(ctx, txSource); err != nil {
+		return nil, fmt.Errorf("failed to simulate payout transaction: %w", err)
+	}
+	payout.Signature, err = transactionSignature(txSource)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, c := range commissions {
+		if err := repo.StartAffiliateCommissionPayout(ctx, repository.StartAffiliateCommissionPayoutParams{
+			ID:              c.ID,
+			PayoutSignature: sql.NullString{String: payout.Signature, Valid: true},
+		}); err != nil {
+			return nil, fmt.Errorf("failed to update affiliate commission: %w", err)
+		}
+		commission := castFromRepositoryAffiliateCommission(c)
+		commission.Status = AffiliateCommissionStatusProcessing
+		commission.PayoutSignature = payout.Signature
+		payout.Commissions = append(payout.Commissions, commission)
+	}
+
+	if err := dbTx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit db transaction: %w", err)
+	}
+
+	// The commissions are not reverted if sending fails, since the transaction may have reached the network anyway.
+	// They're reconciled once the transaction blockhash expires.
+	if _, err := s.sol.SendTransaction(ctx, txSource); err != nil {
+		return nil, fmt.Errorf("failed to send payout transaction %s: %w", payout.Signature, err)
+	}
+
+	return payout, nil
+}
+
+// GetTransactionByReference returns the transaction with the given reference.
+func (s *Service) GetTransactionByReference(ctx context.Context, reference string) (*Transaction, error) {
+	result, err := s.repo.GetTransactionByReference(ctx, reference)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction by reference=%s: %w", reference, err)
+	}
+
+	return castFromRepositoryTransaction(result, s.conf), nil
+}
+
+// GetPaymentTransactions returns all the transactions of the payment, newest first.
+func (s *Service) GetPaymentTransactions(ctx context.Context, paymentID uuid.UUID) ([]*Transaction, error) {
+	txs, err := s.repo.GetTransactionsByPaymentID(ctx, paymentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions of payment=%s: %w", paymentID, err)
+	}
+
+	result := make([]*Transaction, 0, len(txs))
+	for _, tx := range txs {
+		result = append(result, castFromRepositoryTransaction(tx, s.conf))
+	}
+
+	return result, nil
+}
+
+// GetPaymentEvents returns the events recorded for the payment, oldest first.
+func (s *Service) GetPaymentEvents(ctx context.Context, paymentID uuid.UUID) ([]*PaymentEvent, error) {
+	evts, err := s.repo.GetPaymentEvents(ctx, uuid.NullUUID{UUID: paymentID, Valid: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get events of payment=%s: %w", paymentID, err)
+	}
+
+	result := make([]*PaymentEvent, 0, len(evts))
+	for _, e := range evts {
+		result = append(result, castFromRepositoryEvent(e))
+	}
+
+	return result, nil
+}
+
+// MarkPaymentsAsExpired marks all payments that are expired as expired.
+// The payment.expired event is recorded for each of them, so the merchant can release the reserved stock.
+func (s *Service) MarkPaymentsAsExpired(ctx context.Context) error {
+	return s.expireInBatches(ctx, func(ctx context.Context, repo paymentRepository) (int, error) {
+		expired, err := repo.MarkPaymentsExpired(ctx, int32(s.expiryBatchSize))
+		if err != nil {
+			return 0, fmt.Errorf("failed to mark payments as expired: %w", err)
+		}
+
+		for _, p := range expired {
+			if err := s.recordEvent(ctx, repo, events.PaymentExpired, events.PaymentStatusUpdatedPayload{
+				PaymentID: events.PaymentID{PaymentID: p.ID.String()},
+				Status:    string(PaymentStatusExpired),
+			}); err != nil {
+				return 0, err
+			}
+		}
+
+		return len(expired), nil
+	})
+}
+
+// UpdateTransaction updates the status and signature of the transaction with the given reference.
+// The gift card, affiliate and loyalty bookkeeping of the completed transaction is done
+// in the same database transaction as the status change and its event, so they're committed together.
+func (s *Service) UpdateTransaction(ctx context.Context, reference string, status TransactionStatus, signature string) error {
+	return s.inTx(ctx, func(ctx context.Context, repo paymentRepository) error {
+		tx, err := repo.UpdateTransactionByReference(ctx, repository.UpdateTransactionByReferenceParams{
+			Reference:   reference,
+			Status:      castToRepositoryTransactionStatus(status),
+			TxSignature: sql.NullString{String: signature, Valid: signature != ""},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to update transaction status: %w", err)
+		}
+
+		if status == TransactionStatusCompleted {
+			if err := s.completeTransaction(ctx, repo, tx); err != nil {
+				return err
+			}
+		}
+
+		result := castFromRepositoryTransaction(tx, s.conf)
+		return s.recordEvent(ctx, repo, events.TransactionUpdated, events.TransactionUpdatedPayload{
+			PaymentID:   events.PaymentID{PaymentID: result.PaymentID.String()},
+			Reference:   result.Reference,
+			Status:      string(result.Status),
+			Signature:   result.Signature,
+			Transaction: result,
+		})
+	})
+}
+
+// completeTransaction redeems the gift card, records the affiliate commission and the loyalty ledger entries
+// of the completed transaction. Each of them is unique per transaction, so it's safe to call it several times.
+func (s *Service) completeTransaction(ctx context.Context, repo paymentRepository, tx repository.Transaction) error {
+	if tx.GiftCardID.Valid && tx.GiftCardAmount > 0 {
+		if err := redeemGiftCard(ctx, repo, tx); err != nil {
+			return err
+		}
+	}
+
+	if tx.AffiliateID.Valid && tx.AffiliateCommission > 0 {
+		if err := recordAffiliateCommission(ctx, repo, tx); err != nil {
+			return err
+		}
+	}
+
+	if s.loyalty != nil {
+		var redeemed uint64
+		if tx.ApplyBonus.Bool {
+			redeemed = uint64(tx.DiscountAmount)
+		}
+		if err := s.loyalty.WithRepository(repo).RecordTransaction(ctx, tx.ID, tx.SourceWallet, uint64(tx.AccruedBonusAmount), redeemed); err != nil {
+			return fmt.Errorf("failed to record loyalty ledger entries: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// GetPendingTransactions returns all pending transactions.
+func (s *Service) GetPendingTransactions(ctx context.Context) ([]*Transaction, error) {
+	pendingTxs, err := s.repo.GetPendingTransactions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending transactions: %w", err)
+	}
+
+	result := make([]*Transaction, 0, len(pendingTxs))
+	for _, tx := range pendingTxs {
+		result = append(result, castFromRepositoryTransaction(tx, s.conf))
+	}
+
+	return result, nil
+}
+
+// MarkTransactionsAsExpired marks all transactions that are expired as expired.
+// The transaction.updated event is recorded for each of them with the expired status.
+func (s *Service) MarkTransactionsAsExpired(ctx context.Context) error {
+	return s.expireInBatches(ctx, func(ctx context.Context, repo paymentRepository) (int, error) {
+		expired, err := repo.MarkTransactionsAsExpired(ctx, int32(s.expiryBatchSize))
+		if err != nil {
+			return 0, fmt.Errorf("failed to mark transactions as expired: %w", err)
+		}
+
+		for _, tx := range expired {
+			result := castFromRepositoryTransaction(tx, s.conf)
+			result.Status = TransactionStatusExpired
+			if err := s.recordEvent(ctx, repo, events.TransactionUpdated, events.TransactionUpdatedPayload{
+				PaymentID:   events.PaymentID{PaymentID: result.PaymentID.String()},
+				Reference:   result.Reference,
+				Status:      string(result.Status),
+				Signature:   result.Signature,
+				Transaction: result,
+			}); err != nil {
+				return 0, err
+			}
+		}
+
+		return len(expired), nil
+	})
+}
+
+func (s *Service) mergePaymentWithDefaultConfig(payment *Payment) *Payment {
+	if payment.DestinationWallet == "" {
+		payment.DestinationWallet = s.conf.DestinationWallet
+	}
+	if payment.DestinationMint == "" {
+		payment.DestinationMint = s.conf.DestinationMint
+	}
+	if payment.ExpiresAt == nil {
+		payment.ExpiresAt = utils.Pointer(time.Now().Add(s.conf.PaymentTTL))
+	}
+	return payment
+}
+
+// simulateTransaction checks if the built transaction can succeed.
+// Only simulation failures are returned, since the simulation is a best-effort pre-flight check
+// and RPC node errors must not prevent the customer from paying.
+func (s *Service) simulateTransaction(ctx context.Context, base64Tx string) error {
+	if !s.conf.SimulateTransaction {
+		return nil
+	}
+
+	if err := s.sol.SimulateTransaction(ctx, base64Tx); err != nil {
+		var simErr *solana.SimulationError
+		if errors.As(err, &simErr) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getCoupon returns the coupon with the given code if it can be applied to the payment amount.
+func (s *Service) getCoupon(ctx context.Context, code string, amount uint64) (*Coupon, error) {
+	if !s.coupons {
+		return nil, fmt.Errorf("%w: coupons are disabled", ErrCouponUnavailable)
+	}
+
+	c, err := s.repo.GetCouponByCode(ctx, NormalizeCouponCode(code))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrCouponUnavailable
+		}
+		return nil, fmt.Errorf("failed to get coupon: %w", err)
+	}
+
+	coupon := castFromRepositoryCoupon(c)
+	if !coupon.available(amount, time.Now()) {
+		return nil, ErrCouponUnavailable
+	}
+
+	return coupon, nil
+}
+
+// checkoutCoupon returns the coupon applied to the transaction, or nil if there is no coupon code.
+// The usage limits are checked against transactions of the other payments,
+// so the customer can rebuild the transaction of the same payment.
+func (s *Service) checkoutCoupon(ctx context.Context, tx *Transaction, payment *Payment) (*Coupon, error) {
+	if tx.CouponCode == "" {
+		return nil, nil
+	}
+
+	coupon, err := s.getCoupon(ctx, tx.CouponCode, payment.Amount)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkCouponUsage(ctx, s.repo, coupon, tx.SourceWallet, payment.ID); err != nil {
+		return nil, err
+	}
+
+	return coupon, nil
+}
+
+// checkCouponUsage checks if the coupon usage limits are not reached.
+// The wallet usage limit is checked only if the wallet is known.
+func checkCouponUsage(ctx context.Context, repo paymentRepository, c *Coupon, wallet string, paymentID uuid.UUID) error {
+	if c.UsageLimit == 0 && (c.WalletUsageLimit == 0 || wallet == "") {
+		return nil
+	}
+
+	usage, err := repo.GetCouponUsage(ctx, repository.GetCouponUsageParams{
+		CouponID:     uuid.NullUUID{UUID: c.ID, Valid: true},
+		SourceWallet: wallet,
+		PaymentID:    paymentID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get coupon usage: %w", err)
+	}
+	if c.UsageLimit > 0 && uint64(usage.Total) >= c.UsageLimit {
+		return ErrCouponUsageLimit
+	}
+	if c.WalletUsageLimit > 0 && wallet != "" && uint64(usage.Wallet) >= c.WalletUsageLimit {
+		return ErrCouponUsageLimit
+	}
+
+	return nil
+}
+
+// createTransaction stores the transaction. If the coupon or the gift card is applied, its row is locked
+// until the transaction is stored, so the coupon usage limits and the gift card balance are checked
+// and the transaction holds them atomically.
+func (s *Service) createTransaction(ctx context.Context, coupon *Coupon, giftCard *GiftCard, arg repository.CreateTransactionParams) (repository.Transaction, error) {
+	var result repository.Transaction
+	err := s.inTx(ctx, func(ctx context.Context, repo paymentRepository) error {
+		if coupon != nil {
+			c, err := repo.GetCouponForUpdate(ctx, coupon.ID)
+			if err != nil {
+				if errors.Is(err, sql.ErrNoRows) {
+					return ErrCouponUnavailable
+				}
+				return fmt.Errorf("failed to lock coupon: %w", err)
+			}
+			coupon = castFromRepositoryCoupon(c)
+			if !coupon.available(uint64(arg.Amount), time.Now()) {
+				return ErrCouponUnavailable
+			}
+			if err := checkCouponUsage(ctx, repo, coupon, arg.SourceWallet, arg.PaymentID); err != nil {
+				return err
+			}
+		}
+
+		if giftCard != nil {
+			if _, err := lockGiftCard(ctx, repo, giftCard.ID, arg.DestinationMint, arg.PaymentID, uint64(arg.GiftCardAmount)); err != nil {
+				return err
+			}
+		}
+
+		if err := s.spendSponsorBudget(ctx, repo, arg.SponsoredAmount); err != nil {
+			return err
+		}
+
+		var err error
+		result, err = repo.CreateTransaction(ctx, arg)
+		if err != nil {
+			return fmt.Errorf("failed to create transaction: %w", err)
+		}
+
+		return s.recordTransactionCreated(ctx, repo, result)
+	})
+
+	return result, err
+}
+
+// recordTransactionCreated records the transaction.created event of the new transaction.
+func (s *Service) recordTransactionCreated(ctx context.Context, repo paymentRepository, tx repository.Transaction) error {
+	return s.recordEvent(ctx, repo, events.TransactionCreated, events.TransactionCreatedPayload{
+		TransactionID: tx.ID.String(),
+		PaymentID:     events.PaymentID{PaymentID: tx.PaymentID.String()},
+		Reference:     tx.Reference,
+	})
+}
+
+// getGiftCard returns the gift card with the given code if it can be applied to the payment in the given mint.
+func (s *Service) getGiftCard(ctx context.Context, code, mint string) (*GiftCard, error) {
+	if !s.giftCards {
+		return nil, fmt.Errorf("%w: gift cards are disabled", ErrGiftCardUnavailable)
+	}
+
+	c, err := s.repo.GetGiftCardByCode(ctx, NormalizeGiftCardCode(code))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrGiftCardUnavailable
+		}
+		return nil, fmt.Errorf("failed to get gift card: %w", err)
+	}
+
+	card := castFromRepositoryGiftCard(c)
+	if !card.available(mint, time.Now()) {
+		return nil, ErrGiftCardUnavailable
+	}
+
+	return card, nil
+}
+
+// checkoutGiftCard returns the gift card applied to the transaction and its available balance,
+// or nil if there is no gift card code.
+func (s *Service) checkoutGiftCard(ctx context.Context, tx *Transaction, payment *Payment) (*GiftCard, uint64, error) {
+	if tx.GiftCardCode == "" {
+		return nil, 0, nil
+	}
+
+	card, err := s.getGiftCard(ctx, tx.GiftCardCode, payment.DestinationMint)
+	if err != nil {
+		return nil, 0, err
+	}
+	available, err := giftCardAvailable(ctx, s.repo, card, payment.ID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if available == 0 {
+		return nil, 0, ErrGiftCardBalance
+	}
+
+	return card, available, nil
+}
+
+// giftCardAvailable returns the gift card balance excluding the amounts held by pending transactions
+// of the other payments, so the customer can rebuild the transaction of the same payment.
+func giftCardAvailable(ctx context.Context, repo paymentRepository, c *GiftCard, paymentID uuid.UUID) (uint64, error) {
+	held, err := repo.GetGiftCardHeldAmount(ctx, repository.GetGiftCardHeldAmountParams{
+		GiftCardID: uuid.NullUUID{UUID: c.ID, Valid: true},
+		PaymentID:  paymentID,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get gift card held amount: %w", err)
+	}
+	if uint64(held) >= c.Balance {
+		return 0, nil
+	}
+
+	return c.Balance - uint64(held), nil
+}
+
+// lockGiftCard locks the gift card row until the end of the db transaction
+// and checks if the given amount can be paid with the card.
+func lockGiftCard(ctx context.Context, repo paymentRepository, id uuid.UUID, mint string, paymentID uuid.UUID, amount uint64) (*GiftCard, error) {
+	c, err := repo.GetGiftCardForUpdate(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrGiftCardUnavailable
+		}
+		return nil, fmt.Errorf("failed to lock gift card: %w", err)
+	}
+
+	card := castFromRepositoryGiftCard(c)
+	if !card.available(mint, time.Now()) {
+		return nil, ErrGiftCardUnavailable
+	}
+	available, err := giftCardAvailable(ctx, repo, card, paymentID)
+	if err != nil {
+		return nil, err
+	}
+	if available < amount {
+		return nil, ErrGiftCardBalance
+	}
+
+	return card, nil
+}
+
+// redeemGiftCard writes the redemption entry to the gift card ledger and updates the card balance.
+// The entry is unique per transaction, so it's safe to call it several times for the same transaction.
+func redeemGiftCard(ctx context.Context, repo paymentRepository, tx repository.Transaction) error {
+	if _, err := repo.CreateGiftCardLedgerEntry(ctx, repository.CreateGiftCardLedgerEntryParams{
+		GiftCardID:    tx.GiftCardID.UUID,
+		EntryType:     repository.GiftCardEntryTypeRedemption,
+		Amount:        -tx.GiftCardAmount,
+		TransactionID: uuid.NullUUID{UUID: tx.ID, Valid: true},
+	}); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil // already redeemed
+		}
+		return fmt.Errorf("failed to create gift card ledger entry: %w", err)
+	}
+
+	if _, err := repo.UpdateGiftCardBalance(ctx, repository.UpdateGiftCardBalanceParams{
+		ID:     tx.GiftCardID.UUID,
+		Amount: -tx.GiftCardAmount,
+	}); err != nil {
+		return fmt.Errorf("failed to update gift card balance: %w", err)
+	}
+
+	return nil
+}
+
+// getAffiliate returns the active affiliate with the given ref code.
+func (s *Service) getAffiliate(ctx context.Context, ref string) (*Affiliate, error) {
+	if !s.affiliates {
+		return nil, fmt.Errorf("%w: affiliates are disabled", ErrAffiliateUnavailable)
+	}
+
+	a, err := s.repo.GetAffiliateByRefCode(ctx, NormalizeRefCode(ref))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrAffiliateUnavailable
+		}
+		return nil, fmt.Errorf("failed to get affiliate: %w", err)
+	}
+
+	affiliate := castFromRepositoryAffiliate(a)
+	if !affiliate.Active {
+		return nil, ErrAffiliateUnavailable
+	}
+
+	return affiliate, nil
+}
+
+// checkoutAffiliate returns the affiliate referred the customer, or nil if there is no ref code.
+// Unknown and disabled affiliates are ignored, so the customer can still pay by an outdated link.
+func (s *Service) checkoutAffiliate(ctx context.Context, tx *Transaction) (*Affiliate, error) {
+	tx.RefCode = NormalizeRefCode(tx.RefCode)
+	if tx.RefCode == "" || !s.affiliates {
+		return nil, nil
+	}
+
+	affiliate, err := s.getAffiliate(ctx, tx.RefCode)
+	if err != nil {
+		if errors.Is(err, ErrAffiliateUnavailable) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return affiliate, nil
+}
+
+// recordAffiliateCommission stores the commission of the completed transaction.
+// The inline commission is paid by the transaction itself, the other one is accrued for the batch payout.
+// The commission is unique per transaction, so it's safe to call it several times for the same transaction.
+func recordAffiliateCommission(ctx context.Context, repo paymentRepository, tx repository.Transaction) error {
+	arg := repository.CreateAffiliateCommissionParams{
+		AffiliateID:   tx.AffiliateID.UUID,
+		TransactionID: tx.ID,
+		PaymentID:     tx.PaymentID,
+		Mint:          tx.DestinationMint,
+		SaleAmount:    tx.TotalAmount + tx.GiftCardAmount,
+		Amount:        tx.AffiliateCommission,
+		Status:        repository.AffiliateCommissionStatusAccrued,
+	}
+	if tx.AffiliateCommissionInline {
+		arg.Status = repository.AffiliateCommissionStatusPaid
+		arg.PayoutSignature = tx.TxSignature
+		arg.PayoutAt = sql.NullTime{Time: time.Now(), Valid: true}
+	}
+
+	if _, err := repo.CreateAffiliateCommission(ctx, arg); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil // already recorded
+		}
+		return fmt.Errorf("failed to create affiliate commission: %w", err)
+	}
+
+	return nil
+}
+
+// affiliatePayoutTimeout is the time after which the payout transaction can't land anymore,
+// since its blockhash is expired.
+const affiliatePayoutTimeout = 5 * time.Minute
+
+// reconcileAffiliatePayouts checks the payout transactions of the affiliate commissions in processing.
+// The commissions are marked as paid if the transaction succeeded, or as accrued again if it failed
+// or has not landed before its blockhash expired.
+func (s *Service) reconcileAffiliatePayouts(ctx context.Context, id uuid.UUID) error {
+	commissions, err := s.repo.GetAffiliateCommissionsByStatus(ctx, repository.GetAffiliateCommissionsByStatusParams{
+		AffiliateID: id,
+		Status:      repository.AffiliateCommissionStatusProcessing,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get affiliate commissions: %w", err)
+	}
+
+	checked := make(map[string]bool)
+	for _, c := range commissions {
+		if checked[c.PayoutSignature.String] {
+			continue
+		}
+		checked[c.PayoutSignature.String] = true
+
+		status, err := s.sol.GetTransactionStatus(ctx, c.PayoutSignature.String)
+		if err != nil && status != solana.TransactionStatusFailure {
+			return fmt.Errorf("failed to get payout transaction status: %w", err)
+		}
+
+		switch {
+		case status == solana.TransactionStatusSuccess:
+			if err := s.repo.CompleteAffiliatePayout(ctx, c.PayoutSignature); err != nil {
+				return fmt.Errorf("failed to complete affiliate payout: %w", err)
+			}
+		case status == solana.TransactionStatusFailure,
+			status == solana.TransactionStatusUnknown && c.PayoutAt.Valid && time.Since(c.PayoutAt.Time) > affiliatePayoutTimeout:
+			if err := s.repo.RevertAffiliatePayout(ctx, c.PayoutSignature); err != nil {
+				return fmt.Errorf("failed to revert affiliate payout: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// transactionSignature returns the base58 encoded signature of the signed transaction,
+// it's known before the transaction is sent.
+func transactionSignature(txSource string) (string, error) {
+	tx, err := solana.DecodeTransaction(txSource)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode transaction: %w", err)
+	}
+	if len(tx.Signatures) == 0 {
+		return "", errors.New("transaction is not signed")
+	}
+
+	return utils.BytesToBase58(tx.Signatures[0]), nil
+}
+
+// mintAddress resolves the mint address by symbol using the token registry if it is set.
+func (s *Service) mintAddress(currency, fallback string) (string, error) {
+	if s.tokens != nil {
+		return s.tokens.MintAddress(currency, fallback)
+	}
+	return MintAddress(currency, fallback)
+}
+
+// newPaymentBuilder creates a payment transaction builder for the given payer wallet (optional).
+// The sponsor is set if the sponsorship is enabled and the daily budget is not exhausted.
+func (s *Service) newPaymentBuilder(ctx context.Context, wallet string) (*PaymentBuilder, error) {
+	builder := NewPaymentTransactionBuilder(s.sol, s.jup, s.conf).SetDecimalsResolver(s.decimals)
+	if s.tiers != nil {
+		builder = builder.SetTierResolver(s.tiers)
+	}
+
+	rules, err := s.repo.GetActiveBonusRules(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bonus rules: %w", err)
+	}
+	if len(rules) > 0 {
+		var purchases uint64
+		bonusRules := make([]BonusRule, 0, len(rules))
+		for _, r := range rules {
+			bonusRules = append(bonusRules, castFromRepositoryBonusRule(r))
+		}
+		if wallet != "" && usesPurchaseCount(bonusRules) {
+			stats, err := s.repo.GetWalletSpendStats(ctx, wallet)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get wallet spend stats: %w", err)
+			}
+			for _, row := range stats {
+				purchases += uint64(row.Purchases)
+			}
+		}
+		builder = builder.SetBonusRules(bonusRules, purchases)
+	}
+
+	gatingRules, err := s.repo.GetActiveGatingRules(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get gating rules: %w", err)
+	}
+	if len(gatingRules) > 0 {
+		rules := make([]GatingRule, 0, len(gatingRules))
+		for _, r := range gatingRules {
+			rules = append(rules, castFromRepositoryGatingRule(r))
+		}
+		builder = builder.SetGatingRules(rules)
+	}
+
+	if s.sponsor == nil {
+		return builder, nil
+	}
+
+	limit := s.conf.SponsorMaxPerPayment
+	if s.conf.SponsorDailyBudget > 0 {
+		spent, err := s.repo.GetSponsoredAmountSince(ctx, sponsorBudgetDay())
+		if err != nil {
+			return nil, fmt.Errorf("failed to get sponsored amount: %w", err)
+		}
+		if uint64(spent) >= s.conf.SponsorDailyBudget {
+			return builder, nil
+		}
+		if rest := s.conf.SponsorDailyBudget - uint64(spent); limit == 0 || rest < limit {
+			limit = rest
+		}
+	}
+
+	return builder.SetSponsor(*s.sponsor, limit), nil
+}
+
+// spendSponsorBudget checks the sponsored amount fits the rest of the daily budget
+// under the budget lock, which is held until the database transaction ends.
+// Only the submitted transactions spend the budget, so it's checked when the transaction is built
+// and again when it's submitted, see reserveSponsorBudget.
+// Returns ErrSponsorBudgetSpent if the amount doesn't fit.
+func (s *Service) spendSponsorBudget(ctx context.Context, repo paymentRepository, amount int64) error {
+	if amount <= 0 || s.conf.SponsorDailyBudget == 0 {
+		return nil
+	}
+
+	if err := repo.LockSponsorBudget(ctx, sponsorBudgetLockKey); err != nil {
+		return fmt.Errorf("failed to lock sponsor budget: %w", err)
+	}
+	spent, err := repo.GetSponsoredAmountSince(ctx, sponsorBudgetDay())
+	if err != nil {
+		return fmt.Errorf("failed to get sponsored amount: %w", err)
+	}
+	if uint64(spent)+uint64(amount) > s.conf.SponsorDailyBudget {
+		return ErrSponsorBudgetSpent
+	}
+
+	return nil
+}
+
+// reserveSponsorBudget spends the daily budget on the sponsored transaction before it's sent:
+// the signature is stored under the budget lock, so the transaction counts against the budget
+// and the concurrent submissions can't exceed it.
+func (s *Service) reserveSponsorBudget(ctx context.Context, reference, signedTx string, amount uint64) error {
+	signature, err := transactionSignature(signedTx)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrTransactionMismatch, err.Error())
+	}
+
+	return s.inTx(ctx, func(ctx context.Context, repo paymentRepository) error {
+		if err := s.spendSponsorBudget(ctx, repo, int64(amount)); err != nil {
+			return err
+		}
+		if _, err := repo.UpdateTransactionByReference(ctx, repository.UpdateTransactionByReferenceParams{
+			Reference:   reference,
+			Status:      repository.TransactionStatusPending,
+			TxSignature: sql.NullString{String: signature, Valid: true},
+		}); err != nil {
+			return fmt.Errorf("failed to reserve sponsor budget: %w", err)
+		}
+		return nil
+	})
+}
+
+// sponsorBudgetDay returns the start of the current budget day in UTC.
+func sponsorBudgetDay() time.Time {
+	return time.Now().UTC().Truncate(24 * time.Hour)
 }
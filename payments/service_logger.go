@@ -2,6 +2,7 @@ package payments
 
 import (
 	"context"
+	"time"
 
 	"github.com/easypmnt/checkout-api/internal/utils"
 	"github.com/google/uuid"
@@ -67,10 +68,10 @@ func (s *ServiceLogger) GetPaymentByExternalID(ctx context.Context, externalID s
 }
 
 // GeneratePaymentLink generates a new payment link for the given payment.
-func (s *ServiceLogger) GeneratePaymentLink(ctx context.Context, paymentID uuid.UUID, mint string, applyBonus bool) (string, error) {
-	s.log.Debugf("generating payment link: id=%s, mint=%s, apply_bonus=%t", paymentID.String(), mint, applyBonus)
+func (s *ServiceLogger) GeneratePaymentLink(ctx context.Context, paymentID uuid.UUID, mint string, applyBonus bool, coupon, ref string) (string, error) {
+	s.log.Debugf("generating payment link: id=%s, mint=%s, apply_bonus=%t, coupon=%s, ref=%s", paymentID.String(), mint, applyBonus, coupon, ref)
 
-	result, err := s.PaymentService.GeneratePaymentLink(ctx, paymentID, mint, applyBonus)
+	result, err := s.PaymentService.GeneratePaymentLink(ctx, paymentID, mint, applyBonus, coupon, ref)
 	if err != nil {
 		s.log.Errorf("failed to generate payment link: %s", err.Error())
 		return "", err
@@ -138,6 +139,34 @@ func (s *ServiceLogger) BuildTransaction(ctx context.Context, tx *Transaction) (
 	return result, nil
 }
 
+// PreviewTransaction calculates the payment breakdown without building and storing a transaction.
+func (s *ServiceLogger) PreviewTransaction(ctx context.Context, tx *Transaction) (*TransactionPreview, error) {
+	s.log.Debugf("previewing transaction: %s", utils.AnyToString(tx))
+
+	result, err := s.PaymentService.PreviewTransaction(ctx, tx)
+	if err != nil {
+		s.log.Errorf("failed to preview transaction: %s", err.Error())
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// SubmitTransaction verifies the transaction signed by the customer and sends it to the network.
+func (s *ServiceLogger) SubmitTransaction(ctx context.Context, reference, signedTx string) (*Transaction, error) {
+	s.log.Debugf("submitting transaction: %s", reference)
+
+	result, err := s.PaymentService.SubmitTransaction(ctx, reference, signedTx)
+	if err != nil {
+		s.log.Errorf("failed to submit transaction %s: %s", reference, err.Error())
+		return nil, err
+	}
+
+	s.log.Infof("transaction submitted: reference=%s, signature=%s", reference, result.Signature)
+
+	return result, nil
+}
+
 // GetTransactionByReference returns the transaction with the given reference.
 func (s *ServiceLogger) GetTransactionByReference(ctx context.Context, reference string) (*Transaction, error) {
 	s.log.Debugf("getting transaction by reference: %s", reference)
@@ -151,6 +180,32 @@ func (s *ServiceLogger) GetTransactionByReference(ctx context.Context, reference
 	return result, nil
 }
 
+// GetPaymentTransactions returns all the transactions of the payment, newest first.
+func (s *ServiceLogger) GetPaymentTransactions(ctx context.Context, paymentID uuid.UUID) ([]*Transaction, error) {
+	s.log.Debugf("getting transactions of payment: %s", paymentID)
+
+	result, err := s.PaymentService.GetPaymentTransactions(ctx, paymentID)
+	if err != nil {
+		s.log.Errorf("failed to get transactions of payment %s: %s", paymentID, err.Error())
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// GetPaymentEvents returns the events recorded for the payment, oldest first.
+func (s *ServiceLogger) GetPaymentEvents(ctx context.Context, paymentID uuid.UUID) ([]*PaymentEvent, error) {
+	s.log.Debugf("getting events of payment: %s", paymentID)
+
+	result, err := s.PaymentService.GetPaymentEvents(ctx, paymentID)
+	if err != nil {
+		s.log.Errorf("failed to get events of payment %s: %s", paymentID, err.Error())
+		return nil, err
+	}
+
+	return result, nil
+}
+
 // MarkPaymentsAsExpired marks all payments that are expired as expired.
 func (s *ServiceLogger) MarkPaymentsAsExpired(ctx context.Context) error {
 	s.log.Debugf("marking payments as expired")
@@ -205,3 +260,329 @@ func (s *ServiceLogger) MarkTransactionsAsExpired(ctx context.Context) error {
 
 	return nil
 }
+
+// CreateBonusRule creates a new bonus rule.
+func (s *ServiceLogger) CreateBonusRule(ctx context.Context, rule *BonusRule) (*BonusRule, error) {
+	s.log.Debugf("creating bonus rule: %s", utils.AnyToString(rule))
+
+	result, err := s.PaymentService.CreateBonusRule(ctx, rule)
+	if err != nil {
+		s.log.Errorf("failed to create bonus rule: %s", err.Error())
+		return nil, err
+	}
+
+	s.log.Infof("bonus rule created: %s", result.ID.String())
+
+	return result, nil
+}
+
+// GetBonusRules returns all the bonus rules ordered by priority.
+func (s *ServiceLogger) GetBonusRules(ctx context.Context) ([]BonusRule, error) {
+	s.log.Debugf("getting bonus rules")
+
+	result, err := s.PaymentService.GetBonusRules(ctx)
+	if err != nil {
+		s.log.Errorf("failed to get bonus rules: %s", err.Error())
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// UpdateBonusRuleStatus enables or disables the bonus rule with the given ID.
+func (s *ServiceLogger) UpdateBonusRuleStatus(ctx context.Context, id uuid.UUID, active bool) (*BonusRule, error) {
+	s.log.Debugf("updating bonus rule status: id=%s, active=%t", id.String(), active)
+
+	result, err := s.PaymentService.UpdateBonusRuleStatus(ctx, id, active)
+	if err != nil {
+		s.log.Errorf("failed to update bonus rule status: %s", err.Error())
+		return nil, err
+	}
+
+	s.log.Infof("bonus rule status updated: id=%s, active=%t", id.String(), active)
+
+	return result, nil
+}
+
+// DeleteBonusRule deletes the bonus rule with the given ID.
+func (s *ServiceLogger) DeleteBonusRule(ctx context.Context, id uuid.UUID) error {
+	s.log.Debugf("deleting bonus rule: id=%s", id.String())
+
+	if err := s.PaymentService.DeleteBonusRule(ctx, id); err != nil {
+		s.log.Errorf("failed to delete bonus rule with id=%s: %s", id.String(), err.Error())
+		return err
+	}
+
+	s.log.Infof("bonus rule deleted: id=%s", id.String())
+
+	return nil
+}
+
+// CreateCoupon creates a new coupon.
+func (s *ServiceLogger) CreateCoupon(ctx context.Context, coupon *Coupon) (*Coupon, error) {
+	s.log.Debugf("creating coupon: %s", utils.AnyToString(coupon))
+
+	result, err := s.PaymentService.CreateCoupon(ctx, coupon)
+	if err != nil {
+		s.log.Errorf("failed to create coupon: %s", err.Error())
+		return nil, err
+	}
+
+	s.log.Infof("coupon created: id=%s, code=%s", result.ID.String(), result.Code)
+
+	return result, nil
+}
+
+// GetCoupons returns all the coupons, newest first.
+func (s *ServiceLogger) GetCoupons(ctx context.Context) ([]*Coupon, error) {
+	s.log.Debugf("getting coupons")
+
+	result, err := s.PaymentService.GetCoupons(ctx)
+	if err != nil {
+		s.log.Errorf("failed to get coupons: %s", err.Error())
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// UpdateCouponStatus enables or disables the coupon with the given ID.
+func (s *ServiceLogger) UpdateCouponStatus(ctx context.Context, id uuid.UUID, active bool) (*Coupon, error) {
+	s.log.Debugf("updating coupon status: id=%s, active=%t", id.String(), active)
+
+	result, err := s.PaymentService.UpdateCouponStatus(ctx, id, active)
+	if err != nil {
+		s.log.Errorf("failed to update coupon status: %s", err.Error())
+		return nil, err
+	}
+
+	s.log.Infof("coupon status updated: id=%s, active=%t", id.String(), active)
+
+	return result, nil
+}
+
+// DeleteCoupon deletes the coupon with the given ID.
+func (s *ServiceLogger) DeleteCoupon(ctx context.Context, id uuid.UUID) error {
+	s.log.Debugf("deleting coupon: id=%s", id.String())
+
+	if err := s.PaymentService.DeleteCoupon(ctx, id); err != nil {
+		s.log.Errorf("failed to delete coupon with id=%s: %s", id.String(), err.Error())
+		return err
+	}
+
+	s.log.Infof("coupon deleted: id=%s", id.String())
+
+	return nil
+}
+
+// CreateGatingRule creates a new discount rule for holders of the NFT collection or the token.
+func (s *ServiceLogger) CreateGatingRule(ctx context.Context, rule *GatingRule) (*GatingRule, error) {
+	s.log.Debugf("creating gating rule: %s", utils.AnyToString(rule))
+
+	result, err := s.PaymentService.CreateGatingRule(ctx, rule)
+	if err != nil {
+		s.log.Errorf("failed to create gating rule: %s", err.Error())
+		return nil, err
+	}
+
+	s.log.Infof("gating rule created: %s", result.ID.String())
+
+	return result, nil
+}
+
+// GetGatingRules returns all the gating rules.
+func (s *ServiceLogger) GetGatingRules(ctx context.Context) ([]GatingRule, error) {
+	s.log.Debugf("getting gating rules")
+
+	result, err := s.PaymentService.GetGatingRules(ctx)
+	if err != nil {
+		s.log.Errorf("failed to get gating rules: %s", err.Error())
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// UpdateGatingRuleStatus enables or disables the gating rule with the given ID.
+func (s *ServiceLogger) UpdateGatingRuleStatus(ctx context.Context, id uuid.UUID, active bool) (*GatingRule, error) {
+	s.log.Debugf("updating gating rule status: id=%s, active=%t", id.String(), active)
+
+	result, err := s.PaymentService.UpdateGatingRuleStatus(ctx, id, active)
+	if err != nil {
+		s.log.Errorf("failed to update gating rule status: %s", err.Error())
+		return nil, err
+	}
+
+	s.log.Infof("gating rule status updated: id=%s, active=%t", id.String(), active)
+
+	return result, nil
+}
+
+// DeleteGatingRule deletes the gating rule with the given ID.
+func (s *ServiceLogger) DeleteGatingRule(ctx context.Context, id uuid.UUID) error {
+	s.log.Debugf("deleting gating rule: id=%s", id.String())
+
+	if err := s.PaymentService.DeleteGatingRule(ctx, id); err != nil {
+		s.log.Errorf("failed to delete gating rule with id=%s: %s", id.String(), err.Error())
+		return err
+	}
+
+	s.log.Infof("gating rule deleted: id=%s", id.String())
+
+	return nil
+}
+
+// IssueGiftCard issues a new gift card with the initial balance.
+func (s *ServiceLogger) IssueGiftCard(ctx context.Context, card *GiftCard) (*GiftCard, error) {
+	s.log.Debugf("issuing gift card: mint=%s, amount=%d", card.Mint, card.InitialAmount)
+
+	result, err := s.PaymentService.IssueGiftCard(ctx, card)
+	if err != nil {
+		s.log.Errorf("failed to issue gift card: %s", err.Error())
+		return nil, err
+	}
+
+	s.log.Infof("gift card issued: id=%s, mint=%s, amount=%d", result.ID.String(), result.Mint, result.InitialAmount)
+
+	return result, nil
+}
+
+// GetGiftCards returns all the gift cards, newest first.
+func (s *ServiceLogger) GetGiftCards(ctx context.Context) ([]*GiftCard, error) {
+	s.log.Debugf("getting gift cards")
+
+	result, err := s.PaymentService.GetGiftCards(ctx)
+	if err != nil {
+		s.log.Errorf("failed to get gift cards: %s", err.Error())
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// GetGiftCard returns the gift card with the given ID and its balance history.
+func (s *ServiceLogger) GetGiftCard(ctx context.Context, id uuid.UUID) (*GiftCard, error) {
+	s.log.Debugf("getting gift card: id=%s", id.String())
+
+	result, err := s.PaymentService.GetGiftCard(ctx, id)
+	if err != nil {
+		s.log.Errorf("failed to get gift card with id=%s: %s", id.String(), err.Error())
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// UpdateGiftCardStatus enables or disables the gift card with the given ID.
+func (s *ServiceLogger) UpdateGiftCardStatus(ctx context.Context, id uuid.UUID, active bool) (*GiftCard, error) {
+	s.log.Debugf("updating gift card status: id=%s, active=%t", id.String(), active)
+
+	result, err := s.PaymentService.UpdateGiftCardStatus(ctx, id, active)
+	if err != nil {
+		s.log.Errorf("failed to update gift card status: %s", err.Error())
+		return nil, err
+	}
+
+	s.log.Infof("gift card status updated: id=%s, active=%t", id.String(), active)
+
+	return result, nil
+}
+
+// PayWithGiftCard pays the whole payment amount with the gift card and completes the payment.
+func (s *ServiceLogger) PayWithGiftCard(ctx context.Context, paymentID uuid.UUID, code string) (*Transaction, error) {
+	s.log.Debugf("paying with gift card: payment_id=%s", paymentID.String())
+
+	result, err := s.PaymentService.PayWithGiftCard(ctx, paymentID, code)
+	if err != nil {
+		s.log.Errorf("failed to pay with gift card for payment_id=%s: %s", paymentID.String(), err.Error())
+		return nil, err
+	}
+
+	s.log.Infof("payment paid with gift card: payment_id=%s, amount=%d", paymentID.String(), result.GiftCardAmount)
+
+	return result, nil
+}
+
+// CreateAffiliate creates a new affiliate.
+func (s *ServiceLogger) CreateAffiliate(ctx context.Context, affiliate *Affiliate) (*Affiliate, error) {
+	s.log.Debugf("creating affiliate: name=%s, wallet=%s", affiliate.Name, affiliate.Wallet)
+
+	result, err := s.PaymentService.CreateAffiliate(ctx, affiliate)
+	if err != nil {
+		s.log.Errorf("failed to create affiliate: %s", err.Error())
+		return nil, err
+	}
+
+	s.log.Infof("affiliate created: id=%s, ref_code=%s", result.ID.String(), result.RefCode)
+
+	return result, nil
+}
+
+// GetAffiliates returns all the affiliates, newest first.
+func (s *ServiceLogger) GetAffiliates(ctx context.Context) ([]*Affiliate, error) {
+	s.log.Debugf("getting affiliates")
+
+	result, err := s.PaymentService.GetAffiliates(ctx)
+	if err != nil {
+		s.log.Errorf("failed to get affiliates: %s", err.Error())
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// GetAffiliate returns the affiliate with the given ID.
+func (s *ServiceLogger) GetAffiliate(ctx context.Context, id uuid.UUID) (*Affiliate, error) {
+	s.log.Debugf("getting affiliate: id=%s", id.String())
+
+	result, err := s.PaymentService.GetAffiliate(ctx, id)
+	if err != nil {
+		s.log.Errorf("failed to get affiliate: %s", err.Error())
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// UpdateAffiliateStatus enables or disables the affiliate with the given ID.
+func (s *ServiceLogger) UpdateAffiliateStatus(ctx context.Context, id uuid.UUID, active bool) (*Affiliate, error) {
+	s.log.Debugf("updating affiliate status: id=%s, active=%t", id.String(), active)
+
+	result, err := s.PaymentService.UpdateAffiliateStatus(ctx, id, active)
+	if err != nil {
+		s.log.Errorf("failed to update affiliate status: %s", err.Error())
+		return nil, err
+	}
+
+	s.log.Infof("affiliate status updated: id=%s, active=%t", id.String(), active)
+
+	return result, nil
+}
+
+// GetAffiliateReport returns the affiliate sales and commissions created in the given period.
+func (s *ServiceLogger) GetAffiliateReport(ctx context.Context, id uuid.UUID, since, until time.Time) (*AffiliateReport, error) {
+	s.log.Debugf("getting affiliate report: id=%s, since=%s, until=%s", id.String(), since.Format(time.RFC3339), until.Format(time.RFC3339))
+
+	result, err := s.PaymentService.GetAffiliateReport(ctx, id, since, until)
+	if err != nil {
+		s.log.Errorf("failed to get affiliate report: %s", err.Error())
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// PayoutAffiliateCommissions pays out all the accrued commissions of the affiliate.
+func (s *ServiceLogger) PayoutAffiliateCommissions(ctx context.Context, id uuid.UUID) (*AffiliatePayout, error) {
+	s.log.Debugf("paying out affiliate commissions: id=%s", id.String())
+
+	result, err := s.PaymentService.PayoutAffiliateCommissions(ctx, id)
+	if err != nil {
+		s.log.Errorf("failed to pay out affiliate commissions: %s", err.Error())
+		return nil, err
+	}
+
+	s.log.Infof("affiliate commissions paid out: id=%s, commissions=%d, signature=%s", id.String(), len(result.Commissions), result.Signature)
+
+	return result, nil
+}
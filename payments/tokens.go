@@ -1,6 +1,11 @@
 package payments
 
-import "strings"
+import (
+	"fmt"
+	"strings"
+
+	"github.com/easypmnt/checkout-api/internal/utils"
+)
 
 const (
 	SOL  = "So11111111111111111111111111111111111111112"
@@ -15,20 +20,38 @@ var defaultMints = map[string]string{
 	"SOL":  SOL,
 }
 
+// Default tokens, available even if the token registry is not loaded yet.
+var defaultTokens = []Token{
+	{Mint: SOL, Symbol: "SOL", Name: "Wrapped SOL", Decimals: 9},
+	{Mint: USDC, Symbol: "USDC", Name: "USD Coin", Decimals: 6},
+	{Mint: USDT, Symbol: "USDT", Name: "USDT", Decimals: 6},
+}
+
 // MintAddress returns the mint address by symbol.
-// If the symbol is not found, it returns the fallback address.
-// Supports only default mints.
-func MintAddress(currency string, fallback string) string {
+// If the currency is empty, the fallback is used instead.
+// Supports only default mints and base58 encoded mint addresses,
+// use TokenRegistry.MintAddress to resolve any other symbol.
+// Returns ErrUnknownToken if the symbol is not found.
+func MintAddress(currency string, fallback string) (string, error) {
 	if currency == "" {
 		currency = fallback
 	}
 	if address, ok := defaultMints[strings.ToUpper(currency)]; ok {
-		return address
+		return address, nil
 	}
-	if len(currency) < 40 {
-		return SOL
+	if IsMintAddress(currency) {
+		return currency, nil
+	}
+	return "", fmt.Errorf("%w: %s", ErrUnknownToken, currency)
+}
+
+// IsMintAddress checks if the given string is a valid base58 encoded public key.
+func IsMintAddress(s string) bool {
+	if len(s) < 32 || len(s) > 44 {
+		return false
 	}
-	return currency
+	b, err := utils.Base58ToBytes(s)
+	return err == nil && len(b) == 32
 }
 
 // IsSOL checks if the currency is SOL.
@@ -2,9 +2,11 @@ package payments
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/easypmnt/checkout-api/jupiter"
+	"github.com/easypmnt/checkout-api/loyalty"
 	"github.com/easypmnt/checkout-api/repository"
 	"github.com/easypmnt/checkout-api/solana"
 	"github.com/google/uuid"
@@ -18,11 +20,21 @@ type (
 		MaxApplyBonusAmount  uint64
 		MaxApplyBonusPercent uint16 // 10000 = 100%, 100 = 1%, 1 = 0.01%
 		AccrueBonus          bool
-		AccrueBonusRate      uint64
+		AccrueBonusRate      uint64            // 10000 = 100%, 100 = 1%, applied to the value of the payment in bonus tokens
+		AccrueBonusRates     map[string]uint64 // accrual rates by destination mint (symbol or address), override AccrueBonusRate; 0 = no accrual
+		BonusValueBasis      BonusValueBasis   // value the accrual rate is applied to, default: BonusValueBasisToken
 		DestinationMint      string
 		DestinationWallet    string
 		PaymentTTL           time.Duration
 		SolPayBaseURL        string
+		SimulateTransaction  bool // simulate built transactions to return pre-flight errors before the wallet does
+
+		// Gasless checkout: the merchant's account pays network fees and rent instead of the customer.
+		SponsorAccount       string // base58 encoded private key of the fee payer account, sponsorship is disabled if empty
+		SponsorMaxPerPayment uint64 // max sponsored amount per payment transaction in lamports, 0 = unlimited
+		SponsorDailyBudget   uint64 // max sponsored amount per day (UTC) in lamports, 0 = unlimited
+
+		AffiliatePayoutAccount string // base58 encoded private key of the wallet which pays accrued affiliate commissions, payouts are disabled if empty
 	}
 
 	// solanaClient is an RPC client for Solana.
@@ -31,18 +43,51 @@ type (
 		DoesTokenAccountExist(ctx context.Context, base58AtaAddr string) (bool, error)
 		GetMinimumBalanceForRentExemption(ctx context.Context, size uint64) (uint64, error)
 		GetTokenBalance(ctx context.Context, base58Addr, base58MintAddr string) (solana.Balance, error)
+		GetTokenSupply(ctx context.Context, base58MintAddr string) (solana.Balance, error)
+		SimulateTransaction(ctx context.Context, txSource string) error
+		SendTransaction(ctx context.Context, txSource string) (string, error)
+		GetTransactionStatus(ctx context.Context, txhash string) (solana.TransactionStatus, error)
+		GetWalletTokens(ctx context.Context, base58Addr string) (map[string]uint64, error)
+		GetAssetsOrigin(ctx context.Context, base58MintAddrs []string) ([]solana.AssetOrigin, error)
 	}
 
 	// jupiterClient is an REST API client for Jupiter.
 	jupiterClient interface {
 		BestSwap(params jupiter.BestSwapParams) (string, error)
+		ExchangeRate(params jupiter.ExchangeRateParams) (jupiter.Rate, error)
+	}
+
+	// loyaltyLedger records bonus tokens accrued and redeemed by the customer wallet.
+	// The ledger is bound to the repository of the payment service to record the entries
+	// in the database transaction of the completed payment transaction.
+	loyaltyLedger interface {
+		WithRepository(repo loyalty.LedgerRepository) *loyalty.Service
+	}
+
+	// tierResolver resolves the loyalty tier of the customer wallet.
+	tierResolver interface {
+		Tier(ctx context.Context, wallet string) (*loyalty.Tier, error)
+	}
+
+	// txBeginner starts database transactions.
+	txBeginner interface {
+		BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+	}
+
+	// tokenRegistry resolves token symbols to mint addresses.
+	tokenRegistry interface {
+		MintAddress(currency string, fallback string) (string, error)
+		Token(symbolOrMint string) (Token, bool)
 	}
 
 	paymentRepository interface {
+		loyalty.LedgerRepository
+
 		CreatePayment(ctx context.Context, arg repository.CreatePaymentParams) (repository.Payment, error)
 		GetPayment(ctx context.Context, id uuid.UUID) (repository.Payment, error)
 		GetPaymentByExternalID(ctx context.Context, externalID string) (repository.Payment, error)
-		MarkPaymentsExpired(ctx context.Context) error
+		GetPaymentForUpdate(ctx context.Context, id uuid.UUID) (repository.Payment, error)
+		MarkPaymentsExpired(ctx context.Context, batchSize int32) ([]repository.Payment, error)
 		UpdatePaymentStatus(ctx context.Context, arg repository.UpdatePaymentStatusParams) (repository.Payment, error)
 
 		CreateTransaction(ctx context.Context, arg repository.CreateTransactionParams) (repository.Transaction, error)
@@ -51,6 +96,57 @@ type (
 		GetTransactionsByPaymentID(ctx context.Context, paymentID uuid.UUID) ([]repository.Transaction, error)
 		UpdateTransactionByReference(ctx context.Context, arg repository.UpdateTransactionByReferenceParams) (repository.Transaction, error)
 		GetPendingTransactions(ctx context.Context) ([]repository.Transaction, error)
-		MarkTransactionsAsExpired(ctx context.Context) error
+		MarkTransactionsAsExpired(ctx context.Context, batchSize int32) ([]repository.Transaction, error)
+		GetSponsoredAmountSince(ctx context.Context, since time.Time) (int64, error)
+		LockSponsorBudget(ctx context.Context, lockKey int64) error
+		GetWalletSpendStats(ctx context.Context, sourceWallet string) ([]repository.GetWalletSpendStatsRow, error)
+
+		CreateBonusRule(ctx context.Context, arg repository.CreateBonusRuleParams) (repository.BonusRule, error)
+		GetBonusRules(ctx context.Context) ([]repository.BonusRule, error)
+		GetActiveBonusRules(ctx context.Context) ([]repository.BonusRule, error)
+		UpdateBonusRuleStatus(ctx context.Context, arg repository.UpdateBonusRuleStatusParams) (repository.BonusRule, error)
+		DeleteBonusRule(ctx context.Context, id uuid.UUID) error
+
+		CreateCoupon(ctx context.Context, arg repository.CreateCouponParams) (repository.Coupon, error)
+		GetCouponByCode(ctx context.Context, code string) (repository.Coupon, error)
+		GetCouponForUpdate(ctx context.Context, id uuid.UUID) (repository.Coupon, error)
+		GetCoupons(ctx context.Context) ([]repository.Coupon, error)
+		UpdateCouponStatus(ctx context.Context, arg repository.UpdateCouponStatusParams) (repository.Coupon, error)
+		DeleteCoupon(ctx context.Context, id uuid.UUID) error
+		GetCouponUsage(ctx context.Context, arg repository.GetCouponUsageParams) (repository.GetCouponUsageRow, error)
+
+		CreateGatingRule(ctx context.Context, arg repository.CreateGatingRuleParams) (repository.GatingRule, error)
+		GetGatingRules(ctx context.Context) ([]repository.GatingRule, error)
+		GetActiveGatingRules(ctx context.Context) ([]repository.GatingRule, error)
+		UpdateGatingRuleStatus(ctx context.Context, arg repository.UpdateGatingRuleStatusParams) (repository.GatingRule, error)
+		DeleteGatingRule(ctx context.Context, id uuid.UUID) error
+
+		CreateGiftCard(ctx context.Context, arg repository.CreateGiftCardParams) (repository.GiftCard, error)
+		GetGiftCard(ctx context.Context, id uuid.UUID) (repository.GiftCard, error)
+		GetGiftCardByCode(ctx context.Context, code string) (repository.GiftCard, error)
+		GetGiftCardForUpdate(ctx context.Context, id uuid.UUID) (repository.GiftCard, error)
+		GetGiftCards(ctx context.Context) ([]repository.GiftCard, error)
+		UpdateGiftCardStatus(ctx context.Context, arg repository.UpdateGiftCardStatusParams) (repository.GiftCard, error)
+		GetGiftCardLedgerEntries(ctx context.Context, giftCardID uuid.UUID) ([]repository.GiftCardLedger, error)
+		GetGiftCardHeldAmount(ctx context.Context, arg repository.GetGiftCardHeldAmountParams) (int64, error)
+		CreateGiftCardLedgerEntry(ctx context.Context, arg repository.CreateGiftCardLedgerEntryParams) (repository.GiftCardLedger, error)
+		UpdateGiftCardBalance(ctx context.Context, arg repository.UpdateGiftCardBalanceParams) (repository.GiftCard, error)
+
+		CreateAffiliate(ctx context.Context, arg repository.CreateAffiliateParams) (repository.Affiliate, error)
+		GetAffiliate(ctx context.Context, id uuid.UUID) (repository.Affiliate, error)
+		GetAffiliateByRefCode(ctx context.Context, refCode string) (repository.Affiliate, error)
+		GetAffiliates(ctx context.Context) ([]repository.Affiliate, error)
+		UpdateAffiliateStatus(ctx context.Context, arg repository.UpdateAffiliateStatusParams) (repository.Affiliate, error)
+		CreateAffiliateCommission(ctx context.Context, arg repository.CreateAffiliateCommissionParams) (repository.AffiliateCommission, error)
+		GetAffiliateCommissions(ctx context.Context, arg repository.GetAffiliateCommissionsParams) ([]repository.AffiliateCommission, error)
+		GetAffiliateCommissionsByStatus(ctx context.Context, arg repository.GetAffiliateCommissionsByStatusParams) ([]repository.AffiliateCommission, error)
+		CompleteAffiliatePayout(ctx context.Context, payoutSignature sql.NullString) error
+		RevertAffiliatePayout(ctx context.Context, payoutSignature sql.NullString) error
+		GetAffiliateReport(ctx context.Context, arg repository.GetAffiliateReportParams) ([]repository.GetAffiliateReportRow, error)
+
+		CreateOutboxEvent(ctx context.Context, arg repository.CreateOutboxEventParams) error
+		GetPaymentEvents(ctx context.Context, paymentID uuid.NullUUID) ([]repository.EventsOutbox, error)
+
+		WithTx(tx *sql.Tx) *repository.Queries
 	}
 )
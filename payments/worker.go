@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/easypmnt/checkout-api/solana"
 	"github.com/hibiken/asynq"
 )
 
@@ -15,6 +16,7 @@ const (
 	TaskCheckPaymentByReference   = "check_payment_by_reference"
 	TaskMarkTransactionsAsExpired = "mark_transactions_as_expired"
 	TaskCheckPendingTransactions  = "check_pending_transactions"
+	TaskRebroadcastTransaction    = "rebroadcast_transaction"
 )
 
 // Reference payload to check payment by reference task.
@@ -22,6 +24,13 @@ type ReferencePayload struct {
 	Reference string `json:"reference"`
 }
 
+// RebroadcastPayload is a payload to rebroadcast a submitted transaction task.
+type RebroadcastPayload struct {
+	Reference   string `json:"reference"`
+	Signature   string `json:"signature"`
+	Transaction string `json:"transaction"`
+}
+
 type (
 	// Worker is a task handler for email delivery.
 	Worker struct {
@@ -40,6 +49,9 @@ type (
 
 	workerSolanaClient interface {
 		ValidateTransactionByReference(ctx context.Context, reference, destination string, amount uint64, mint string) (string, error)
+		SendTransaction(ctx context.Context, txSource string) (string, error)
+		GetTransactionStatus(ctx context.Context, txhash string) (solana.TransactionStatus, error)
+		IsBlockhashValid(ctx context.Context, blockhash string) (bool, error)
 	}
 
 	paymentEnqueuer interface {
@@ -58,6 +70,7 @@ func (w *Worker) Register(mux *asynq.ServeMux) {
 	mux.HandleFunc(TaskCheckPaymentByReference, w.CheckPaymentByReference)
 	mux.HandleFunc(TaskMarkTransactionsAsExpired, w.MarkTransactionsAsExpired)
 	mux.HandleFunc(TaskCheckPendingTransactions, w.CheckPendingTransactions)
+	mux.HandleFunc(TaskRebroadcastTransaction, w.RebroadcastTransaction)
 }
 
 // FireEvent sends a webhook event to the specified URL.
@@ -101,7 +114,7 @@ func (w *Worker) CheckPaymentByReference(ctx context.Context, t *asynq.Task) err
 				ctx,
 				p.Reference,
 				tx.DestinationWallet,
-				tx.TotalAmount,
+				tx.MerchantAmount(),
 				tx.DestinationMint,
 			)
 			if err != nil {
@@ -143,3 +156,72 @@ func (w *Worker) CheckPendingTransactions(ctx context.Context, t *asynq.Task) er
 
 	return nil
 }
+
+// RebroadcastTransaction resends the submitted transaction until it is confirmed or its blockhash expires.
+// Transaction status is updated once the transaction is finalized, failed or can't be processed anymore.
+func (w *Worker) RebroadcastTransaction(ctx context.Context, t *asynq.Task) error {
+	var p RebroadcastPayload
+	if err := json.Unmarshal(t.Payload(), &p); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w", err)
+	}
+
+	tx, err := solana.DecodeTransaction(p.Transaction)
+	if err != nil {
+		return fmt.Errorf("failed to decode transaction: %w", err)
+	}
+
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			status, err := w.sol.GetTransactionStatus(ctx, p.Signature)
+			switch {
+			case status == solana.TransactionStatusFailure:
+				return w.updatePendingTransaction(ctx, p.Reference, TransactionStatusFailed, p.Signature)
+			case err != nil:
+				continue // the status is unknown, check it again with the next tick
+			case status == solana.TransactionStatusSuccess:
+				return w.updatePendingTransaction(ctx, p.Reference, TransactionStatusCompleted, p.Signature)
+			case status == solana.TransactionStatusInProgress:
+				continue // landed, waiting for finalization
+			}
+
+			valid, err := w.sol.IsBlockhashValid(ctx, tx.Message.RecentBlockHash)
+			if err != nil {
+				continue
+			}
+			if !valid {
+				// The transaction could land right before the blockhash expired.
+				if status, err := w.sol.GetTransactionStatus(ctx, p.Signature); err == nil && status == solana.TransactionStatusUnknown {
+					return w.updatePendingTransaction(ctx, p.Reference, TransactionStatusFailed, p.Signature)
+				}
+				continue // landed or failed, it's settled with the next tick
+			}
+
+			// Errors are expected here, e.g. if the transaction has been already processed.
+			_, _ = w.sol.SendTransaction(ctx, p.Transaction)
+		}
+	}
+}
+
+// updatePendingTransaction updates the transaction status only if it is still pending,
+// since the transaction could be already processed by the reference check task.
+func (w *Worker) updatePendingTransaction(ctx context.Context, reference string, status TransactionStatus, signature string) error {
+	tx, err := w.svc.GetTransactionByReference(ctx, reference)
+	if err != nil {
+		return fmt.Errorf("failed to get transaction by reference: %w", err)
+	}
+	if tx.Status != TransactionStatusPending {
+		return nil
+	}
+
+	if err := w.svc.UpdateTransaction(ctx, reference, status, signature); err != nil {
+		return fmt.Errorf("failed to update transaction status: %w", err)
+	}
+
+	return nil
+}
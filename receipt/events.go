@@ -0,0 +1,28 @@
+package receipt
+
+import (
+	"context"
+
+	"github.com/easypmnt/checkout-api/events"
+)
+
+type receiptEnqueuer interface {
+	MintReceipt(ctx context.Context, paymentID string) error
+}
+
+// PaymentSucceededListener is a listener for the payment.succeeded event,
+// it enqueues the task to mint the receipt NFT to the payer.
+func PaymentSucceededListener(enq receiptEnqueuer) events.Listener {
+	return func(ctx context.Context, event events.EventName, payload interface{}) error {
+		if payload == nil || event != events.PaymentSucceeded {
+			return nil
+		}
+
+		p, ok := payload.(events.PaymentStatusUpdatedPayload)
+		if !ok {
+			return nil
+		}
+
+		return enq.MintReceipt(ctx, p.GetPaymentID())
+	}
+}
@@ -0,0 +1,326 @@
+package receipt
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/easypmnt/checkout-api/internal/utils"
+	"github.com/easypmnt/checkout-api/repository"
+	"github.com/easypmnt/checkout-api/solana"
+	"github.com/easypmnt/checkout-api/solana/metadata"
+	"github.com/google/uuid"
+	"github.com/portto/solana-go-sdk/types"
+)
+
+// Predefined errors.
+var (
+	ErrPaymentNotFound     = errors.New("payment not found")
+	ErrPaymentNotCompleted = errors.New("payment is not completed")
+	ErrPayerUnknown        = errors.New("payer wallet is unknown")
+	ErrMintFailed          = errors.New("receipt mint transaction failed")
+	ErrMintPending         = errors.New("receipt mint transaction is not confirmed yet")
+)
+
+// confirmationTimeout is the max duration to wait for the mint transaction confirmation.
+const confirmationTimeout = time.Minute
+
+type (
+	// Service mints receipt NFTs to the payers of completed payments.
+	Service struct {
+		repo      receiptRepository
+		sol       solanaClient
+		storage   storage
+		conf      Config
+		authority types.Account
+	}
+
+	// Config is the receipt NFT configuration.
+	Config struct {
+		Authority   string // required; base58 encoded private key of the merchant account, it pays fees and is the update authority of receipts
+		Name        string // required; name of the receipt, the order ID is appended if it fits into 32 characters
+		Symbol      string // required; symbol of the receipt, up to 10 characters
+		Description string // optional; description of the receipt, the default one is used if empty
+		ImageURI    string // required; absolute URI of the receipt image
+		ExternalURL string // optional; URL of the merchant website
+	}
+
+	receiptRepository interface {
+		GetPayment(ctx context.Context, id uuid.UUID) (repository.Payment, error)
+		GetTransactionsByPaymentID(ctx context.Context, paymentID uuid.UUID) ([]repository.Transaction, error)
+		UpdatePaymentReceiptMint(ctx context.Context, arg repository.UpdatePaymentReceiptMintParams) (repository.Payment, error)
+		ConfirmPaymentReceiptMint(ctx context.Context, arg repository.ConfirmPaymentReceiptMintParams) error
+		ClearPaymentReceiptMint(ctx context.Context, arg repository.ClearPaymentReceiptMintParams) error
+	}
+
+	solanaClient interface {
+		solana.SolanaClient
+		SendTransaction(ctx context.Context, txSource string) (string, error)
+		GetTransactionStatus(ctx context.Context, txhash string) (solana.TransactionStatus, error)
+		IsBlockhashValid(ctx context.Context, blockhash string) (bool, error)
+		WaitForTransactionConfirmed(ctx context.Context, txhash string, maxDuration time.Duration) (solana.TransactionStatus, error)
+	}
+
+	// storage uploads files and returns their public URI, e.g. arweave.Client.
+	storage interface {
+		Upload(data []byte, contentType, ext string) (string, error)
+	}
+)
+
+// NewService creates a new receipt service.
+func NewService(repo receiptRepository, sol solanaClient, storage storage, conf Config) *Service {
+	authority, err := types.AccountFromBase58(conf.Authority)
+	if err != nil {
+		panic(fmt.Errorf("failed to parse receipt authority account: %w", err))
+	}
+
+	return &Service{
+		repo:      repo,
+		sol:       sol,
+		storage:   storage,
+		conf:      conf,
+		authority: authority,
+	}
+}
+
+// MintReceipt mints the receipt NFT to the payer of the completed payment
+// and records its mint address on the payment.
+// If the receipt is already minted, the recorded mint address is returned.
+// The mint address is pending until its transaction is confirmed: a retry checks the transaction
+// and mints a new receipt only if the pending one has failed or can't be processed anymore.
+func (s *Service) MintReceipt(ctx context.Context, paymentID uuid.UUID) (string, error) {
+	payment, err := s.repo.GetPayment(ctx, paymentID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", ErrPaymentNotFound
+		}
+		return "", fmt.Errorf("failed to get payment: %w", err)
+	}
+	if payment.ReceiptMint.Valid {
+		if payment.ReceiptConfirmed {
+			return payment.ReceiptMint.String, nil
+		}
+		minted, err := s.checkPendingMint(ctx, payment)
+		if err != nil || minted {
+			return payment.ReceiptMint.String, err
+		}
+	}
+	if payment.Status != repository.PaymentStatusCompleted {
+		return "", ErrPaymentNotCompleted
+	}
+
+	tx, err := s.completedTransaction(ctx, paymentID)
+	if err != nil {
+		return "", err
+	}
+	if tx.SourceWallet == "" {
+		return "", ErrPayerUnknown // e.g. the payment is paid with a gift card
+	}
+
+	md, err := s.buildMetadata(payment, tx)
+	if err != nil {
+		return "", err
+	}
+
+	mdJSON, err := md.ToJSON()
+	if err != nil {
+		return "", fmt.Errorf("failed to encode receipt metadata: %w", err)
+	}
+
+	mdURI, err := s.storage.Upload(mdJSON, "application/json", "json")
+	if err != nil {
+		return "", fmt.Errorf("failed to upload receipt metadata: %w", err)
+	}
+
+	mint := types.NewAccount()
+	authority := s.authority.PublicKey.ToBase58()
+
+	txSource, err := solana.NewTransactionBuilder(s.sol).
+		SetFeePayer(authority).
+		AddSigner(mint).
+		AddSigner(s.authority).
+		AddInstruction(solana.CreateNonFungibleToken(solana.CreateNonFungibleTokenParam{
+			Mint:        mint.PublicKey.ToBase58(),
+			Authority:   authority,
+			FeePayer:    authority,
+			Owner:       tx.SourceWallet,
+			Name:        md.Name,
+			Symbol:      md.Symbol,
+			MetadataURI: mdURI,
+		})).
+		Build(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to build receipt mint transaction: %w", err)
+	}
+
+	signedTx, err := solana.DecodeTransaction(txSource)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode receipt mint transaction: %w", err)
+	}
+
+	// The pending mint is recorded before the transaction is sent, so a retried task
+	// does not mint a second receipt while the transaction can still be processed.
+	pending, err := s.repo.UpdatePaymentReceiptMint(ctx, repository.UpdatePaymentReceiptMintParams{
+		ID:                 paymentID,
+		ReceiptMint:        mint.PublicKey.ToBase58(),
+		ReceiptTxSignature: utils.BytesToBase58(signedTx.Signatures[0]),
+		ReceiptTxBlockhash: signedTx.Message.RecentBlockHash,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", fmt.Errorf("%w: minted by another task", ErrMintPending)
+		}
+		return "", fmt.Errorf("failed to record receipt mint: %w", err)
+	}
+
+	if _, err := s.sol.SendTransaction(ctx, txSource); err != nil {
+		return "", fmt.Errorf("failed to send receipt mint transaction: %w", err)
+	}
+
+	status, err := s.sol.WaitForTransactionConfirmed(ctx, pending.ReceiptTxSignature.String, confirmationTimeout)
+	if err != nil && status != solana.TransactionStatusFailure {
+		return "", fmt.Errorf("failed to confirm receipt mint transaction %s: %w", pending.ReceiptTxSignature.String, err)
+	}
+	if status != solana.TransactionStatusSuccess {
+		if err := s.clearPendingMint(ctx, pending); err != nil {
+			return "", err
+		}
+		return "", fmt.Errorf("%w: %s", ErrMintFailed, pending.ReceiptTxSignature.String)
+	}
+
+	if err := s.confirmPendingMint(ctx, pending); err != nil {
+		return "", err
+	}
+
+	return mint.PublicKey.ToBase58(), nil
+}
+
+// checkPendingMint checks the transaction of the pending receipt mint.
+// Returns true if the receipt is minted, false if the pending mint is cleared to mint a new receipt:
+// the transaction has failed or its blockhash has expired before it landed.
+// Returns ErrMintPending if the transaction can still be processed.
+func (s *Service) checkPendingMint(ctx context.Context, payment repository.Payment) (bool, error) {
+	txSig := payment.ReceiptTxSignature.String
+
+	status, err := s.sol.GetTransactionStatus(ctx, txSig)
+	if err != nil && status != solana.TransactionStatusFailure {
+		return false, fmt.Errorf("failed to get receipt mint transaction status %s: %w", txSig, err)
+	}
+	switch status {
+	case solana.TransactionStatusSuccess:
+		return true, s.confirmPendingMint(ctx, payment)
+	case solana.TransactionStatusFailure:
+		return false, s.clearPendingMint(ctx, payment)
+	case solana.TransactionStatusInProgress:
+		return false, fmt.Errorf("%w: %s", ErrMintPending, txSig)
+	}
+
+	valid, err := s.sol.IsBlockhashValid(ctx, payment.ReceiptTxBlockhash.String)
+	if err != nil {
+		return false, fmt.Errorf("failed to check receipt mint transaction blockhash: %w", err)
+	}
+	if valid {
+		return false, fmt.Errorf("%w: %s", ErrMintPending, txSig)
+	}
+
+	// The transaction could land right before its blockhash expired, so the status is checked once more.
+	status, err = s.sol.GetTransactionStatus(ctx, txSig)
+	if err != nil && status != solana.TransactionStatusFailure {
+		return false, fmt.Errorf("failed to get receipt mint transaction status %s: %w", txSig, err)
+	}
+	switch status {
+	case solana.TransactionStatusSuccess:
+		return true, s.confirmPendingMint(ctx, payment)
+	case solana.TransactionStatusInProgress:
+		return false, fmt.Errorf("%w: %s", ErrMintPending, txSig)
+	}
+
+	return false, s.clearPendingMint(ctx, payment)
+}
+
+// confirmPendingMint records that the receipt mint transaction is confirmed.
+func (s *Service) confirmPendingMint(ctx context.Context, payment repository.Payment) error {
+	if err := s.repo.ConfirmPaymentReceiptMint(ctx, repository.ConfirmPaymentReceiptMintParams{
+		ID:          payment.ID,
+		ReceiptMint: payment.ReceiptMint.String,
+	}); err != nil {
+		return fmt.Errorf("failed to confirm receipt mint: %w", err)
+	}
+	return nil
+}
+
+// clearPendingMint removes the pending receipt mint whose transaction will never be processed.
+func (s *Service) clearPendingMint(ctx context.Context, payment repository.Payment) error {
+	if err := s.repo.ClearPaymentReceiptMint(ctx, repository.ClearPaymentReceiptMintParams{
+		ID:          payment.ID,
+		ReceiptMint: payment.ReceiptMint.String,
+	}); err != nil {
+		return fmt.Errorf("failed to clear receipt mint: %w", err)
+	}
+	return nil
+}
+
+// completedTransaction returns the transaction which completed the payment.
+func (s *Service) completedTransaction(ctx context.Context, paymentID uuid.UUID) (repository.Transaction, error) {
+	txs, err := s.repo.GetTransactionsByPaymentID(ctx, paymentID)
+	if err != nil {
+		return repository.Transaction{}, fmt.Errorf("failed to get payment transactions: %w", err)
+	}
+
+	for _, tx := range txs {
+		if tx.Status == repository.TransactionStatusCompleted {
+			return tx, nil
+		}
+	}
+
+	return repository.Transaction{}, ErrPaymentNotCompleted
+}
+
+// buildMetadata builds the receipt NFT metadata with the order details in attributes.
+func (s *Service) buildMetadata(payment repository.Payment, tx repository.Transaction) (*metadata.Metadata, error) {
+	orderID := payment.ID.String()
+	if payment.ExternalID.Valid && payment.ExternalID.String != "" {
+		orderID = payment.ExternalID.String
+	}
+
+	name := s.conf.Name
+	if n := fmt.Sprintf("%s #%s", s.conf.Name, orderID); len(n) <= 32 {
+		name = n
+	}
+
+	description := s.conf.Description
+	if description == "" {
+		description = fmt.Sprintf("Proof of purchase for the order %s", orderID)
+	}
+
+	paidAt := tx.CreatedAt
+	if tx.UpdatedAt.Valid {
+		paidAt = tx.UpdatedAt.Time
+	}
+
+	md, err := metadata.NewNFTMetadataBuilder().
+		SetName(name).
+		SetSymbol(s.conf.Symbol).
+		SetDescription(description).
+		SetImage(s.conf.ImageURI).
+		SetExternalURL(s.conf.ExternalURL).
+		SetCategory(metadata.PropertyCategoryImage).
+		SetAttribute("order_id", orderID).
+		SetAttribute("payment_id", payment.ID.String()).
+		SetAttribute("amount", uint64(payment.Amount)).
+		SetAttribute("mint", payment.DestinationMint).
+		SetAttribute("signature", tx.TxSignature.String).
+		SetAttributeStruct(metadata.Attribute{
+			TraitType:   "date",
+			Value:       paidAt.Unix(),
+			DisplayType: metadata.AttributeDisplayDate,
+		}).
+		Build()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build receipt metadata: %w", err)
+	}
+
+	return md, nil
+}
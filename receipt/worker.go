@@ -0,0 +1,73 @@
+package receipt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+)
+
+// Task names.
+const (
+	TaskMintReceipt = "mint_receipt"
+)
+
+// MintReceiptPayload is a payload of the mint receipt task.
+type MintReceiptPayload struct {
+	PaymentID string `json:"payment_id"`
+}
+
+type (
+	// Worker is a task handler for receipt NFTs.
+	Worker struct {
+		svc service
+		log logger
+	}
+
+	service interface {
+		MintReceipt(ctx context.Context, paymentID uuid.UUID) (string, error)
+	}
+
+	logger interface {
+		Infof(format string, args ...interface{})
+		Errorf(format string, args ...interface{})
+	}
+)
+
+// NewWorker creates a new receipt task handler.
+func NewWorker(svc service, log logger) *Worker {
+	return &Worker{svc: svc, log: log}
+}
+
+// Register registers task handlers for receipt NFTs.
+func (w *Worker) Register(mux *asynq.ServeMux) {
+	mux.HandleFunc(TaskMintReceipt, w.MintReceipt)
+}
+
+// MintReceipt mints the receipt NFT to the payer of the completed payment.
+func (w *Worker) MintReceipt(ctx context.Context, t *asynq.Task) error {
+	var p MintReceiptPayload
+	if err := json.Unmarshal(t.Payload(), &p); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w", err)
+	}
+
+	paymentID, err := uuid.Parse(p.PaymentID)
+	if err != nil {
+		return fmt.Errorf("failed to parse payment id: %v: %w", err, asynq.SkipRetry)
+	}
+
+	mint, err := w.svc.MintReceipt(ctx, paymentID)
+	if err != nil {
+		w.log.Errorf("failed to mint receipt: payment_id=%s, error=%v", p.PaymentID, err)
+		if errors.Is(err, ErrPaymentNotFound) || errors.Is(err, ErrPaymentNotCompleted) || errors.Is(err, ErrPayerUnknown) {
+			return fmt.Errorf("failed to mint receipt: %v: %w", err, asynq.SkipRetry)
+		}
+		return fmt.Errorf("failed to mint receipt: %w", err)
+	}
+	w.log.Infof("receipt minted: payment_id=%s, mint=%s", p.PaymentID, mint)
+
+	return nil
+}
@@ -24,30 +24,186 @@ func New(db DBTX) *Queries {
 func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	q := Queries{db: db}
 	var err error
+	if q.clearPaymentReceiptMintStmt, err = db.PrepareContext(ctx, clearPaymentReceiptMint); err != nil {
+		return nil, fmt.Errorf("error preparing query ClearPaymentReceiptMint: %w", err)
+	}
+	if q.completeAffiliatePayoutStmt, err = db.PrepareContext(ctx, completeAffiliatePayout); err != nil {
+		return nil, fmt.Errorf("error preparing query CompleteAffiliatePayout: %w", err)
+	}
+	if q.confirmPaymentReceiptMintStmt, err = db.PrepareContext(ctx, confirmPaymentReceiptMint); err != nil {
+		return nil, fmt.Errorf("error preparing query ConfirmPaymentReceiptMint: %w", err)
+	}
+	if q.countWebhookParkedEventsStmt, err = db.PrepareContext(ctx, countWebhookParkedEvents); err != nil {
+		return nil, fmt.Errorf("error preparing query CountWebhookParkedEvents: %w", err)
+	}
+	if q.createAffiliateStmt, err = db.PrepareContext(ctx, createAffiliate); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateAffiliate: %w", err)
+	}
+	if q.createAffiliateCommissionStmt, err = db.PrepareContext(ctx, createAffiliateCommission); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateAffiliateCommission: %w", err)
+	}
+	if q.createBonusRuleStmt, err = db.PrepareContext(ctx, createBonusRule); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateBonusRule: %w", err)
+	}
+	if q.createCouponStmt, err = db.PrepareContext(ctx, createCoupon); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateCoupon: %w", err)
+	}
+	if q.createGatingRuleStmt, err = db.PrepareContext(ctx, createGatingRule); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateGatingRule: %w", err)
+	}
+	if q.createGiftCardStmt, err = db.PrepareContext(ctx, createGiftCard); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateGiftCard: %w", err)
+	}
+	if q.createGiftCardLedgerEntryStmt, err = db.PrepareContext(ctx, createGiftCardLedgerEntry); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateGiftCardLedgerEntry: %w", err)
+	}
+	if q.createLoyaltyLedgerEntryStmt, err = db.PrepareContext(ctx, createLoyaltyLedgerEntry); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateLoyaltyLedgerEntry: %w", err)
+	}
+	if q.createOutboxEventStmt, err = db.PrepareContext(ctx, createOutboxEvent); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateOutboxEvent: %w", err)
+	}
 	if q.createPaymentStmt, err = db.PrepareContext(ctx, createPayment); err != nil {
 		return nil, fmt.Errorf("error preparing query CreatePayment: %w", err)
 	}
 	if q.createTransactionStmt, err = db.PrepareContext(ctx, createTransaction); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateTransaction: %w", err)
 	}
+	if q.createWebhookDeliveryStmt, err = db.PrepareContext(ctx, createWebhookDelivery); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateWebhookDelivery: %w", err)
+	}
+	if q.createWebhookEndpointStmt, err = db.PrepareContext(ctx, createWebhookEndpoint); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateWebhookEndpoint: %w", err)
+	}
+	if q.createWebhookParkedEventStmt, err = db.PrepareContext(ctx, createWebhookParkedEvent); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateWebhookParkedEvent: %w", err)
+	}
+	if q.deleteBonusRuleStmt, err = db.PrepareContext(ctx, deleteBonusRule); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteBonusRule: %w", err)
+	}
+	if q.deleteCouponStmt, err = db.PrepareContext(ctx, deleteCoupon); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteCoupon: %w", err)
+	}
 	if q.deleteExpiredTokensStmt, err = db.PrepareContext(ctx, deleteExpiredTokens); err != nil {
 		return nil, fmt.Errorf("error preparing query DeleteExpiredTokens: %w", err)
 	}
+	if q.deleteGatingRuleStmt, err = db.PrepareContext(ctx, deleteGatingRule); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteGatingRule: %w", err)
+	}
 	if q.deleteTokenStmt, err = db.PrepareContext(ctx, deleteToken); err != nil {
 		return nil, fmt.Errorf("error preparing query DeleteToken: %w", err)
 	}
 	if q.deleteTokensByCredentialStmt, err = db.PrepareContext(ctx, deleteTokensByCredential); err != nil {
 		return nil, fmt.Errorf("error preparing query DeleteTokensByCredential: %w", err)
 	}
+	if q.deleteWebhookEndpointStmt, err = db.PrepareContext(ctx, deleteWebhookEndpoint); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteWebhookEndpoint: %w", err)
+	}
+	if q.deleteWebhookParkedEventStmt, err = db.PrepareContext(ctx, deleteWebhookParkedEvent); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteWebhookParkedEvent: %w", err)
+	}
+	if q.disableFailingWebhookEndpointStmt, err = db.PrepareContext(ctx, disableFailingWebhookEndpoint); err != nil {
+		return nil, fmt.Errorf("error preparing query DisableFailingWebhookEndpoint: %w", err)
+	}
+	if q.getAccruedAffiliateCommissionsForUpdateStmt, err = db.PrepareContext(ctx, getAccruedAffiliateCommissionsForUpdate); err != nil {
+		return nil, fmt.Errorf("error preparing query GetAccruedAffiliateCommissionsForUpdate: %w", err)
+	}
+	if q.getActiveBonusRulesStmt, err = db.PrepareContext(ctx, getActiveBonusRules); err != nil {
+		return nil, fmt.Errorf("error preparing query GetActiveBonusRules: %w", err)
+	}
+	if q.getActiveGatingRulesStmt, err = db.PrepareContext(ctx, getActiveGatingRules); err != nil {
+		return nil, fmt.Errorf("error preparing query GetActiveGatingRules: %w", err)
+	}
+	if q.getAffiliateStmt, err = db.PrepareContext(ctx, getAffiliate); err != nil {
+		return nil, fmt.Errorf("error preparing query GetAffiliate: %w", err)
+	}
+	if q.getAffiliateByRefCodeStmt, err = db.PrepareContext(ctx, getAffiliateByRefCode); err != nil {
+		return nil, fmt.Errorf("error preparing query GetAffiliateByRefCode: %w", err)
+	}
+	if q.getAffiliateCommissionsStmt, err = db.PrepareContext(ctx, getAffiliateCommissions); err != nil {
+		return nil, fmt.Errorf("error preparing query GetAffiliateCommissions: %w", err)
+	}
+	if q.getAffiliateCommissionsByStatusStmt, err = db.PrepareContext(ctx, getAffiliateCommissionsByStatus); err != nil {
+		return nil, fmt.Errorf("error preparing query GetAffiliateCommissionsByStatus: %w", err)
+	}
+	if q.getAffiliateReportStmt, err = db.PrepareContext(ctx, getAffiliateReport); err != nil {
+		return nil, fmt.Errorf("error preparing query GetAffiliateReport: %w", err)
+	}
+	if q.getAffiliatesStmt, err = db.PrepareContext(ctx, getAffiliates); err != nil {
+		return nil, fmt.Errorf("error preparing query GetAffiliates: %w", err)
+	}
+	if q.getBonusRulesStmt, err = db.PrepareContext(ctx, getBonusRules); err != nil {
+		return nil, fmt.Errorf("error preparing query GetBonusRules: %w", err)
+	}
+	if q.getCouponByCodeStmt, err = db.PrepareContext(ctx, getCouponByCode); err != nil {
+		return nil, fmt.Errorf("error preparing query GetCouponByCode: %w", err)
+	}
+	if q.getCouponForUpdateStmt, err = db.PrepareContext(ctx, getCouponForUpdate); err != nil {
+		return nil, fmt.Errorf("error preparing query GetCouponForUpdate: %w", err)
+	}
+	if q.getCouponUsageStmt, err = db.PrepareContext(ctx, getCouponUsage); err != nil {
+		return nil, fmt.Errorf("error preparing query GetCouponUsage: %w", err)
+	}
+	if q.getCouponsStmt, err = db.PrepareContext(ctx, getCoupons); err != nil {
+		return nil, fmt.Errorf("error preparing query GetCoupons: %w", err)
+	}
+	if q.getDueOutboxEventsStmt, err = db.PrepareContext(ctx, getDueOutboxEvents); err != nil {
+		return nil, fmt.Errorf("error preparing query GetDueOutboxEvents: %w", err)
+	}
+	if q.getGatingRulesStmt, err = db.PrepareContext(ctx, getGatingRules); err != nil {
+		return nil, fmt.Errorf("error preparing query GetGatingRules: %w", err)
+	}
+	if q.getGiftCardStmt, err = db.PrepareContext(ctx, getGiftCard); err != nil {
+		return nil, fmt.Errorf("error preparing query GetGiftCard: %w", err)
+	}
+	if q.getGiftCardByCodeStmt, err = db.PrepareContext(ctx, getGiftCardByCode); err != nil {
+		return nil, fmt.Errorf("error preparing query GetGiftCardByCode: %w", err)
+	}
+	if q.getGiftCardForUpdateStmt, err = db.PrepareContext(ctx, getGiftCardForUpdate); err != nil {
+		return nil, fmt.Errorf("error preparing query GetGiftCardForUpdate: %w", err)
+	}
+	if q.getGiftCardHeldAmountStmt, err = db.PrepareContext(ctx, getGiftCardHeldAmount); err != nil {
+		return nil, fmt.Errorf("error preparing query GetGiftCardHeldAmount: %w", err)
+	}
+	if q.getGiftCardLedgerEntriesStmt, err = db.PrepareContext(ctx, getGiftCardLedgerEntries); err != nil {
+		return nil, fmt.Errorf("error preparing query GetGiftCardLedgerEntries: %w", err)
+	}
+	if q.getGiftCardsStmt, err = db.PrepareContext(ctx, getGiftCards); err != nil {
+		return nil, fmt.Errorf("error preparing query GetGiftCards: %w", err)
+	}
+	if q.getLoyaltyBalanceStmt, err = db.PrepareContext(ctx, getLoyaltyBalance); err != nil {
+		return nil, fmt.Errorf("error preparing query GetLoyaltyBalance: %w", err)
+	}
+	if q.getLoyaltyBalancesStmt, err = db.PrepareContext(ctx, getLoyaltyBalances); err != nil {
+		return nil, fmt.Errorf("error preparing query GetLoyaltyBalances: %w", err)
+	}
+	if q.getLoyaltyLedgerEntriesStmt, err = db.PrepareContext(ctx, getLoyaltyLedgerEntries); err != nil {
+		return nil, fmt.Errorf("error preparing query GetLoyaltyLedgerEntries: %w", err)
+	}
+	if q.getLoyaltyWalletTierStmt, err = db.PrepareContext(ctx, getLoyaltyWalletTier); err != nil {
+		return nil, fmt.Errorf("error preparing query GetLoyaltyWalletTier: %w", err)
+	}
 	if q.getPaymentStmt, err = db.PrepareContext(ctx, getPayment); err != nil {
 		return nil, fmt.Errorf("error preparing query GetPayment: %w", err)
 	}
 	if q.getPaymentByExternalIDStmt, err = db.PrepareContext(ctx, getPaymentByExternalID); err != nil {
 		return nil, fmt.Errorf("error preparing query GetPaymentByExternalID: %w", err)
 	}
+	if q.getPaymentEventsStmt, err = db.PrepareContext(ctx, getPaymentEvents); err != nil {
+		return nil, fmt.Errorf("error preparing query GetPaymentEvents: %w", err)
+	}
+	if q.getPaymentForUpdateStmt, err = db.PrepareContext(ctx, getPaymentForUpdate); err != nil {
+		return nil, fmt.Errorf("error preparing query GetPaymentForUpdate: %w", err)
+	}
 	if q.getPendingTransactionsStmt, err = db.PrepareContext(ctx, getPendingTransactions); err != nil {
 		return nil, fmt.Errorf("error preparing query GetPendingTransactions: %w", err)
 	}
+	if q.getSponsoredAmountSinceStmt, err = db.PrepareContext(ctx, getSponsoredAmountSince); err != nil {
+		return nil, fmt.Errorf("error preparing query GetSponsoredAmountSince: %w", err)
+	}
+	if q.getSubscribedWebhookEndpointsStmt, err = db.PrepareContext(ctx, getSubscribedWebhookEndpoints); err != nil {
+		return nil, fmt.Errorf("error preparing query GetSubscribedWebhookEndpoints: %w", err)
+	}
 	if q.getTokenStmt, err = db.PrepareContext(ctx, getToken); err != nil {
 		return nil, fmt.Errorf("error preparing query GetToken: %w", err)
 	}
@@ -63,26 +219,175 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.getTransactionsByPaymentIDStmt, err = db.PrepareContext(ctx, getTransactionsByPaymentID); err != nil {
 		return nil, fmt.Errorf("error preparing query GetTransactionsByPaymentID: %w", err)
 	}
+	if q.getWalletSpendStatsStmt, err = db.PrepareContext(ctx, getWalletSpendStats); err != nil {
+		return nil, fmt.Errorf("error preparing query GetWalletSpendStats: %w", err)
+	}
+	if q.getWebhookDeliveriesStmt, err = db.PrepareContext(ctx, getWebhookDeliveries); err != nil {
+		return nil, fmt.Errorf("error preparing query GetWebhookDeliveries: %w", err)
+	}
+	if q.getWebhookDeliveryStmt, err = db.PrepareContext(ctx, getWebhookDelivery); err != nil {
+		return nil, fmt.Errorf("error preparing query GetWebhookDelivery: %w", err)
+	}
+	if q.getWebhookEndpointStmt, err = db.PrepareContext(ctx, getWebhookEndpoint); err != nil {
+		return nil, fmt.Errorf("error preparing query GetWebhookEndpoint: %w", err)
+	}
+	if q.getWebhookEndpointsStmt, err = db.PrepareContext(ctx, getWebhookEndpoints); err != nil {
+		return nil, fmt.Errorf("error preparing query GetWebhookEndpoints: %w", err)
+	}
+	if q.getWebhookEndpointsToFlushStmt, err = db.PrepareContext(ctx, getWebhookEndpointsToFlush); err != nil {
+		return nil, fmt.Errorf("error preparing query GetWebhookEndpointsToFlush: %w", err)
+	}
+	if q.getWebhookParkedEventsStmt, err = db.PrepareContext(ctx, getWebhookParkedEvents); err != nil {
+		return nil, fmt.Errorf("error preparing query GetWebhookParkedEvents: %w", err)
+	}
+	if q.lockOutboxRelayStmt, err = db.PrepareContext(ctx, lockOutboxRelay); err != nil {
+		return nil, fmt.Errorf("error preparing query LockOutboxRelay: %w", err)
+	}
+	if q.lockSponsorBudgetStmt, err = db.PrepareContext(ctx, lockSponsorBudget); err != nil {
+		return nil, fmt.Errorf("error preparing query LockSponsorBudget: %w", err)
+	}
+	if q.markOutboxEventPublishedStmt, err = db.PrepareContext(ctx, markOutboxEventPublished); err != nil {
+		return nil, fmt.Errorf("error preparing query MarkOutboxEventPublished: %w", err)
+	}
 	if q.markPaymentsExpiredStmt, err = db.PrepareContext(ctx, markPaymentsExpired); err != nil {
 		return nil, fmt.Errorf("error preparing query MarkPaymentsExpired: %w", err)
 	}
 	if q.markTransactionsAsExpiredStmt, err = db.PrepareContext(ctx, markTransactionsAsExpired); err != nil {
 		return nil, fmt.Errorf("error preparing query MarkTransactionsAsExpired: %w", err)
 	}
+	if q.pauseWebhookEndpointStmt, err = db.PrepareContext(ctx, pauseWebhookEndpoint); err != nil {
+		return nil, fmt.Errorf("error preparing query PauseWebhookEndpoint: %w", err)
+	}
+	if q.recordOutboxEventFailureStmt, err = db.PrepareContext(ctx, recordOutboxEventFailure); err != nil {
+		return nil, fmt.Errorf("error preparing query RecordOutboxEventFailure: %w", err)
+	}
+	if q.recordWebhookEndpointFailureStmt, err = db.PrepareContext(ctx, recordWebhookEndpointFailure); err != nil {
+		return nil, fmt.Errorf("error preparing query RecordWebhookEndpointFailure: %w", err)
+	}
+	if q.recordWebhookEndpointSuccessStmt, err = db.PrepareContext(ctx, recordWebhookEndpointSuccess); err != nil {
+		return nil, fmt.Errorf("error preparing query RecordWebhookEndpointSuccess: %w", err)
+	}
+	if q.reenableWebhookEndpointStmt, err = db.PrepareContext(ctx, reenableWebhookEndpoint); err != nil {
+		return nil, fmt.Errorf("error preparing query ReenableWebhookEndpoint: %w", err)
+	}
+	if q.resumeWebhookEndpointStmt, err = db.PrepareContext(ctx, resumeWebhookEndpoint); err != nil {
+		return nil, fmt.Errorf("error preparing query ResumeWebhookEndpoint: %w", err)
+	}
+	if q.revertAffiliatePayoutStmt, err = db.PrepareContext(ctx, revertAffiliatePayout); err != nil {
+		return nil, fmt.Errorf("error preparing query RevertAffiliatePayout: %w", err)
+	}
+	if q.rotateWebhookEndpointSecretStmt, err = db.PrepareContext(ctx, rotateWebhookEndpointSecret); err != nil {
+		return nil, fmt.Errorf("error preparing query RotateWebhookEndpointSecret: %w", err)
+	}
+	if q.startAffiliateCommissionPayoutStmt, err = db.PrepareContext(ctx, startAffiliateCommissionPayout); err != nil {
+		return nil, fmt.Errorf("error preparing query StartAffiliateCommissionPayout: %w", err)
+	}
 	if q.storeTokenStmt, err = db.PrepareContext(ctx, storeToken); err != nil {
 		return nil, fmt.Errorf("error preparing query StoreToken: %w", err)
 	}
+	if q.updateAffiliateStatusStmt, err = db.PrepareContext(ctx, updateAffiliateStatus); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateAffiliateStatus: %w", err)
+	}
+	if q.updateBonusRuleStatusStmt, err = db.PrepareContext(ctx, updateBonusRuleStatus); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateBonusRuleStatus: %w", err)
+	}
+	if q.updateCouponStatusStmt, err = db.PrepareContext(ctx, updateCouponStatus); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateCouponStatus: %w", err)
+	}
+	if q.updateGatingRuleStatusStmt, err = db.PrepareContext(ctx, updateGatingRuleStatus); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateGatingRuleStatus: %w", err)
+	}
+	if q.updateGiftCardBalanceStmt, err = db.PrepareContext(ctx, updateGiftCardBalance); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateGiftCardBalance: %w", err)
+	}
+	if q.updateGiftCardStatusStmt, err = db.PrepareContext(ctx, updateGiftCardStatus); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateGiftCardStatus: %w", err)
+	}
+	if q.updatePaymentReceiptMintStmt, err = db.PrepareContext(ctx, updatePaymentReceiptMint); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdatePaymentReceiptMint: %w", err)
+	}
 	if q.updatePaymentStatusStmt, err = db.PrepareContext(ctx, updatePaymentStatus); err != nil {
 		return nil, fmt.Errorf("error preparing query UpdatePaymentStatus: %w", err)
 	}
 	if q.updateTransactionByReferenceStmt, err = db.PrepareContext(ctx, updateTransactionByReference); err != nil {
 		return nil, fmt.Errorf("error preparing query UpdateTransactionByReference: %w", err)
 	}
+	if q.updateWebhookEndpointStmt, err = db.PrepareContext(ctx, updateWebhookEndpoint); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateWebhookEndpoint: %w", err)
+	}
+	if q.upsertLoyaltyWalletTierStmt, err = db.PrepareContext(ctx, upsertLoyaltyWalletTier); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertLoyaltyWalletTier: %w", err)
+	}
 	return &q, nil
 }
 
 func (q *Queries) Close() error {
 	var err error
+	if q.clearPaymentReceiptMintStmt != nil {
+		if cerr := q.clearPaymentReceiptMintStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing clearPaymentReceiptMintStmt: %w", cerr)
+		}
+	}
+	if q.completeAffiliatePayoutStmt != nil {
+		if cerr := q.completeAffiliatePayoutStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing completeAffiliatePayoutStmt: %w", cerr)
+		}
+	}
+	if q.confirmPaymentReceiptMintStmt != nil {
+		if cerr := q.confirmPaymentReceiptMintStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing confirmPaymentReceiptMintStmt: %w", cerr)
+		}
+	}
+	if q.countWebhookParkedEventsStmt != nil {
+		if cerr := q.countWebhookParkedEventsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countWebhookParkedEventsStmt: %w", cerr)
+		}
+	}
+	if q.createAffiliateStmt != nil {
+		if cerr := q.createAffiliateStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createAffiliateStmt: %w", cerr)
+		}
+	}
+	if q.createAffiliateCommissionStmt != nil {
+		if cerr := q.createAffiliateCommissionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createAffiliateCommissionStmt: %w", cerr)
+		}
+	}
+	if q.createBonusRuleStmt != nil {
+		if cerr := q.createBonusRuleStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createBonusRuleStmt: %w", cerr)
+		}
+	}
+	if q.createCouponStmt != nil {
+		if cerr := q.createCouponStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createCouponStmt: %w", cerr)
+		}
+	}
+	if q.createGatingRuleStmt != nil {
+		if cerr := q.createGatingRuleStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createGatingRuleStmt: %w", cerr)
+		}
+	}
+	if q.createGiftCardStmt != nil {
+		if cerr := q.createGiftCardStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createGiftCardStmt: %w", cerr)
+		}
+	}
+	if q.createGiftCardLedgerEntryStmt != nil {
+		if cerr := q.createGiftCardLedgerEntryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createGiftCardLedgerEntryStmt: %w", cerr)
+		}
+	}
+	if q.createLoyaltyLedgerEntryStmt != nil {
+		if cerr := q.createLoyaltyLedgerEntryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createLoyaltyLedgerEntryStmt: %w", cerr)
+		}
+	}
+	if q.createOutboxEventStmt != nil {
+		if cerr := q.createOutboxEventStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createOutboxEventStmt: %w", cerr)
+		}
+	}
 	if q.createPaymentStmt != nil {
 		if cerr := q.createPaymentStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing createPaymentStmt: %w", cerr)
@@ -93,11 +398,41 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing createTransactionStmt: %w", cerr)
 		}
 	}
+	if q.createWebhookDeliveryStmt != nil {
+		if cerr := q.createWebhookDeliveryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createWebhookDeliveryStmt: %w", cerr)
+		}
+	}
+	if q.createWebhookEndpointStmt != nil {
+		if cerr := q.createWebhookEndpointStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createWebhookEndpointStmt: %w", cerr)
+		}
+	}
+	if q.createWebhookParkedEventStmt != nil {
+		if cerr := q.createWebhookParkedEventStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createWebhookParkedEventStmt: %w", cerr)
+		}
+	}
+	if q.deleteBonusRuleStmt != nil {
+		if cerr := q.deleteBonusRuleStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteBonusRuleStmt: %w", cerr)
+		}
+	}
+	if q.deleteCouponStmt != nil {
+		if cerr := q.deleteCouponStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteCouponStmt: %w", cerr)
+		}
+	}
 	if q.deleteExpiredTokensStmt != nil {
 		if cerr := q.deleteExpiredTokensStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing deleteExpiredTokensStmt: %w", cerr)
 		}
 	}
+	if q.deleteGatingRuleStmt != nil {
+		if cerr := q.deleteGatingRuleStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteGatingRuleStmt: %w", cerr)
+		}
+	}
 	if q.deleteTokenStmt != nil {
 		if cerr := q.deleteTokenStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing deleteTokenStmt: %w", cerr)
@@ -108,6 +443,151 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing deleteTokensByCredentialStmt: %w", cerr)
 		}
 	}
+	if q.deleteWebhookEndpointStmt != nil {
+		if cerr := q.deleteWebhookEndpointStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteWebhookEndpointStmt: %w", cerr)
+		}
+	}
+	if q.deleteWebhookParkedEventStmt != nil {
+		if cerr := q.deleteWebhookParkedEventStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteWebhookParkedEventStmt: %w", cerr)
+		}
+	}
+	if q.disableFailingWebhookEndpointStmt != nil {
+		if cerr := q.disableFailingWebhookEndpointStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing disableFailingWebhookEndpointStmt: %w", cerr)
+		}
+	}
+	if q.getAccruedAffiliateCommissionsForUpdateStmt != nil {
+		if cerr := q.getAccruedAffiliateCommissionsForUpdateStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getAccruedAffiliateCommissionsForUpdateStmt: %w", cerr)
+		}
+	}
+	if q.getActiveBonusRulesStmt != nil {
+		if cerr := q.getActiveBonusRulesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getActiveBonusRulesStmt: %w", cerr)
+		}
+	}
+	if q.getActiveGatingRulesStmt != nil {
+		if cerr := q.getActiveGatingRulesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getActiveGatingRulesStmt: %w", cerr)
+		}
+	}
+	if q.getAffiliateStmt != nil {
+		if cerr := q.getAffiliateStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getAffiliateStmt: %w", cerr)
+		}
+	}
+	if q.getAffiliateByRefCodeStmt != nil {
+		if cerr := q.getAffiliateByRefCodeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getAffiliateByRefCodeStmt: %w", cerr)
+		}
+	}
+	if q.getAffiliateCommissionsStmt != nil {
+		if cerr := q.getAffiliateCommissionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getAffiliateCommissionsStmt: %w", cerr)
+		}
+	}
+	if q.getAffiliateCommissionsByStatusStmt != nil {
+		if cerr := q.getAffiliateCommissionsByStatusStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getAffiliateCommissionsByStatusStmt: %w", cerr)
+		}
+	}
+	if q.getAffiliateReportStmt != nil {
+		if cerr := q.getAffiliateReportStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getAffiliateReportStmt: %w", cerr)
+		}
+	}
+	if q.getAffiliatesStmt != nil {
+		if cerr := q.getAffiliatesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getAffiliatesStmt: %w", cerr)
+		}
+	}
+	if q.getBonusRulesStmt != nil {
+		if cerr := q.getBonusRulesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getBonusRulesStmt: %w", cerr)
+		}
+	}
+	if q.getCouponByCodeStmt != nil {
+		if cerr := q.getCouponByCodeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getCouponByCodeStmt: %w", cerr)
+		}
+	}
+	if q.getCouponForUpdateStmt != nil {
+		if cerr := q.getCouponForUpdateStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getCouponForUpdateStmt: %w", cerr)
+		}
+	}
+	if q.getCouponUsageStmt != nil {
+		if cerr := q.getCouponUsageStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getCouponUsageStmt: %w", cerr)
+		}
+	}
+	if q.getCouponsStmt != nil {
+		if cerr := q.getCouponsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getCouponsStmt: %w", cerr)
+		}
+	}
+	if q.getDueOutboxEventsStmt != nil {
+		if cerr := q.getDueOutboxEventsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getDueOutboxEventsStmt: %w", cerr)
+		}
+	}
+	if q.getGatingRulesStmt != nil {
+		if cerr := q.getGatingRulesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getGatingRulesStmt: %w", cerr)
+		}
+	}
+	if q.getGiftCardStmt != nil {
+		if cerr := q.getGiftCardStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getGiftCardStmt: %w", cerr)
+		}
+	}
+	if q.getGiftCardByCodeStmt != nil {
+		if cerr := q.getGiftCardByCodeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getGiftCardByCodeStmt: %w", cerr)
+		}
+	}
+	if q.getGiftCardForUpdateStmt != nil {
+		if cerr := q.getGiftCardForUpdateStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getGiftCardForUpdateStmt: %w", cerr)
+		}
+	}
+	if q.getGiftCardHeldAmountStmt != nil {
+		if cerr := q.getGiftCardHeldAmountStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getGiftCardHeldAmountStmt: %w", cerr)
+		}
+	}
+	if q.getGiftCardLedgerEntriesStmt != nil {
+		if cerr := q.getGiftCardLedgerEntriesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getGiftCardLedgerEntriesStmt: %w", cerr)
+		}
+	}
+	if q.getGiftCardsStmt != nil {
+		if cerr := q.getGiftCardsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getGiftCardsStmt: %w", cerr)
+		}
+	}
+	if q.getLoyaltyBalanceStmt != nil {
+		if cerr := q.getLoyaltyBalanceStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getLoyaltyBalanceStmt: %w", cerr)
+		}
+	}
+	if q.getLoyaltyBalancesStmt != nil {
+		if cerr := q.getLoyaltyBalancesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getLoyaltyBalancesStmt: %w", cerr)
+		}
+	}
+	if q.getLoyaltyLedgerEntriesStmt != nil {
+		if cerr := q.getLoyaltyLedgerEntriesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getLoyaltyLedgerEntriesStmt: %w", cerr)
+		}
+	}
+	if q.getLoyaltyWalletTierStmt != nil {
+		if cerr := q.getLoyaltyWalletTierStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getLoyaltyWalletTierStmt: %w", cerr)
+		}
+	}
 	if q.getPaymentStmt != nil {
 		if cerr := q.getPaymentStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getPaymentStmt: %w", cerr)
@@ -118,11 +598,31 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing getPaymentByExternalIDStmt: %w", cerr)
 		}
 	}
+	if q.getPaymentEventsStmt != nil {
+		if cerr := q.getPaymentEventsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getPaymentEventsStmt: %w", cerr)
+		}
+	}
+	if q.getPaymentForUpdateStmt != nil {
+		if cerr := q.getPaymentForUpdateStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getPaymentForUpdateStmt: %w", cerr)
+		}
+	}
 	if q.getPendingTransactionsStmt != nil {
 		if cerr := q.getPendingTransactionsStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getPendingTransactionsStmt: %w", cerr)
 		}
 	}
+	if q.getSponsoredAmountSinceStmt != nil {
+		if cerr := q.getSponsoredAmountSinceStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getSponsoredAmountSinceStmt: %w", cerr)
+		}
+	}
+	if q.getSubscribedWebhookEndpointsStmt != nil {
+		if cerr := q.getSubscribedWebhookEndpointsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getSubscribedWebhookEndpointsStmt: %w", cerr)
+		}
+	}
 	if q.getTokenStmt != nil {
 		if cerr := q.getTokenStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getTokenStmt: %w", cerr)
@@ -148,6 +648,56 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing getTransactionsByPaymentIDStmt: %w", cerr)
 		}
 	}
+	if q.getWalletSpendStatsStmt != nil {
+		if cerr := q.getWalletSpendStatsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getWalletSpendStatsStmt: %w", cerr)
+		}
+	}
+	if q.getWebhookDeliveriesStmt != nil {
+		if cerr := q.getWebhookDeliveriesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getWebhookDeliveriesStmt: %w", cerr)
+		}
+	}
+	if q.getWebhookDeliveryStmt != nil {
+		if cerr := q.getWebhookDeliveryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getWebhookDeliveryStmt: %w", cerr)
+		}
+	}
+	if q.getWebhookEndpointStmt != nil {
+		if cerr := q.getWebhookEndpointStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getWebhookEndpointStmt: %w", cerr)
+		}
+	}
+	if q.getWebhookEndpointsStmt != nil {
+		if cerr := q.getWebhookEndpointsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getWebhookEndpointsStmt: %w", cerr)
+		}
+	}
+	if q.getWebhookEndpointsToFlushStmt != nil {
+		if cerr := q.getWebhookEndpointsToFlushStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getWebhookEndpointsToFlushStmt: %w", cerr)
+		}
+	}
+	if q.getWebhookParkedEventsStmt != nil {
+		if cerr := q.getWebhookParkedEventsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getWebhookParkedEventsStmt: %w", cerr)
+		}
+	}
+	if q.lockOutboxRelayStmt != nil {
+		if cerr := q.lockOutboxRelayStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing lockOutboxRelayStmt: %w", cerr)
+		}
+	}
+	if q.lockSponsorBudgetStmt != nil {
+		if cerr := q.lockSponsorBudgetStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing lockSponsorBudgetStmt: %w", cerr)
+		}
+	}
+	if q.markOutboxEventPublishedStmt != nil {
+		if cerr := q.markOutboxEventPublishedStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing markOutboxEventPublishedStmt: %w", cerr)
+		}
+	}
 	if q.markPaymentsExpiredStmt != nil {
 		if cerr := q.markPaymentsExpiredStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing markPaymentsExpiredStmt: %w", cerr)
@@ -158,11 +708,91 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing markTransactionsAsExpiredStmt: %w", cerr)
 		}
 	}
+	if q.pauseWebhookEndpointStmt != nil {
+		if cerr := q.pauseWebhookEndpointStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing pauseWebhookEndpointStmt: %w", cerr)
+		}
+	}
+	if q.recordOutboxEventFailureStmt != nil {
+		if cerr := q.recordOutboxEventFailureStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing recordOutboxEventFailureStmt: %w", cerr)
+		}
+	}
+	if q.recordWebhookEndpointFailureStmt != nil {
+		if cerr := q.recordWebhookEndpointFailureStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing recordWebhookEndpointFailureStmt: %w", cerr)
+		}
+	}
+	if q.recordWebhookEndpointSuccessStmt != nil {
+		if cerr := q.recordWebhookEndpointSuccessStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing recordWebhookEndpointSuccessStmt: %w", cerr)
+		}
+	}
+	if q.reenableWebhookEndpointStmt != nil {
+		if cerr := q.reenableWebhookEndpointStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing reenableWebhookEndpointStmt: %w", cerr)
+		}
+	}
+	if q.resumeWebhookEndpointStmt != nil {
+		if cerr := q.resumeWebhookEndpointStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing resumeWebhookEndpointStmt: %w", cerr)
+		}
+	}
+	if q.revertAffiliatePayoutStmt != nil {
+		if cerr := q.revertAffiliatePayoutStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing revertAffiliatePayoutStmt: %w", cerr)
+		}
+	}
+	if q.rotateWebhookEndpointSecretStmt != nil {
+		if cerr := q.rotateWebhookEndpointSecretStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing rotateWebhookEndpointSecretStmt: %w", cerr)
+		}
+	}
+	if q.startAffiliateCommissionPayoutStmt != nil {
+		if cerr := q.startAffiliateCommissionPayoutStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing startAffiliateCommissionPayoutStmt: %w", cerr)
+		}
+	}
 	if q.storeTokenStmt != nil {
 		if cerr := q.storeTokenStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing storeTokenStmt: %w", cerr)
 		}
 	}
+	if q.updateAffiliateStatusStmt != nil {
+		if cerr := q.updateAffiliateStatusStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateAffiliateStatusStmt: %w", cerr)
+		}
+	}
+	if q.updateBonusRuleStatusStmt != nil {
+		if cerr := q.updateBonusRuleStatusStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateBonusRuleStatusStmt: %w", cerr)
+		}
+	}
+	if q.updateCouponStatusStmt != nil {
+		if cerr := q.updateCouponStatusStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateCouponStatusStmt: %w", cerr)
+		}
+	}
+	if q.updateGatingRuleStatusStmt != nil {
+		if cerr := q.updateGatingRuleStatusStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateGatingRuleStatusStmt: %w", cerr)
+		}
+	}
+	if q.updateGiftCardBalanceStmt != nil {
+		if cerr := q.updateGiftCardBalanceStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateGiftCardBalanceStmt: %w", cerr)
+		}
+	}
+	if q.updateGiftCardStatusStmt != nil {
+		if cerr := q.updateGiftCardStatusStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateGiftCardStatusStmt: %w", cerr)
+		}
+	}
+	if q.updatePaymentReceiptMintStmt != nil {
+		if cerr := q.updatePaymentReceiptMintStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updatePaymentReceiptMintStmt: %w", cerr)
+		}
+	}
 	if q.updatePaymentStatusStmt != nil {
 		if cerr := q.updatePaymentStatusStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing updatePaymentStatusStmt: %w", cerr)
@@ -173,6 +803,16 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing updateTransactionByReferenceStmt: %w", cerr)
 		}
 	}
+	if q.updateWebhookEndpointStmt != nil {
+		if cerr := q.updateWebhookEndpointStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateWebhookEndpointStmt: %w", cerr)
+		}
+	}
+	if q.upsertLoyaltyWalletTierStmt != nil {
+		if cerr := q.upsertLoyaltyWalletTierStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertLoyaltyWalletTierStmt: %w", cerr)
+		}
+	}
 	return err
 }
 
@@ -212,47 +852,207 @@ func (q *Queries) queryRow(ctx context.Context, stmt *sql.Stmt, query string, ar
 type Queries struct {
 	db                                               DBTX
 	tx                                               *sql.Tx
+	clearPaymentReceiptMintStmt                      *sql.Stmt
+	completeAffiliatePayoutStmt                      *sql.Stmt
+	confirmPaymentReceiptMintStmt                    *sql.Stmt
+	countWebhookParkedEventsStmt                     *sql.Stmt
+	createAffiliateStmt                              *sql.Stmt
+	createAffiliateCommissionStmt                    *sql.Stmt
+	createBonusRuleStmt                              *sql.Stmt
+	createCouponStmt                                 *sql.Stmt
+	createGatingRuleStmt                             *sql.Stmt
+	createGiftCardStmt                               *sql.Stmt
+	createGiftCardLedgerEntryStmt                    *sql.Stmt
+	createLoyaltyLedgerEntryStmt                     *sql.Stmt
+	createOutboxEventStmt                            *sql.Stmt
 	createPaymentStmt                                *sql.Stmt
 	createTransactionStmt                            *sql.Stmt
+	createWebhookDeliveryStmt                        *sql.Stmt
+	createWebhookEndpointStmt                        *sql.Stmt
+	createWebhookParkedEventStmt                     *sql.Stmt
+	deleteBonusRuleStmt                              *sql.Stmt
+	deleteCouponStmt                                 *sql.Stmt
 	deleteExpiredTokensStmt                          *sql.Stmt
+	deleteGatingRuleStmt                             *sql.Stmt
 	deleteTokenStmt                                  *sql.Stmt
 	deleteTokensByCredentialStmt                     *sql.Stmt
+	deleteWebhookEndpointStmt                        *sql.Stmt
+	deleteWebhookParkedEventStmt                     *sql.Stmt
+	disableFailingWebhookEndpointStmt                *sql.Stmt
+	getAccruedAffiliateCommissionsForUpdateStmt      *sql.Stmt
+	getActiveBonusRulesStmt                          *sql.Stmt
+	getActiveGatingRulesStmt                         *sql.Stmt
+	getAffiliateStmt                                 *sql.Stmt
+	getAffiliateByRefCodeStmt                        *sql.Stmt
+	getAffiliateCommissionsStmt                      *sql.Stmt
+	getAffiliateCommissionsByStatusStmt              *sql.Stmt
+	getAffiliateReportStmt                           *sql.Stmt
+	getAffiliatesStmt                                *sql.Stmt
+	getBonusRulesStmt                                *sql.Stmt
+	getCouponByCodeStmt                              *sql.Stmt
+	getCouponForUpdateStmt                           *sql.Stmt
+	getCouponUsageStmt                               *sql.Stmt
+	getCouponsStmt                                   *sql.Stmt
+	getDueOutboxEventsStmt                           *sql.Stmt
+	getGatingRulesStmt                               *sql.Stmt
+	getGiftCardStmt                                  *sql.Stmt
+	getGiftCardByCodeStmt                            *sql.Stmt
+	getGiftCardForUpdateStmt                         *sql.Stmt
+	getGiftCardHeldAmountStmt                        *sql.Stmt
+	getGiftCardLedgerEntriesStmt                     *sql.Stmt
+	getGiftCardsStmt                                 *sql.Stmt
+	getLoyaltyBalanceStmt                            *sql.Stmt
+	getLoyaltyBalancesStmt                           *sql.Stmt
+	getLoyaltyLedgerEntriesStmt                      *sql.Stmt
+	getLoyaltyWalletTierStmt                         *sql.Stmt
 	getPaymentStmt                                   *sql.Stmt
 	getPaymentByExternalIDStmt                       *sql.Stmt
+	getPaymentEventsStmt                             *sql.Stmt
+	getPaymentForUpdateStmt                          *sql.Stmt
 	getPendingTransactionsStmt                       *sql.Stmt
+	getSponsoredAmountSinceStmt                      *sql.Stmt
+	getSubscribedWebhookEndpointsStmt                *sql.Stmt
 	getTokenStmt                                     *sql.Stmt
 	getTransactionStmt                               *sql.Stmt
 	getTransactionByPaymentIDSourceWalletAndMintStmt *sql.Stmt
 	getTransactionByReferenceStmt                    *sql.Stmt
 	getTransactionsByPaymentIDStmt                   *sql.Stmt
+	getWalletSpendStatsStmt                          *sql.Stmt
+	getWebhookDeliveriesStmt                         *sql.Stmt
+	getWebhookDeliveryStmt                           *sql.Stmt
+	getWebhookEndpointStmt                           *sql.Stmt
+	getWebhookEndpointsStmt                          *sql.Stmt
+	getWebhookEndpointsToFlushStmt                   *sql.Stmt
+	getWebhookParkedEventsStmt                       *sql.Stmt
+	lockOutboxRelayStmt                              *sql.Stmt
+	lockSponsorBudgetStmt                            *sql.Stmt
+	markOutboxEventPublishedStmt                     *sql.Stmt
 	markPaymentsExpiredStmt                          *sql.Stmt
 	markTransactionsAsExpiredStmt                    *sql.Stmt
+	pauseWebhookEndpointStmt                         *sql.Stmt
+	recordOutboxEventFailureStmt                     *sql.Stmt
+	recordWebhookEndpointFailureStmt                 *sql.Stmt
+	recordWebhookEndpointSuccessStmt                 *sql.Stmt
+	reenableWebhookEndpointStmt                      *sql.Stmt
+	resumeWebhookEndpointStmt                        *sql.Stmt
+	revertAffiliatePayoutStmt                        *sql.Stmt
+	rotateWebhookEndpointSecretStmt                  *sql.Stmt
+	startAffiliateCommissionPayoutStmt               *sql.Stmt
 	storeTokenStmt                                   *sql.Stmt
+	updateAffiliateStatusStmt                        *sql.Stmt
+	updateBonusRuleStatusStmt                        *sql.Stmt
+	updateCouponStatusStmt                           *sql.Stmt
+	updateGatingRuleStatusStmt                       *sql.Stmt
+	updateGiftCardBalanceStmt                        *sql.Stmt
+	updateGiftCardStatusStmt                         *sql.Stmt
+	updatePaymentReceiptMintStmt                     *sql.Stmt
 	updatePaymentStatusStmt                          *sql.Stmt
 	updateTransactionByReferenceStmt                 *sql.Stmt
+	updateWebhookEndpointStmt                        *sql.Stmt
+	upsertLoyaltyWalletTierStmt                      *sql.Stmt
 }
 
 func (q *Queries) WithTx(tx *sql.Tx) *Queries {
 	return &Queries{
-		db:                           tx,
-		tx:                           tx,
-		createPaymentStmt:            q.createPaymentStmt,
-		createTransactionStmt:        q.createTransactionStmt,
-		deleteExpiredTokensStmt:      q.deleteExpiredTokensStmt,
-		deleteTokenStmt:              q.deleteTokenStmt,
-		deleteTokensByCredentialStmt: q.deleteTokensByCredentialStmt,
-		getPaymentStmt:               q.getPaymentStmt,
-		getPaymentByExternalIDStmt:   q.getPaymentByExternalIDStmt,
-		getPendingTransactionsStmt:   q.getPendingTransactionsStmt,
-		getTokenStmt:                 q.getTokenStmt,
-		getTransactionStmt:           q.getTransactionStmt,
+		db:                                               tx,
+		tx:                                               tx,
+		clearPaymentReceiptMintStmt:                      q.clearPaymentReceiptMintStmt,
+		completeAffiliatePayoutStmt:                      q.completeAffiliatePayoutStmt,
+		confirmPaymentReceiptMintStmt:                    q.confirmPaymentReceiptMintStmt,
+		countWebhookParkedEventsStmt:                     q.countWebhookParkedEventsStmt,
+		createAffiliateStmt:                              q.createAffiliateStmt,
+		createAffiliateCommissionStmt:                    q.createAffiliateCommissionStmt,
+		createBonusRuleStmt:                              q.createBonusRuleStmt,
+		createCouponStmt:                                 q.createCouponStmt,
+		createGatingRuleStmt:                             q.createGatingRuleStmt,
+		createGiftCardStmt:                               q.createGiftCardStmt,
+		createGiftCardLedgerEntryStmt:                    q.createGiftCardLedgerEntryStmt,
+		createLoyaltyLedgerEntryStmt:                     q.createLoyaltyLedgerEntryStmt,
+		createOutboxEventStmt:                            q.createOutboxEventStmt,
+		createPaymentStmt:                                q.createPaymentStmt,
+		createTransactionStmt:                            q.createTransactionStmt,
+		createWebhookDeliveryStmt:                        q.createWebhookDeliveryStmt,
+		createWebhookEndpointStmt:                        q.createWebhookEndpointStmt,
+		createWebhookParkedEventStmt:                     q.createWebhookParkedEventStmt,
+		deleteBonusRuleStmt:                              q.deleteBonusRuleStmt,
+		deleteCouponStmt:                                 q.deleteCouponStmt,
+		deleteExpiredTokensStmt:                          q.deleteExpiredTokensStmt,
+		deleteGatingRuleStmt:                             q.deleteGatingRuleStmt,
+		deleteTokenStmt:                                  q.deleteTokenStmt,
+		deleteTokensByCredentialStmt:                     q.deleteTokensByCredentialStmt,
+		deleteWebhookEndpointStmt:                        q.deleteWebhookEndpointStmt,
+		deleteWebhookParkedEventStmt:                     q.deleteWebhookParkedEventStmt,
+		disableFailingWebhookEndpointStmt:                q.disableFailingWebhookEndpointStmt,
+		getAccruedAffiliateCommissionsForUpdateStmt:      q.getAccruedAffiliateCommissionsForUpdateStmt,
+		getActiveBonusRulesStmt:                          q.getActiveBonusRulesStmt,
+		getActiveGatingRulesStmt:                         q.getActiveGatingRulesStmt,
+		getAffiliateStmt:                                 q.getAffiliateStmt,
+		getAffiliateByRefCodeStmt:                        q.getAffiliateByRefCodeStmt,
+		getAffiliateCommissionsStmt:                      q.getAffiliateCommissionsStmt,
+		getAffiliateCommissionsByStatusStmt:              q.getAffiliateCommissionsByStatusStmt,
+		getAffiliateReportStmt:                           q.getAffiliateReportStmt,
+		getAffiliatesStmt:                                q.getAffiliatesStmt,
+		getBonusRulesStmt:                                q.getBonusRulesStmt,
+		getCouponByCodeStmt:                              q.getCouponByCodeStmt,
+		getCouponForUpdateStmt:                           q.getCouponForUpdateStmt,
+		getCouponUsageStmt:                               q.getCouponUsageStmt,
+		getCouponsStmt:                                   q.getCouponsStmt,
+		getDueOutboxEventsStmt:                           q.getDueOutboxEventsStmt,
+		getGatingRulesStmt:                               q.getGatingRulesStmt,
+		getGiftCardStmt:                                  q.getGiftCardStmt,
+		getGiftCardByCodeStmt:                            q.getGiftCardByCodeStmt,
+		getGiftCardForUpdateStmt:                         q.getGiftCardForUpdateStmt,
+		getGiftCardHeldAmountStmt:                        q.getGiftCardHeldAmountStmt,
+		getGiftCardLedgerEntriesStmt:                     q.getGiftCardLedgerEntriesStmt,
+		getGiftCardsStmt:                                 q.getGiftCardsStmt,
+		getLoyaltyBalanceStmt:                            q.getLoyaltyBalanceStmt,
+		getLoyaltyBalancesStmt:                           q.getLoyaltyBalancesStmt,
+		getLoyaltyLedgerEntriesStmt:                      q.getLoyaltyLedgerEntriesStmt,
+		getLoyaltyWalletTierStmt:                         q.getLoyaltyWalletTierStmt,
+		getPaymentStmt:                                   q.getPaymentStmt,
+		getPaymentByExternalIDStmt:                       q.getPaymentByExternalIDStmt,
+		getPaymentEventsStmt:                             q.getPaymentEventsStmt,
+		getPaymentForUpdateStmt:                          q.getPaymentForUpdateStmt,
+		getPendingTransactionsStmt:                       q.getPendingTransactionsStmt,
+		getSponsoredAmountSinceStmt:                      q.getSponsoredAmountSinceStmt,
+		getSubscribedWebhookEndpointsStmt:                q.getSubscribedWebhookEndpointsStmt,
+		getTokenStmt:                                     q.getTokenStmt,
+		getTransactionStmt:                               q.getTransactionStmt,
 		getTransactionByPaymentIDSourceWalletAndMintStmt: q.getTransactionByPaymentIDSourceWalletAndMintStmt,
 		getTransactionByReferenceStmt:                    q.getTransactionByReferenceStmt,
 		getTransactionsByPaymentIDStmt:                   q.getTransactionsByPaymentIDStmt,
+		getWalletSpendStatsStmt:                          q.getWalletSpendStatsStmt,
+		getWebhookDeliveriesStmt:                         q.getWebhookDeliveriesStmt,
+		getWebhookDeliveryStmt:                           q.getWebhookDeliveryStmt,
+		getWebhookEndpointStmt:                           q.getWebhookEndpointStmt,
+		getWebhookEndpointsStmt:                          q.getWebhookEndpointsStmt,
+		getWebhookEndpointsToFlushStmt:                   q.getWebhookEndpointsToFlushStmt,
+		getWebhookParkedEventsStmt:                       q.getWebhookParkedEventsStmt,
+		lockOutboxRelayStmt:                              q.lockOutboxRelayStmt,
+		lockSponsorBudgetStmt:                            q.lockSponsorBudgetStmt,
+		markOutboxEventPublishedStmt:                     q.markOutboxEventPublishedStmt,
 		markPaymentsExpiredStmt:                          q.markPaymentsExpiredStmt,
 		markTransactionsAsExpiredStmt:                    q.markTransactionsAsExpiredStmt,
+		pauseWebhookEndpointStmt:                         q.pauseWebhookEndpointStmt,
+		recordOutboxEventFailureStmt:                     q.recordOutboxEventFailureStmt,
+		recordWebhookEndpointFailureStmt:                 q.recordWebhookEndpointFailureStmt,
+		recordWebhookEndpointSuccessStmt:                 q.recordWebhookEndpointSuccessStmt,
+		reenableWebhookEndpointStmt:                      q.reenableWebhookEndpointStmt,
+		resumeWebhookEndpointStmt:                        q.resumeWebhookEndpointStmt,
+		revertAffiliatePayoutStmt:                        q.revertAffiliatePayoutStmt,
+		rotateWebhookEndpointSecretStmt:                  q.rotateWebhookEndpointSecretStmt,
+		startAffiliateCommissionPayoutStmt:               q.startAffiliateCommissionPayoutStmt,
 		storeTokenStmt:                                   q.storeTokenStmt,
+		updateAffiliateStatusStmt:                        q.updateAffiliateStatusStmt,
+		updateBonusRuleStatusStmt:                        q.updateBonusRuleStatusStmt,
+		updateCouponStatusStmt:                           q.updateCouponStatusStmt,
+		updateGatingRuleStatusStmt:                       q.updateGatingRuleStatusStmt,
+		updateGiftCardBalanceStmt:                        q.updateGiftCardBalanceStmt,
+		updateGiftCardStatusStmt:                         q.updateGiftCardStatusStmt,
+		updatePaymentReceiptMintStmt:                     q.updatePaymentReceiptMintStmt,
 		updatePaymentStatusStmt:                          q.updatePaymentStatusStmt,
 		updateTransactionByReferenceStmt:                 q.updateTransactionByReferenceStmt,
+		updateWebhookEndpointStmt:                        q.updateWebhookEndpointStmt,
+		upsertLoyaltyWalletTierStmt:                      q.upsertLoyaltyWalletTierStmt,
 	}
 }
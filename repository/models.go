@@ -7,12 +7,355 @@ package repository
 import (
 	"database/sql"
 	"database/sql/driver"
+	"encoding/json"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+type AffiliateCommissionStatus string
+
+const (
+	AffiliateCommissionStatusAccrued    AffiliateCommissionStatus = "accrued"
+	AffiliateCommissionStatusProcessing AffiliateCommissionStatus = "processing"
+	AffiliateCommissionStatusPaid       AffiliateCommissionStatus = "paid"
+)
+
+func (e *AffiliateCommissionStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = AffiliateCommissionStatus(s)
+	case string:
+		*e = AffiliateCommissionStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for AffiliateCommissionStatus: %T", src)
+	}
+	return nil
+}
+
+type NullAffiliateCommissionStatus struct {
+	AffiliateCommissionStatus AffiliateCommissionStatus
+	Valid                     bool // Valid is true if AffiliateCommissionStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullAffiliateCommissionStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.AffiliateCommissionStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.AffiliateCommissionStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullAffiliateCommissionStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return ns.AffiliateCommissionStatus, nil
+}
+
+type AffiliateCommissionType string
+
+const (
+	AffiliateCommissionTypePercent AffiliateCommissionType = "percent"
+	AffiliateCommissionTypeFixed   AffiliateCommissionType = "fixed"
+)
+
+func (e *AffiliateCommissionType) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = AffiliateCommissionType(s)
+	case string:
+		*e = AffiliateCommissionType(s)
+	default:
+		return fmt.Errorf("unsupported scan type for AffiliateCommissionType: %T", src)
+	}
+	return nil
+}
+
+type NullAffiliateCommissionType struct {
+	AffiliateCommissionType AffiliateCommissionType
+	Valid                   bool // Valid is true if AffiliateCommissionType is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullAffiliateCommissionType) Scan(value interface{}) error {
+	if value == nil {
+		ns.AffiliateCommissionType, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.AffiliateCommissionType.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullAffiliateCommissionType) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return ns.AffiliateCommissionType, nil
+}
+
+type AffiliatePayoutMode string
+
+const (
+	AffiliatePayoutModeInline  AffiliatePayoutMode = "inline"
+	AffiliatePayoutModeAccrued AffiliatePayoutMode = "accrued"
+)
+
+func (e *AffiliatePayoutMode) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = AffiliatePayoutMode(s)
+	case string:
+		*e = AffiliatePayoutMode(s)
+	default:
+		return fmt.Errorf("unsupported scan type for AffiliatePayoutMode: %T", src)
+	}
+	return nil
+}
+
+type NullAffiliatePayoutMode struct {
+	AffiliatePayoutMode AffiliatePayoutMode
+	Valid               bool // Valid is true if AffiliatePayoutMode is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullAffiliatePayoutMode) Scan(value interface{}) error {
+	if value == nil {
+		ns.AffiliatePayoutMode, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.AffiliatePayoutMode.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullAffiliatePayoutMode) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return ns.AffiliatePayoutMode, nil
+}
+
+type BonusRuleAction string
+
+const (
+	BonusRuleActionAccrualMultiplier BonusRuleAction = "accrual_multiplier"
+	BonusRuleActionFlatBonus         BonusRuleAction = "flat_bonus"
+	BonusRuleActionDiscountPercent   BonusRuleAction = "discount_percent"
+	BonusRuleActionFreeItem          BonusRuleAction = "free_item"
+)
+
+func (e *BonusRuleAction) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = BonusRuleAction(s)
+	case string:
+		*e = BonusRuleAction(s)
+	default:
+		return fmt.Errorf("unsupported scan type for BonusRuleAction: %T", src)
+	}
+	return nil
+}
+
+type NullBonusRuleAction struct {
+	BonusRuleAction BonusRuleAction
+	Valid           bool // Valid is true if BonusRuleAction is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullBonusRuleAction) Scan(value interface{}) error {
+	if value == nil {
+		ns.BonusRuleAction, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.BonusRuleAction.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullBonusRuleAction) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return ns.BonusRuleAction, nil
+}
+
+type CouponDiscountType string
+
+const (
+	CouponDiscountTypePercent CouponDiscountType = "percent"
+	CouponDiscountTypeFixed   CouponDiscountType = "fixed"
+)
+
+func (e *CouponDiscountType) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = CouponDiscountType(s)
+	case string:
+		*e = CouponDiscountType(s)
+	default:
+		return fmt.Errorf("unsupported scan type for CouponDiscountType: %T", src)
+	}
+	return nil
+}
+
+type NullCouponDiscountType struct {
+	CouponDiscountType CouponDiscountType
+	Valid              bool // Valid is true if CouponDiscountType is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullCouponDiscountType) Scan(value interface{}) error {
+	if value == nil {
+		ns.CouponDiscountType, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.CouponDiscountType.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullCouponDiscountType) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return ns.CouponDiscountType, nil
+}
+
+type GatingRuleType string
+
+const (
+	GatingRuleTypeCollection   GatingRuleType = "collection"
+	GatingRuleTypeCreator      GatingRuleType = "creator"
+	GatingRuleTypeTokenBalance GatingRuleType = "token_balance"
+)
+
+func (e *GatingRuleType) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = GatingRuleType(s)
+	case string:
+		*e = GatingRuleType(s)
+	default:
+		return fmt.Errorf("unsupported scan type for GatingRuleType: %T", src)
+	}
+	return nil
+}
+
+type NullGatingRuleType struct {
+	GatingRuleType GatingRuleType
+	Valid          bool // Valid is true if GatingRuleType is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullGatingRuleType) Scan(value interface{}) error {
+	if value == nil {
+		ns.GatingRuleType, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.GatingRuleType.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullGatingRuleType) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return ns.GatingRuleType, nil
+}
+
+type GiftCardEntryType string
+
+const (
+	GiftCardEntryTypeIssue      GiftCardEntryType = "issue"
+	GiftCardEntryTypeRedemption GiftCardEntryType = "redemption"
+)
+
+func (e *GiftCardEntryType) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = GiftCardEntryType(s)
+	case string:
+		*e = GiftCardEntryType(s)
+	default:
+		return fmt.Errorf("unsupported scan type for GiftCardEntryType: %T", src)
+	}
+	return nil
+}
+
+type NullGiftCardEntryType struct {
+	GiftCardEntryType GiftCardEntryType
+	Valid             bool // Valid is true if GiftCardEntryType is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullGiftCardEntryType) Scan(value interface{}) error {
+	if value == nil {
+		ns.GiftCardEntryType, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.GiftCardEntryType.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullGiftCardEntryType) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return ns.GiftCardEntryType, nil
+}
+
+type LoyaltyEntryType string
+
+const (
+	LoyaltyEntryTypeAccrual    LoyaltyEntryType = "accrual"
+	LoyaltyEntryTypeRedemption LoyaltyEntryType = "redemption"
+	LoyaltyEntryTypeAdjustment LoyaltyEntryType = "adjustment"
+	LoyaltyEntryTypeExpiry     LoyaltyEntryType = "expiry"
+)
+
+func (e *LoyaltyEntryType) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = LoyaltyEntryType(s)
+	case string:
+		*e = LoyaltyEntryType(s)
+	default:
+		return fmt.Errorf("unsupported scan type for LoyaltyEntryType: %T", src)
+	}
+	return nil
+}
+
+type NullLoyaltyEntryType struct {
+	LoyaltyEntryType LoyaltyEntryType
+	Valid            bool // Valid is true if LoyaltyEntryType is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullLoyaltyEntryType) Scan(value interface{}) error {
+	if value == nil {
+		ns.LoyaltyEntryType, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.LoyaltyEntryType.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullLoyaltyEntryType) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return ns.LoyaltyEntryType, nil
+}
+
 type PaymentStatus string
 
 const (
@@ -103,17 +446,132 @@ func (ns NullTransactionStatus) Value() (driver.Value, error) {
 	return ns.TransactionStatus, nil
 }
 
+type Affiliate struct {
+	ID              uuid.UUID               `json:"id"`
+	Name            string                  `json:"name"`
+	RefCode         string                  `json:"ref_code"`
+	Wallet          string                  `json:"wallet"`
+	CommissionType  AffiliateCommissionType `json:"commission_type"`
+	CommissionValue int64                   `json:"commission_value"`
+	PayoutMode      AffiliatePayoutMode     `json:"payout_mode"`
+	Active          bool                    `json:"active"`
+	CreatedAt       time.Time               `json:"created_at"`
+}
+
+type AffiliateCommission struct {
+	ID              uuid.UUID                 `json:"id"`
+	AffiliateID     uuid.UUID                 `json:"affiliate_id"`
+	TransactionID   uuid.UUID                 `json:"transaction_id"`
+	PaymentID       uuid.UUID                 `json:"payment_id"`
+	Mint            string                    `json:"mint"`
+	SaleAmount      int64                     `json:"sale_amount"`
+	Amount          int64                     `json:"amount"`
+	Status          AffiliateCommissionStatus `json:"status"`
+	PayoutSignature sql.NullString            `json:"payout_signature"`
+	PayoutAt        sql.NullTime              `json:"payout_at"`
+	CreatedAt       time.Time                 `json:"created_at"`
+}
+
+type BonusRule struct {
+	ID          uuid.UUID       `json:"id"`
+	Name        string          `json:"name"`
+	Priority    int32           `json:"priority"`
+	Conditions  json.RawMessage `json:"conditions"`
+	Action      BonusRuleAction `json:"action"`
+	ActionValue int64           `json:"action_value"`
+	Active      bool            `json:"active"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+type Coupon struct {
+	ID               uuid.UUID          `json:"id"`
+	Code             string             `json:"code"`
+	DiscountType     CouponDiscountType `json:"discount_type"`
+	DiscountValue    int64              `json:"discount_value"`
+	MinAmount        int64              `json:"min_amount"`
+	UsageLimit       int64              `json:"usage_limit"`
+	WalletUsageLimit int64              `json:"wallet_usage_limit"`
+	ExpiresAt        sql.NullTime       `json:"expires_at"`
+	Active           bool               `json:"active"`
+	CreatedAt        time.Time          `json:"created_at"`
+}
+
+type EventsOutbox struct {
+	ID            int64           `json:"id"`
+	Event         string          `json:"event"`
+	Payload       json.RawMessage `json:"payload"`
+	PaymentID     uuid.NullUUID   `json:"payment_id"`
+	Attempts      int32           `json:"attempts"`
+	LastError     sql.NullString  `json:"last_error"`
+	NextAttemptAt time.Time       `json:"next_attempt_at"`
+	CreatedAt     time.Time       `json:"created_at"`
+	PublishedAt   sql.NullTime    `json:"published_at"`
+	Source        string          `json:"source"`
+}
+
+type GatingRule struct {
+	ID              uuid.UUID      `json:"id"`
+	Name            string         `json:"name"`
+	RuleType        GatingRuleType `json:"rule_type"`
+	Address         string         `json:"address"`
+	MinBalance      int64          `json:"min_balance"`
+	DiscountPercent int64          `json:"discount_percent"`
+	Active          bool           `json:"active"`
+	CreatedAt       time.Time      `json:"created_at"`
+}
+
+type GiftCard struct {
+	ID            uuid.UUID    `json:"id"`
+	Code          string       `json:"code"`
+	Mint          string       `json:"mint"`
+	InitialAmount int64        `json:"initial_amount"`
+	Balance       int64        `json:"balance"`
+	ExpiresAt     sql.NullTime `json:"expires_at"`
+	Active        bool         `json:"active"`
+	CreatedAt     time.Time    `json:"created_at"`
+}
+
+type GiftCardLedger struct {
+	ID            uuid.UUID         `json:"id"`
+	GiftCardID    uuid.UUID         `json:"gift_card_id"`
+	EntryType     GiftCardEntryType `json:"entry_type"`
+	Amount        int64             `json:"amount"`
+	TransactionID uuid.NullUUID     `json:"transaction_id"`
+	CreatedAt     time.Time         `json:"created_at"`
+}
+
+type LoyaltyLedger struct {
+	ID            uuid.UUID        `json:"id"`
+	Wallet        string           `json:"wallet"`
+	Mint          string           `json:"mint"`
+	EntryType     LoyaltyEntryType `json:"entry_type"`
+	Amount        int64            `json:"amount"`
+	TransactionID uuid.NullUUID    `json:"transaction_id"`
+	Note          sql.NullString   `json:"note"`
+	CreatedAt     time.Time        `json:"created_at"`
+}
+
+type LoyaltyWallet struct {
+	Wallet    string    `json:"wallet"`
+	Tier      string    `json:"tier"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
 type Payment struct {
-	ID                uuid.UUID      `json:"id"`
-	ExternalID        sql.NullString `json:"external_id"`
-	DestinationWallet string         `json:"destination_wallet"`
-	DestinationMint   string         `json:"destination_mint"`
-	Amount            int64          `json:"amount"`
-	Status            PaymentStatus  `json:"status"`
-	Message           sql.NullString `json:"message"`
-	ExpiresAt         sql.NullTime   `json:"expires_at"`
-	CreatedAt         time.Time      `json:"created_at"`
-	UpdatedAt         sql.NullTime   `json:"updated_at"`
+	ID                 uuid.UUID      `json:"id"`
+	ExternalID         sql.NullString `json:"external_id"`
+	DestinationWallet  string         `json:"destination_wallet"`
+	DestinationMint    string         `json:"destination_mint"`
+	Amount             int64          `json:"amount"`
+	Status             PaymentStatus  `json:"status"`
+	Message            sql.NullString `json:"message"`
+	ExpiresAt          sql.NullTime   `json:"expires_at"`
+	CreatedAt          time.Time      `json:"created_at"`
+	UpdatedAt          sql.NullTime   `json:"updated_at"`
+	ReceiptMint        sql.NullString `json:"receipt_mint"`
+	ReceiptTxSignature sql.NullString `json:"receipt_tx_signature"`
+	ReceiptTxBlockhash sql.NullString `json:"receipt_tx_blockhash"`
+	ReceiptConfirmed   bool           `json:"receipt_confirmed"`
 }
 
 type Token struct {
@@ -128,22 +586,83 @@ type Token struct {
 }
 
 type Transaction struct {
-	ID                 uuid.UUID         `json:"id"`
-	PaymentID          uuid.UUID         `json:"payment_id"`
-	Reference          string            `json:"reference"`
-	SourceWallet       string            `json:"source_wallet"`
-	SourceMint         string            `json:"source_mint"`
-	DestinationWallet  string            `json:"destination_wallet"`
-	DestinationMint    string            `json:"destination_mint"`
-	Amount             int64             `json:"amount"`
-	DiscountAmount     int64             `json:"discount_amount"`
-	TotalAmount        int64             `json:"total_amount"`
-	AccruedBonusAmount int64             `json:"accrued_bonus_amount"`
-	Message            sql.NullString    `json:"message"`
-	Memo               sql.NullString    `json:"memo"`
-	ApplyBonus         sql.NullBool      `json:"apply_bonus"`
-	TxSignature        sql.NullString    `json:"tx_signature"`
-	Status             TransactionStatus `json:"status"`
-	CreatedAt          time.Time         `json:"created_at"`
-	UpdatedAt          sql.NullTime      `json:"updated_at"`
+	ID                        uuid.UUID         `json:"id"`
+	PaymentID                 uuid.UUID         `json:"payment_id"`
+	Reference                 string            `json:"reference"`
+	SourceWallet              string            `json:"source_wallet"`
+	SourceMint                string            `json:"source_mint"`
+	DestinationWallet         string            `json:"destination_wallet"`
+	DestinationMint           string            `json:"destination_mint"`
+	Amount                    int64             `json:"amount"`
+	DiscountAmount            int64             `json:"discount_amount"`
+	TotalAmount               int64             `json:"total_amount"`
+	AccruedBonusAmount        int64             `json:"accrued_bonus_amount"`
+	Message                   sql.NullString    `json:"message"`
+	Memo                      sql.NullString    `json:"memo"`
+	ApplyBonus                sql.NullBool      `json:"apply_bonus"`
+	TxSignature               sql.NullString    `json:"tx_signature"`
+	Status                    TransactionStatus `json:"status"`
+	CreatedAt                 time.Time         `json:"created_at"`
+	UpdatedAt                 sql.NullTime      `json:"updated_at"`
+	FeePayer                  sql.NullString    `json:"fee_payer"`
+	SponsoredAmount           int64             `json:"sponsored_amount"`
+	PromoDiscountAmount       int64             `json:"promo_discount_amount"`
+	AppliedRules              json.RawMessage   `json:"applied_rules"`
+	CouponID                  uuid.NullUUID     `json:"coupon_id"`
+	CouponDiscountAmount      int64             `json:"coupon_discount_amount"`
+	GatingRuleID              uuid.NullUUID     `json:"gating_rule_id"`
+	GatingAsset               sql.NullString    `json:"gating_asset"`
+	GatingDiscountAmount      int64             `json:"gating_discount_amount"`
+	GiftCardID                uuid.NullUUID     `json:"gift_card_id"`
+	GiftCardAmount            int64             `json:"gift_card_amount"`
+	RefCode                   sql.NullString    `json:"ref_code"`
+	AffiliateID               uuid.NullUUID     `json:"affiliate_id"`
+	AffiliateCommission       int64             `json:"affiliate_commission"`
+	AffiliateCommissionInline bool              `json:"affiliate_commission_inline"`
+}
+
+type WebhookDelivery struct {
+	ID             uuid.UUID       `json:"id"`
+	EndpointID     uuid.UUID       `json:"endpoint_id"`
+	URL            string          `json:"url"`
+	Event          string          `json:"event"`
+	EventID        uuid.UUID       `json:"event_id"`
+	RequestBody    json.RawMessage `json:"request_body"`
+	ResponseStatus sql.NullInt32   `json:"response_status"`
+	ResponseBody   sql.NullString  `json:"response_body"`
+	LatencyMs      int32           `json:"latency_ms"`
+	Error          sql.NullString  `json:"error"`
+	Succeeded      bool            `json:"succeeded"`
+	ReplayOf       uuid.NullUUID   `json:"replay_of"`
+	CreatedAt      time.Time       `json:"created_at"`
+	PaymentID      uuid.NullUUID   `json:"payment_id"`
+}
+
+type WebhookEndpoint struct {
+	ID                      uuid.UUID      `json:"id"`
+	URL                     string         `json:"url"`
+	Secret                  string         `json:"secret"`
+	Description             sql.NullString `json:"description"`
+	EventTypes              []string       `json:"event_types"`
+	Enabled                 bool           `json:"enabled"`
+	UpdatedAt               sql.NullTime   `json:"updated_at"`
+	CreatedAt               time.Time      `json:"created_at"`
+	PreviousSecret          sql.NullString `json:"previous_secret"`
+	PreviousSecretExpiresAt sql.NullTime   `json:"previous_secret_expires_at"`
+	ApiVersion              string         `json:"api_version"`
+	ConsecutiveFailures     int32          `json:"consecutive_failures"`
+	FailingSince            sql.NullTime   `json:"failing_since"`
+	LastSuccessAt           sql.NullTime   `json:"last_success_at"`
+	LastFailureAt           sql.NullTime   `json:"last_failure_at"`
+	PausedUntil             sql.NullTime   `json:"paused_until"`
+	DisabledReason          sql.NullString `json:"disabled_reason"`
+}
+
+type WebhookParkedEvent struct {
+	ID         int64           `json:"id"`
+	EndpointID uuid.UUID       `json:"endpoint_id"`
+	EventID    uuid.UUID       `json:"event_id"`
+	Event      string          `json:"event"`
+	Payload    json.RawMessage `json:"payload"`
+	CreatedAt  time.Time       `json:"created_at"`
 }
@@ -12,6 +12,38 @@ import (
 	"github.com/google/uuid"
 )
 
+const clearPaymentReceiptMint = `-- name: ClearPaymentReceiptMint :exec
+UPDATE payments
+SET receipt_mint = NULL,
+    receipt_tx_signature = NULL,
+    receipt_tx_blockhash = NULL
+WHERE id = $1 AND receipt_mint = $2::VARCHAR AND receipt_confirmed = false
+`
+
+type ClearPaymentReceiptMintParams struct {
+	ID          uuid.UUID `json:"id"`
+	ReceiptMint string    `json:"receipt_mint"`
+}
+
+func (q *Queries) ClearPaymentReceiptMint(ctx context.Context, arg ClearPaymentReceiptMintParams) error {
+	_, err := q.exec(ctx, q.clearPaymentReceiptMintStmt, clearPaymentReceiptMint, arg.ID, arg.ReceiptMint)
+	return err
+}
+
+const confirmPaymentReceiptMint = `-- name: ConfirmPaymentReceiptMint :exec
+UPDATE payments SET receipt_confirmed = true WHERE id = $1 AND receipt_mint = $2::VARCHAR
+`
+
+type ConfirmPaymentReceiptMintParams struct {
+	ID          uuid.UUID `json:"id"`
+	ReceiptMint string    `json:"receipt_mint"`
+}
+
+func (q *Queries) ConfirmPaymentReceiptMint(ctx context.Context, arg ConfirmPaymentReceiptMintParams) error {
+	_, err := q.exec(ctx, q.confirmPaymentReceiptMintStmt, confirmPaymentReceiptMint, arg.ID, arg.ReceiptMint)
+	return err
+}
+
 const createPayment = `-- name: CreatePayment :one
 INSERT INTO payments (
     external_id, 
@@ -31,7 +63,7 @@ VALUES (
     $6, 
     $7
 )
-RETURNING id, external_id, destination_wallet, destination_mint, amount, status, message, expires_at, created_at, updated_at
+RETURNING id, external_id, destination_wallet, destination_mint, amount, status, message, expires_at, created_at, updated_at, receipt_mint, receipt_tx_signature, receipt_tx_blockhash, receipt_confirmed
 `
 
 type CreatePaymentParams struct {
@@ -66,12 +98,16 @@ func (q *Queries) CreatePayment(ctx context.Context, arg CreatePaymentParams) (P
 		&i.ExpiresAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ReceiptMint,
+		&i.ReceiptTxSignature,
+		&i.ReceiptTxBlockhash,
+		&i.ReceiptConfirmed,
 	)
 	return i, err
 }
 
 const getPayment = `-- name: GetPayment :one
-SELECT id, external_id, destination_wallet, destination_mint, amount, status, message, expires_at, created_at, updated_at FROM payments WHERE id = $1
+SELECT id, external_id, destination_wallet, destination_mint, amount, status, message, expires_at, created_at, updated_at, receipt_mint, receipt_tx_signature, receipt_tx_blockhash, receipt_confirmed FROM payments WHERE id = $1
 `
 
 func (q *Queries) GetPayment(ctx context.Context, id uuid.UUID) (Payment, error) {
@@ -88,12 +124,16 @@ func (q *Queries) GetPayment(ctx context.Context, id uuid.UUID) (Payment, error)
 		&i.ExpiresAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ReceiptMint,
+		&i.ReceiptTxSignature,
+		&i.ReceiptTxBlockhash,
+		&i.ReceiptConfirmed,
 	)
 	return i, err
 }
 
 const getPaymentByExternalID = `-- name: GetPaymentByExternalID :one
-SELECT id, external_id, destination_wallet, destination_mint, amount, status, message, expires_at, created_at, updated_at FROM payments WHERE external_id = $1::VARCHAR
+SELECT id, external_id, destination_wallet, destination_mint, amount, status, message, expires_at, created_at, updated_at, receipt_mint, receipt_tx_signature, receipt_tx_blockhash, receipt_confirmed FROM payments WHERE external_id = $1::VARCHAR
 `
 
 func (q *Queries) GetPaymentByExternalID(ctx context.Context, externalID string) (Payment, error) {
@@ -110,21 +150,138 @@ func (q *Queries) GetPaymentByExternalID(ctx context.Context, externalID string)
 		&i.ExpiresAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ReceiptMint,
+		&i.ReceiptTxSignature,
+		&i.ReceiptTxBlockhash,
+		&i.ReceiptConfirmed,
 	)
 	return i, err
 }
 
-const markPaymentsExpired = `-- name: MarkPaymentsExpired :exec
-UPDATE payments SET status = 'expired'::payment_status WHERE expires_at < NOW() AND status = 'new'::payment_status
+const getPaymentForUpdate = `-- name: GetPaymentForUpdate :one
+SELECT id, external_id, destination_wallet, destination_mint, amount, status, message, expires_at, created_at, updated_at, receipt_mint, receipt_tx_signature, receipt_tx_blockhash, receipt_confirmed FROM payments WHERE id = $1 FOR UPDATE
 `
 
-func (q *Queries) MarkPaymentsExpired(ctx context.Context) error {
-	_, err := q.exec(ctx, q.markPaymentsExpiredStmt, markPaymentsExpired)
-	return err
+func (q *Queries) GetPaymentForUpdate(ctx context.Context, id uuid.UUID) (Payment, error) {
+	row := q.queryRow(ctx, q.getPaymentForUpdateStmt, getPaymentForUpdate, id)
+	var i Payment
+	err := row.Scan(
+		&i.ID,
+		&i.ExternalID,
+		&i.DestinationWallet,
+		&i.DestinationMint,
+		&i.Amount,
+		&i.Status,
+		&i.Message,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ReceiptMint,
+		&i.ReceiptTxSignature,
+		&i.ReceiptTxBlockhash,
+		&i.ReceiptConfirmed,
+	)
+	return i, err
+}
+
+const markPaymentsExpired = `-- name: MarkPaymentsExpired :many
+-- The payments are expired in batches, the locked ones are left to the next batch.
+UPDATE payments SET status = 'expired'::payment_status 
+WHERE id IN (
+    SELECT id FROM payments 
+    WHERE expires_at < NOW() AND status = 'new'::payment_status
+    ORDER BY expires_at
+    LIMIT $1::int
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, external_id, destination_wallet, destination_mint, amount, status, message, expires_at, created_at, updated_at, receipt_mint, receipt_tx_signature, receipt_tx_blockhash, receipt_confirmed
+`
+
+func (q *Queries) MarkPaymentsExpired(ctx context.Context, batchSize int32) ([]Payment, error) {
+	rows, err := q.query(ctx, q.markPaymentsExpiredStmt, markPaymentsExpired, batchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Payment
+	for rows.Next() {
+		var i Payment
+		if err := rows.Scan(
+			&i.ID,
+			&i.ExternalID,
+			&i.DestinationWallet,
+			&i.DestinationMint,
+			&i.Amount,
+			&i.Status,
+			&i.Message,
+			&i.ExpiresAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.ReceiptMint,
+			&i.ReceiptTxSignature,
+			&i.ReceiptTxBlockhash,
+			&i.ReceiptConfirmed,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updatePaymentReceiptMint = `-- name: UpdatePaymentReceiptMint :one
+-- The receipt mint is pending until its transaction is confirmed.
+UPDATE payments
+SET receipt_mint = $1::VARCHAR,
+    receipt_tx_signature = $2::VARCHAR,
+    receipt_tx_blockhash = $3::VARCHAR,
+    receipt_confirmed = false
+WHERE id = $4 AND receipt_mint IS NULL
+RETURNING id, external_id, destination_wallet, destination_mint, amount, status, message, expires_at, created_at, updated_at, receipt_mint, receipt_tx_signature, receipt_tx_blockhash, receipt_confirmed
+`
+
+type UpdatePaymentReceiptMintParams struct {
+	ReceiptMint        string    `json:"receipt_mint"`
+	ReceiptTxSignature string    `json:"receipt_tx_signature"`
+	ReceiptTxBlockhash string    `json:"receipt_tx_blockhash"`
+	ID                 uuid.UUID `json:"id"`
+}
+
+func (q *Queries) UpdatePaymentReceiptMint(ctx context.Context, arg UpdatePaymentReceiptMintParams) (Payment, error) {
+	row := q.queryRow(ctx, q.updatePaymentReceiptMintStmt, updatePaymentReceiptMint,
+		arg.ReceiptMint,
+		arg.ReceiptTxSignature,
+		arg.ReceiptTxBlockhash,
+		arg.ID,
+	)
+	var i Payment
+	err := row.Scan(
+		&i.ID,
+		&i.ExternalID,
+		&i.DestinationWallet,
+		&i.DestinationMint,
+		&i.Amount,
+		&i.Status,
+		&i.Message,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ReceiptMint,
+		&i.ReceiptTxSignature,
+		&i.ReceiptTxBlockhash,
+		&i.ReceiptConfirmed,
+	)
+	return i, err
 }
 
 const updatePaymentStatus = `-- name: UpdatePaymentStatus :one
-UPDATE payments SET status = $1 WHERE id = $2 RETURNING id, external_id, destination_wallet, destination_mint, amount, status, message, expires_at, created_at, updated_at
+UPDATE payments SET status = $1 WHERE id = $2 RETURNING id, external_id, destination_wallet, destination_mint, amount, status, message, expires_at, created_at, updated_at, receipt_mint, receipt_tx_signature, receipt_tx_blockhash, receipt_confirmed
 `
 
 type UpdatePaymentStatusParams struct {
@@ -146,6 +303,10 @@ func (q *Queries) UpdatePaymentStatus(ctx context.Context, arg UpdatePaymentStat
 		&i.ExpiresAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ReceiptMint,
+		&i.ReceiptTxSignature,
+		&i.ReceiptTxBlockhash,
+		&i.ReceiptConfirmed,
 	)
 	return i, err
 }
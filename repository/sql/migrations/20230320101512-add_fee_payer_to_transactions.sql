@@ -0,0 +1,14 @@
+
+-- +migrate Up
+-- +migrate StatementBegin
+ALTER TABLE transactions ADD COLUMN fee_payer VARCHAR DEFAULT NULL;
+ALTER TABLE transactions ADD COLUMN sponsored_amount BIGINT NOT NULL DEFAULT 0;
+CREATE INDEX transactions_sponsored ON transactions USING BTREE (created_at) WHERE sponsored_amount > 0;
+-- +migrate StatementEnd
+
+-- +migrate Down
+-- +migrate StatementBegin
+DROP INDEX IF EXISTS transactions_sponsored;
+ALTER TABLE transactions DROP COLUMN IF EXISTS sponsored_amount;
+ALTER TABLE transactions DROP COLUMN IF EXISTS fee_payer;
+-- +migrate StatementEnd
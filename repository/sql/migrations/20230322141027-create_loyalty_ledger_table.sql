@@ -0,0 +1,24 @@
+
+-- +migrate Up
+-- +migrate StatementBegin
+CREATE TYPE loyalty_entry_type AS ENUM ('accrual', 'redemption', 'adjustment', 'expiry');
+
+CREATE TABLE IF NOT EXISTS loyalty_ledger (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    wallet VARCHAR NOT NULL,
+    mint VARCHAR NOT NULL,
+    entry_type loyalty_entry_type NOT NULL,
+    amount BIGINT NOT NULL,
+    transaction_id uuid DEFAULT NULL REFERENCES transactions(id) ON DELETE SET NULL,
+    note VARCHAR DEFAULT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+CREATE INDEX loyalty_ledger_wallet ON loyalty_ledger USING BTREE (wallet, mint, created_at);
+CREATE UNIQUE INDEX loyalty_ledger_transaction ON loyalty_ledger USING BTREE (transaction_id, entry_type) WHERE transaction_id IS NOT NULL;
+-- +migrate StatementEnd
+
+-- +migrate Down
+-- +migrate StatementBegin
+DROP TABLE IF EXISTS loyalty_ledger;
+DROP TYPE IF EXISTS loyalty_entry_type;
+-- +migrate StatementEnd
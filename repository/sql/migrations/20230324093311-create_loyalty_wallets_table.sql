@@ -0,0 +1,14 @@
+
+-- +migrate Up
+-- +migrate StatementBegin
+CREATE TABLE IF NOT EXISTS loyalty_wallets (
+    wallet VARCHAR PRIMARY KEY,
+    tier VARCHAR NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT now()
+);
+-- +migrate StatementEnd
+
+-- +migrate Down
+-- +migrate StatementBegin
+DROP TABLE IF EXISTS loyalty_wallets;
+-- +migrate StatementEnd
@@ -0,0 +1,28 @@
+
+-- +migrate Up
+-- +migrate StatementBegin
+CREATE TYPE bonus_rule_action AS ENUM ('accrual_multiplier', 'flat_bonus', 'discount_percent', 'free_item');
+
+CREATE TABLE IF NOT EXISTS bonus_rules (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR NOT NULL,
+    priority INTEGER NOT NULL DEFAULT 0,
+    conditions JSONB NOT NULL DEFAULT '{}'::jsonb,
+    action bonus_rule_action NOT NULL,
+    action_value BIGINT NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+CREATE INDEX bonus_rules_active ON bonus_rules USING BTREE (priority) WHERE active = true;
+
+ALTER TABLE transactions ADD COLUMN promo_discount_amount BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE transactions ADD COLUMN applied_rules JSONB NOT NULL DEFAULT '[]'::jsonb;
+-- +migrate StatementEnd
+
+-- +migrate Down
+-- +migrate StatementBegin
+ALTER TABLE transactions DROP COLUMN IF EXISTS applied_rules;
+ALTER TABLE transactions DROP COLUMN IF EXISTS promo_discount_amount;
+DROP TABLE IF EXISTS bonus_rules;
+DROP TYPE IF EXISTS bonus_rule_action;
+-- +migrate StatementEnd
@@ -0,0 +1,32 @@
+
+-- +migrate Up
+-- +migrate StatementBegin
+CREATE TYPE coupon_discount_type AS ENUM ('percent', 'fixed');
+
+CREATE TABLE IF NOT EXISTS coupons (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    code VARCHAR NOT NULL,
+    discount_type coupon_discount_type NOT NULL,
+    discount_value BIGINT NOT NULL,
+    min_amount BIGINT NOT NULL DEFAULT 0,
+    usage_limit BIGINT NOT NULL DEFAULT 0,
+    wallet_usage_limit BIGINT NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP DEFAULT NULL,
+    active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+CREATE UNIQUE INDEX coupons_code ON coupons USING BTREE (code);
+
+ALTER TABLE transactions ADD COLUMN coupon_id uuid DEFAULT NULL REFERENCES coupons(id) ON DELETE SET NULL;
+ALTER TABLE transactions ADD COLUMN coupon_discount_amount BIGINT NOT NULL DEFAULT 0;
+CREATE INDEX transactions_coupon_id ON transactions USING BTREE (coupon_id) WHERE coupon_id IS NOT NULL;
+-- +migrate StatementEnd
+
+-- +migrate Down
+-- +migrate StatementBegin
+DROP INDEX IF EXISTS transactions_coupon_id;
+ALTER TABLE transactions DROP COLUMN IF EXISTS coupon_discount_amount;
+ALTER TABLE transactions DROP COLUMN IF EXISTS coupon_id;
+DROP TABLE IF EXISTS coupons;
+DROP TYPE IF EXISTS coupon_discount_type;
+-- +migrate StatementEnd
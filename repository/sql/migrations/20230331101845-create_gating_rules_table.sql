@@ -0,0 +1,29 @@
+
+-- +migrate Up
+-- +migrate StatementBegin
+CREATE TYPE gating_rule_type AS ENUM ('collection', 'creator', 'token_balance');
+
+CREATE TABLE IF NOT EXISTS gating_rules (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR NOT NULL,
+    rule_type gating_rule_type NOT NULL,
+    address VARCHAR NOT NULL,
+    min_balance BIGINT NOT NULL DEFAULT 0,
+    discount_percent BIGINT NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+ALTER TABLE transactions ADD COLUMN gating_rule_id uuid DEFAULT NULL REFERENCES gating_rules(id) ON DELETE SET NULL;
+ALTER TABLE transactions ADD COLUMN gating_asset VARCHAR DEFAULT NULL;
+ALTER TABLE transactions ADD COLUMN gating_discount_amount BIGINT NOT NULL DEFAULT 0;
+-- +migrate StatementEnd
+
+-- +migrate Down
+-- +migrate StatementBegin
+ALTER TABLE transactions DROP COLUMN IF EXISTS gating_discount_amount;
+ALTER TABLE transactions DROP COLUMN IF EXISTS gating_asset;
+ALTER TABLE transactions DROP COLUMN IF EXISTS gating_rule_id;
+DROP TABLE IF EXISTS gating_rules;
+DROP TYPE IF EXISTS gating_rule_type;
+-- +migrate StatementEnd
@@ -0,0 +1,9 @@
+-- +migrate Up
+-- +migrate StatementBegin
+ALTER TABLE payments ADD COLUMN receipt_mint VARCHAR DEFAULT NULL;
+-- +migrate StatementEnd
+
+-- +migrate Down
+-- +migrate StatementBegin
+ALTER TABLE payments DROP COLUMN IF EXISTS receipt_mint;
+-- +migrate StatementEnd
@@ -0,0 +1,40 @@
+
+-- +migrate Up
+-- +migrate StatementBegin
+CREATE TABLE IF NOT EXISTS gift_cards (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    code VARCHAR NOT NULL,
+    mint VARCHAR NOT NULL,
+    initial_amount BIGINT NOT NULL,
+    balance BIGINT NOT NULL,
+    expires_at TIMESTAMP DEFAULT NULL,
+    active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+CREATE UNIQUE INDEX gift_cards_code ON gift_cards USING BTREE (code);
+
+CREATE TYPE gift_card_entry_type AS ENUM ('issue', 'redemption');
+
+CREATE TABLE IF NOT EXISTS gift_card_ledger (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    gift_card_id uuid NOT NULL REFERENCES gift_cards(id) ON DELETE CASCADE,
+    entry_type gift_card_entry_type NOT NULL,
+    amount BIGINT NOT NULL,
+    transaction_id uuid DEFAULT NULL REFERENCES transactions(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+CREATE INDEX gift_card_ledger_gift_card ON gift_card_ledger USING BTREE (gift_card_id, created_at);
+CREATE UNIQUE INDEX gift_card_ledger_transaction ON gift_card_ledger USING BTREE (transaction_id) WHERE transaction_id IS NOT NULL;
+
+ALTER TABLE transactions ADD COLUMN gift_card_id uuid DEFAULT NULL REFERENCES gift_cards(id) ON DELETE SET NULL;
+ALTER TABLE transactions ADD COLUMN gift_card_amount BIGINT NOT NULL DEFAULT 0;
+-- +migrate StatementEnd
+
+-- +migrate Down
+-- +migrate StatementBegin
+ALTER TABLE transactions DROP COLUMN IF EXISTS gift_card_amount;
+ALTER TABLE transactions DROP COLUMN IF EXISTS gift_card_id;
+DROP TABLE IF EXISTS gift_card_ledger;
+DROP TYPE IF EXISTS gift_card_entry_type;
+DROP TABLE IF EXISTS gift_cards;
+-- +migrate StatementEnd
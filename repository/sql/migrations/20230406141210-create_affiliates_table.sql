@@ -0,0 +1,57 @@
+
+-- +migrate Up
+-- +migrate StatementBegin
+CREATE TYPE affiliate_commission_type AS ENUM ('percent', 'fixed');
+CREATE TYPE affiliate_payout_mode AS ENUM ('inline', 'accrued');
+CREATE TYPE affiliate_commission_status AS ENUM ('accrued', 'processing', 'paid');
+
+CREATE TABLE IF NOT EXISTS affiliates (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR NOT NULL,
+    ref_code VARCHAR NOT NULL,
+    wallet VARCHAR NOT NULL,
+    commission_type affiliate_commission_type NOT NULL,
+    commission_value BIGINT NOT NULL,
+    payout_mode affiliate_payout_mode NOT NULL DEFAULT 'accrued',
+    active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+CREATE UNIQUE INDEX affiliates_ref_code ON affiliates USING BTREE (ref_code);
+
+ALTER TABLE transactions ADD COLUMN ref_code VARCHAR DEFAULT NULL;
+ALTER TABLE transactions ADD COLUMN affiliate_id uuid DEFAULT NULL REFERENCES affiliates(id) ON DELETE SET NULL;
+ALTER TABLE transactions ADD COLUMN affiliate_commission BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE transactions ADD COLUMN affiliate_commission_inline BOOLEAN NOT NULL DEFAULT false;
+CREATE INDEX transactions_affiliate_id ON transactions USING BTREE (affiliate_id) WHERE affiliate_id IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS affiliate_commissions (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    affiliate_id uuid NOT NULL REFERENCES affiliates(id) ON DELETE CASCADE,
+    transaction_id uuid NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+    payment_id uuid NOT NULL REFERENCES payments(id) ON DELETE CASCADE,
+    mint VARCHAR NOT NULL,
+    sale_amount BIGINT NOT NULL,
+    amount BIGINT NOT NULL,
+    status affiliate_commission_status NOT NULL,
+    payout_signature VARCHAR DEFAULT NULL,
+    payout_at TIMESTAMP DEFAULT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+CREATE UNIQUE INDEX affiliate_commissions_transaction ON affiliate_commissions USING BTREE (transaction_id);
+CREATE INDEX affiliate_commissions_affiliate ON affiliate_commissions USING BTREE (affiliate_id, created_at);
+CREATE INDEX affiliate_commissions_payout_signature ON affiliate_commissions USING BTREE (payout_signature) WHERE payout_signature IS NOT NULL;
+-- +migrate StatementEnd
+
+-- +migrate Down
+-- +migrate StatementBegin
+DROP TABLE IF EXISTS affiliate_commissions;
+DROP INDEX IF EXISTS transactions_affiliate_id;
+ALTER TABLE transactions DROP COLUMN IF EXISTS affiliate_commission_inline;
+ALTER TABLE transactions DROP COLUMN IF EXISTS affiliate_commission;
+ALTER TABLE transactions DROP COLUMN IF EXISTS affiliate_id;
+ALTER TABLE transactions DROP COLUMN IF EXISTS ref_code;
+DROP TABLE IF EXISTS affiliates;
+DROP TYPE IF EXISTS affiliate_commission_status;
+DROP TYPE IF EXISTS affiliate_payout_mode;
+DROP TYPE IF EXISTS affiliate_commission_type;
+-- +migrate StatementEnd
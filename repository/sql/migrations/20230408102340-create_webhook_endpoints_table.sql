@@ -0,0 +1,19 @@
+-- +migrate Up
+-- +migrate StatementBegin
+CREATE TABLE IF NOT EXISTS webhook_endpoints (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    url VARCHAR NOT NULL,
+    secret VARCHAR NOT NULL,
+    description VARCHAR DEFAULT NULL,
+    event_types TEXT[] NOT NULL DEFAULT '{}',
+    enabled BOOLEAN NOT NULL DEFAULT true,
+    updated_at TIMESTAMP DEFAULT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+CREATE INDEX webhook_endpoints_enabled ON webhook_endpoints USING BTREE (enabled);
+-- +migrate StatementEnd
+
+-- +migrate Down
+-- +migrate StatementBegin
+DROP TABLE IF EXISTS webhook_endpoints;
+-- +migrate StatementEnd
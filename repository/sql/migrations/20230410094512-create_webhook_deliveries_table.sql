@@ -0,0 +1,27 @@
+-- +migrate Up
+-- +migrate StatementBegin
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    endpoint_id uuid NOT NULL,
+    url VARCHAR NOT NULL,
+    event VARCHAR NOT NULL,
+    event_id uuid NOT NULL,
+    request_body JSONB NOT NULL,
+    response_status INTEGER DEFAULT NULL,
+    response_body TEXT DEFAULT NULL,
+    latency_ms INTEGER NOT NULL DEFAULT 0,
+    error TEXT DEFAULT NULL,
+    succeeded BOOLEAN NOT NULL DEFAULT false,
+    replay_of uuid DEFAULT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+CREATE INDEX webhook_deliveries_endpoint_id_created_at ON webhook_deliveries USING BTREE (endpoint_id, created_at);
+CREATE INDEX webhook_deliveries_event_created_at ON webhook_deliveries USING BTREE (event, created_at);
+CREATE INDEX webhook_deliveries_event_id ON webhook_deliveries USING BTREE (event_id);
+CREATE INDEX webhook_deliveries_created_at ON webhook_deliveries USING BTREE (created_at);
+-- +migrate StatementEnd
+
+-- +migrate Down
+-- +migrate StatementBegin
+DROP TABLE IF EXISTS webhook_deliveries;
+-- +migrate StatementEnd
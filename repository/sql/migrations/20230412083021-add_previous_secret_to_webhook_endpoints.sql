@@ -0,0 +1,11 @@
+-- +migrate Up
+-- +migrate StatementBegin
+ALTER TABLE webhook_endpoints ADD COLUMN previous_secret VARCHAR DEFAULT NULL;
+ALTER TABLE webhook_endpoints ADD COLUMN previous_secret_expires_at TIMESTAMP DEFAULT NULL;
+-- +migrate StatementEnd
+
+-- +migrate Down
+-- +migrate StatementBegin
+ALTER TABLE webhook_endpoints DROP COLUMN IF EXISTS previous_secret_expires_at;
+ALTER TABLE webhook_endpoints DROP COLUMN IF EXISTS previous_secret;
+-- +migrate StatementEnd
@@ -0,0 +1,10 @@
+-- +migrate Up
+-- +migrate StatementBegin
+-- Existing endpoints keep receiving the payloads they were built for.
+ALTER TABLE webhook_endpoints ADD COLUMN api_version VARCHAR NOT NULL DEFAULT '2023-03-10';
+-- +migrate StatementEnd
+
+-- +migrate Down
+-- +migrate StatementBegin
+ALTER TABLE webhook_endpoints DROP COLUMN IF EXISTS api_version;
+-- +migrate StatementEnd
@@ -0,0 +1,32 @@
+-- +migrate Up
+-- +migrate StatementBegin
+ALTER TABLE webhook_endpoints ADD COLUMN consecutive_failures INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE webhook_endpoints ADD COLUMN failing_since TIMESTAMP DEFAULT NULL;
+ALTER TABLE webhook_endpoints ADD COLUMN last_success_at TIMESTAMP DEFAULT NULL;
+ALTER TABLE webhook_endpoints ADD COLUMN last_failure_at TIMESTAMP DEFAULT NULL;
+ALTER TABLE webhook_endpoints ADD COLUMN paused_until TIMESTAMP DEFAULT NULL;
+ALTER TABLE webhook_endpoints ADD COLUMN disabled_reason VARCHAR DEFAULT NULL;
+CREATE INDEX webhook_endpoints_paused_until ON webhook_endpoints USING BTREE (paused_until) WHERE paused_until IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS webhook_parked_events (
+    id BIGSERIAL PRIMARY KEY,
+    endpoint_id uuid NOT NULL REFERENCES webhook_endpoints(id) ON DELETE CASCADE,
+    event_id uuid NOT NULL,
+    event VARCHAR NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+CREATE INDEX webhook_parked_events_endpoint_id ON webhook_parked_events USING BTREE (endpoint_id, id);
+-- +migrate StatementEnd
+
+-- +migrate Down
+-- +migrate StatementBegin
+DROP TABLE IF EXISTS webhook_parked_events;
+DROP INDEX IF EXISTS webhook_endpoints_paused_until;
+ALTER TABLE webhook_endpoints DROP COLUMN IF EXISTS disabled_reason;
+ALTER TABLE webhook_endpoints DROP COLUMN IF EXISTS paused_until;
+ALTER TABLE webhook_endpoints DROP COLUMN IF EXISTS last_failure_at;
+ALTER TABLE webhook_endpoints DROP COLUMN IF EXISTS last_success_at;
+ALTER TABLE webhook_endpoints DROP COLUMN IF EXISTS failing_since;
+ALTER TABLE webhook_endpoints DROP COLUMN IF EXISTS consecutive_failures;
+-- +migrate StatementEnd
@@ -0,0 +1,21 @@
+-- +migrate Up
+-- +migrate StatementBegin
+CREATE TABLE IF NOT EXISTS events_outbox (
+    id BIGSERIAL PRIMARY KEY,
+    event VARCHAR NOT NULL,
+    payload JSONB NOT NULL,
+    payment_id uuid DEFAULT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT DEFAULT NULL,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT now(),
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    published_at TIMESTAMP DEFAULT NULL
+);
+CREATE INDEX events_outbox_unpublished ON events_outbox USING BTREE (id) WHERE published_at IS NULL;
+CREATE INDEX events_outbox_payment_id ON events_outbox USING BTREE (payment_id, id);
+-- +migrate StatementEnd
+
+-- +migrate Down
+-- +migrate StatementBegin
+DROP TABLE IF EXISTS events_outbox;
+-- +migrate StatementEnd
@@ -0,0 +1,9 @@
+-- +migrate Up
+-- +migrate StatementBegin
+ALTER TABLE events_outbox ADD COLUMN source VARCHAR NOT NULL DEFAULT '';
+-- +migrate StatementEnd
+
+-- +migrate Down
+-- +migrate StatementBegin
+ALTER TABLE events_outbox DROP COLUMN IF EXISTS source;
+-- +migrate StatementEnd
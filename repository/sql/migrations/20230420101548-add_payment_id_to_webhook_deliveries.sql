@@ -0,0 +1,11 @@
+-- +migrate Up
+-- +migrate StatementBegin
+ALTER TABLE webhook_deliveries ADD COLUMN payment_id uuid DEFAULT NULL;
+CREATE INDEX webhook_deliveries_payment_id ON webhook_deliveries USING BTREE (payment_id, created_at);
+-- +migrate StatementEnd
+
+-- +migrate Down
+-- +migrate StatementBegin
+DROP INDEX IF EXISTS webhook_deliveries_payment_id;
+ALTER TABLE webhook_deliveries DROP COLUMN IF EXISTS payment_id;
+-- +migrate StatementEnd
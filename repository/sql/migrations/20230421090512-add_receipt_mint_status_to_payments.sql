@@ -0,0 +1,14 @@
+-- +migrate Up
+-- +migrate StatementBegin
+ALTER TABLE payments ADD COLUMN receipt_tx_signature VARCHAR DEFAULT NULL;
+ALTER TABLE payments ADD COLUMN receipt_tx_blockhash VARCHAR DEFAULT NULL;
+ALTER TABLE payments ADD COLUMN receipt_confirmed BOOLEAN NOT NULL DEFAULT false;
+UPDATE payments SET receipt_confirmed = true WHERE receipt_mint IS NOT NULL;
+-- +migrate StatementEnd
+
+-- +migrate Down
+-- +migrate StatementBegin
+ALTER TABLE payments DROP COLUMN IF EXISTS receipt_confirmed;
+ALTER TABLE payments DROP COLUMN IF EXISTS receipt_tx_blockhash;
+ALTER TABLE payments DROP COLUMN IF EXISTS receipt_tx_signature;
+-- +migrate StatementEnd
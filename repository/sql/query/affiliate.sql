@@ -0,0 +1,70 @@
+-- name: CreateAffiliate :one
+INSERT INTO affiliates (name, ref_code, wallet, commission_type, commission_value, payout_mode, active)
+VALUES (@name, @ref_code, @wallet, @commission_type, @commission_value, @payout_mode, @active)
+RETURNING *;
+
+-- name: GetAffiliate :one
+SELECT * FROM affiliates WHERE id = @id;
+
+-- name: GetAffiliateByRefCode :one
+SELECT * FROM affiliates WHERE ref_code = @ref_code;
+
+-- name: GetAffiliates :many
+SELECT * FROM affiliates ORDER BY created_at DESC;
+
+-- name: UpdateAffiliateStatus :one
+UPDATE affiliates SET active = @active WHERE id = @id RETURNING *;
+
+-- name: CreateAffiliateCommission :one
+INSERT INTO affiliate_commissions (affiliate_id, transaction_id, payment_id, mint, sale_amount, amount, status, payout_signature, payout_at)
+VALUES (@affiliate_id, @transaction_id, @payment_id, @mint, @sale_amount, @amount, @status, @payout_signature, @payout_at)
+ON CONFLICT (transaction_id) DO NOTHING
+RETURNING *;
+
+-- name: GetAffiliateCommissions :many
+SELECT * FROM affiliate_commissions 
+WHERE affiliate_id = @affiliate_id 
+    AND created_at >= @since 
+    AND created_at < @until
+ORDER BY created_at DESC;
+
+-- name: GetAffiliateCommissionsByStatus :many
+SELECT * FROM affiliate_commissions WHERE affiliate_id = @affiliate_id AND status = @status ORDER BY created_at;
+
+-- name: GetAccruedAffiliateCommissionsForUpdate :many
+SELECT * FROM affiliate_commissions 
+WHERE affiliate_id = @affiliate_id 
+    AND status = 'accrued'::affiliate_commission_status 
+ORDER BY created_at 
+FOR UPDATE;
+
+-- name: StartAffiliateCommissionPayout :exec
+UPDATE affiliate_commissions 
+SET status = 'processing'::affiliate_commission_status, payout_signature = @payout_signature, payout_at = now() 
+WHERE id = @id AND status = 'accrued'::affiliate_commission_status;
+
+-- name: CompleteAffiliatePayout :exec
+UPDATE affiliate_commissions 
+SET status = 'paid'::affiliate_commission_status 
+WHERE payout_signature = @payout_signature AND status = 'processing'::affiliate_commission_status;
+
+-- name: RevertAffiliatePayout :exec
+UPDATE affiliate_commissions 
+SET status = 'accrued'::affiliate_commission_status, payout_signature = NULL, payout_at = NULL 
+WHERE payout_signature = @payout_signature AND status = 'processing'::affiliate_commission_status;
+
+-- name: GetAffiliateReport :many
+SELECT 
+    mint,
+    COUNT(*) AS payments,
+    COALESCE(SUM(sale_amount), 0)::bigint AS sales_amount,
+    COALESCE(SUM(amount), 0)::bigint AS commission_amount,
+    COALESCE(SUM(amount) FILTER (WHERE status = 'accrued'::affiliate_commission_status), 0)::bigint AS accrued_amount,
+    COALESCE(SUM(amount) FILTER (WHERE status = 'processing'::affiliate_commission_status), 0)::bigint AS processing_amount,
+    COALESCE(SUM(amount) FILTER (WHERE status = 'paid'::affiliate_commission_status), 0)::bigint AS paid_amount
+FROM affiliate_commissions 
+WHERE affiliate_id = @affiliate_id 
+    AND created_at >= @since 
+    AND created_at < @until
+GROUP BY mint
+ORDER BY mint;
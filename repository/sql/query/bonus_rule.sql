@@ -0,0 +1,16 @@
+-- name: CreateBonusRule :one
+INSERT INTO bonus_rules (name, priority, conditions, action, action_value, active)
+VALUES (@name, @priority, @conditions, @action, @action_value, @active)
+RETURNING *;
+
+-- name: GetBonusRules :many
+SELECT * FROM bonus_rules ORDER BY priority DESC, created_at;
+
+-- name: GetActiveBonusRules :many
+SELECT * FROM bonus_rules WHERE active = true ORDER BY priority DESC, created_at;
+
+-- name: UpdateBonusRuleStatus :one
+UPDATE bonus_rules SET active = @active WHERE id = @id RETURNING *;
+
+-- name: DeleteBonusRule :exec
+DELETE FROM bonus_rules WHERE id = @id;
@@ -0,0 +1,28 @@
+-- name: CreateCoupon :one
+INSERT INTO coupons (code, discount_type, discount_value, min_amount, usage_limit, wallet_usage_limit, expires_at, active)
+VALUES (@code, @discount_type, @discount_value, @min_amount, @usage_limit, @wallet_usage_limit, @expires_at, @active)
+RETURNING *;
+
+-- name: GetCouponByCode :one
+SELECT * FROM coupons WHERE code = @code;
+
+-- name: GetCouponForUpdate :one
+SELECT * FROM coupons WHERE id = @id FOR UPDATE;
+
+-- name: GetCoupons :many
+SELECT * FROM coupons ORDER BY created_at DESC;
+
+-- name: UpdateCouponStatus :one
+UPDATE coupons SET active = @active WHERE id = @id RETURNING *;
+
+-- name: DeleteCoupon :exec
+DELETE FROM coupons WHERE id = @id;
+
+-- name: GetCouponUsage :one
+SELECT 
+    COUNT(DISTINCT payment_id) AS total,
+    COUNT(DISTINCT payment_id) FILTER (WHERE source_wallet = @source_wallet) AS wallet
+FROM transactions 
+WHERE coupon_id = @coupon_id 
+    AND payment_id <> @payment_id
+    AND status IN ('pending'::transaction_status, 'completed'::transaction_status);
@@ -0,0 +1,16 @@
+-- name: CreateGatingRule :one
+INSERT INTO gating_rules (name, rule_type, address, min_balance, discount_percent, active)
+VALUES (@name, @rule_type, @address, @min_balance, @discount_percent, @active)
+RETURNING *;
+
+-- name: GetGatingRules :many
+SELECT * FROM gating_rules ORDER BY created_at;
+
+-- name: GetActiveGatingRules :many
+SELECT * FROM gating_rules WHERE active = true ORDER BY discount_percent DESC;
+
+-- name: UpdateGatingRuleStatus :one
+UPDATE gating_rules SET active = @active WHERE id = @id RETURNING *;
+
+-- name: DeleteGatingRule :exec
+DELETE FROM gating_rules WHERE id = @id;
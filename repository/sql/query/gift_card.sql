@@ -0,0 +1,59 @@
+-- name: CreateGiftCard :one
+INSERT INTO gift_cards (
+    code,
+    mint,
+    initial_amount,
+    balance,
+    expires_at
+)
+VALUES (
+    @code,
+    @mint,
+    @initial_amount,
+    @balance,
+    @expires_at
+)
+RETURNING *;
+
+-- name: GetGiftCard :one
+SELECT * FROM gift_cards WHERE id = @id;
+
+-- name: GetGiftCardByCode :one
+SELECT * FROM gift_cards WHERE code = @code;
+
+-- name: GetGiftCardForUpdate :one
+SELECT * FROM gift_cards WHERE id = @id FOR UPDATE;
+
+-- name: GetGiftCards :many
+SELECT * FROM gift_cards ORDER BY created_at DESC;
+
+-- name: UpdateGiftCardStatus :one
+UPDATE gift_cards SET active = @active WHERE id = @id RETURNING *;
+
+-- name: UpdateGiftCardBalance :one
+UPDATE gift_cards SET balance = balance + @amount::bigint WHERE id = @id RETURNING *;
+
+-- name: CreateGiftCardLedgerEntry :one
+INSERT INTO gift_card_ledger (
+    gift_card_id,
+    entry_type,
+    amount,
+    transaction_id
+)
+VALUES (
+    @gift_card_id,
+    @entry_type,
+    @amount,
+    @transaction_id
+)
+ON CONFLICT (transaction_id) WHERE transaction_id IS NOT NULL DO NOTHING
+RETURNING *;
+
+-- name: GetGiftCardLedgerEntries :many
+SELECT * FROM gift_card_ledger WHERE gift_card_id = @gift_card_id ORDER BY created_at DESC;
+
+-- name: GetGiftCardHeldAmount :one
+SELECT COALESCE(SUM(gift_card_amount), 0)::bigint AS held FROM transactions 
+WHERE gift_card_id = @gift_card_id 
+    AND status = 'pending'::transaction_status 
+    AND payment_id != @payment_id;
@@ -0,0 +1,41 @@
+-- name: CreateLoyaltyLedgerEntry :exec
+INSERT INTO loyalty_ledger (
+    wallet,
+    mint,
+    entry_type,
+    amount,
+    transaction_id,
+    note
+)
+VALUES (
+    @wallet,
+    @mint,
+    @entry_type,
+    @amount,
+    @transaction_id,
+    @note
+)
+ON CONFLICT (transaction_id, entry_type) WHERE transaction_id IS NOT NULL DO NOTHING;
+
+-- name: GetLoyaltyBalance :one
+SELECT COALESCE(SUM(amount), 0)::bigint AS balance FROM loyalty_ledger WHERE wallet = @wallet AND mint = @mint;
+
+-- name: GetLoyaltyLedgerEntries :many
+SELECT * FROM loyalty_ledger 
+WHERE wallet = @wallet AND mint = @mint 
+ORDER BY created_at DESC 
+LIMIT @limit OFFSET @offset;
+
+-- name: GetLoyaltyBalances :many
+SELECT wallet, COALESCE(SUM(amount), 0)::bigint AS balance FROM loyalty_ledger 
+WHERE mint = @mint 
+GROUP BY wallet 
+ORDER BY wallet;
+
+-- name: GetLoyaltyWalletTier :one
+SELECT tier FROM loyalty_wallets WHERE wallet = @wallet;
+
+-- name: UpsertLoyaltyWalletTier :exec
+INSERT INTO loyalty_wallets (wallet, tier) 
+VALUES (@wallet, @tier) 
+ON CONFLICT (wallet) DO UPDATE SET tier = EXCLUDED.tier, updated_at = now();
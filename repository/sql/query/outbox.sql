@@ -0,0 +1,33 @@
+-- name: CreateOutboxEvent :exec
+INSERT INTO events_outbox (event, payload, payment_id, source) VALUES (@event, @payload, @payment_id, @source);
+
+-- name: LockOutboxRelay :one
+SELECT pg_try_advisory_xact_lock(@lock_key::bigint)::boolean AS locked;
+
+-- name: GetDueOutboxEvents :many
+-- The event is skipped while an earlier event of the same payment waits for the retry.
+-- The id order of the events of the same payment is their commit order only because
+-- the payment row is locked while the event is recorded, see outbox.Record.
+SELECT * FROM events_outbox o
+WHERE o.published_at IS NULL 
+    AND o.next_attempt_at <= now()
+    AND NOT EXISTS (
+        SELECT 1 FROM events_outbox p 
+        WHERE p.payment_id = o.payment_id 
+            AND p.published_at IS NULL 
+            AND p.id < o.id 
+            AND p.next_attempt_at > now()
+    )
+ORDER BY o.id
+LIMIT @limit;
+
+-- name: MarkOutboxEventPublished :exec
+UPDATE events_outbox SET published_at = now() WHERE id = @id;
+
+-- name: RecordOutboxEventFailure :exec
+UPDATE events_outbox 
+SET attempts = attempts + 1, last_error = @last_error, next_attempt_at = @next_attempt_at 
+WHERE id = @id;
+
+-- name: GetPaymentEvents :many
+SELECT * FROM events_outbox WHERE payment_id = @payment_id ORDER BY id;
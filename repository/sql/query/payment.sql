@@ -28,5 +28,37 @@ SELECT * FROM payments WHERE external_id = @external_id::VARCHAR;
 -- name: UpdatePaymentStatus :one
 UPDATE payments SET status = @status WHERE id = @id RETURNING *;
 
--- name: MarkPaymentsExpired :exec
-UPDATE payments SET status = 'expired'::payment_status WHERE expires_at < NOW() AND status = 'new'::payment_status;
\ No newline at end of file
+-- name: MarkPaymentsExpired :many
+-- The payments are expired in batches, the locked ones are left to the next batch.
+UPDATE payments SET status = 'expired'::payment_status 
+WHERE id IN (
+    SELECT id FROM payments 
+    WHERE expires_at < NOW() AND status = 'new'::payment_status
+    ORDER BY expires_at
+    LIMIT @batch_size::int
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: UpdatePaymentReceiptMint :one
+-- The receipt mint is pending until its transaction is confirmed.
+UPDATE payments
+SET receipt_mint = @receipt_mint::VARCHAR,
+    receipt_tx_signature = @receipt_tx_signature::VARCHAR,
+    receipt_tx_blockhash = @receipt_tx_blockhash::VARCHAR,
+    receipt_confirmed = false
+WHERE id = @id AND receipt_mint IS NULL
+RETURNING *;
+
+-- name: ConfirmPaymentReceiptMint :exec
+UPDATE payments SET receipt_confirmed = true WHERE id = @id AND receipt_mint = @receipt_mint::VARCHAR;
+
+-- name: ClearPaymentReceiptMint :exec
+UPDATE payments
+SET receipt_mint = NULL,
+    receipt_tx_signature = NULL,
+    receipt_tx_blockhash = NULL
+WHERE id = @id AND receipt_mint = @receipt_mint::VARCHAR AND receipt_confirmed = false;
+
+-- name: GetPaymentForUpdate :one
+SELECT * FROM payments WHERE id = @id FOR UPDATE;
@@ -13,6 +13,21 @@ INSERT INTO transactions (
     message,
     memo,
     apply_bonus,
+    fee_payer,
+    sponsored_amount,
+    promo_discount_amount,
+    applied_rules,
+    coupon_id,
+    coupon_discount_amount,
+    gating_rule_id,
+    gating_asset,
+    gating_discount_amount,
+    gift_card_id,
+    gift_card_amount,
+    ref_code,
+    affiliate_id,
+    affiliate_commission,
+    affiliate_commission_inline,
     status
 ) 
 VALUES (
@@ -29,6 +44,21 @@ VALUES (
     @message,
     @memo,
     @apply_bonus,
+    @fee_payer,
+    @sponsored_amount,
+    @promo_discount_amount,
+    @applied_rules,
+    @coupon_id,
+    @coupon_discount_amount,
+    @gating_rule_id,
+    @gating_asset,
+    @gating_discount_amount,
+    @gift_card_id,
+    @gift_card_amount,
+    @ref_code,
+    @affiliate_id,
+    @affiliate_commission,
+    @affiliate_commission_inline,
     @status
 )
 RETURNING *;
@@ -57,8 +87,34 @@ LIMIT 1;
 -- name: GetPendingTransactions :many
 SELECT * FROM transactions WHERE status = 'pending'::transaction_status;
 
--- name: MarkTransactionsAsExpired :exec
+-- name: MarkTransactionsAsExpired :many
+-- The transactions are expired in batches, the locked ones are left to the next batch.
 UPDATE transactions SET status = 'expired'::transaction_status 
-WHERE status = 'pending'::transaction_status AND payment_id IN (
-    SELECT id FROM payments WHERE status = 'expired'::payment_status
-);
\ No newline at end of file
+WHERE id IN (
+    SELECT t.id FROM transactions t
+    WHERE t.status = 'pending'::transaction_status AND t.payment_id IN (
+        SELECT id FROM payments WHERE status = 'expired'::payment_status
+    )
+    ORDER BY t.created_at
+    LIMIT @batch_size::int
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: GetSponsoredAmountSince :one
+-- Only the submitted transactions spend the budget, the built but never signed ones don't.
+SELECT COALESCE(SUM(sponsored_amount), 0)::bigint AS sponsored_amount FROM transactions 
+WHERE created_at >= @since 
+    AND (status = 'completed'::transaction_status
+        OR (status = 'pending'::transaction_status AND tx_signature IS NOT NULL));
+
+-- name: LockSponsorBudget :exec
+-- Serializes the sponsored transactions, so the daily budget is checked and spent atomically.
+-- The lock is released when the database transaction ends.
+SELECT pg_advisory_xact_lock(@lock_key::bigint);
+
+-- name: GetWalletSpendStats :many
+-- The spend is grouped by destination mint, since the amounts of different mints are in different base units.
+SELECT destination_mint, COUNT(*) AS purchases, COALESCE(SUM(total_amount), 0)::bigint AS spend FROM transactions 
+WHERE source_wallet = @source_wallet AND status = 'completed'::transaction_status
+GROUP BY destination_mint;
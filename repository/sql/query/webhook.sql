@@ -0,0 +1,154 @@
+-- name: CreateWebhookEndpoint :one
+INSERT INTO webhook_endpoints (
+    url,
+    secret,
+    description,
+    event_types,
+    enabled,
+    api_version
+)
+VALUES (
+    @url,
+    @secret,
+    @description,
+    @event_types,
+    @enabled,
+    @api_version
+)
+RETURNING *;
+
+-- name: GetWebhookEndpoint :one
+SELECT * FROM webhook_endpoints WHERE id = @id;
+
+-- name: GetWebhookEndpoints :many
+SELECT * FROM webhook_endpoints ORDER BY created_at DESC;
+
+-- name: GetSubscribedWebhookEndpoints :many
+-- The endpoints disabled by the circuit breaker get the events too, they're parked till the endpoint is enabled.
+SELECT * FROM webhook_endpoints
+WHERE enabled = true OR disabled_reason = 'failing_delivery'
+ORDER BY created_at;
+
+-- name: UpdateWebhookEndpoint :one
+UPDATE webhook_endpoints
+SET url = @url,
+    description = @description,
+    event_types = @event_types,
+    enabled = @enabled,
+    api_version = @api_version,
+    updated_at = now()
+WHERE id = @id
+RETURNING *;
+
+-- name: RotateWebhookEndpointSecret :one
+UPDATE webhook_endpoints
+SET previous_secret = secret,
+    previous_secret_expires_at = @previous_secret_expires_at,
+    secret = @secret,
+    updated_at = now()
+WHERE id = @id
+RETURNING *;
+
+-- name: DeleteWebhookEndpoint :execrows
+DELETE FROM webhook_endpoints WHERE id = @id;
+
+-- name: CreateWebhookDelivery :one
+INSERT INTO webhook_deliveries (
+    endpoint_id,
+    url,
+    event,
+    event_id,
+    request_body,
+    response_status,
+    response_body,
+    latency_ms,
+    error,
+    succeeded,
+    replay_of,
+    payment_id
+)
+VALUES (
+    @endpoint_id,
+    @url,
+    @event,
+    @event_id,
+    @request_body,
+    @response_status,
+    @response_body,
+    @latency_ms,
+    @error,
+    @succeeded,
+    @replay_of,
+    @payment_id
+)
+RETURNING *;
+
+-- name: GetWebhookDelivery :one
+SELECT * FROM webhook_deliveries WHERE id = @id;
+
+-- name: GetWebhookDeliveries :many
+SELECT * FROM webhook_deliveries
+WHERE created_at >= @since
+    AND created_at < @until
+    AND (@any_endpoint::boolean OR endpoint_id = @endpoint_id)
+    AND (@event::varchar = '' OR event = @event)
+    AND (@any_event_id::boolean OR event_id = @event_id)
+    AND (@any_payment_id::boolean OR payment_id = @payment_id::uuid)
+    AND (@status::varchar = '' OR succeeded = (@status = 'succeeded'))
+ORDER BY created_at DESC
+LIMIT @limit OFFSET @offset;
+
+-- name: RecordWebhookEndpointSuccess :exec
+UPDATE webhook_endpoints
+SET consecutive_failures = 0,
+    failing_since = NULL,
+    last_success_at = now()
+WHERE id = @id;
+
+-- name: RecordWebhookEndpointFailure :one
+UPDATE webhook_endpoints
+SET consecutive_failures = consecutive_failures + 1,
+    failing_since = COALESCE(failing_since, now()),
+    last_failure_at = now()
+WHERE id = @id
+RETURNING *;
+
+-- name: PauseWebhookEndpoint :exec
+UPDATE webhook_endpoints SET paused_until = @paused_until WHERE id = @id;
+
+-- name: ResumeWebhookEndpoint :execrows
+UPDATE webhook_endpoints SET paused_until = NULL
+WHERE id = @id AND NOT EXISTS (SELECT 1 FROM webhook_parked_events WHERE endpoint_id = webhook_endpoints.id);
+
+-- name: DisableFailingWebhookEndpoint :execrows
+UPDATE webhook_endpoints
+SET enabled = false,
+    disabled_reason = @disabled_reason,
+    updated_at = now()
+WHERE id = @id AND enabled = true;
+
+-- name: ReenableWebhookEndpoint :exec
+UPDATE webhook_endpoints
+SET disabled_reason = NULL,
+    consecutive_failures = 0,
+    failing_since = NULL,
+    paused_until = now()
+WHERE id = @id;
+
+-- name: GetWebhookEndpointsToFlush :many
+SELECT * FROM webhook_endpoints
+WHERE enabled = true AND paused_until IS NOT NULL AND paused_until <= now()
+ORDER BY paused_until;
+
+-- name: CreateWebhookParkedEvent :exec
+INSERT INTO webhook_parked_events (endpoint_id, event_id, event, payload)
+VALUES (@endpoint_id, @event_id, @event, @payload);
+
+-- name: GetWebhookParkedEvents :many
+SELECT * FROM webhook_parked_events WHERE endpoint_id = @endpoint_id ORDER BY id LIMIT @limit;
+
+-- name: DeleteWebhookParkedEvent :exec
+DELETE FROM webhook_parked_events WHERE id = @id;
+
+-- name: CountWebhookParkedEvents :one
+SELECT COUNT(*)::bigint AS parked FROM webhook_parked_events WHERE endpoint_id = @endpoint_id;
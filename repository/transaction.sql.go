@@ -8,6 +8,8 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -27,6 +29,21 @@ INSERT INTO transactions (
     message,
     memo,
     apply_bonus,
+    fee_payer,
+    sponsored_amount,
+    promo_discount_amount,
+    applied_rules,
+    coupon_id,
+    coupon_discount_amount,
+    gating_rule_id,
+    gating_asset,
+    gating_discount_amount,
+    gift_card_id,
+    gift_card_amount,
+    ref_code,
+    affiliate_id,
+    affiliate_commission,
+    affiliate_commission_inline,
     status
 ) 
 VALUES (
@@ -43,26 +60,56 @@ VALUES (
     $11,
     $12,
     $13,
-    $14
+    $14,
+    $15,
+    $16,
+    $17,
+    $18,
+    $19,
+    $20,
+    $21,
+    $22,
+    $23,
+    $24,
+    $25,
+    $26,
+    $27,
+    $28,
+    $29
 )
-RETURNING id, payment_id, reference, source_wallet, source_mint, destination_wallet, destination_mint, amount, discount_amount, total_amount, accrued_bonus_amount, message, memo, apply_bonus, tx_signature, status, created_at, updated_at
+RETURNING id, payment_id, reference, source_wallet, source_mint, destination_wallet, destination_mint, amount, discount_amount, total_amount, accrued_bonus_amount, message, memo, apply_bonus, tx_signature, status, created_at, updated_at, fee_payer, sponsored_amount, promo_discount_amount, applied_rules, coupon_id, coupon_discount_amount, gating_rule_id, gating_asset, gating_discount_amount, gift_card_id, gift_card_amount, ref_code, affiliate_id, affiliate_commission, affiliate_commission_inline
 `
 
 type CreateTransactionParams struct {
-	PaymentID          uuid.UUID         `json:"payment_id"`
-	Reference          string            `json:"reference"`
-	SourceWallet       string            `json:"source_wallet"`
-	SourceMint         string            `json:"source_mint"`
-	DestinationWallet  string            `json:"destination_wallet"`
-	DestinationMint    string            `json:"destination_mint"`
-	Amount             int64             `json:"amount"`
-	DiscountAmount     int64             `json:"discount_amount"`
-	TotalAmount        int64             `json:"total_amount"`
-	AccruedBonusAmount int64             `json:"accrued_bonus_amount"`
-	Message            sql.NullString    `json:"message"`
-	Memo               sql.NullString    `json:"memo"`
-	ApplyBonus         sql.NullBool      `json:"apply_bonus"`
-	Status             TransactionStatus `json:"status"`
+	PaymentID                 uuid.UUID         `json:"payment_id"`
+	Reference                 string            `json:"reference"`
+	SourceWallet              string            `json:"source_wallet"`
+	SourceMint                string            `json:"source_mint"`
+	DestinationWallet         string            `json:"destination_wallet"`
+	DestinationMint           string            `json:"destination_mint"`
+	Amount                    int64             `json:"amount"`
+	DiscountAmount            int64             `json:"discount_amount"`
+	TotalAmount               int64             `json:"total_amount"`
+	AccruedBonusAmount        int64             `json:"accrued_bonus_amount"`
+	Message                   sql.NullString    `json:"message"`
+	Memo                      sql.NullString    `json:"memo"`
+	ApplyBonus                sql.NullBool      `json:"apply_bonus"`
+	FeePayer                  sql.NullString    `json:"fee_payer"`
+	SponsoredAmount           int64             `json:"sponsored_amount"`
+	PromoDiscountAmount       int64             `json:"promo_discount_amount"`
+	AppliedRules              json.RawMessage   `json:"applied_rules"`
+	CouponID                  uuid.NullUUID     `json:"coupon_id"`
+	CouponDiscountAmount      int64             `json:"coupon_discount_amount"`
+	GatingRuleID              uuid.NullUUID     `json:"gating_rule_id"`
+	GatingAsset               sql.NullString    `json:"gating_asset"`
+	GatingDiscountAmount      int64             `json:"gating_discount_amount"`
+	GiftCardID                uuid.NullUUID     `json:"gift_card_id"`
+	GiftCardAmount            int64             `json:"gift_card_amount"`
+	RefCode                   sql.NullString    `json:"ref_code"`
+	AffiliateID               uuid.NullUUID     `json:"affiliate_id"`
+	AffiliateCommission       int64             `json:"affiliate_commission"`
+	AffiliateCommissionInline bool              `json:"affiliate_commission_inline"`
+	Status                    TransactionStatus `json:"status"`
 }
 
 func (q *Queries) CreateTransaction(ctx context.Context, arg CreateTransactionParams) (Transaction, error) {
@@ -80,6 +127,21 @@ func (q *Queries) CreateTransaction(ctx context.Context, arg CreateTransactionPa
 		arg.Message,
 		arg.Memo,
 		arg.ApplyBonus,
+		arg.FeePayer,
+		arg.SponsoredAmount,
+		arg.PromoDiscountAmount,
+		arg.AppliedRules,
+		arg.CouponID,
+		arg.CouponDiscountAmount,
+		arg.GatingRuleID,
+		arg.GatingAsset,
+		arg.GatingDiscountAmount,
+		arg.GiftCardID,
+		arg.GiftCardAmount,
+		arg.RefCode,
+		arg.AffiliateID,
+		arg.AffiliateCommission,
+		arg.AffiliateCommissionInline,
 		arg.Status,
 	)
 	var i Transaction
@@ -102,12 +164,27 @@ func (q *Queries) CreateTransaction(ctx context.Context, arg CreateTransactionPa
 		&i.Status,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.FeePayer,
+		&i.SponsoredAmount,
+		&i.PromoDiscountAmount,
+		&i.AppliedRules,
+		&i.CouponID,
+		&i.CouponDiscountAmount,
+		&i.GatingRuleID,
+		&i.GatingAsset,
+		&i.GatingDiscountAmount,
+		&i.GiftCardID,
+		&i.GiftCardAmount,
+		&i.RefCode,
+		&i.AffiliateID,
+		&i.AffiliateCommission,
+		&i.AffiliateCommissionInline,
 	)
 	return i, err
 }
 
 const getPendingTransactions = `-- name: GetPendingTransactions :many
-SELECT id, payment_id, reference, source_wallet, source_mint, destination_wallet, destination_mint, amount, discount_amount, total_amount, accrued_bonus_amount, message, memo, apply_bonus, tx_signature, status, created_at, updated_at FROM transactions WHERE status = 'pending'::transaction_status
+SELECT id, payment_id, reference, source_wallet, source_mint, destination_wallet, destination_mint, amount, discount_amount, total_amount, accrued_bonus_amount, message, memo, apply_bonus, tx_signature, status, created_at, updated_at, fee_payer, sponsored_amount, promo_discount_amount, applied_rules, coupon_id, coupon_discount_amount, gating_rule_id, gating_asset, gating_discount_amount, gift_card_id, gift_card_amount, ref_code, affiliate_id, affiliate_commission, affiliate_commission_inline FROM transactions WHERE status = 'pending'::transaction_status
 `
 
 func (q *Queries) GetPendingTransactions(ctx context.Context) ([]Transaction, error) {
@@ -138,6 +215,21 @@ func (q *Queries) GetPendingTransactions(ctx context.Context) ([]Transaction, er
 			&i.Status,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.FeePayer,
+			&i.SponsoredAmount,
+			&i.PromoDiscountAmount,
+			&i.AppliedRules,
+			&i.CouponID,
+			&i.CouponDiscountAmount,
+			&i.GatingRuleID,
+			&i.GatingAsset,
+			&i.GatingDiscountAmount,
+			&i.GiftCardID,
+			&i.GiftCardAmount,
+			&i.RefCode,
+			&i.AffiliateID,
+			&i.AffiliateCommission,
+			&i.AffiliateCommissionInline,
 		); err != nil {
 			return nil, err
 		}
@@ -152,8 +244,23 @@ func (q *Queries) GetPendingTransactions(ctx context.Context) ([]Transaction, er
 	return items, nil
 }
 
+const getSponsoredAmountSince = `-- name: GetSponsoredAmountSince :one
+-- Only the submitted transactions spend the budget, the built but never signed ones don't.
+SELECT COALESCE(SUM(sponsored_amount), 0)::bigint AS sponsored_amount FROM transactions 
+WHERE created_at >= $1 
+    AND (status = 'completed'::transaction_status
+        OR (status = 'pending'::transaction_status AND tx_signature IS NOT NULL))
+`
+
+func (q *Queries) GetSponsoredAmountSince(ctx context.Context, since time.Time) (int64, error) {
+	row := q.queryRow(ctx, q.getSponsoredAmountSinceStmt, getSponsoredAmountSince, since)
+	var sponsoredAmount int64
+	err := row.Scan(&sponsoredAmount)
+	return sponsoredAmount, err
+}
+
 const getTransaction = `-- name: GetTransaction :one
-SELECT id, payment_id, reference, source_wallet, source_mint, destination_wallet, destination_mint, amount, discount_amount, total_amount, accrued_bonus_amount, message, memo, apply_bonus, tx_signature, status, created_at, updated_at FROM transactions WHERE id = $1
+SELECT id, payment_id, reference, source_wallet, source_mint, destination_wallet, destination_mint, amount, discount_amount, total_amount, accrued_bonus_amount, message, memo, apply_bonus, tx_signature, status, created_at, updated_at, fee_payer, sponsored_amount, promo_discount_amount, applied_rules, coupon_id, coupon_discount_amount, gating_rule_id, gating_asset, gating_discount_amount, gift_card_id, gift_card_amount, ref_code, affiliate_id, affiliate_commission, affiliate_commission_inline FROM transactions WHERE id = $1
 `
 
 func (q *Queries) GetTransaction(ctx context.Context, id uuid.UUID) (Transaction, error) {
@@ -178,12 +285,27 @@ func (q *Queries) GetTransaction(ctx context.Context, id uuid.UUID) (Transaction
 		&i.Status,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.FeePayer,
+		&i.SponsoredAmount,
+		&i.PromoDiscountAmount,
+		&i.AppliedRules,
+		&i.CouponID,
+		&i.CouponDiscountAmount,
+		&i.GatingRuleID,
+		&i.GatingAsset,
+		&i.GatingDiscountAmount,
+		&i.GiftCardID,
+		&i.GiftCardAmount,
+		&i.RefCode,
+		&i.AffiliateID,
+		&i.AffiliateCommission,
+		&i.AffiliateCommissionInline,
 	)
 	return i, err
 }
 
 const getTransactionByPaymentIDSourceWalletAndMint = `-- name: GetTransactionByPaymentIDSourceWalletAndMint :one
-SELECT id, payment_id, reference, source_wallet, source_mint, destination_wallet, destination_mint, amount, discount_amount, total_amount, accrued_bonus_amount, message, memo, apply_bonus, tx_signature, status, created_at, updated_at FROM transactions 
+SELECT id, payment_id, reference, source_wallet, source_mint, destination_wallet, destination_mint, amount, discount_amount, total_amount, accrued_bonus_amount, message, memo, apply_bonus, tx_signature, status, created_at, updated_at, fee_payer, sponsored_amount, promo_discount_amount, applied_rules, coupon_id, coupon_discount_amount, gating_rule_id, gating_asset, gating_discount_amount, gift_card_id, gift_card_amount, ref_code, affiliate_id, affiliate_commission, affiliate_commission_inline FROM transactions 
 WHERE payment_id = $1 
     AND source_wallet = $2 
     AND source_mint = $3
@@ -220,12 +342,27 @@ func (q *Queries) GetTransactionByPaymentIDSourceWalletAndMint(ctx context.Conte
 		&i.Status,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.FeePayer,
+		&i.SponsoredAmount,
+		&i.PromoDiscountAmount,
+		&i.AppliedRules,
+		&i.CouponID,
+		&i.CouponDiscountAmount,
+		&i.GatingRuleID,
+		&i.GatingAsset,
+		&i.GatingDiscountAmount,
+		&i.GiftCardID,
+		&i.GiftCardAmount,
+		&i.RefCode,
+		&i.AffiliateID,
+		&i.AffiliateCommission,
+		&i.AffiliateCommissionInline,
 	)
 	return i, err
 }
 
 const getTransactionByReference = `-- name: GetTransactionByReference :one
-SELECT id, payment_id, reference, source_wallet, source_mint, destination_wallet, destination_mint, amount, discount_amount, total_amount, accrued_bonus_amount, message, memo, apply_bonus, tx_signature, status, created_at, updated_at FROM transactions WHERE reference = $1
+SELECT id, payment_id, reference, source_wallet, source_mint, destination_wallet, destination_mint, amount, discount_amount, total_amount, accrued_bonus_amount, message, memo, apply_bonus, tx_signature, status, created_at, updated_at, fee_payer, sponsored_amount, promo_discount_amount, applied_rules, coupon_id, coupon_discount_amount, gating_rule_id, gating_asset, gating_discount_amount, gift_card_id, gift_card_amount, ref_code, affiliate_id, affiliate_commission, affiliate_commission_inline FROM transactions WHERE reference = $1
 `
 
 func (q *Queries) GetTransactionByReference(ctx context.Context, reference string) (Transaction, error) {
@@ -250,12 +387,27 @@ func (q *Queries) GetTransactionByReference(ctx context.Context, reference strin
 		&i.Status,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.FeePayer,
+		&i.SponsoredAmount,
+		&i.PromoDiscountAmount,
+		&i.AppliedRules,
+		&i.CouponID,
+		&i.CouponDiscountAmount,
+		&i.GatingRuleID,
+		&i.GatingAsset,
+		&i.GatingDiscountAmount,
+		&i.GiftCardID,
+		&i.GiftCardAmount,
+		&i.RefCode,
+		&i.AffiliateID,
+		&i.AffiliateCommission,
+		&i.AffiliateCommissionInline,
 	)
 	return i, err
 }
 
 const getTransactionsByPaymentID = `-- name: GetTransactionsByPaymentID :many
-SELECT id, payment_id, reference, source_wallet, source_mint, destination_wallet, destination_mint, amount, discount_amount, total_amount, accrued_bonus_amount, message, memo, apply_bonus, tx_signature, status, created_at, updated_at FROM transactions WHERE payment_id = $1 ORDER BY created_at DESC
+SELECT id, payment_id, reference, source_wallet, source_mint, destination_wallet, destination_mint, amount, discount_amount, total_amount, accrued_bonus_amount, message, memo, apply_bonus, tx_signature, status, created_at, updated_at, fee_payer, sponsored_amount, promo_discount_amount, applied_rules, coupon_id, coupon_discount_amount, gating_rule_id, gating_asset, gating_discount_amount, gift_card_id, gift_card_amount, ref_code, affiliate_id, affiliate_commission, affiliate_commission_inline FROM transactions WHERE payment_id = $1 ORDER BY created_at DESC
 `
 
 func (q *Queries) GetTransactionsByPaymentID(ctx context.Context, paymentID uuid.UUID) ([]Transaction, error) {
@@ -286,6 +438,21 @@ func (q *Queries) GetTransactionsByPaymentID(ctx context.Context, paymentID uuid
 			&i.Status,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.FeePayer,
+			&i.SponsoredAmount,
+			&i.PromoDiscountAmount,
+			&i.AppliedRules,
+			&i.CouponID,
+			&i.CouponDiscountAmount,
+			&i.GatingRuleID,
+			&i.GatingAsset,
+			&i.GatingDiscountAmount,
+			&i.GiftCardID,
+			&i.GiftCardAmount,
+			&i.RefCode,
+			&i.AffiliateID,
+			&i.AffiliateCommission,
+			&i.AffiliateCommissionInline,
 		); err != nil {
 			return nil, err
 		}
@@ -300,20 +467,127 @@ func (q *Queries) GetTransactionsByPaymentID(ctx context.Context, paymentID uuid
 	return items, nil
 }
 
-const markTransactionsAsExpired = `-- name: MarkTransactionsAsExpired :exec
+const getWalletSpendStats = `-- name: GetWalletSpendStats :many
+-- The spend is grouped by destination mint, since the amounts of different mints are in different base units.
+SELECT destination_mint, COUNT(*) AS purchases, COALESCE(SUM(total_amount), 0)::bigint AS spend FROM transactions 
+WHERE source_wallet = $1 AND status = 'completed'::transaction_status
+GROUP BY destination_mint
+`
+
+type GetWalletSpendStatsRow struct {
+	DestinationMint string `json:"destination_mint"`
+	Purchases       int64  `json:"purchases"`
+	Spend           int64  `json:"spend"`
+}
+
+func (q *Queries) GetWalletSpendStats(ctx context.Context, sourceWallet string) ([]GetWalletSpendStatsRow, error) {
+	rows, err := q.query(ctx, q.getWalletSpendStatsStmt, getWalletSpendStats, sourceWallet)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetWalletSpendStatsRow
+	for rows.Next() {
+		var i GetWalletSpendStatsRow
+		if err := rows.Scan(&i.DestinationMint, &i.Purchases, &i.Spend); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockSponsorBudget = `-- name: LockSponsorBudget :exec
+-- Serializes the sponsored transactions, so the daily budget is checked and spent atomically.
+-- The lock is released when the database transaction ends.
+SELECT pg_advisory_xact_lock($1::bigint)
+`
+
+func (q *Queries) LockSponsorBudget(ctx context.Context, lockKey int64) error {
+	_, err := q.exec(ctx, q.lockSponsorBudgetStmt, lockSponsorBudget, lockKey)
+	return err
+}
+
+const markTransactionsAsExpired = `-- name: MarkTransactionsAsExpired :many
+-- The transactions are expired in batches, the locked ones are left to the next batch.
 UPDATE transactions SET status = 'expired'::transaction_status 
-WHERE status = 'pending'::transaction_status AND payment_id IN (
-    SELECT id FROM payments WHERE status = 'expired'::payment_status
+WHERE id IN (
+    SELECT t.id FROM transactions t
+    WHERE t.status = 'pending'::transaction_status AND t.payment_id IN (
+        SELECT id FROM payments WHERE status = 'expired'::payment_status
+    )
+    ORDER BY t.created_at
+    LIMIT $1::int
+    FOR UPDATE SKIP LOCKED
 )
+RETURNING id, payment_id, reference, source_wallet, source_mint, destination_wallet, destination_mint, amount, discount_amount, total_amount, accrued_bonus_amount, message, memo, apply_bonus, tx_signature, status, created_at, updated_at, fee_payer, sponsored_amount, promo_discount_amount, applied_rules, coupon_id, coupon_discount_amount, gating_rule_id, gating_asset, gating_discount_amount, gift_card_id, gift_card_amount, ref_code, affiliate_id, affiliate_commission, affiliate_commission_inline
 `
 
-func (q *Queries) MarkTransactionsAsExpired(ctx context.Context) error {
-	_, err := q.exec(ctx, q.markTransactionsAsExpiredStmt, markTransactionsAsExpired)
-	return err
+func (q *Queries) MarkTransactionsAsExpired(ctx context.Context, batchSize int32) ([]Transaction, error) {
+	rows, err := q.query(ctx, q.markTransactionsAsExpiredStmt, markTransactionsAsExpired, batchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Transaction
+	for rows.Next() {
+		var i Transaction
+		if err := rows.Scan(
+			&i.ID,
+			&i.PaymentID,
+			&i.Reference,
+			&i.SourceWallet,
+			&i.SourceMint,
+			&i.DestinationWallet,
+			&i.DestinationMint,
+			&i.Amount,
+			&i.DiscountAmount,
+			&i.TotalAmount,
+			&i.AccruedBonusAmount,
+			&i.Message,
+			&i.Memo,
+			&i.ApplyBonus,
+			&i.TxSignature,
+			&i.Status,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.FeePayer,
+			&i.SponsoredAmount,
+			&i.PromoDiscountAmount,
+			&i.AppliedRules,
+			&i.CouponID,
+			&i.CouponDiscountAmount,
+			&i.GatingRuleID,
+			&i.GatingAsset,
+			&i.GatingDiscountAmount,
+			&i.GiftCardID,
+			&i.GiftCardAmount,
+			&i.RefCode,
+			&i.AffiliateID,
+			&i.AffiliateCommission,
+			&i.AffiliateCommissionInline,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
 const updateTransactionByReference = `-- name: UpdateTransactionByReference :one
-UPDATE transactions SET tx_signature = $1, status = $2 WHERE reference = $3 RETURNING id, payment_id, reference, source_wallet, source_mint, destination_wallet, destination_mint, amount, discount_amount, total_amount, accrued_bonus_amount, message, memo, apply_bonus, tx_signature, status, created_at, updated_at
+UPDATE transactions SET tx_signature = $1, status = $2 WHERE reference = $3 RETURNING id, payment_id, reference, source_wallet, source_mint, destination_wallet, destination_mint, amount, discount_amount, total_amount, accrued_bonus_amount, message, memo, apply_bonus, tx_signature, status, created_at, updated_at, fee_payer, sponsored_amount, promo_discount_amount, applied_rules, coupon_id, coupon_discount_amount, gating_rule_id, gating_asset, gating_discount_amount, gift_card_id, gift_card_amount, ref_code, affiliate_id, affiliate_commission, affiliate_commission_inline
 `
 
 type UpdateTransactionByReferenceParams struct {
@@ -344,6 +618,21 @@ func (q *Queries) UpdateTransactionByReference(ctx context.Context, arg UpdateTr
 		&i.Status,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.FeePayer,
+		&i.SponsoredAmount,
+		&i.PromoDiscountAmount,
+		&i.AppliedRules,
+		&i.CouponID,
+		&i.CouponDiscountAmount,
+		&i.GatingRuleID,
+		&i.GatingAsset,
+		&i.GatingDiscountAmount,
+		&i.GiftCardID,
+		&i.GiftCardAmount,
+		&i.RefCode,
+		&i.AffiliateID,
+		&i.AffiliateCommission,
+		&i.AffiliateCommissionInline,
 	)
 	return i, err
 }
@@ -9,7 +9,10 @@ import (
 	"github.com/easypmnt/checkout-api/internal/utils"
 	"github.com/easypmnt/checkout-api/internal/validator"
 	"github.com/easypmnt/checkout-api/jupiter"
+	"github.com/easypmnt/checkout-api/loyalty"
 	"github.com/easypmnt/checkout-api/payments"
+	"github.com/easypmnt/checkout-api/timeline"
+	"github.com/easypmnt/checkout-api/webhook"
 	"github.com/go-kit/kit/endpoint"
 	"github.com/google/uuid"
 )
@@ -22,9 +25,49 @@ type (
 		CancelPayment              endpoint.Endpoint
 		GetPayment                 endpoint.Endpoint
 		GetPaymentByExternalID     endpoint.Endpoint
+		GetPaymentEvents           endpoint.Endpoint
+		GetPaymentTimeline         endpoint.Endpoint
+		IssueSubscriptionToken     endpoint.Endpoint
 		GeneratePaymentLink        endpoint.Endpoint
 		GeneratePaymentTransaction endpoint.Endpoint
+		PreviewPaymentTransaction  endpoint.Endpoint
+		SubmitTransaction          endpoint.Endpoint
 		GetExchangeRate            endpoint.Endpoint
+		GetSupportedTokens         endpoint.Endpoint
+		GetLoyaltyWallet           endpoint.Endpoint
+		CreateBonusRule            endpoint.Endpoint
+		GetBonusRules              endpoint.Endpoint
+		UpdateBonusRuleStatus      endpoint.Endpoint
+		DeleteBonusRule            endpoint.Endpoint
+		CreateCoupon               endpoint.Endpoint
+		GetCoupons                 endpoint.Endpoint
+		UpdateCouponStatus         endpoint.Endpoint
+		DeleteCoupon               endpoint.Endpoint
+		CreateGatingRule           endpoint.Endpoint
+		GetGatingRules             endpoint.Endpoint
+		UpdateGatingRuleStatus     endpoint.Endpoint
+		DeleteGatingRule           endpoint.Endpoint
+		IssueGiftCard              endpoint.Endpoint
+		GetGiftCards               endpoint.Endpoint
+		GetGiftCard                endpoint.Endpoint
+		UpdateGiftCardStatus       endpoint.Endpoint
+		PayWithGiftCard            endpoint.Endpoint
+		CreateAffiliate            endpoint.Endpoint
+		GetAffiliates              endpoint.Endpoint
+		GetAffiliate               endpoint.Endpoint
+		UpdateAffiliateStatus      endpoint.Endpoint
+		GetAffiliateReport         endpoint.Endpoint
+		PayoutAffiliate            endpoint.Endpoint
+		CreateWebhookEndpoint      endpoint.Endpoint
+		GetWebhookEndpoints        endpoint.Endpoint
+		GetWebhookEndpoint         endpoint.Endpoint
+		UpdateWebhookEndpoint      endpoint.Endpoint
+		DeleteWebhookEndpoint      endpoint.Endpoint
+		RotateWebhookSecret        endpoint.Endpoint
+		GetWebhookEndpointHealth   endpoint.Endpoint
+		GetWebhookDeliveries       endpoint.Endpoint
+		GetWebhookDelivery         endpoint.Endpoint
+		ReplayWebhookDelivery      endpoint.Endpoint
 	}
 
 	Config struct {
@@ -39,35 +82,170 @@ type (
 		GetPayment(ctx context.Context, id uuid.UUID) (*payments.Payment, error)
 		// GetPaymentByExternalID returns the payment with the given external ID.
 		GetPaymentByExternalID(ctx context.Context, externalID string) (*payments.Payment, error)
+		// GetPaymentEvents returns the events recorded for the payment, oldest first.
+		GetPaymentEvents(ctx context.Context, paymentID uuid.UUID) ([]*payments.PaymentEvent, error)
 		// GeneratePaymentLink generates a new payment link for the given payment.
-		GeneratePaymentLink(ctx context.Context, paymentID uuid.UUID, mint string, applyBonus bool) (string, error)
+		GeneratePaymentLink(ctx context.Context, paymentID uuid.UUID, mint string, applyBonus bool, coupon, ref string) (string, error)
 		// CancelPayment cancels the payment with the given ID.
 		CancelPayment(ctx context.Context, id uuid.UUID) error
 		// CancelPaymentByExternalID cancels the payment with the given external ID.
 		CancelPaymentByExternalID(ctx context.Context, externalID string) error
 		// BuildTransaction builds a new transaction for the given payment.
 		BuildTransaction(ctx context.Context, tx *payments.Transaction) (*payments.Transaction, error)
+		// PreviewTransaction calculates the payment breakdown without building and storing a transaction.
+		PreviewTransaction(ctx context.Context, tx *payments.Transaction) (*payments.TransactionPreview, error)
 		// GetTransactionByReference returns the transaction with the given reference.
 		GetTransactionByReference(ctx context.Context, reference string) (*payments.Transaction, error)
+		// SubmitTransaction verifies the transaction signed by the customer and sends it to the network.
+		SubmitTransaction(ctx context.Context, reference, signedTx string) (*payments.Transaction, error)
+		// CreateBonusRule creates a new bonus rule.
+		CreateBonusRule(ctx context.Context, rule *payments.BonusRule) (*payments.BonusRule, error)
+		// GetBonusRules returns all the bonus rules ordered by priority.
+		GetBonusRules(ctx context.Context) ([]payments.BonusRule, error)
+		// UpdateBonusRuleStatus enables or disables the bonus rule with the given ID.
+		UpdateBonusRuleStatus(ctx context.Context, id uuid.UUID, active bool) (*payments.BonusRule, error)
+		// DeleteBonusRule deletes the bonus rule with the given ID.
+		DeleteBonusRule(ctx context.Context, id uuid.UUID) error
+		// CreateCoupon creates a new coupon.
+		CreateCoupon(ctx context.Context, coupon *payments.Coupon) (*payments.Coupon, error)
+		// GetCoupons returns all the coupons, newest first.
+		GetCoupons(ctx context.Context) ([]*payments.Coupon, error)
+		// UpdateCouponStatus enables or disables the coupon with the given ID.
+		UpdateCouponStatus(ctx context.Context, id uuid.UUID, active bool) (*payments.Coupon, error)
+		// DeleteCoupon deletes the coupon with the given ID.
+		DeleteCoupon(ctx context.Context, id uuid.UUID) error
+		// CreateGatingRule creates a new discount rule for holders of the NFT collection or the token.
+		CreateGatingRule(ctx context.Context, rule *payments.GatingRule) (*payments.GatingRule, error)
+		// GetGatingRules returns all the gating rules.
+		GetGatingRules(ctx context.Context) ([]payments.GatingRule, error)
+		// UpdateGatingRuleStatus enables or disables the gating rule with the given ID.
+		UpdateGatingRuleStatus(ctx context.Context, id uuid.UUID, active bool) (*payments.GatingRule, error)
+		// DeleteGatingRule deletes the gating rule with the given ID.
+		DeleteGatingRule(ctx context.Context, id uuid.UUID) error
+		// IssueGiftCard issues a new gift card with the initial balance.
+		IssueGiftCard(ctx context.Context, card *payments.GiftCard) (*payments.GiftCard, error)
+		// GetGiftCards returns all the gift cards, newest first.
+		GetGiftCards(ctx context.Context) ([]*payments.GiftCard, error)
+		// GetGiftCard returns the gift card with the given ID and its balance history.
+		GetGiftCard(ctx context.Context, id uuid.UUID) (*payments.GiftCard, error)
+		// UpdateGiftCardStatus enables or disables the gift card with the given ID.
+		UpdateGiftCardStatus(ctx context.Context, id uuid.UUID, active bool) (*payments.GiftCard, error)
+		// PayWithGiftCard pays the whole payment amount with the gift card and completes the payment.
+		PayWithGiftCard(ctx context.Context, paymentID uuid.UUID, code string) (*payments.Transaction, error)
+		// CreateAffiliate creates a new affiliate.
+		CreateAffiliate(ctx context.Context, affiliate *payments.Affiliate) (*payments.Affiliate, error)
+		// GetAffiliates returns all the affiliates, newest first.
+		GetAffiliates(ctx context.Context) ([]*payments.Affiliate, error)
+		// GetAffiliate returns the affiliate with the given ID.
+		GetAffiliate(ctx context.Context, id uuid.UUID) (*payments.Affiliate, error)
+		// UpdateAffiliateStatus enables or disables the affiliate with the given ID.
+		UpdateAffiliateStatus(ctx context.Context, id uuid.UUID, active bool) (*payments.Affiliate, error)
+		// GetAffiliateReport returns the affiliate sales and commissions created in the given period.
+		GetAffiliateReport(ctx context.Context, id uuid.UUID, since, until time.Time) (*payments.AffiliateReport, error)
+		// PayoutAffiliateCommissions pays out all the accrued commissions of the affiliate.
+		PayoutAffiliateCommissions(ctx context.Context, id uuid.UUID) (*payments.AffiliatePayout, error)
 	}
 
 	jupiterClient interface {
 		ExchangeRate(params jupiter.ExchangeRateParams) (jupiter.Rate, error)
 	}
+
+	tokenRegistry interface {
+		// Tokens returns all the tokens which can be used to pay for a payment.
+		Tokens() []payments.Token
+	}
+
+	loyaltyService interface {
+		// GetWallet returns the loyalty balance and history of the given wallet.
+		GetWallet(ctx context.Context, wallet string, limit, offset int32) (*loyalty.Wallet, error)
+	}
+
+	webhookService interface {
+		// CreateEndpoint creates a new webhook endpoint.
+		CreateEndpoint(ctx context.Context, endpoint *webhook.Endpoint) (*webhook.Endpoint, error)
+		// GetEndpoints returns all the webhook endpoints, newest first.
+		GetEndpoints(ctx context.Context) ([]*webhook.Endpoint, error)
+		// GetEndpoint returns the webhook endpoint with the given ID.
+		GetEndpoint(ctx context.Context, id uuid.UUID) (*webhook.Endpoint, error)
+		// UpdateEndpoint updates the URL, description, event types and status of the webhook endpoint.
+		UpdateEndpoint(ctx context.Context, endpoint *webhook.Endpoint) (*webhook.Endpoint, error)
+		// DeleteEndpoint deletes the webhook endpoint with the given ID.
+		DeleteEndpoint(ctx context.Context, id uuid.UUID) error
+		// RotateSecret replaces the secret of the webhook endpoint, the previous one stays active for the given duration.
+		RotateSecret(ctx context.Context, id uuid.UUID, previousSecretTTL time.Duration) (*webhook.Endpoint, error)
+		// EndpointHealth returns the delivery health of the webhook endpoint with the given ID.
+		EndpointHealth(ctx context.Context, id uuid.UUID) (*webhook.EndpointHealth, error)
+		// GetDeliveries returns the webhook deliveries matching the filter, newest first.
+		GetDeliveries(ctx context.Context, filter webhook.DeliveryFilter) ([]*webhook.Delivery, error)
+		// GetDelivery returns the webhook delivery with the given ID.
+		GetDelivery(ctx context.Context, id uuid.UUID) (*webhook.Delivery, error)
+		// Replay sends the request body of the given delivery to its endpoint again.
+		Replay(ctx context.Context, deliveryID uuid.UUID) (*webhook.Delivery, error)
+	}
+
+	timelineService interface {
+		// Timeline returns the human-readable timeline of the payment, oldest first.
+		Timeline(ctx context.Context, paymentID uuid.UUID) ([]timeline.Entry, error)
+	}
+
+	subscriptionTokens interface {
+		// Issue returns the token to subscribe to the events of the payment and its expiration time.
+		Issue(paymentID string) (string, time.Time, error)
+	}
 )
 
 // MakeEndpoints returns an Endpoints struct where each field is an endpoint
 // that comprises the server.
-func MakeEndpoints(ps paymentService, jup jupiterClient, cfg Config) Endpoints {
+func MakeEndpoints(ps paymentService, jup jupiterClient, tokens tokenRegistry, ls loyaltyService, ws webhookService, ts timelineService, st subscriptionTokens, cfg Config) Endpoints {
 	return Endpoints{
 		GetAppInfo:                 makeGetAppInfoEndpoint(cfg),
 		CreatePayment:              makeCreatePaymentEndpoint(ps),
 		CancelPayment:              makeCancelPaymentEndpoint(ps),
 		GetPayment:                 makeGetPaymentEndpoint(ps),
 		GetPaymentByExternalID:     makeGetPaymentByExternalIDEndpoint(ps),
+		GetPaymentEvents:           makeGetPaymentEventsEndpoint(ps),
+		GetPaymentTimeline:         makeGetPaymentTimelineEndpoint(ts),
+		IssueSubscriptionToken:     makeIssueSubscriptionTokenEndpoint(ps, st),
 		GeneratePaymentLink:        makeGeneratePaymentLinkEndpoint(ps),
 		GeneratePaymentTransaction: makeGeneratePaymentTransactionEndpoint(ps),
+		PreviewPaymentTransaction:  makePreviewPaymentTransactionEndpoint(ps),
+		SubmitTransaction:          makeSubmitTransactionEndpoint(ps),
 		GetExchangeRate:            makeGetExchangeRateEndpoint(jup),
+		GetSupportedTokens:         makeGetSupportedTokensEndpoint(tokens),
+		GetLoyaltyWallet:           makeGetLoyaltyWalletEndpoint(ls),
+		CreateBonusRule:            makeCreateBonusRuleEndpoint(ps),
+		GetBonusRules:              makeGetBonusRulesEndpoint(ps),
+		UpdateBonusRuleStatus:      makeUpdateBonusRuleStatusEndpoint(ps),
+		DeleteBonusRule:            makeDeleteBonusRuleEndpoint(ps),
+		CreateCoupon:               makeCreateCouponEndpoint(ps),
+		GetCoupons:                 makeGetCouponsEndpoint(ps),
+		UpdateCouponStatus:         makeUpdateCouponStatusEndpoint(ps),
+		DeleteCoupon:               makeDeleteCouponEndpoint(ps),
+		CreateGatingRule:           makeCreateGatingRuleEndpoint(ps),
+		GetGatingRules:             makeGetGatingRulesEndpoint(ps),
+		UpdateGatingRuleStatus:     makeUpdateGatingRuleStatusEndpoint(ps),
+		DeleteGatingRule:           makeDeleteGatingRuleEndpoint(ps),
+		IssueGiftCard:              makeIssueGiftCardEndpoint(ps),
+		GetGiftCards:               makeGetGiftCardsEndpoint(ps),
+		GetGiftCard:                makeGetGiftCardEndpoint(ps),
+		UpdateGiftCardStatus:       makeUpdateGiftCardStatusEndpoint(ps),
+		PayWithGiftCard:            makePayWithGiftCardEndpoint(ps),
+		CreateAffiliate:            makeCreateAffiliateEndpoint(ps),
+		GetAffiliates:              makeGetAffiliatesEndpoint(ps),
+		GetAffiliate:               makeGetAffiliateEndpoint(ps),
+		UpdateAffiliateStatus:      makeUpdateAffiliateStatusEndpoint(ps),
+		GetAffiliateReport:         makeGetAffiliateReportEndpoint(ps),
+		PayoutAffiliate:            makePayoutAffiliateEndpoint(ps),
+		CreateWebhookEndpoint:      makeCreateWebhookEndpointEndpoint(ws),
+		GetWebhookEndpoints:        makeGetWebhookEndpointsEndpoint(ws),
+		GetWebhookEndpoint:         makeGetWebhookEndpointEndpoint(ws),
+		UpdateWebhookEndpoint:      makeUpdateWebhookEndpointEndpoint(ws),
+		DeleteWebhookEndpoint:      makeDeleteWebhookEndpointEndpoint(ws),
+		RotateWebhookSecret:        makeRotateWebhookSecretEndpoint(ws),
+		GetWebhookEndpointHealth:   makeGetWebhookEndpointHealthEndpoint(ws),
+		GetWebhookDeliveries:       makeGetWebhookDeliveriesEndpoint(ws),
+		GetWebhookDelivery:         makeGetWebhookDeliveryEndpoint(ws),
+		ReplayWebhookDelivery:      makeReplayWebhookDeliveryEndpoint(ws),
 	}
 }
 
@@ -168,6 +346,83 @@ func makeGetPaymentEndpoint(ps paymentService) endpoint.Endpoint {
 	}
 }
 
+// GetPaymentEventsResponse is the response type for the GetPaymentEvents method.
+type GetPaymentEventsResponse struct {
+	Events []*payments.PaymentEvent `json:"events"`
+}
+
+// makeGetPaymentEventsEndpoint returns an endpoint function for the GetPaymentEvents method.
+func makeGetPaymentEventsEndpoint(ps paymentService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		paymentID, ok := request.(uuid.UUID)
+		if !ok {
+			return nil, ErrInvalidRequest
+		}
+
+		// Check the payment exists, so the unknown ID is not an empty history.
+		if _, err := ps.GetPayment(ctx, paymentID); err != nil {
+			return nil, err
+		}
+
+		evts, err := ps.GetPaymentEvents(ctx, paymentID)
+		if err != nil {
+			return nil, err
+		}
+
+		return GetPaymentEventsResponse{Events: evts}, nil
+	}
+}
+
+// GetPaymentTimelineResponse is the response type for the GetPaymentTimeline method.
+type GetPaymentTimelineResponse struct {
+	Timeline []timeline.Entry `json:"timeline"`
+}
+
+// makeGetPaymentTimelineEndpoint returns an endpoint function for the GetPaymentTimeline method.
+func makeGetPaymentTimelineEndpoint(ts timelineService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		paymentID, ok := request.(uuid.UUID)
+		if !ok {
+			return nil, ErrInvalidRequest
+		}
+
+		entries, err := ts.Timeline(ctx, paymentID)
+		if err != nil {
+			return nil, err
+		}
+
+		return GetPaymentTimelineResponse{Timeline: entries}, nil
+	}
+}
+
+// SubscriptionTokenResponse is the response type for the IssueSubscriptionToken method.
+// The token is passed to the checkout widget to subscribe to the payment events via websocket.
+type SubscriptionTokenResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// makeIssueSubscriptionTokenEndpoint returns an endpoint function for the IssueSubscriptionToken method.
+func makeIssueSubscriptionTokenEndpoint(ps paymentService, st subscriptionTokens) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		paymentID, ok := request.(uuid.UUID)
+		if !ok {
+			return nil, ErrInvalidRequest
+		}
+
+		if _, err := ps.GetPayment(ctx, paymentID); err != nil {
+			return nil, err
+		}
+
+		token, expiresAt, err := st.Issue(paymentID.String())
+		if err != nil {
+			return nil, err
+		}
+
+		return SubscriptionTokenResponse{Token: token, ExpiresAt: expiresAt}, nil
+	}
+}
+
 // makeGetPaymentByExternalIDEndpoint returns an endpoint function for the GetPaymentByExternalID method.
 func makeGetPaymentByExternalIDEndpoint(ps paymentService) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
@@ -190,6 +445,8 @@ type GeneratePaymentLinkRequest struct {
 	PaymentID  uuid.UUID `json:"-" validate:"-" label:"Payment ID"`
 	Mint       string    `json:"mint,omitempty" validate:"-" label:"Selected Mint"`
 	ApplyBonus bool      `json:"apply_bonus,omitempty" validate:"bool" label:"Apply Bonus"`
+	Coupon     string    `json:"coupon,omitempty" validate:"-" label:"Coupon Code"`
+	Ref        string    `json:"ref,omitempty" validate:"-" label:"Affiliate Ref Code"`
 }
 
 // GeneratePaymentLinkResponse is the response type for the GeneratePaymentLink method.
@@ -208,7 +465,7 @@ func makeGeneratePaymentLinkEndpoint(ps paymentService) endpoint.Endpoint {
 			return nil, validator.NewValidationError(v)
 		}
 
-		link, err := ps.GeneratePaymentLink(ctx, req.PaymentID, req.Mint, req.ApplyBonus)
+		link, err := ps.GeneratePaymentLink(ctx, req.PaymentID, req.Mint, req.ApplyBonus, req.Coupon, req.Ref)
 		if err != nil {
 			return nil, err
 		}
@@ -223,6 +480,9 @@ type GeneratePaymentTransactionRequest struct {
 	SourceWallet string `json:"account" validate:"required" label:"Account public key"`
 	Mint         string `json:"-" validate:"-"`
 	ApplyBonus   string `json:"-" validate:"bool"`
+	Coupon       string `json:"-" validate:"-"`
+	GiftCard     string `json:"-" validate:"-"`
+	Ref          string `json:"-" validate:"-"`
 }
 
 // GeneratePaymentTransactionResponse is the response type for the GeneratePaymentTransaction method.
@@ -253,6 +513,9 @@ func makeGeneratePaymentTransactionEndpoint(ps paymentService) endpoint.Endpoint
 			SourceWallet: req.SourceWallet,
 			SourceMint:   req.Mint,
 			ApplyBonus:   applyBonus,
+			CouponCode:   req.Coupon,
+			GiftCardCode: req.GiftCard,
+			RefCode:      req.Ref,
 		}
 
 		result, err := ps.BuildTransaction(ctx, tx)
@@ -267,6 +530,93 @@ func makeGeneratePaymentTransactionEndpoint(ps paymentService) endpoint.Endpoint
 	}
 }
 
+// PreviewPaymentTransactionRequest is the request type for the PreviewPaymentTransaction method.
+type PreviewPaymentTransactionRequest struct {
+	PaymentID    string `json:"-" validate:"required|uuid" label:"Payment ID"`
+	SourceWallet string `json:"-" validate:"-" label:"Account public key"`
+	Mint         string `json:"-" validate:"-"`
+	ApplyBonus   string `json:"-" validate:"bool"`
+	Coupon       string `json:"-" validate:"-"`
+	GiftCard     string `json:"-" validate:"-"`
+	Ref          string `json:"-" validate:"-"`
+}
+
+// PreviewPaymentTransactionResponse is the response type for the PreviewPaymentTransaction method.
+type PreviewPaymentTransactionResponse struct {
+	Preview *payments.TransactionPreview `json:"preview"`
+}
+
+// makePreviewPaymentTransactionEndpoint returns an endpoint function for the PreviewPaymentTransaction method.
+func makePreviewPaymentTransactionEndpoint(ps paymentService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(PreviewPaymentTransactionRequest)
+		if !ok {
+			return nil, ErrInvalidRequest
+		}
+		if v := validator.ValidateStruct(req); len(v) > 0 {
+			return nil, validator.NewValidationError(v)
+		}
+
+		paymentID, err := uuid.Parse(req.PaymentID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid payment ID: %v", ErrInvalidParameter, err)
+		}
+
+		applyBonus, _ := strconv.ParseBool(req.ApplyBonus)
+		preview, err := ps.PreviewTransaction(ctx, &payments.Transaction{
+			PaymentID:    paymentID,
+			SourceWallet: req.SourceWallet,
+			SourceMint:   req.Mint,
+			ApplyBonus:   applyBonus,
+			CouponCode:   req.Coupon,
+			GiftCardCode: req.GiftCard,
+			RefCode:      req.Ref,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return PreviewPaymentTransactionResponse{Preview: preview}, nil
+	}
+}
+
+// SubmitTransactionRequest is the request type for the SubmitTransaction method.
+type SubmitTransactionRequest struct {
+	Reference   string `json:"-" validate:"required" label:"Reference"`
+	Transaction string `json:"transaction" validate:"required|base64" label:"Signed transaction"`
+}
+
+// SubmitTransactionResponse is the response type for the SubmitTransaction method.
+type SubmitTransactionResponse struct {
+	Reference string `json:"reference"`
+	Signature string `json:"signature"`
+	Status    string `json:"status"`
+}
+
+// makeSubmitTransactionEndpoint returns an endpoint function for the SubmitTransaction method.
+func makeSubmitTransactionEndpoint(ps paymentService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(SubmitTransactionRequest)
+		if !ok {
+			return nil, ErrInvalidRequest
+		}
+		if v := validator.ValidateStruct(req); len(v) > 0 {
+			return nil, validator.NewValidationError(v)
+		}
+
+		tx, err := ps.SubmitTransaction(ctx, req.Reference, req.Transaction)
+		if err != nil {
+			return nil, err
+		}
+
+		return SubmitTransactionResponse{
+			Reference: tx.Reference,
+			Signature: tx.Signature,
+			Status:    string(tx.Status),
+		}, nil
+	}
+}
+
 // GetExchangeRateRequest is the request type for the GetExchangeRate method.
 type GetExchangeRateRequest struct {
 	InCurrency  string `json:"in_currency" validate:"required" label:"In Currency"`
@@ -306,3 +656,885 @@ func makeGetExchangeRateEndpoint(jup jupiterClient) endpoint.Endpoint {
 		}, nil
 	}
 }
+
+// GetSupportedTokensResponse is the response type for the GetSupportedTokens method.
+type GetSupportedTokensResponse struct {
+	Tokens []payments.Token `json:"tokens"`
+}
+
+// makeGetSupportedTokensEndpoint returns an endpoint function for the GetSupportedTokens method.
+func makeGetSupportedTokensEndpoint(tokens tokenRegistry) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		return GetSupportedTokensResponse{Tokens: tokens.Tokens()}, nil
+	}
+}
+
+// GetLoyaltyWalletRequest is the request type for the GetLoyaltyWallet method.
+type GetLoyaltyWalletRequest struct {
+	Wallet string `json:"-" validate:"required" label:"Wallet"`
+	Limit  int32  `json:"-" validate:"min:1|max:100" label:"Limit"`
+	Offset int32  `json:"-" validate:"min:0" label:"Offset"`
+}
+
+// makeGetLoyaltyWalletEndpoint returns an endpoint function for the GetLoyaltyWallet method.
+func makeGetLoyaltyWalletEndpoint(ls loyaltyService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(GetLoyaltyWalletRequest)
+		if !ok {
+			return nil, ErrInvalidRequest
+		}
+		if v := validator.ValidateStruct(req); len(v) > 0 {
+			return nil, validator.NewValidationError(v)
+		}
+
+		return ls.GetWallet(ctx, req.Wallet, req.Limit, req.Offset)
+	}
+}
+
+// CreateBonusRuleRequest is the request type for the CreateBonusRule method.
+type CreateBonusRuleRequest struct {
+	Name       string                       `json:"name" validate:"required|max_len:100" label:"Name"`
+	Priority   int32                        `json:"priority" validate:"-" label:"Priority"`
+	Conditions payments.BonusRuleConditions `json:"conditions" validate:"-" label:"Conditions"`
+	Action     string                       `json:"action" validate:"required|in:accrual_multiplier,flat_bonus,discount_percent,free_item" label:"Action"`
+	Value      uint64                       `json:"value" validate:"required|gt:0" label:"Value"`
+	Active     *bool                        `json:"active,omitempty" validate:"-" label:"Active"`
+}
+
+// BonusRuleResponse is the response type for the bonus rule methods.
+type BonusRuleResponse struct {
+	Rule *payments.BonusRule `json:"rule"`
+}
+
+// makeCreateBonusRuleEndpoint returns an endpoint function for the CreateBonusRule method.
+func makeCreateBonusRuleEndpoint(ps paymentService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(CreateBonusRuleRequest)
+		if !ok {
+			return nil, ErrInvalidRequest
+		}
+		if v := validator.ValidateStruct(req); len(v) > 0 {
+			return nil, validator.NewValidationError(v)
+		}
+
+		rule, err := ps.CreateBonusRule(ctx, &payments.BonusRule{
+			Name:       req.Name,
+			Priority:   req.Priority,
+			Conditions: req.Conditions,
+			Action:     payments.BonusRuleAction(req.Action),
+			Value:      req.Value,
+			Active:     req.Active == nil || *req.Active,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return BonusRuleResponse{Rule: rule}, nil
+	}
+}
+
+// GetBonusRulesResponse is the response type for the GetBonusRules method.
+type GetBonusRulesResponse struct {
+	Rules []payments.BonusRule `json:"rules"`
+}
+
+// makeGetBonusRulesEndpoint returns an endpoint function for the GetBonusRules method.
+func makeGetBonusRulesEndpoint(ps paymentService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		rules, err := ps.GetBonusRules(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		return GetBonusRulesResponse{Rules: rules}, nil
+	}
+}
+
+// UpdateBonusRuleStatusRequest is the request type for the UpdateBonusRuleStatus method.
+type UpdateBonusRuleStatusRequest struct {
+	RuleID uuid.UUID `json:"-" validate:"-" label:"Rule ID"`
+	Active bool      `json:"active" validate:"bool" label:"Active"`
+}
+
+// makeUpdateBonusRuleStatusEndpoint returns an endpoint function for the UpdateBonusRuleStatus method.
+func makeUpdateBonusRuleStatusEndpoint(ps paymentService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(UpdateBonusRuleStatusRequest)
+		if !ok {
+			return nil, ErrInvalidRequest
+		}
+
+		rule, err := ps.UpdateBonusRuleStatus(ctx, req.RuleID, req.Active)
+		if err != nil {
+			return nil, err
+		}
+
+		return BonusRuleResponse{Rule: rule}, nil
+	}
+}
+
+// makeDeleteBonusRuleEndpoint returns an endpoint function for the DeleteBonusRule method.
+func makeDeleteBonusRuleEndpoint(ps paymentService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		ruleID, ok := request.(uuid.UUID)
+		if !ok {
+			return nil, ErrInvalidRequest
+		}
+
+		if err := ps.DeleteBonusRule(ctx, ruleID); err != nil {
+			return nil, err
+		}
+
+		return nil, nil
+	}
+}
+
+// CreateCouponRequest is the request type for the CreateCoupon method.
+type CreateCouponRequest struct {
+	Code             string     `json:"code" validate:"required|max_len:50" label:"Code"`
+	DiscountType     string     `json:"discount_type" validate:"required|in:percent,fixed" label:"Discount Type"`
+	DiscountValue    uint64     `json:"discount_value" validate:"required|gt:0" label:"Discount Value"`
+	MinAmount        uint64     `json:"min_amount,omitempty" validate:"-" label:"Min Amount"`
+	UsageLimit       uint64     `json:"usage_limit,omitempty" validate:"-" label:"Usage Limit"`
+	WalletUsageLimit uint64     `json:"wallet_usage_limit,omitempty" validate:"-" label:"Wallet Usage Limit"`
+	ExpiresAt        *time.Time `json:"expires_at,omitempty" validate:"-" label:"Expires At"`
+	Active           *bool      `json:"active,omitempty" validate:"-" label:"Active"`
+}
+
+// CouponResponse is the response type for the coupon methods.
+type CouponResponse struct {
+	Coupon *payments.Coupon `json:"coupon"`
+}
+
+// makeCreateCouponEndpoint returns an endpoint function for the CreateCoupon method.
+func makeCreateCouponEndpoint(ps paymentService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(CreateCouponRequest)
+		if !ok {
+			return nil, ErrInvalidRequest
+		}
+		if v := validator.ValidateStruct(req); len(v) > 0 {
+			return nil, validator.NewValidationError(v)
+		}
+
+		coupon, err := ps.CreateCoupon(ctx, &payments.Coupon{
+			Code:             req.Code,
+			DiscountType:     payments.CouponDiscountType(req.DiscountType),
+			DiscountValue:    req.DiscountValue,
+			MinAmount:        req.MinAmount,
+			UsageLimit:       req.UsageLimit,
+			WalletUsageLimit: req.WalletUsageLimit,
+			ExpiresAt:        req.ExpiresAt,
+			Active:           req.Active == nil || *req.Active,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return CouponResponse{Coupon: coupon}, nil
+	}
+}
+
+// GetCouponsResponse is the response type for the GetCoupons method.
+type GetCouponsResponse struct {
+	Coupons []*payments.Coupon `json:"coupons"`
+}
+
+// makeGetCouponsEndpoint returns an endpoint function for the GetCoupons method.
+func makeGetCouponsEndpoint(ps paymentService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		coupons, err := ps.GetCoupons(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		return GetCouponsResponse{Coupons: coupons}, nil
+	}
+}
+
+// UpdateCouponStatusRequest is the request type for the UpdateCouponStatus method.
+type UpdateCouponStatusRequest struct {
+	CouponID uuid.UUID `json:"-" validate:"-" label:"Coupon ID"`
+	Active   bool      `json:"active" validate:"bool" label:"Active"`
+}
+
+// makeUpdateCouponStatusEndpoint returns an endpoint function for the UpdateCouponStatus method.
+func makeUpdateCouponStatusEndpoint(ps paymentService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(UpdateCouponStatusRequest)
+		if !ok {
+			return nil, ErrInvalidRequest
+		}
+
+		coupon, err := ps.UpdateCouponStatus(ctx, req.CouponID, req.Active)
+		if err != nil {
+			return nil, err
+		}
+
+		return CouponResponse{Coupon: coupon}, nil
+	}
+}
+
+// makeDeleteCouponEndpoint returns an endpoint function for the DeleteCoupon method.
+func makeDeleteCouponEndpoint(ps paymentService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		couponID, ok := request.(uuid.UUID)
+		if !ok {
+			return nil, ErrInvalidRequest
+		}
+
+		if err := ps.DeleteCoupon(ctx, couponID); err != nil {
+			return nil, err
+		}
+
+		return nil, nil
+	}
+}
+
+// CreateGatingRuleRequest is the request type for the CreateGatingRule method.
+type CreateGatingRuleRequest struct {
+	Name            string `json:"name" validate:"required|max_len:100" label:"Name"`
+	Type            string `json:"type" validate:"required|in:collection,creator,token_balance" label:"Type"`
+	Address         string `json:"address" validate:"required" label:"Address"`
+	MinBalance      uint64 `json:"min_balance,omitempty" validate:"-" label:"Min Balance"`
+	DiscountPercent uint64 `json:"discount_percent" validate:"required|gt:0|max:10000" label:"Discount Percent"`
+	Active          *bool  `json:"active,omitempty" validate:"-" label:"Active"`
+}
+
+// GatingRuleResponse is the response type for the gating rule methods.
+type GatingRuleResponse struct {
+	Rule *payments.GatingRule `json:"rule"`
+}
+
+// makeCreateGatingRuleEndpoint returns an endpoint function for the CreateGatingRule method.
+func makeCreateGatingRuleEndpoint(ps paymentService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(CreateGatingRuleRequest)
+		if !ok {
+			return nil, ErrInvalidRequest
+		}
+		if v := validator.ValidateStruct(req); len(v) > 0 {
+			return nil, validator.NewValidationError(v)
+		}
+
+		rule, err := ps.CreateGatingRule(ctx, &payments.GatingRule{
+			Name:            req.Name,
+			Type:            payments.GatingRuleType(req.Type),
+			Address:         req.Address,
+			MinBalance:      req.MinBalance,
+			DiscountPercent: req.DiscountPercent,
+			Active:          req.Active == nil || *req.Active,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return GatingRuleResponse{Rule: rule}, nil
+	}
+}
+
+// GetGatingRulesResponse is the response type for the GetGatingRules method.
+type GetGatingRulesResponse struct {
+	Rules []payments.GatingRule `json:"rules"`
+}
+
+// makeGetGatingRulesEndpoint returns an endpoint function for the GetGatingRules method.
+func makeGetGatingRulesEndpoint(ps paymentService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		rules, err := ps.GetGatingRules(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		return GetGatingRulesResponse{Rules: rules}, nil
+	}
+}
+
+// UpdateGatingRuleStatusRequest is the request type for the UpdateGatingRuleStatus method.
+type UpdateGatingRuleStatusRequest struct {
+	RuleID uuid.UUID `json:"-" validate:"-" label:"Rule ID"`
+	Active bool      `json:"active" validate:"bool" label:"Active"`
+}
+
+// makeUpdateGatingRuleStatusEndpoint returns an endpoint function for the UpdateGatingRuleStatus method.
+func makeUpdateGatingRuleStatusEndpoint(ps paymentService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(UpdateGatingRuleStatusRequest)
+		if !ok {
+			return nil, ErrInvalidRequest
+		}
+
+		rule, err := ps.UpdateGatingRuleStatus(ctx, req.RuleID, req.Active)
+		if err != nil {
+			return nil, err
+		}
+
+		return GatingRuleResponse{Rule: rule}, nil
+	}
+}
+
+// makeDeleteGatingRuleEndpoint returns an endpoint function for the DeleteGatingRule method.
+func makeDeleteGatingRuleEndpoint(ps paymentService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		ruleID, ok := request.(uuid.UUID)
+		if !ok {
+			return nil, ErrInvalidRequest
+		}
+
+		if err := ps.DeleteGatingRule(ctx, ruleID); err != nil {
+			return nil, err
+		}
+
+		return nil, nil
+	}
+}
+
+// IssueGiftCardRequest is the request type for the IssueGiftCard method.
+type IssueGiftCardRequest struct {
+	Code      string     `json:"code,omitempty" validate:"max_len:50" label:"Code"`
+	Mint      string     `json:"mint,omitempty" validate:"-" label:"Mint"`
+	Amount    uint64     `json:"amount" validate:"required|gt:0" label:"Amount"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty" validate:"-" label:"Expires At"`
+}
+
+// GiftCardResponse is the response type for the gift card methods.
+type GiftCardResponse struct {
+	GiftCard *payments.GiftCard `json:"gift_card"`
+}
+
+// makeIssueGiftCardEndpoint returns an endpoint function for the IssueGiftCard method.
+func makeIssueGiftCardEndpoint(ps paymentService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(IssueGiftCardRequest)
+		if !ok {
+			return nil, ErrInvalidRequest
+		}
+		if v := validator.ValidateStruct(req); len(v) > 0 {
+			return nil, validator.NewValidationError(v)
+		}
+
+		card, err := ps.IssueGiftCard(ctx, &payments.GiftCard{
+			Code:          req.Code,
+			Mint:          req.Mint,
+			InitialAmount: req.Amount,
+			ExpiresAt:     req.ExpiresAt,
+			Active:        true,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return GiftCardResponse{GiftCard: card}, nil
+	}
+}
+
+// GetGiftCardsResponse is the response type for the GetGiftCards method.
+type GetGiftCardsResponse struct {
+	GiftCards []*payments.GiftCard `json:"gift_cards"`
+}
+
+// makeGetGiftCardsEndpoint returns an endpoint function for the GetGiftCards method.
+func makeGetGiftCardsEndpoint(ps paymentService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		cards, err := ps.GetGiftCards(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		return GetGiftCardsResponse{GiftCards: cards}, nil
+	}
+}
+
+// makeGetGiftCardEndpoint returns an endpoint function for the GetGiftCard method.
+func makeGetGiftCardEndpoint(ps paymentService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		cardID, ok := request.(uuid.UUID)
+		if !ok {
+			return nil, ErrInvalidRequest
+		}
+
+		card, err := ps.GetGiftCard(ctx, cardID)
+		if err != nil {
+			return nil, err
+		}
+
+		return GiftCardResponse{GiftCard: card}, nil
+	}
+}
+
+// UpdateGiftCardStatusRequest is the request type for the UpdateGiftCardStatus method.
+type UpdateGiftCardStatusRequest struct {
+	GiftCardID uuid.UUID `json:"-" validate:"-" label:"Gift Card ID"`
+	Active     bool      `json:"active" validate:"bool" label:"Active"`
+}
+
+// makeUpdateGiftCardStatusEndpoint returns an endpoint function for the UpdateGiftCardStatus method.
+func makeUpdateGiftCardStatusEndpoint(ps paymentService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(UpdateGiftCardStatusRequest)
+		if !ok {
+			return nil, ErrInvalidRequest
+		}
+
+		card, err := ps.UpdateGiftCardStatus(ctx, req.GiftCardID, req.Active)
+		if err != nil {
+			return nil, err
+		}
+
+		return GiftCardResponse{GiftCard: card}, nil
+	}
+}
+
+// PayWithGiftCardRequest is the request type for the PayWithGiftCard method.
+type PayWithGiftCardRequest struct {
+	PaymentID string `json:"-" validate:"required|uuid" label:"Payment ID"`
+	Code      string `json:"code" validate:"required" label:"Gift Card Code"`
+}
+
+// PayWithGiftCardResponse is the response type for the PayWithGiftCard method.
+type PayWithGiftCardResponse struct {
+	Reference string `json:"reference"`
+	Status    string `json:"status"`
+}
+
+// makePayWithGiftCardEndpoint returns an endpoint function for the PayWithGiftCard method.
+func makePayWithGiftCardEndpoint(ps paymentService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(PayWithGiftCardRequest)
+		if !ok {
+			return nil, ErrInvalidRequest
+		}
+		if v := validator.ValidateStruct(req); len(v) > 0 {
+			return nil, validator.NewValidationError(v)
+		}
+
+		paymentID, err := uuid.Parse(req.PaymentID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid payment ID: %v", ErrInvalidParameter, err)
+		}
+
+		tx, err := ps.PayWithGiftCard(ctx, paymentID, req.Code)
+		if err != nil {
+			return nil, err
+		}
+
+		return PayWithGiftCardResponse{
+			Reference: tx.Reference,
+			Status:    string(tx.Status),
+		}, nil
+	}
+}
+
+// CreateAffiliateRequest is the request type for the CreateAffiliate method.
+type CreateAffiliateRequest struct {
+	Name            string `json:"name" validate:"required|max_len:100" label:"Name"`
+	RefCode         string `json:"ref_code,omitempty" validate:"max_len:50" label:"Ref Code"`
+	Wallet          string `json:"wallet" validate:"required" label:"Wallet"`
+	CommissionType  string `json:"commission_type" validate:"required|in:percent,fixed" label:"Commission Type"`
+	CommissionValue uint64 `json:"commission_value" validate:"required|gt:0" label:"Commission Value"`
+	PayoutMode      string `json:"payout_mode,omitempty" validate:"in:inline,accrued" label:"Payout Mode"`
+	Active          *bool  `json:"active,omitempty" validate:"-" label:"Active"`
+}
+
+// AffiliateResponse is the response type for the affiliate methods.
+type AffiliateResponse struct {
+	Affiliate *payments.Affiliate `json:"affiliate"`
+}
+
+// makeCreateAffiliateEndpoint returns an endpoint function for the CreateAffiliate method.
+func makeCreateAffiliateEndpoint(ps paymentService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(CreateAffiliateRequest)
+		if !ok {
+			return nil, ErrInvalidRequest
+		}
+		if v := validator.ValidateStruct(req); len(v) > 0 {
+			return nil, validator.NewValidationError(v)
+		}
+
+		affiliate, err := ps.CreateAffiliate(ctx, &payments.Affiliate{
+			Name:            req.Name,
+			RefCode:         req.RefCode,
+			Wallet:          req.Wallet,
+			CommissionType:  payments.AffiliateCommissionType(req.CommissionType),
+			CommissionValue: req.CommissionValue,
+			PayoutMode:      payments.AffiliatePayoutMode(req.PayoutMode),
+			Active:          req.Active == nil || *req.Active,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return AffiliateResponse{Affiliate: affiliate}, nil
+	}
+}
+
+// GetAffiliatesResponse is the response type for the GetAffiliates method.
+type GetAffiliatesResponse struct {
+	Affiliates []*payments.Affiliate `json:"affiliates"`
+}
+
+// makeGetAffiliatesEndpoint returns an endpoint function for the GetAffiliates method.
+func makeGetAffiliatesEndpoint(ps paymentService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		affiliates, err := ps.GetAffiliates(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		return GetAffiliatesResponse{Affiliates: affiliates}, nil
+	}
+}
+
+// makeGetAffiliateEndpoint returns an endpoint function for the GetAffiliate method.
+func makeGetAffiliateEndpoint(ps paymentService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		affiliateID, ok := request.(uuid.UUID)
+		if !ok {
+			return nil, ErrInvalidRequest
+		}
+
+		affiliate, err := ps.GetAffiliate(ctx, affiliateID)
+		if err != nil {
+			return nil, err
+		}
+
+		return AffiliateResponse{Affiliate: affiliate}, nil
+	}
+}
+
+// UpdateAffiliateStatusRequest is the request type for the UpdateAffiliateStatus method.
+type UpdateAffiliateStatusRequest struct {
+	AffiliateID uuid.UUID `json:"-" validate:"-" label:"Affiliate ID"`
+	Active      bool      `json:"active" validate:"bool" label:"Active"`
+}
+
+// makeUpdateAffiliateStatusEndpoint returns an endpoint function for the UpdateAffiliateStatus method.
+func makeUpdateAffiliateStatusEndpoint(ps paymentService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(UpdateAffiliateStatusRequest)
+		if !ok {
+			return nil, ErrInvalidRequest
+		}
+
+		affiliate, err := ps.UpdateAffiliateStatus(ctx, req.AffiliateID, req.Active)
+		if err != nil {
+			return nil, err
+		}
+
+		return AffiliateResponse{Affiliate: affiliate}, nil
+	}
+}
+
+// GetAffiliateReportRequest is the request type for the GetAffiliateReport method.
+type GetAffiliateReportRequest struct {
+	AffiliateID uuid.UUID
+	Since       time.Time
+	Until       time.Time
+}
+
+// GetAffiliateReportResponse is the response type for the GetAffiliateReport method.
+type GetAffiliateReportResponse struct {
+	Report *payments.AffiliateReport `json:"report"`
+}
+
+// makeGetAffiliateReportEndpoint returns an endpoint function for the GetAffiliateReport method.
+func makeGetAffiliateReportEndpoint(ps paymentService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(GetAffiliateReportRequest)
+		if !ok {
+			return nil, ErrInvalidRequest
+		}
+		if !req.Since.Before(req.Until) {
+			return nil, fmt.Errorf("%w: from must be before to", ErrInvalidParameter)
+		}
+
+		report, err := ps.GetAffiliateReport(ctx, req.AffiliateID, req.Since, req.Until)
+		if err != nil {
+			return nil, err
+		}
+
+		return GetAffiliateReportResponse{Report: report}, nil
+	}
+}
+
+// PayoutAffiliateResponse is the response type for the PayoutAffiliate method.
+type PayoutAffiliateResponse struct {
+	Payout *payments.AffiliatePayout `json:"payout"`
+}
+
+// makePayoutAffiliateEndpoint returns an endpoint function for the PayoutAffiliateCommissions method.
+func makePayoutAffiliateEndpoint(ps paymentService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		affiliateID, ok := request.(uuid.UUID)
+		if !ok {
+			return nil, ErrInvalidRequest
+		}
+
+		payout, err := ps.PayoutAffiliateCommissions(ctx, affiliateID)
+		if err != nil {
+			return nil, err
+		}
+
+		return PayoutAffiliateResponse{Payout: payout}, nil
+	}
+}
+
+// WebhookEndpointRequest is the request type for the CreateWebhookEndpoint and UpdateWebhookEndpoint methods.
+type WebhookEndpointRequest struct {
+	EndpointID  uuid.UUID `json:"-" validate:"-" label:"Endpoint ID"`
+	URL         string    `json:"url" validate:"required|max_len:2048" label:"URL"`
+	Secret      string    `json:"secret,omitempty" validate:"max_len:256" label:"Secret"`
+	Description string    `json:"description,omitempty" validate:"max_len:255" label:"Description"`
+	EventTypes  []string  `json:"event_types,omitempty" validate:"-" label:"Event Types"`
+	Enabled     *bool     `json:"enabled,omitempty" validate:"-" label:"Enabled"`
+	APIVersion  string    `json:"api_version,omitempty" validate:"-" label:"API Version"` // the latest version by default, kept on update
+}
+
+// WebhookEndpointResponse is the response type for the webhook endpoint methods.
+type WebhookEndpointResponse struct {
+	Endpoint *webhook.Endpoint `json:"endpoint"`
+}
+
+// makeCreateWebhookEndpointEndpoint returns an endpoint function for the CreateWebhookEndpoint method.
+func makeCreateWebhookEndpointEndpoint(ws webhookService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(WebhookEndpointRequest)
+		if !ok {
+			return nil, ErrInvalidRequest
+		}
+		if v := validator.ValidateStruct(req); len(v) > 0 {
+			return nil, validator.NewValidationError(v)
+		}
+
+		e, err := ws.CreateEndpoint(ctx, &webhook.Endpoint{
+			URL:         req.URL,
+			Secret:      req.Secret,
+			Description: req.Description,
+			EventTypes:  req.EventTypes,
+			Enabled:     req.Enabled == nil || *req.Enabled,
+			APIVersion:  req.APIVersion,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return WebhookEndpointResponse{Endpoint: e}, nil
+	}
+}
+
+// GetWebhookEndpointsResponse is the response type for the GetWebhookEndpoints method.
+type GetWebhookEndpointsResponse struct {
+	Endpoints []*webhook.Endpoint `json:"endpoints"`
+}
+
+// makeGetWebhookEndpointsEndpoint returns an endpoint function for the GetWebhookEndpoints method.
+func makeGetWebhookEndpointsEndpoint(ws webhookService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		endpoints, err := ws.GetEndpoints(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		return GetWebhookEndpointsResponse{Endpoints: endpoints}, nil
+	}
+}
+
+// makeGetWebhookEndpointEndpoint returns an endpoint function for the GetWebhookEndpoint method.
+func makeGetWebhookEndpointEndpoint(ws webhookService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		endpointID, ok := request.(uuid.UUID)
+		if !ok {
+			return nil, ErrInvalidRequest
+		}
+
+		e, err := ws.GetEndpoint(ctx, endpointID)
+		if err != nil {
+			return nil, err
+		}
+
+		return WebhookEndpointResponse{Endpoint: e}, nil
+	}
+}
+
+// makeUpdateWebhookEndpointEndpoint returns an endpoint function for the UpdateWebhookEndpoint method.
+// The secret can't be changed.
+func makeUpdateWebhookEndpointEndpoint(ws webhookService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(WebhookEndpointRequest)
+		if !ok {
+			return nil, ErrInvalidRequest
+		}
+		if v := validator.ValidateStruct(req); len(v) > 0 {
+			return nil, validator.NewValidationError(v)
+		}
+
+		e, err := ws.UpdateEndpoint(ctx, &webhook.Endpoint{
+			ID:          req.EndpointID,
+			URL:         req.URL,
+			Description: req.Description,
+			EventTypes:  req.EventTypes,
+			Enabled:     req.Enabled == nil || *req.Enabled,
+			APIVersion:  req.APIVersion,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return WebhookEndpointResponse{Endpoint: e}, nil
+	}
+}
+
+// makeDeleteWebhookEndpointEndpoint returns an endpoint function for the DeleteWebhookEndpoint method.
+func makeDeleteWebhookEndpointEndpoint(ws webhookService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		endpointID, ok := request.(uuid.UUID)
+		if !ok {
+			return nil, ErrInvalidRequest
+		}
+
+		if err := ws.DeleteEndpoint(ctx, endpointID); err != nil {
+			return nil, err
+		}
+
+		return nil, nil
+	}
+}
+
+// RotateWebhookSecretRequest is the request type for the RotateWebhookSecret method.
+type RotateWebhookSecretRequest struct {
+	EndpointID uuid.UUID `json:"-"`
+	// The lifetime of the previous secret in seconds, 24 hours by default, 7 days at most.
+	// Payloads are signed with both secrets until the previous one expires.
+	ExpiresIn *int64 `json:"expires_in,omitempty"`
+}
+
+// makeRotateWebhookSecretEndpoint returns an endpoint function for the RotateWebhookSecret method.
+// The response contains the new secret.
+func makeRotateWebhookSecretEndpoint(ws webhookService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(RotateWebhookSecretRequest)
+		if !ok {
+			return nil, ErrInvalidRequest
+		}
+
+		ttl := webhook.DefaultPreviousSecretTTL
+		if req.ExpiresIn != nil {
+			ttl = time.Duration(*req.ExpiresIn) * time.Second
+		}
+
+		e, err := ws.RotateSecret(ctx, req.EndpointID, ttl)
+		if err != nil {
+			return nil, err
+		}
+
+		return WebhookEndpointResponse{Endpoint: e}, nil
+	}
+}
+
+// WebhookEndpointHealthResponse is the response type for the GetWebhookEndpointHealth method.
+type WebhookEndpointHealthResponse struct {
+	Health *webhook.EndpointHealth `json:"health"`
+}
+
+// makeGetWebhookEndpointHealthEndpoint returns an endpoint function for the GetWebhookEndpointHealth method.
+func makeGetWebhookEndpointHealthEndpoint(ws webhookService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		endpointID, ok := request.(uuid.UUID)
+		if !ok {
+			return nil, ErrInvalidRequest
+		}
+
+		h, err := ws.EndpointHealth(ctx, endpointID)
+		if err != nil {
+			return nil, err
+		}
+
+		return WebhookEndpointHealthResponse{Health: h}, nil
+	}
+}
+
+// GetWebhookDeliveriesRequest is the request type for the GetWebhookDeliveries method.
+type GetWebhookDeliveriesRequest struct {
+	EndpointID *uuid.UUID
+	Event      string
+	EventID    *uuid.UUID
+	PaymentID  *uuid.UUID
+	Status     string
+	Since      time.Time
+	Until      time.Time
+	Limit      int32
+	Offset     int32
+}
+
+// GetWebhookDeliveriesResponse is the response type for the GetWebhookDeliveries method.
+type GetWebhookDeliveriesResponse struct {
+	Deliveries []*webhook.Delivery `json:"deliveries"`
+}
+
+// makeGetWebhookDeliveriesEndpoint returns an endpoint function for the GetWebhookDeliveries method.
+func makeGetWebhookDeliveriesEndpoint(ws webhookService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(GetWebhookDeliveriesRequest)
+		if !ok {
+			return nil, ErrInvalidRequest
+		}
+
+		deliveries, err := ws.GetDeliveries(ctx, webhook.DeliveryFilter{
+			EndpointID: req.EndpointID,
+			Event:      req.Event,
+			EventID:    req.EventID,
+			PaymentID:  req.PaymentID,
+			Status:     req.Status,
+			Since:      req.Since,
+			Until:      req.Until,
+			Limit:      req.Limit,
+			Offset:     req.Offset,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return GetWebhookDeliveriesResponse{Deliveries: deliveries}, nil
+	}
+}
+
+// WebhookDeliveryResponse is the response type for the GetWebhookDelivery and ReplayWebhookDelivery methods.
+type WebhookDeliveryResponse struct {
+	Delivery *webhook.Delivery `json:"delivery"`
+}
+
+// makeGetWebhookDeliveryEndpoint returns an endpoint function for the GetWebhookDelivery method.
+func makeGetWebhookDeliveryEndpoint(ws webhookService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		deliveryID, ok := request.(uuid.UUID)
+		if !ok {
+			return nil, ErrInvalidRequest
+		}
+
+		d, err := ws.GetDelivery(ctx, deliveryID)
+		if err != nil {
+			return nil, err
+		}
+
+		return WebhookDeliveryResponse{Delivery: d}, nil
+	}
+}
+
+// makeReplayWebhookDeliveryEndpoint returns an endpoint function for the ReplayWebhookDelivery method.
+// The response contains the new delivery, check its status to find out if the replay succeeded.
+func makeReplayWebhookDeliveryEndpoint(ws webhookService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		deliveryID, ok := request.(uuid.UUID)
+		if !ok {
+			return nil, ErrInvalidRequest
+		}
+
+		d, err := ws.Replay(ctx, deliveryID)
+		if err != nil {
+			return nil, err
+		}
+
+		return WebhookDeliveryResponse{Delivery: d}, nil
+	}
+}
@@ -5,6 +5,9 @@ import (
 	"net/http"
 
 	"github.com/easypmnt/checkout-api/internal/httpencoder"
+	"github.com/easypmnt/checkout-api/payments"
+	"github.com/easypmnt/checkout-api/solana"
+	"github.com/easypmnt/checkout-api/webhook"
 )
 
 // Predefined errors.
@@ -21,6 +24,30 @@ var ErrorCodes = map[error]int{
 	ErrInvalidParameter: http.StatusBadRequest,
 	ErrForbidden:        http.StatusForbidden,
 	ErrNotFound:         http.StatusNotFound,
+
+	payments.ErrUnknownToken:         http.StatusBadRequest,
+	payments.ErrTransactionMismatch:  http.StatusBadRequest,
+	payments.ErrInvalidBonusRule:     http.StatusBadRequest,
+	payments.ErrInvalidCoupon:        http.StatusBadRequest,
+	payments.ErrCouponUnavailable:    http.StatusBadRequest,
+	payments.ErrCouponUsageLimit:     http.StatusConflict,
+	payments.ErrInvalidGatingRule:    http.StatusBadRequest,
+	payments.ErrInvalidGiftCard:      http.StatusBadRequest,
+	payments.ErrGiftCardUnavailable:  http.StatusBadRequest,
+	payments.ErrGiftCardBalance:      http.StatusConflict,
+	payments.ErrGiftCardCovered:      http.StatusConflict,
+	payments.ErrInvalidAffiliate:     http.StatusBadRequest,
+	payments.ErrAffiliateUnavailable: http.StatusBadRequest,
+	payments.ErrPayoutDisabled:       http.StatusBadRequest,
+	payments.ErrNothingToPayout:      http.StatusConflict,
+	payments.ErrSponsorBudgetSpent:   http.StatusConflict,
+
+	webhook.ErrEndpointNotFound: http.StatusNotFound,
+	webhook.ErrInvalidEndpoint:  http.StatusBadRequest,
+	webhook.ErrEndpointDisabled: http.StatusConflict,
+
+	webhook.ErrDeliveryNotFound:      http.StatusNotFound,
+	webhook.ErrInvalidDeliveryFilter: http.StatusBadRequest,
 }
 
 // Error messages
@@ -29,10 +56,48 @@ var ErrorMessages = map[error]string{
 	ErrInvalidParameter: "Some parameters are invalid",
 	ErrForbidden:        "Forbidden. You don't have permission to access this account",
 	ErrNotFound:         "Not found",
+
+	payments.ErrUnknownToken:         "Unknown or unsupported token",
+	payments.ErrTransactionMismatch:  "Signed transaction does not match the payment transaction",
+	payments.ErrInvalidBonusRule:     "Invalid bonus rule",
+	payments.ErrInvalidCoupon:        "Invalid coupon",
+	payments.ErrCouponUnavailable:    "The coupon is invalid, expired or not applicable to this payment",
+	payments.ErrCouponUsageLimit:     "The coupon usage limit has been reached",
+	payments.ErrInvalidGatingRule:    "Invalid gating rule",
+	payments.ErrInvalidGiftCard:      "Invalid gift card",
+	payments.ErrGiftCardUnavailable:  "The gift card is invalid, expired or not applicable to this payment",
+	payments.ErrGiftCardBalance:      "The gift card balance is insufficient",
+	payments.ErrGiftCardCovered:      "The payment is fully covered by the gift card, pay with the gift card instead",
+	payments.ErrInvalidAffiliate:     "Invalid affiliate",
+	payments.ErrAffiliateUnavailable: "The affiliate ref code is invalid or disabled",
+	payments.ErrPayoutDisabled:       "Affiliate payouts are disabled",
+	payments.ErrNothingToPayout:      "The affiliate has no accrued commissions to pay out",
+	payments.ErrSponsorBudgetSpent:   "The daily sponsorship budget is spent, build the transaction again",
+
+	webhook.ErrEndpointNotFound: "Webhook endpoint not found",
+	webhook.ErrInvalidEndpoint:  "Invalid webhook endpoint",
+	webhook.ErrEndpointDisabled: "The webhook endpoint is disabled",
+
+	webhook.ErrDeliveryNotFound:      "Webhook delivery not found",
+	webhook.ErrInvalidDeliveryFilter: "Invalid webhook delivery filter: check the status, period, limit and offset",
+}
+
+// Transaction simulation error messages, the wallets show them to the customer.
+var SimulationErrorMessages = map[error]string{
+	solana.ErrSimulationFailed:          "The transaction cannot be completed. Please try again later.",
+	solana.ErrInsufficientFunds:         "Not enough funds in your wallet to pay.",
+	solana.ErrInsufficientFundsForFee:   "Not enough SOL in your wallet to pay the network fee.",
+	solana.ErrInsufficientFundsForRent:  "Not enough SOL in your wallet to cover the token account rent.",
+	solana.ErrTokenAccountDoesNotExist:  "Your wallet has no token account for the selected token.",
+	solana.ErrSlippageToleranceExceeded: "The token price has changed too much. Please try again.",
 }
 
 // NewError creates a new error
 func NewError(err error) *httpencoder.ErrorResponse {
+	if resp := newSimulationError(err); resp != nil {
+		return resp
+	}
+
 	code, ok := ErrorCodes[err]
 	if !ok {
 		if stdErr := findError(err); stdErr != nil {
@@ -67,3 +132,23 @@ func findError(err error) error {
 	}
 	return nil
 }
+
+// newSimulationError creates a new error from the transaction simulation error.
+// Returns nil if the error is not a simulation error.
+func newSimulationError(err error) *httpencoder.ErrorResponse {
+	var simErr *solana.SimulationError
+	if !errors.As(err, &simErr) {
+		return nil
+	}
+
+	msg, ok := SimulationErrorMessages[simErr.Err]
+	if !ok {
+		msg = SimulationErrorMessages[solana.ErrSimulationFailed]
+	}
+
+	return &httpencoder.ErrorResponse{
+		Code:    http.StatusBadRequest,
+		Error:   simErr.Err.Error(),
+		Message: msg,
+	}
+}
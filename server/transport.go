@@ -6,8 +6,12 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/easypmnt/checkout-api/events"
 	"github.com/easypmnt/checkout-api/internal/httpencoder"
 	"github.com/easypmnt/checkout-api/internal/validator"
 	"github.com/go-chi/chi/v5"
@@ -27,11 +31,7 @@ type (
 // MakeHTTPHandler returns an http.Handler that can be used to serve the API.
 func MakeHTTPHandler(e Endpoints, log logger, authMdw middlewareFunc) http.Handler {
 	r := chi.NewRouter()
-
-	options := []httptransport.ServerOption{
-		httptransport.ServerErrorHandler(transport.NewLogErrorHandler(log)),
-		httptransport.ServerErrorEncoder(httpencoder.EncodeError(log, codeAndMessageFrom)),
-	}
+	options := serverOptions(log)
 
 	// Without auth
 	r.Group(func(r chi.Router) {
@@ -48,6 +48,34 @@ func MakeHTTPHandler(e Endpoints, log logger, authMdw middlewareFunc) http.Handl
 			httpencoder.EncodeResponseAsIs,
 			options...,
 		).ServeHTTP)
+
+		r.Get("/checkout/{payment_id}/{mint}/{apply_bonus}/preview", httptransport.NewServer(
+			e.PreviewPaymentTransaction,
+			decodePreviewPaymentTransactionRequest,
+			httpencoder.EncodeResponse,
+			options...,
+		).ServeHTTP)
+
+		r.Post("/checkout/{reference}/submit", httptransport.NewServer(
+			e.SubmitTransaction,
+			decodeSubmitTransactionRequest,
+			httpencoder.EncodeResponse,
+			options...,
+		).ServeHTTP)
+
+		r.Post("/checkout/{payment_id}/gift-card", httptransport.NewServer(
+			e.PayWithGiftCard,
+			decodePayWithGiftCardRequest,
+			httpencoder.EncodeResponse,
+			options...,
+		).ServeHTTP)
+
+		r.Get("/tokens", httptransport.NewServer(
+			e.GetSupportedTokens,
+			decodeGetSupportedTokensRequest,
+			httpencoder.EncodeResponse,
+			options...,
+		).ServeHTTP)
 	})
 
 	// With auth
@@ -68,6 +96,27 @@ func MakeHTTPHandler(e Endpoints, log logger, authMdw middlewareFunc) http.Handl
 			options...,
 		).ServeHTTP)
 
+		r.Get("/pid/{payment_id}/events", httptransport.NewServer(
+			e.GetPaymentEvents,
+			decodeGetPaymentRequest,
+			httpencoder.EncodeResponse,
+			options...,
+		).ServeHTTP)
+
+		r.Get("/pid/{payment_id}/timeline", httptransport.NewServer(
+			e.GetPaymentTimeline,
+			decodeGetPaymentRequest,
+			httpencoder.EncodeResponse,
+			options...,
+		).ServeHTTP)
+
+		r.Post("/pid/{payment_id}/subscription", httptransport.NewServer(
+			e.IssueSubscriptionToken,
+			decodeGetPaymentRequest,
+			httpencoder.EncodeResponse,
+			options...,
+		).ServeHTTP)
+
 		r.Get("/ext/{external_id}", httptransport.NewServer(
 			e.GetPaymentByExternalID,
 			decodeGetPaymentByExternalIDRequest,
@@ -102,11 +151,274 @@ func MakeHTTPHandler(e Endpoints, log logger, authMdw middlewareFunc) http.Handl
 			httpencoder.EncodeResponse,
 			options...,
 		).ServeHTTP)
+
+		r.Post("/rules", httptransport.NewServer(
+			e.CreateBonusRule,
+			decodeCreateBonusRuleRequest,
+			httpencoder.EncodeResponse,
+			options...,
+		).ServeHTTP)
+
+		r.Get("/rules", httptransport.NewServer(
+			e.GetBonusRules,
+			decodeGetBonusRulesRequest,
+			httpencoder.EncodeResponse,
+			options...,
+		).ServeHTTP)
+
+		r.Put("/rules/{rule_id}/status", httptransport.NewServer(
+			e.UpdateBonusRuleStatus,
+			decodeUpdateBonusRuleStatusRequest,
+			httpencoder.EncodeResponse,
+			options...,
+		).ServeHTTP)
+
+		r.Delete("/rules/{rule_id}", httptransport.NewServer(
+			e.DeleteBonusRule,
+			decodeDeleteBonusRuleRequest,
+			httpencoder.EncodeResponse,
+			options...,
+		).ServeHTTP)
+
+		r.Post("/coupons", httptransport.NewServer(
+			e.CreateCoupon,
+			decodeCreateCouponRequest,
+			httpencoder.EncodeResponse,
+			options...,
+		).ServeHTTP)
+
+		r.Get("/coupons", httptransport.NewServer(
+			e.GetCoupons,
+			decodeGetCouponsRequest,
+			httpencoder.EncodeResponse,
+			options...,
+		).ServeHTTP)
+
+		r.Put("/coupons/{coupon_id}/status", httptransport.NewServer(
+			e.UpdateCouponStatus,
+			decodeUpdateCouponStatusRequest,
+			httpencoder.EncodeResponse,
+			options...,
+		).ServeHTTP)
+
+		r.Delete("/coupons/{coupon_id}", httptransport.NewServer(
+			e.DeleteCoupon,
+			decodeDeleteCouponRequest,
+			httpencoder.EncodeResponse,
+			options...,
+		).ServeHTTP)
+
+		r.Post("/gating-rules", httptransport.NewServer(
+			e.CreateGatingRule,
+			decodeCreateGatingRuleRequest,
+			httpencoder.EncodeResponse,
+			options...,
+		).ServeHTTP)
+
+		r.Get("/gating-rules", httptransport.NewServer(
+			e.GetGatingRules,
+			decodeGetGatingRulesRequest,
+			httpencoder.EncodeResponse,
+			options...,
+		).ServeHTTP)
+
+		r.Put("/gating-rules/{rule_id}/status", httptransport.NewServer(
+			e.UpdateGatingRuleStatus,
+			decodeUpdateGatingRuleStatusRequest,
+			httpencoder.EncodeResponse,
+			options...,
+		).ServeHTTP)
+
+		r.Delete("/gating-rules/{rule_id}", httptransport.NewServer(
+			e.DeleteGatingRule,
+			decodeDeleteGatingRuleRequest,
+			httpencoder.EncodeResponse,
+			options...,
+		).ServeHTTP)
+
+		r.Post("/gift-cards", httptransport.NewServer(
+			e.IssueGiftCard,
+			decodeIssueGiftCardRequest,
+			httpencoder.EncodeResponse,
+			options...,
+		).ServeHTTP)
+
+		r.Get("/gift-cards", httptransport.NewServer(
+			e.GetGiftCards,
+			decodeGetGiftCardsRequest,
+			httpencoder.EncodeResponse,
+			options...,
+		).ServeHTTP)
+
+		r.Get("/gift-cards/{gift_card_id}", httptransport.NewServer(
+			e.GetGiftCard,
+			decodeGetGiftCardRequest,
+			httpencoder.EncodeResponse,
+			options...,
+		).ServeHTTP)
+
+		r.Put("/gift-cards/{gift_card_id}/status", httptransport.NewServer(
+			e.UpdateGiftCardStatus,
+			decodeUpdateGiftCardStatusRequest,
+			httpencoder.EncodeResponse,
+			options...,
+		).ServeHTTP)
+
+		r.Post("/affiliates", httptransport.NewServer(
+			e.CreateAffiliate,
+			decodeCreateAffiliateRequest,
+			httpencoder.EncodeResponse,
+			options...,
+		).ServeHTTP)
+
+		r.Get("/affiliates", httptransport.NewServer(
+			e.GetAffiliates,
+			decodeGetAffiliatesRequest,
+			httpencoder.EncodeResponse,
+			options...,
+		).ServeHTTP)
+
+		r.Get("/affiliates/{affiliate_id}", httptransport.NewServer(
+			e.GetAffiliate,
+			decodeAffiliateIDRequest,
+			httpencoder.EncodeResponse,
+			options...,
+		).ServeHTTP)
+
+		r.Put("/affiliates/{affiliate_id}/status", httptransport.NewServer(
+			e.UpdateAffiliateStatus,
+			decodeUpdateAffiliateStatusRequest,
+			httpencoder.EncodeResponse,
+			options...,
+		).ServeHTTP)
+
+		r.Get("/affiliates/{affiliate_id}/report", httptransport.NewServer(
+			e.GetAffiliateReport,
+			decodeGetAffiliateReportRequest,
+			httpencoder.EncodeResponse,
+			options...,
+		).ServeHTTP)
+
+		r.Post("/affiliates/{affiliate_id}/payout", httptransport.NewServer(
+			e.PayoutAffiliate,
+			decodeAffiliateIDRequest,
+			httpencoder.EncodeResponse,
+			options...,
+		).ServeHTTP)
+
+		r.Post("/webhooks", httptransport.NewServer(
+			e.CreateWebhookEndpoint,
+			decodeCreateWebhookEndpointRequest,
+			httpencoder.EncodeResponse,
+			options...,
+		).ServeHTTP)
+
+		r.Get("/webhooks", httptransport.NewServer(
+			e.GetWebhookEndpoints,
+			decodeGetWebhookEndpointsRequest,
+			httpencoder.EncodeResponse,
+			options...,
+		).ServeHTTP)
+
+		r.Post("/webhooks/{endpoint_id}/rotate-secret", httptransport.NewServer(
+			e.RotateWebhookSecret,
+			decodeRotateWebhookSecretRequest,
+			httpencoder.EncodeResponse,
+			options...,
+		).ServeHTTP)
+
+		r.Get("/webhooks/{endpoint_id}/health", httptransport.NewServer(
+			e.GetWebhookEndpointHealth,
+			decodeWebhookEndpointIDRequest,
+			httpencoder.EncodeResponse,
+			options...,
+		).ServeHTTP)
+
+		r.Get("/webhooks/{endpoint_id}", httptransport.NewServer(
+			e.GetWebhookEndpoint,
+			decodeWebhookEndpointIDRequest,
+			httpencoder.EncodeResponse,
+			options...,
+		).ServeHTTP)
+
+		r.Put("/webhooks/{endpoint_id}", httptransport.NewServer(
+			e.UpdateWebhookEndpoint,
+			decodeUpdateWebhookEndpointRequest,
+			httpencoder.EncodeResponse,
+			options...,
+		).ServeHTTP)
+
+		r.Delete("/webhooks/{endpoint_id}", httptransport.NewServer(
+			e.DeleteWebhookEndpoint,
+			decodeWebhookEndpointIDRequest,
+			httpencoder.EncodeResponse,
+			options...,
+		).ServeHTTP)
 	})
 
 	return r
 }
 
+// MakeLoyaltyHTTPHandler returns an http.Handler that serves the loyalty wallets.
+// The wallet history is available to the merchant only.
+func MakeLoyaltyHTTPHandler(e Endpoints, log logger, authMdw middlewareFunc) http.Handler {
+	r := chi.NewRouter()
+	options := serverOptions(log)
+
+	r.Use(authMdw)
+
+	r.Get("/{wallet}", httptransport.NewServer(
+		e.GetLoyaltyWallet,
+		decodeGetLoyaltyWalletRequest,
+		httpencoder.EncodeResponse,
+		options...,
+	).ServeHTTP)
+
+	return r
+}
+
+// MakeWebhookDeliveriesHTTPHandler returns an http.Handler that serves the webhook delivery log.
+func MakeWebhookDeliveriesHTTPHandler(e Endpoints, log logger, authMdw middlewareFunc) http.Handler {
+	r := chi.NewRouter()
+	options := serverOptions(log)
+
+	r.Use(authMdw)
+
+	r.Get("/", httptransport.NewServer(
+		e.GetWebhookDeliveries,
+		decodeGetWebhookDeliveriesRequest,
+		httpencoder.EncodeResponse,
+		options...,
+	).ServeHTTP)
+
+	r.Get("/{delivery_id}", httptransport.NewServer(
+		e.GetWebhookDelivery,
+		decodeWebhookDeliveryIDRequest,
+		httpencoder.EncodeResponse,
+		options...,
+	).ServeHTTP)
+
+	r.Post("/{delivery_id}/replay", httptransport.NewServer(
+		e.ReplayWebhookDelivery,
+		decodeWebhookDeliveryIDRequest,
+		httpencoder.EncodeResponse,
+		options...,
+	).ServeHTTP)
+
+	return r
+}
+
+// serverOptions returns the options shared by all the endpoints.
+func serverOptions(log logger) []httptransport.ServerOption {
+	return []httptransport.ServerOption{
+		httptransport.ServerErrorHandler(transport.NewLogErrorHandler(log)),
+		httptransport.ServerErrorEncoder(httpencoder.EncodeError(log, codeAndMessageFrom)),
+		httptransport.ServerBefore(func(ctx context.Context, _ *http.Request) context.Context {
+			return events.WithSource(ctx, events.SourceAPI)
+		}),
+	}
+}
+
 // returns http error code by error type
 func codeAndMessageFrom(err error) (int, interface{}) {
 	if errors.Is(err, validator.ErrValidation) {
@@ -139,6 +451,36 @@ func decodeGeneratePaymentTransactionRequest(ctx context.Context, r *http.Reques
 	req.PaymentID = chi.URLParam(r, "payment_id")
 	req.Mint = chi.URLParam(r, "mint")
 	req.ApplyBonus = chi.URLParam(r, "apply_bonus")
+	req.Coupon = r.URL.Query().Get("coupon")
+	req.GiftCard = r.URL.Query().Get("gift_card")
+	req.Ref = r.URL.Query().Get("ref")
+
+	return req, nil
+}
+
+// decodePreviewPaymentTransactionRequest is a transport/http.DecodeRequestFunc that decodes
+// the request from the URL parameters. The account, coupon, gift_card and ref query parameters are optional.
+func decodePreviewPaymentTransactionRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	return PreviewPaymentTransactionRequest{
+		PaymentID:    chi.URLParam(r, "payment_id"),
+		SourceWallet: r.URL.Query().Get("account"),
+		Mint:         chi.URLParam(r, "mint"),
+		ApplyBonus:   chi.URLParam(r, "apply_bonus"),
+		Coupon:       r.URL.Query().Get("coupon"),
+		GiftCard:     r.URL.Query().Get("gift_card"),
+		Ref:          r.URL.Query().Get("ref"),
+	}, nil
+}
+
+// decodeSubmitTransactionRequest is a transport/http.DecodeRequestFunc that decodes a
+// JSON-encoded request from the HTTP request body.
+func decodeSubmitTransactionRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	var req SubmitTransactionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, fmt.Errorf("invalid request body: %w", err)
+	}
+
+	req.Reference = chi.URLParam(r, "reference")
 
 	return req, nil
 }
@@ -209,3 +551,434 @@ func decodeGetExchangeRateRequest(ctx context.Context, r *http.Request) (interfa
 
 	return req, nil
 }
+
+// decodeGetSupportedTokensRequest is a transport/http.DecodeRequestFunc that decodes a
+// JSON-encoded request from the HTTP request body.
+func decodeGetSupportedTokensRequest(_ context.Context, _ *http.Request) (interface{}, error) {
+	return nil, nil
+}
+
+// decodeGetLoyaltyWalletRequest is a transport/http.DecodeRequestFunc that decodes
+// the request from the URL parameters. The limit and offset query parameters are optional.
+func decodeGetLoyaltyWalletRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	req := GetLoyaltyWalletRequest{
+		Wallet: chi.URLParam(r, "wallet"),
+		Limit:  50,
+	}
+
+	if limit := r.URL.Query().Get("limit"); limit != "" {
+		v, err := strconv.ParseInt(limit, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid limit: %w", err)
+		}
+		req.Limit = int32(v)
+	}
+	if offset := r.URL.Query().Get("offset"); offset != "" {
+		v, err := strconv.ParseInt(offset, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid offset: %w", err)
+		}
+		req.Offset = int32(v)
+	}
+
+	return req, nil
+}
+
+// decodeCreateBonusRuleRequest is a transport/http.DecodeRequestFunc that decodes a
+// JSON-encoded request from the HTTP request body.
+func decodeCreateBonusRuleRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var req CreateBonusRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, fmt.Errorf("invalid request body: %w", err)
+	}
+
+	return req, nil
+}
+
+// decodeGetBonusRulesRequest is a transport/http.DecodeRequestFunc for the request without parameters.
+func decodeGetBonusRulesRequest(_ context.Context, _ *http.Request) (interface{}, error) {
+	return nil, nil
+}
+
+// decodeUpdateBonusRuleStatusRequest is a transport/http.DecodeRequestFunc that decodes a
+// JSON-encoded request from the HTTP request body and the rule ID from the URL.
+func decodeUpdateBonusRuleStatusRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var req UpdateBonusRuleStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, fmt.Errorf("invalid request body: %w", err)
+	}
+
+	ruleID, err := uuid.Parse(chi.URLParam(r, "rule_id"))
+	if err != nil {
+		return nil, ErrInvalidRequest
+	}
+	req.RuleID = ruleID
+
+	return req, nil
+}
+
+// decodeDeleteBonusRuleRequest is a transport/http.DecodeRequestFunc that decodes
+// the rule ID from the URL.
+func decodeDeleteBonusRuleRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	ruleID, err := uuid.Parse(chi.URLParam(r, "rule_id"))
+	if err != nil {
+		return nil, ErrInvalidRequest
+	}
+
+	return ruleID, nil
+}
+
+// decodeCreateCouponRequest is a transport/http.DecodeRequestFunc that decodes a
+// JSON-encoded request from the HTTP request body.
+func decodeCreateCouponRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var req CreateCouponRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, fmt.Errorf("invalid request body: %w", err)
+	}
+
+	return req, nil
+}
+
+// decodeGetCouponsRequest is a transport/http.DecodeRequestFunc for the request without parameters.
+func decodeGetCouponsRequest(_ context.Context, _ *http.Request) (interface{}, error) {
+	return nil, nil
+}
+
+// decodeUpdateCouponStatusRequest is a transport/http.DecodeRequestFunc that decodes a
+// JSON-encoded request from the HTTP request body and the coupon ID from the URL.
+func decodeUpdateCouponStatusRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var req UpdateCouponStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, fmt.Errorf("invalid request body: %w", err)
+	}
+
+	couponID, err := uuid.Parse(chi.URLParam(r, "coupon_id"))
+	if err != nil {
+		return nil, ErrInvalidRequest
+	}
+	req.CouponID = couponID
+
+	return req, nil
+}
+
+// decodeDeleteCouponRequest is a transport/http.DecodeRequestFunc that decodes
+// the coupon ID from the URL.
+func decodeDeleteCouponRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	couponID, err := uuid.Parse(chi.URLParam(r, "coupon_id"))
+	if err != nil {
+		return nil, ErrInvalidRequest
+	}
+
+	return couponID, nil
+}
+
+// decodeCreateGatingRuleRequest is a transport/http.DecodeRequestFunc that decodes a
+// JSON-encoded request from the HTTP request body.
+func decodeCreateGatingRuleRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var req CreateGatingRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, fmt.Errorf("invalid request body: %w", err)
+	}
+
+	return req, nil
+}
+
+// decodeGetGatingRulesRequest is a transport/http.DecodeRequestFunc for the request without parameters.
+func decodeGetGatingRulesRequest(_ context.Context, _ *http.Request) (interface{}, error) {
+	return nil, nil
+}
+
+// decodeUpdateGatingRuleStatusRequest is a transport/http.DecodeRequestFunc that decodes a
+// JSON-encoded request from the HTTP request body and the rule ID from the URL.
+func decodeUpdateGatingRuleStatusRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var req UpdateGatingRuleStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, fmt.Errorf("invalid request body: %w", err)
+	}
+
+	ruleID, err := uuid.Parse(chi.URLParam(r, "rule_id"))
+	if err != nil {
+		return nil, ErrInvalidRequest
+	}
+	req.RuleID = ruleID
+
+	return req, nil
+}
+
+// decodeDeleteGatingRuleRequest is a transport/http.DecodeRequestFunc that decodes
+// the rule ID from the URL.
+func decodeDeleteGatingRuleRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	ruleID, err := uuid.Parse(chi.URLParam(r, "rule_id"))
+	if err != nil {
+		return nil, ErrInvalidRequest
+	}
+
+	return ruleID, nil
+}
+
+// decodePayWithGiftCardRequest is a transport/http.DecodeRequestFunc that decodes a
+// JSON-encoded request from the HTTP request body and the payment ID from the URL.
+func decodePayWithGiftCardRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var req PayWithGiftCardRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, fmt.Errorf("invalid request body: %w", err)
+	}
+
+	req.PaymentID = chi.URLParam(r, "payment_id")
+
+	return req, nil
+}
+
+// decodeIssueGiftCardRequest is a transport/http.DecodeRequestFunc that decodes a
+// JSON-encoded request from the HTTP request body.
+func decodeIssueGiftCardRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var req IssueGiftCardRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, fmt.Errorf("invalid request body: %w", err)
+	}
+
+	return req, nil
+}
+
+// decodeGetGiftCardsRequest is a transport/http.DecodeRequestFunc for the request without parameters.
+func decodeGetGiftCardsRequest(_ context.Context, _ *http.Request) (interface{}, error) {
+	return nil, nil
+}
+
+// decodeGetGiftCardRequest is a transport/http.DecodeRequestFunc that decodes
+// the gift card ID from the URL.
+func decodeGetGiftCardRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	cardID, err := uuid.Parse(chi.URLParam(r, "gift_card_id"))
+	if err != nil {
+		return nil, ErrInvalidRequest
+	}
+
+	return cardID, nil
+}
+
+// decodeUpdateGiftCardStatusRequest is a transport/http.DecodeRequestFunc that decodes a
+// JSON-encoded request from the HTTP request body and the gift card ID from the URL.
+func decodeUpdateGiftCardStatusRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var req UpdateGiftCardStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, fmt.Errorf("invalid request body: %w", err)
+	}
+
+	cardID, err := uuid.Parse(chi.URLParam(r, "gift_card_id"))
+	if err != nil {
+		return nil, ErrInvalidRequest
+	}
+	req.GiftCardID = cardID
+
+	return req, nil
+}
+
+// decodeCreateAffiliateRequest is a transport/http.DecodeRequestFunc that decodes a
+// JSON-encoded request from the HTTP request body.
+func decodeCreateAffiliateRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var req CreateAffiliateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, fmt.Errorf("invalid request body: %w", err)
+	}
+
+	return req, nil
+}
+
+// decodeGetAffiliatesRequest is a transport/http.DecodeRequestFunc for the request without parameters.
+func decodeGetAffiliatesRequest(_ context.Context, _ *http.Request) (interface{}, error) {
+	return nil, nil
+}
+
+// decodeAffiliateIDRequest is a transport/http.DecodeRequestFunc that decodes
+// the affiliate ID from the URL.
+func decodeAffiliateIDRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	affiliateID, err := uuid.Parse(chi.URLParam(r, "affiliate_id"))
+	if err != nil {
+		return nil, ErrInvalidRequest
+	}
+
+	return affiliateID, nil
+}
+
+// decodeUpdateAffiliateStatusRequest is a transport/http.DecodeRequestFunc that decodes a
+// JSON-encoded request from the HTTP request body and the affiliate ID from the URL.
+func decodeUpdateAffiliateStatusRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var req UpdateAffiliateStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, fmt.Errorf("invalid request body: %w", err)
+	}
+
+	affiliateID, err := uuid.Parse(chi.URLParam(r, "affiliate_id"))
+	if err != nil {
+		return nil, ErrInvalidRequest
+	}
+	req.AffiliateID = affiliateID
+
+	return req, nil
+}
+
+// decodeGetAffiliateReportRequest is a transport/http.DecodeRequestFunc that decodes
+// the affiliate ID from the URL and the report period from the `from` and `to` query parameters (RFC3339).
+// The period is the last 30 days by default.
+func decodeGetAffiliateReportRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	affiliateID, err := uuid.Parse(chi.URLParam(r, "affiliate_id"))
+	if err != nil {
+		return nil, ErrInvalidRequest
+	}
+
+	req := GetAffiliateReportRequest{
+		AffiliateID: affiliateID,
+		Until:       time.Now(),
+	}
+	if to := r.URL.Query().Get("to"); to != "" {
+		if req.Until, err = time.Parse(time.RFC3339, to); err != nil {
+			return nil, fmt.Errorf("%w: invalid to: %v", ErrInvalidParameter, err)
+		}
+	}
+	req.Since = req.Until.AddDate(0, 0, -30)
+	if from := r.URL.Query().Get("from"); from != "" {
+		if req.Since, err = time.Parse(time.RFC3339, from); err != nil {
+			return nil, fmt.Errorf("%w: invalid from: %v", ErrInvalidParameter, err)
+		}
+	}
+
+	return req, nil
+}
+
+// decodeCreateWebhookEndpointRequest is a transport/http.DecodeRequestFunc that decodes a
+// JSON-encoded request from the HTTP request body.
+func decodeCreateWebhookEndpointRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var req WebhookEndpointRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, fmt.Errorf("invalid request body: %w", err)
+	}
+
+	return req, nil
+}
+
+// decodeGetWebhookEndpointsRequest is a transport/http.DecodeRequestFunc for the request without parameters.
+func decodeGetWebhookEndpointsRequest(_ context.Context, _ *http.Request) (interface{}, error) {
+	return nil, nil
+}
+
+// decodeWebhookEndpointIDRequest is a transport/http.DecodeRequestFunc that decodes
+// the webhook endpoint ID from the URL.
+func decodeWebhookEndpointIDRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	endpointID, err := uuid.Parse(chi.URLParam(r, "endpoint_id"))
+	if err != nil {
+		return nil, ErrInvalidRequest
+	}
+
+	return endpointID, nil
+}
+
+// decodeUpdateWebhookEndpointRequest is a transport/http.DecodeRequestFunc that decodes a
+// JSON-encoded request from the HTTP request body and the webhook endpoint ID from the URL.
+func decodeUpdateWebhookEndpointRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var req WebhookEndpointRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, fmt.Errorf("invalid request body: %w", err)
+	}
+
+	endpointID, err := uuid.Parse(chi.URLParam(r, "endpoint_id"))
+	if err != nil {
+		return nil, ErrInvalidRequest
+	}
+	req.EndpointID = endpointID
+
+	return req, nil
+}
+
+// decodeRotateWebhookSecretRequest is a transport/http.DecodeRequestFunc that decodes
+// the webhook endpoint ID from the URL and the optional JSON-encoded request body.
+func decodeRotateWebhookSecretRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var req RotateWebhookSecretRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("invalid request body: %w", err)
+	}
+
+	endpointID, err := uuid.Parse(chi.URLParam(r, "endpoint_id"))
+	if err != nil {
+		return nil, ErrInvalidRequest
+	}
+	req.EndpointID = endpointID
+
+	return req, nil
+}
+
+// decodeGetWebhookDeliveriesRequest is a transport/http.DecodeRequestFunc that decodes
+// the delivery log filter from the query parameters: endpoint_id (the nil UUID for the default endpoint),
+// event, event_id, payment_id, status (succeeded or failed), from and to (RFC3339), limit and offset.
+// The period is the last 30 days by default.
+func decodeGetWebhookDeliveriesRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	q := r.URL.Query()
+	req := GetWebhookDeliveriesRequest{
+		Event:  q.Get("event"),
+		Status: q.Get("status"),
+		Until:  time.Now(),
+		Limit:  50,
+	}
+
+	if v := q.Get("endpoint_id"); v != "" {
+		endpointID, err := uuid.Parse(v)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid endpoint_id: %v", ErrInvalidParameter, err)
+		}
+		req.EndpointID = &endpointID
+	}
+	if v := q.Get("event_id"); v != "" {
+		eventID, err := uuid.Parse(v)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid event_id: %v", ErrInvalidParameter, err)
+		}
+		req.EventID = &eventID
+	}
+	if v := q.Get("payment_id"); v != "" {
+		paymentID, err := uuid.Parse(v)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid payment_id: %v", ErrInvalidParameter, err)
+		}
+		req.PaymentID = &paymentID
+	}
+
+	var err error
+	if to := q.Get("to"); to != "" {
+		if req.Until, err = time.Parse(time.RFC3339, to); err != nil {
+			return nil, fmt.Errorf("%w: invalid to: %v", ErrInvalidParameter, err)
+		}
+	}
+	req.Since = req.Until.AddDate(0, 0, -30)
+	if from := q.Get("from"); from != "" {
+		if req.Since, err = time.Parse(time.RFC3339, from); err != nil {
+			return nil, fmt.Errorf("%w: invalid from: %v", ErrInvalidParameter, err)
+		}
+	}
+
+	if limit := q.Get("limit"); limit != "" {
+		v, err := strconv.ParseInt(limit, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid limit: %w", err)
+		}
+		req.Limit = int32(v)
+	}
+	if offset := q.Get("offset"); offset != "" {
+		v, err := strconv.ParseInt(offset, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid offset: %w", err)
+		}
+		req.Offset = int32(v)
+	}
+
+	return req, nil
+}
+
+// decodeWebhookDeliveryIDRequest is a transport/http.DecodeRequestFunc that decodes
+// the webhook delivery ID from the URL.
+func decodeWebhookDeliveryIDRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	deliveryID, err := uuid.Parse(chi.URLParam(r, "delivery_id"))
+	if err != nil {
+		return nil, ErrInvalidRequest
+	}
+
+	return deliveryID, nil
+}
@@ -2,17 +2,20 @@ package solana
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/binary"
 	"encoding/json"
 	"fmt"
-	"net/http"
 	"strings"
 	"time"
 
+	"github.com/easypmnt/checkout-api/internal/utils"
 	"github.com/easypmnt/checkout-api/solana/metadata"
 	"github.com/pkg/errors"
 	"github.com/portto/solana-go-sdk/client"
 	"github.com/portto/solana-go-sdk/common"
 	"github.com/portto/solana-go-sdk/program/metaplex/token_metadata"
+	"github.com/portto/solana-go-sdk/program/token"
 	"github.com/portto/solana-go-sdk/rpc"
 )
 
@@ -161,9 +164,12 @@ func (c *Client) GetMinimumBalanceForRentExemption(ctx context.Context, size uin
 }
 
 // GetTransactionStatus gets the transaction status.
+// The ledger history is searched as well, so the status of an old transaction is known too.
 // Returns the transaction status or an error.
 func (c *Client) GetTransactionStatus(ctx context.Context, txhash string) (TransactionStatus, error) {
-	status, err := c.rpcClient.GetSignatureStatus(ctx, txhash)
+	status, err := c.rpcClient.GetSignatureStatusWithConfig(ctx, txhash, rpc.GetSignatureStatusesConfig{
+		SearchTransactionHistory: true,
+	})
 	if err != nil {
 		return TransactionStatusUnknown, fmt.Errorf("failed to get transaction status: %v", err)
 	}
@@ -201,6 +207,41 @@ func (c *Client) SendTransaction(ctx context.Context, txSource string) (string,
 	return txSig, nil
 }
 
+// IsBlockhashValid checks if the given blockhash is still valid,
+// i.e. a transaction with this blockhash can still be processed by the network.
+func (c *Client) IsBlockhashValid(ctx context.Context, blockhash string) (bool, error) {
+	valid, err := c.rpcClient.IsBlockhashValid(ctx, blockhash)
+	if err != nil {
+		return false, fmt.Errorf("failed to check blockhash: %w", err)
+	}
+
+	return valid, nil
+}
+
+// SimulateTransaction simulates the given base64 encoded transaction without signature verification,
+// so a transaction which is not signed by the customer yet can be checked as well.
+// Returns *SimulationError if the transaction would fail, or an error if the simulation request failed.
+func (c *Client) SimulateTransaction(ctx context.Context, txSource string) error {
+	tx, err := DecodeTransaction(txSource)
+	if err != nil {
+		return fmt.Errorf("failed to simulate transaction: base64 to bytes: %w", err)
+	}
+
+	result, err := c.rpcClient.SimulateTransactionWithConfig(ctx, tx, client.SimulateTransactionConfig{
+		SigVerify:  false,
+		Commitment: rpc.CommitmentConfirmed,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to simulate transaction: %w", err)
+	}
+
+	if simErr := NewSimulationError(result.Err, result.Logs); simErr != nil {
+		return simErr
+	}
+
+	return nil
+}
+
 // WaitForTransactionConfirmed waits for a transaction to be confirmed.
 // Returns the transaction status or an error.
 // If maxDuration is 0, it will wait for 5 minutes.
@@ -309,6 +350,52 @@ func (c *Client) GetTokenSupply(ctx context.Context, base58MintAddr string) (Bal
 	return NewBalance(amount, decimals), nil
 }
 
+// GetTokenHolders returns the balances of all holders of the given base58 encoded token mint
+// mapped by the owner wallet address. Empty token accounts are skipped.
+// Only the owner and amount of the token accounts are requested, so it's cheap enough
+// to take a snapshot of the token with thousands of holders.
+func (c *Client) GetTokenHolders(ctx context.Context, base58MintAddr string) (map[string]uint64, error) {
+	resp, err := c.rpcClient.RpcClient.GetProgramAccountsWithConfig(ctx, common.TokenProgramID.ToBase58(), rpc.GetProgramAccountsConfig{
+		Encoding:   rpc.AccountEncodingBase64,
+		Commitment: rpc.CommitmentConfirmed,
+		DataSlice:  &rpc.DataSlice{Offset: 32, Length: 40}, // owner (32 bytes) + amount (8 bytes)
+		Filters: []rpc.GetProgramAccountsConfigFilter{
+			{DataSize: token.TokenAccountSize},
+			{MemCmp: &rpc.GetProgramAccountsConfigFilterMemCmp{Offset: 0, Bytes: base58MintAddr}},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get token accounts by mint: %w", err)
+	}
+	if resp.Error != nil {
+		return nil, fmt.Errorf("failed to get token accounts by mint: %v", resp.Error)
+	}
+
+	result := make(map[string]uint64, len(resp.Result))
+	for _, acc := range resp.Result {
+		data, ok := acc.Account.Data.([]any)
+		if !ok || len(data) != 2 || data[1] != string(rpc.AccountEncodingBase64) {
+			return nil, fmt.Errorf("unexpected data of token account %s", acc.Pubkey)
+		}
+		raw, ok := data[0].(string)
+		if !ok {
+			return nil, fmt.Errorf("unexpected data of token account %s", acc.Pubkey)
+		}
+		b, err := base64.StdEncoding.DecodeString(raw)
+		if err != nil || len(b) != 40 {
+			return nil, fmt.Errorf("invalid data of token account %s", acc.Pubkey)
+		}
+
+		amount := binary.LittleEndian.Uint64(b[32:])
+		if amount == 0 {
+			continue
+		}
+		result[common.PublicKeyFromBytes(b[:32]).ToBase58()] += amount
+	}
+
+	return result, nil
+}
+
 // GetFungibleTokenMetadata returns the on-chain SPL token metadata by the given base58 encoded SPL token mint address.
 // Returns the token metadata or an error.
 func (c *Client) GetFungibleTokenMetadata(ctx context.Context, base58MintAddr string) (result *FungibleTokenMetadata, err error) {
@@ -354,10 +441,13 @@ func (c *Client) GetFungibleTokenMetadata(ctx context.Context, base58MintAddr st
 		return result, fmt.Errorf("failed to deserialize metadata: %w", err)
 	}
 
+	origin := NewAssetOrigin(md)
 	result = &FungibleTokenMetadata{
-		Mint:   base58MintAddr,
-		Name:   md.Data.Name,
-		Symbol: md.Data.Symbol,
+		Mint:       base58MintAddr,
+		Name:       md.Data.Name,
+		Symbol:     md.Data.Symbol,
+		Collection: origin.Collection,
+		Creators:   origin.Creators,
 	}
 
 	if sup, err := c.GetTokenSupply(ctx, base58MintAddr); err == nil {
@@ -378,25 +468,79 @@ func (c *Client) GetFungibleTokenMetadata(ctx context.Context, base58MintAddr st
 	return result, nil
 }
 
+// GetAssetsOrigin returns the verified collection and creators of the given base58 encoded mint addresses.
+// It reads the same on-chain metadata as GetFungibleTokenMetadata, but in batches and without
+// loading the off-chain metadata, so it's cheap enough to check all the NFTs of a wallet.
+// Mints without metadata are skipped.
+func (c *Client) GetAssetsOrigin(ctx context.Context, base58MintAddrs []string) ([]AssetOrigin, error) {
+	result := make([]AssetOrigin, 0, len(base58MintAddrs))
+	for start := 0; start < len(base58MintAddrs); start += MaxMultipleAccounts {
+		end := start + MaxMultipleAccounts
+		if end > len(base58MintAddrs) {
+			end = len(base58MintAddrs)
+		}
+
+		metadataAccounts := make([]string, 0, end-start)
+		for _, mint := range base58MintAddrs[start:end] {
+			metadataAccount, err := token_metadata.GetTokenMetaPubkey(common.PublicKeyFromString(mint))
+			if err != nil {
+				return nil, fmt.Errorf("failed to get token metadata account: %w", err)
+			}
+			metadataAccounts = append(metadataAccounts, metadataAccount.ToBase58())
+		}
+
+		accounts, err := c.rpcClient.GetMultipleAccounts(ctx, metadataAccounts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get metadata accounts: %w", err)
+		}
+
+		for _, acc := range accounts {
+			if len(acc.Data) == 0 {
+				continue
+			}
+			md, err := token_metadata.MetadataDeserialize(acc.Data)
+			if err != nil {
+				continue
+			}
+			result = append(result, NewAssetOrigin(md))
+		}
+	}
+
+	return result, nil
+}
+
+// GetWalletTokens returns the token balances of the given base58 encoded wallet address mapped by mint address.
+// Empty token accounts are skipped.
+func (c *Client) GetWalletTokens(ctx context.Context, base58Addr string) (map[string]uint64, error) {
+	accounts, err := c.rpcClient.GetTokenAccountsByOwner(ctx, base58Addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get token accounts by owner: %w", err)
+	}
+
+	result := make(map[string]uint64, len(accounts))
+	for _, acc := range accounts {
+		if acc.Amount == 0 {
+			continue
+		}
+		result[acc.Mint.ToBase58()] += acc.Amount
+	}
+
+	return result, nil
+}
+
 // @deprecated
 // getDeprecatedTokenMetadata returns the deprecated SPL token metadata by the given base58 encoded SPL token mint address.
 // This is a temporary solution to support the deprecated metadata format.
 // Returns the token metadata or an error.
 // Works only with mainnet.
-func (c *Client) getDeprecatedTokenMetadata(_ context.Context, base58MintAddr string) (*FungibleTokenMetadata, error) {
+func (c *Client) getDeprecatedTokenMetadata(ctx context.Context, base58MintAddr string) (*FungibleTokenMetadata, error) {
 	if c.tokenListPath == "" || base58MintAddr == "" {
 		return nil, fmt.Errorf("failed to get token metadata: token list path or mint address is empty")
 	}
 
-	resp, err := http.Get(c.tokenListPath)
+	tokenList, err := c.GetTokenList(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to download token list from uri: %w", err)
-	}
-	defer resp.Body.Close()
-
-	var tokenList TokenList
-	if err := json.NewDecoder(resp.Body).Decode(&tokenList); err != nil {
-		return nil, fmt.Errorf("failed to decode token list from uri: %w", err)
+		return nil, err
 	}
 
 	// Find token metadata.
@@ -432,6 +576,27 @@ func (c *Client) getDeprecatedTokenMetadata(_ context.Context, base58MintAddr st
 	return &result, nil
 }
 
+// GetTokenList returns the token list loaded from the configured token list path.
+// The path can be either a URL or a local file path.
+// Returns the token list or an error.
+func (c *Client) GetTokenList(_ context.Context) (*TokenList, error) {
+	if c.tokenListPath == "" {
+		return nil, fmt.Errorf("failed to get token list: token list path is empty")
+	}
+
+	b, err := utils.GetFileByPath(c.tokenListPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load token list: %w", err)
+	}
+
+	var tokenList TokenList
+	if err := json.Unmarshal(b, &tokenList); err != nil {
+		return nil, fmt.Errorf("failed to decode token list: %w", err)
+	}
+
+	return &tokenList, nil
+}
+
 // ValidateTransactionByReference returns the transaction by the given reference.
 // Returns transaction signature or an error if the transaction is not found or the transaction failed.
 func (c *Client) ValidateTransactionByReference(ctx context.Context, reference, destination string, amount uint64, mint string) (string, error) {
@@ -18,4 +18,17 @@ var (
 	ErrNoTransactionsFound       = errors.New("no transactions found")
 	ErrTransactionNotConfirmed   = errors.New("transaction not confirmed")
 	ErrTransactionNotFound       = errors.New("transaction not found")
+
+	// Signed transaction verification errors.
+	ErrUnsupportedTransactionVersion = errors.New("unsupported transaction version")
+	ErrFeePayerMismatch              = errors.New("transaction fee payer does not match")
+	ErrMissingSignature              = errors.New("transaction is not fully signed")
+	ErrPaymentTransferNotFound       = errors.New("payment transfer instruction not found")
+
+	// Transaction simulation errors.
+	ErrSimulationFailed          = errors.New("transaction simulation failed")
+	ErrInsufficientFunds         = errors.New("insufficient funds")
+	ErrInsufficientFundsForFee   = errors.New("insufficient funds for fee")
+	ErrInsufficientFundsForRent  = errors.New("insufficient funds for rent")
+	ErrSlippageToleranceExceeded = errors.New("slippage tolerance exceeded")
 )
@@ -17,6 +17,20 @@ import (
 	"github.com/portto/solana-go-sdk/types"
 )
 
+// FindAssociatedTokenAddress returns the base58 encoded associated token account address
+// for the given base58 encoded owner and mint addresses.
+func FindAssociatedTokenAddress(base58Owner, base58Mint string) (string, error) {
+	ata, _, err := common.FindAssociatedTokenAddress(
+		common.PublicKeyFromString(base58Owner),
+		common.PublicKeyFromString(base58Mint),
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to find associated token address: %w", err)
+	}
+
+	return ata.ToBase58(), nil
+}
+
 // CreateAssociatedTokenAccountParam defines the parameters for creating an associated token account.
 type CreateAssociatedTokenAccountParam struct {
 	Funder string // base58 encoded public key of the account that will fund the associated token account. Must be a signer.
@@ -144,6 +158,7 @@ type TransferTokenParam struct {
 	Mint      string // required; base58 encoded public key of the mint of the token to send.
 	Reference string // optional; base58 encoded public key to use as a reference for the transaction.
 	Amount    uint64 // required; the amount of tokens to send (in token minimal units), e.g. 1 USDT = 1000000 (10^6) lamports.
+	Funder    string // optional; base58 encoded public key of the account that funds the recipient's token account, defaults to the sender. Must be a signer.
 }
 
 // Validate validates the parameters.
@@ -169,7 +184,7 @@ func (p TransferTokenParam) Validate() error {
 // TransferToken transfers tokens from one wallet to another.
 // Note: This function does not check if the sender has enough tokens to send. It is the responsibility
 // of the caller to check this.
-// FeePayer must be provided if Sender is not set.
+// Recipient's associated token account is created if it does not exist, the rent is paid by the funder.
 func TransferToken(params TransferTokenParam) InstructionFunc {
 	return func(ctx context.Context, c SolanaClient) ([]types.Instruction, error) {
 		if err := params.Validate(); err != nil {
@@ -180,7 +195,11 @@ func TransferToken(params TransferTokenParam) InstructionFunc {
 			senderPubKey    = common.PublicKeyFromString(params.Sender)
 			recipientPubKey = common.PublicKeyFromString(params.Recipient)
 			mintPubKey      = common.PublicKeyFromString(params.Mint)
+			funderPubKey    = senderPubKey
 		)
+		if params.Funder != "" {
+			funderPubKey = common.PublicKeyFromString(params.Funder)
+		}
 		senderAta, _, err := common.FindAssociatedTokenAddress(senderPubKey, mintPubKey)
 		if err != nil {
 			return nil, fmt.Errorf("failed to find associated token address for sender wallet: %w", err)
@@ -196,7 +215,7 @@ func TransferToken(params TransferTokenParam) InstructionFunc {
 			instructions = append(instructions,
 				associated_token_account.CreateAssociatedTokenAccount(
 					associated_token_account.CreateAssociatedTokenAccountParam{
-						Funder:                 senderPubKey,
+						Funder:                 funderPubKey,
 						Owner:                  recipientPubKey,
 						Mint:                   mintPubKey,
 						AssociatedTokenAccount: recipientAta,
@@ -484,6 +503,143 @@ func MintFungibleToken(params MintFungibleTokenParams) InstructionFunc {
 	}
 }
 
+// CreateNonFungibleTokenParam defines the parameters for the CreateNonFungibleToken instruction.
+type CreateNonFungibleTokenParam struct {
+	Mint        string // required; The new token mint public key, must be a signer.
+	Authority   string // required; The mint and update authority of the token, must be a signer.
+	FeePayer    string // required; The wallet to pay the fees from, must be a signer.
+	Owner       string // required; The wallet to receive the token.
+	Name        string // required; Name of the token, up to 32 characters.
+	Symbol      string // required; Symbol of the token, up to 10 characters.
+	MetadataURI string // required; URI of the uploaded token metadata.
+}
+
+// Validate checks that the required fields of the params are set.
+func (p CreateNonFungibleTokenParam) Validate() error {
+	if p.Mint == "" {
+		return fmt.Errorf("mint address is required")
+	}
+	if p.Authority == "" {
+		return fmt.Errorf("authority public key is required")
+	}
+	if p.FeePayer == "" {
+		return fmt.Errorf("fee payer public key is required")
+	}
+	if p.Owner == "" {
+		return fmt.Errorf("owner public key is required")
+	}
+	if p.Name == "" || len(p.Name) > 32 {
+		return fmt.Errorf("token name must be between 1 and 32 characters")
+	}
+	if p.Symbol == "" || len(p.Symbol) > 10 {
+		return fmt.Errorf("token symbol must be between 1 and 10 characters")
+	}
+	if !strings.HasPrefix(p.MetadataURI, "http://") && !strings.HasPrefix(p.MetadataURI, "https://") {
+		return fmt.Errorf("field MetadataURI must be a valid URI")
+	}
+	return nil
+}
+
+// CreateNonFungibleToken creates instructions for minting a new NFT to the owner wallet:
+// the mint account with 0 decimals, the token metadata, a single token in the owner's
+// associated token account and the master edition with zero max supply,
+// so no more tokens or prints can be minted.
+// Unlike CreateFungibleToken, the metadata is not downloaded from the URI,
+// since freshly uploaded files may not be available yet.
+func CreateNonFungibleToken(params CreateNonFungibleTokenParam) InstructionFunc {
+	return func(ctx context.Context, c SolanaClient) ([]types.Instruction, error) {
+		if err := params.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid params: %w", err)
+		}
+
+		var (
+			mintPubKey  = common.PublicKeyFromString(params.Mint)
+			authPubKey  = common.PublicKeyFromString(params.Authority)
+			feePayer    = common.PublicKeyFromString(params.FeePayer)
+			ownerPubKey = common.PublicKeyFromString(params.Owner)
+		)
+
+		metaPubkey, err := token_metadata.GetTokenMetaPubkey(mintPubKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get token metadata pubkey: %w", err)
+		}
+
+		editionPubkey, err := token_metadata.GetMasterEdition(mintPubKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get master edition pubkey: %w", err)
+		}
+
+		ownerAta, _, err := common.FindAssociatedTokenAddress(ownerPubKey, mintPubKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find associated token address: %w", err)
+		}
+
+		rentExemption, err := c.GetMinimumBalanceForRentExemption(ctx, token.MintAccountSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get minimum balance for rent exemption: %w", err)
+		}
+
+		instructions := []types.Instruction{
+			system.CreateAccount(system.CreateAccountParam{
+				From:     feePayer,
+				New:      mintPubKey,
+				Owner:    common.TokenProgramID,
+				Lamports: rentExemption,
+				Space:    token.MintAccountSize,
+			}),
+			token.InitializeMint2(token.InitializeMint2Param{
+				Decimals:   0,
+				Mint:       mintPubKey,
+				MintAuth:   authPubKey,
+				FreezeAuth: utils.Pointer(authPubKey),
+			}),
+			token_metadata.CreateMetadataAccountV2(token_metadata.CreateMetadataAccountV2Param{
+				Metadata:                metaPubkey,
+				Mint:                    mintPubKey,
+				MintAuthority:           authPubKey,
+				Payer:                   feePayer,
+				UpdateAuthority:         authPubKey,
+				UpdateAuthorityIsSigner: true,
+				IsMutable:               false,
+				Data: token_metadata.DataV2{
+					Name:   params.Name,
+					Symbol: params.Symbol,
+					Uri:    params.MetadataURI,
+					Creators: &[]token_metadata.Creator{
+						{Address: authPubKey, Verified: true, Share: 100},
+					},
+				},
+			}),
+			associated_token_account.CreateAssociatedTokenAccount(
+				associated_token_account.CreateAssociatedTokenAccountParam{
+					Funder:                 feePayer,
+					Owner:                  ownerPubKey,
+					Mint:                   mintPubKey,
+					AssociatedTokenAccount: ownerAta,
+				},
+			),
+			token.MintTo(token.MintToParam{
+				Mint:    mintPubKey,
+				To:      ownerAta,
+				Auth:    authPubKey,
+				Signers: []common.PublicKey{},
+				Amount:  1,
+			}),
+			token_metadata.CreateMasterEditionV3(token_metadata.CreateMasterEditionParam{
+				Edition:         editionPubkey,
+				Mint:            mintPubKey,
+				UpdateAuthority: authPubKey,
+				MintAuthority:   authPubKey,
+				Metadata:        metaPubkey,
+				Payer:           feePayer,
+				MaxSupply:       utils.Pointer(uint64(0)),
+			}),
+		}
+
+		return instructions, nil
+	}
+}
+
 // BurnTokenParams are the parameters for the BurnToken instruction.
 type BurnTokenParams struct {
 	Mint              string // base58 encoded public key of the mint
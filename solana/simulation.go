@@ -0,0 +1,74 @@
+package solana
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SimulationError represents a failed transaction simulation.
+// Err is one of the predefined errors describing the failure reason.
+type SimulationError struct {
+	Err  error
+	Logs []string
+}
+
+// Error returns the error message.
+func (e *SimulationError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrSimulationFailed.Error(), e.Err.Error())
+}
+
+// Unwrap returns the failure reason.
+func (e *SimulationError) Unwrap() error {
+	return e.Err
+}
+
+// Program log patterns of the common failures.
+var simulationLogPatterns = []struct {
+	pattern string
+	err     error
+}{
+	{"insufficient lamports", ErrInsufficientFunds},                // system program transfer
+	{"Error: insufficient funds", ErrInsufficientFunds},            // token program transfer or burn
+	{"SlippageToleranceExceeded", ErrSlippageToleranceExceeded},    // jupiter anchor error
+	{"custom program error: 0x1771", ErrSlippageToleranceExceeded}, // jupiter error code 6001
+	{"Error: Account not associated with this Mint", ErrTokenAccountDoesNotExist},
+	{"AccountNotInitialized", ErrTokenAccountDoesNotExist},
+	{"invalid account data for instruction", ErrTokenAccountDoesNotExist}, // token account is not created yet
+}
+
+// NewSimulationError parses the transaction error and program logs returned by the simulation
+// and returns the simulation error with the most specific failure reason.
+// Returns nil if the transaction error is nil.
+func NewSimulationError(txErr interface{}, logs []string) *SimulationError {
+	if txErr == nil {
+		return nil
+	}
+
+	result := &SimulationError{Err: ErrSimulationFailed, Logs: logs}
+
+	// Transaction level errors, the transaction is not executed at all.
+	switch e := txErr.(type) {
+	case string:
+		if e == "InsufficientFundsForFee" || e == "AccountNotFound" {
+			result.Err = ErrInsufficientFundsForFee
+			return result
+		}
+	case map[string]interface{}:
+		if _, ok := e["InsufficientFundsForRent"]; ok {
+			result.Err = ErrInsufficientFundsForRent
+			return result
+		}
+	}
+
+	// Instruction level errors, the reason is in the program logs.
+	for _, log := range logs {
+		for _, p := range simulationLogPatterns {
+			if strings.Contains(log, p.pattern) {
+				result.Err = p.err
+				return result
+			}
+		}
+	}
+
+	return result
+}
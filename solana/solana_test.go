@@ -9,6 +9,7 @@ import (
 	"github.com/dmitrymomot/go-env"
 	"github.com/easypmnt/checkout-api/internal/utils"
 	"github.com/easypmnt/checkout-api/solana"
+	"github.com/portto/solana-go-sdk/program/metaplex/token_metadata"
 	"github.com/portto/solana-go-sdk/types"
 	"github.com/stretchr/testify/require"
 )
@@ -538,3 +539,197 @@ func TestCheckSolTransferTransaction(t *testing.T) {
 		require.Error(t, err)
 	})
 }
+
+func TestNewSimulationError(t *testing.T) {
+	tests := []struct {
+		name  string
+		txErr interface{}
+		logs  []string
+		want  error
+	}{
+		{
+			name:  "insufficient funds for fee",
+			txErr: "InsufficientFundsForFee",
+			want:  solana.ErrInsufficientFundsForFee,
+		},
+		{
+			name:  "insufficient funds for rent",
+			txErr: map[string]interface{}{"InsufficientFundsForRent": map[string]interface{}{"account_index": 0}},
+			want:  solana.ErrInsufficientFundsForRent,
+		},
+		{
+			name:  "insufficient token balance",
+			txErr: map[string]interface{}{"InstructionError": []interface{}{1, map[string]interface{}{"Custom": 1}}},
+			logs: []string{
+				"Program TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA invoke [1]",
+				"Program log: Instruction: Transfer",
+				"Program log: Error: insufficient funds",
+			},
+			want: solana.ErrInsufficientFunds,
+		},
+		{
+			name:  "slippage",
+			txErr: map[string]interface{}{"InstructionError": []interface{}{2, map[string]interface{}{"Custom": 6001}}},
+			logs:  []string{"Program JUP4Fb2cqiRUcaTHdrPC8h2gNsA2ETXiPDD33WcGuJB failed: custom program error: 0x1771"},
+			want:  solana.ErrSlippageToleranceExceeded,
+		},
+		{
+			name:  "unknown failure",
+			txErr: map[string]interface{}{"InstructionError": []interface{}{0, "GenericError"}},
+			want:  solana.ErrSimulationFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := solana.NewSimulationError(tt.txErr, tt.logs)
+			require.NotNil(t, err)
+			require.ErrorIs(t, err, tt.want)
+		})
+	}
+
+	require.Nil(t, solana.NewSimulationError(nil, nil))
+}
+
+func TestCheckPaymentTransaction(t *testing.T) {
+	ctx := context.Background()
+	referenceAcc := types.NewAccount()
+	amount := uint64(2500000)
+
+	instructions, err := solana.TransferSOL(solana.TransferSOLParams{
+		Sender:    wallet1.PublicKey.ToBase58(),
+		Recipient: wallet2.PublicKey.ToBase58(),
+		Reference: referenceAcc.PublicKey.ToBase58(),
+		Amount:    amount,
+	})(ctx, nil)
+	require.NoError(t, err)
+
+	tx, err := types.NewTransaction(types.NewTransactionParam{
+		Message: types.NewMessage(types.NewMessageParam{
+			FeePayer:        wallet1.PublicKey,
+			RecentBlockhash: "EkSnNWid2cvwEVnVx9aBqawnmiCNiDgp3gUdkDPTKN1N",
+			Instructions:    instructions,
+		}),
+		Signers: []types.Account{wallet1},
+	})
+	require.NoError(t, err)
+
+	txSource, err := solana.EncodeTransaction(tx)
+	require.NoError(t, err)
+
+	params := solana.CheckPaymentTransactionParams{
+		FeePayer:  wallet1.PublicKey.ToBase58(),
+		Reference: referenceAcc.PublicKey.ToBase58(),
+		Recipient: wallet2.PublicKey.ToBase58(),
+		Amount:    amount,
+	}
+
+	t.Run("valid transaction", func(t *testing.T) {
+		require.NoError(t, solana.CheckPaymentTransaction(txSource, params))
+	})
+
+	t.Run("fee payer mismatch", func(t *testing.T) {
+		p := params
+		p.FeePayer = wallet2.PublicKey.ToBase58()
+		require.ErrorIs(t, solana.CheckPaymentTransaction(txSource, p), solana.ErrFeePayerMismatch)
+	})
+
+	t.Run("amount mismatch", func(t *testing.T) {
+		p := params
+		p.Amount = amount + 1
+		require.ErrorIs(t, solana.CheckPaymentTransaction(txSource, p), solana.ErrPaymentTransferNotFound)
+	})
+
+	t.Run("reference mismatch", func(t *testing.T) {
+		p := params
+		p.Reference = types.NewAccount().PublicKey.ToBase58()
+		require.ErrorIs(t, solana.CheckPaymentTransaction(txSource, p), solana.ErrPaymentTransferNotFound)
+	})
+
+	t.Run("missing signature", func(t *testing.T) {
+		unsigned := tx
+		unsigned.Signatures = []types.Signature{make([]byte, 64)}
+		unsignedSource, err := solana.EncodeTransaction(unsigned)
+		require.NoError(t, err)
+		require.ErrorIs(t, solana.CheckPaymentTransaction(unsignedSource, params), solana.ErrMissingSignature)
+	})
+}
+
+func TestNewAssetOrigin(t *testing.T) {
+	collection := types.NewAccount().PublicKey
+	creator := types.NewAccount().PublicKey
+	mint := types.NewAccount().PublicKey
+
+	t.Run("verified collection and creators", func(t *testing.T) {
+		origin := solana.NewAssetOrigin(token_metadata.Metadata{
+			Mint: mint,
+			Data: token_metadata.Data{
+				Creators: &[]token_metadata.Creator{
+					{Address: creator, Verified: true, Share: 50},
+					{Address: wallet1.PublicKey, Verified: false, Share: 50},
+				},
+			},
+			Collection: &token_metadata.Collection{Verified: true, Key: collection},
+		})
+		require.Equal(t, mint.ToBase58(), origin.Mint)
+		require.Equal(t, collection.ToBase58(), origin.Collection)
+		require.Equal(t, []string{creator.ToBase58()}, origin.Creators)
+	})
+
+	t.Run("unverified collection", func(t *testing.T) {
+		origin := solana.NewAssetOrigin(token_metadata.Metadata{
+			Mint:       mint,
+			Collection: &token_metadata.Collection{Verified: false, Key: collection},
+		})
+		require.Empty(t, origin.Collection)
+		require.Empty(t, origin.Creators)
+	})
+}
+
+type rentClientStub struct{ solana.SolanaClient }
+
+func (rentClientStub) GetMinimumBalanceForRentExemption(context.Context, uint64) (uint64, error) {
+	return 1461600, nil
+}
+
+func TestCreateNonFungibleToken(t *testing.T) {
+	ctx := context.Background()
+	mint := types.NewAccount()
+	params := solana.CreateNonFungibleTokenParam{
+		Mint:        mint.PublicKey.ToBase58(),
+		Authority:   wallet1.PublicKey.ToBase58(),
+		FeePayer:    wallet1.PublicKey.ToBase58(),
+		Owner:       wallet2.PublicKey.ToBase58(),
+		Name:        "Receipt #1",
+		Symbol:      "RCPT",
+		MetadataURI: "https://www.arweave.net/receipt?ext=json",
+	}
+
+	t.Run("valid params", func(t *testing.T) {
+		instructions, err := solana.CreateNonFungibleToken(params)(ctx, rentClientStub{})
+		require.NoError(t, err)
+		require.Len(t, instructions, 6)
+
+		ata, err := solana.FindAssociatedTokenAddress(params.Owner, params.Mint)
+		require.NoError(t, err)
+		edition, err := token_metadata.GetMasterEdition(mint.PublicKey)
+		require.NoError(t, err)
+
+		require.Equal(t, ata, instructions[4].Accounts[1].PubKey.ToBase58()) // mint to the owner's token account
+		require.Equal(t, edition, instructions[5].Accounts[0].PubKey)        // master edition
+	})
+
+	t.Run("invalid metadata uri", func(t *testing.T) {
+		p := params
+		p.MetadataURI = "receipt.json"
+		_, err := solana.CreateNonFungibleToken(p)(ctx, rentClientStub{})
+		require.Error(t, err)
+	})
+
+	t.Run("too long name", func(t *testing.T) {
+		p := params
+		p.Name = "Proof of purchase receipt for the order"
+		_, err := solana.CreateNonFungibleToken(p)(ctx, rentClientStub{})
+		require.Error(t, err)
+	})
+}
@@ -1,12 +1,17 @@
 package solana
 
 import (
+	"crypto/ed25519"
+	"encoding/binary"
 	"fmt"
 	"strconv"
 
 	"github.com/easypmnt/checkout-api/internal/utils"
 	"github.com/pkg/errors"
 	"github.com/portto/solana-go-sdk/client"
+	"github.com/portto/solana-go-sdk/common"
+	"github.com/portto/solana-go-sdk/program/system"
+	"github.com/portto/solana-go-sdk/program/token"
 	"github.com/portto/solana-go-sdk/types"
 )
 
@@ -112,3 +117,96 @@ func CheckTokenTransferTransaction(meta *client.TransactionMeta, tx types.Transa
 
 	return nil
 }
+
+// CheckPaymentTransactionParams defines the parameters to check a signed payment transaction.
+type CheckPaymentTransactionParams struct {
+	FeePayer  string // required; base58 encoded public key of the expected fee payer.
+	Reference string // required; base58 encoded reference public key of the payment.
+	Recipient string // required; base58 encoded public key of the recipient wallet.
+	Mint      string // optional; base58 encoded mint address, empty for SOL transfers.
+	Amount    uint64 // required; amount to be transferred to the recipient.
+}
+
+// CheckPaymentTransaction checks if a signed transaction matches the payment:
+// the fee payer is the expected one, all the required signatures are valid,
+// and the transaction contains the transfer of the amount to the recipient with the reference.
+func CheckPaymentTransaction(txSource string, params CheckPaymentTransactionParams) error {
+	tx, err := DecodeTransaction(txSource)
+	if err != nil {
+		return err
+	}
+	if tx.Message.Version != types.MessageVersionLegacy {
+		return ErrUnsupportedTransactionVersion
+	}
+	if len(tx.Message.Accounts) == 0 || tx.Message.Accounts[0].ToBase58() != params.FeePayer {
+		return ErrFeePayerMismatch
+	}
+
+	msg, err := tx.Message.Serialize()
+	if err != nil {
+		return errors.Wrap(err, "failed to serialize transaction message")
+	}
+	for i := 0; i < int(tx.Message.Header.NumRequireSignatures); i++ {
+		if i >= len(tx.Signatures) || !ed25519.Verify(tx.Message.Accounts[i].Bytes(), msg, tx.Signatures[i]) {
+			return ErrMissingSignature
+		}
+	}
+
+	recipient := params.Recipient
+	if params.Mint != "" {
+		if recipient, err = FindAssociatedTokenAddress(params.Recipient, params.Mint); err != nil {
+			return err
+		}
+	}
+
+	for _, ins := range tx.Message.DecompileInstructions() {
+		if !hasAccount(ins.Accounts, params.Reference) {
+			continue
+		}
+		if to, amount, ok := parseTransferInstruction(ins, params.Mint != ""); ok &&
+			to == recipient && amount == params.Amount {
+			return nil
+		}
+	}
+
+	return ErrPaymentTransferNotFound
+}
+
+// parseTransferInstruction returns the destination account and the amount
+// of the system or token program transfer instruction.
+func parseTransferInstruction(ins types.Instruction, isToken bool) (string, uint64, bool) {
+	switch {
+	case !isToken && ins.ProgramID == common.SystemProgramID:
+		if len(ins.Data) < 12 || len(ins.Accounts) < 2 ||
+			system.Instruction(binary.LittleEndian.Uint32(ins.Data[:4])) != system.InstructionTransfer {
+			return "", 0, false
+		}
+		return ins.Accounts[1].PubKey.ToBase58(), binary.LittleEndian.Uint64(ins.Data[4:12]), true
+	case isToken && ins.ProgramID == common.TokenProgramID:
+		if len(ins.Data) < 9 {
+			return "", 0, false
+		}
+		amount := binary.LittleEndian.Uint64(ins.Data[1:9])
+		switch token.Instruction(ins.Data[0]) {
+		case token.InstructionTransfer:
+			if len(ins.Accounts) > 1 {
+				return ins.Accounts[1].PubKey.ToBase58(), amount, true
+			}
+		case token.InstructionTransferChecked:
+			if len(ins.Accounts) > 2 {
+				return ins.Accounts[2].PubKey.ToBase58(), amount, true
+			}
+		}
+	}
+	return "", 0, false
+}
+
+// hasAccount checks if the account list contains the given base58 encoded public key.
+func hasAccount(accounts []types.AccountMeta, base58Addr string) bool {
+	for _, acc := range accounts {
+		if acc.PubKey.ToBase58() == base58Addr {
+			return true
+		}
+	}
+	return false
+}
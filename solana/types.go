@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/easypmnt/checkout-api/internal/utils"
+	"github.com/portto/solana-go-sdk/program/metaplex/token_metadata"
 	"github.com/portto/solana-go-sdk/rpc"
 	"github.com/portto/solana-go-sdk/types"
 )
@@ -76,13 +77,40 @@ func ParseTransactionStatus(s rpc.Commitment) TransactionStatus {
 
 // FungibleTokenMetadata represents the metadata of a fungible token.
 type FungibleTokenMetadata struct {
-	Mint        string `json:"mint"`
-	Name        string `json:"name"`
-	Symbol      string `json:"symbol"`
-	Decimals    uint8  `json:"decimals"`
-	LogoURI     string `json:"logo_uri"`
-	Description string `json:"description,omitempty"`
-	ExternalURL string `json:"external_url,omitempty"`
+	Mint        string   `json:"mint"`
+	Name        string   `json:"name"`
+	Symbol      string   `json:"symbol"`
+	Decimals    uint8    `json:"decimals"`
+	LogoURI     string   `json:"logo_uri"`
+	Description string   `json:"description,omitempty"`
+	ExternalURL string   `json:"external_url,omitempty"`
+	Collection  string   `json:"collection,omitempty"` // verified collection mint address
+	Creators    []string `json:"creators,omitempty"`   // verified creator addresses
+}
+
+// AssetOrigin represents the verified collection and creators of a token from its on-chain metadata.
+// It's used to check if the token belongs to the NFT collection.
+type AssetOrigin struct {
+	Mint       string   `json:"mint"`
+	Collection string   `json:"collection,omitempty"` // verified collection mint address
+	Creators   []string `json:"creators,omitempty"`   // verified creator addresses
+}
+
+// NewAssetOrigin returns the verified collection and creators from the given on-chain token metadata.
+// Unverified collection and creators are skipped, since anyone can set them.
+func NewAssetOrigin(md token_metadata.Metadata) AssetOrigin {
+	result := AssetOrigin{Mint: md.Mint.ToBase58()}
+	if md.Collection != nil && md.Collection.Verified {
+		result.Collection = md.Collection.Key.ToBase58()
+	}
+	if md.Data.Creators != nil {
+		for _, c := range *md.Data.Creators {
+			if c.Verified {
+				result.Creators = append(result.Creators, c.Address.ToBase58())
+			}
+		}
+	}
+	return result
 }
 
 // @deprecated
@@ -113,6 +141,12 @@ type (
 	}
 )
 
+// LamportsPerSignature is the base fee per transaction signature.
+const LamportsPerSignature uint64 = 5000
+
+// MaxMultipleAccounts is the max number of accounts requested by a single getMultipleAccounts call.
+const MaxMultipleAccounts = 100
+
 // Token list chain IDs
 const (
 	ChainIdMainnet = 101 // Mainnet-beta
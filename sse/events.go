@@ -1,6 +1,7 @@
 package sse
 
 import (
+	"context"
 	"errors"
 	"fmt"
 
@@ -13,7 +14,7 @@ type sseService interface {
 
 // TranslateEventsToSSEChannel translates the events from the events package to the webhook events.
 func TranslateEventsToSSEChannel(sse sseService) events.Listener {
-	return func(event events.EventName, payload interface{}) error {
+	return func(_ context.Context, event events.EventName, payload interface{}) error {
 		if payload == nil {
 			return nil
 		}
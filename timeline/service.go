@@ -0,0 +1,252 @@
+package timeline
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/easypmnt/checkout-api/events"
+	"github.com/easypmnt/checkout-api/payments"
+	"github.com/easypmnt/checkout-api/webhook"
+	"github.com/google/uuid"
+)
+
+// maxDeliveries is the max number of webhook deliveries shown in the timeline.
+const maxDeliveries = 100
+
+// Predefined entry kinds.
+const (
+	KindEvent   = "event"   // the event recorded for the payment
+	KindWebhook = "webhook" // the webhook delivery of the payment event
+)
+
+type (
+	// Service builds the human-readable timeline of the payment.
+	Service struct {
+		payments paymentSource
+		webhooks deliverySource
+	}
+
+	// Entry is the timeline entry.
+	Entry struct {
+		Time    time.Time `json:"time"`
+		Kind    string    `json:"kind"` // event or webhook
+		Name    string    `json:"name"` // event name
+		Title   string    `json:"title"`
+		Details string    `json:"details,omitempty"`
+		Source  string    `json:"source,omitempty"` // api, task:<type> or event:<name>
+	}
+
+	paymentSource interface {
+		GetPayment(ctx context.Context, id uuid.UUID) (*payments.Payment, error)
+		GetPaymentTransactions(ctx context.Context, paymentID uuid.UUID) ([]*payments.Transaction, error)
+		GetPaymentEvents(ctx context.Context, paymentID uuid.UUID) ([]*payments.PaymentEvent, error)
+	}
+
+	deliverySource interface {
+		GetDeliveries(ctx context.Context, filter webhook.DeliveryFilter) ([]*webhook.Delivery, error)
+	}
+)
+
+// NewService creates a new timeline service.
+func NewService(payments paymentSource, webhooks deliverySource) *Service {
+	return &Service{
+		payments: payments,
+		webhooks: webhooks,
+	}
+}
+
+// Timeline returns the timeline of the payment, oldest first:
+// the recorded events merged with the webhook deliveries.
+// The payments created before the events were recorded get the timeline
+// built from the payment and its transactions.
+func (s *Service) Timeline(ctx context.Context, paymentID uuid.UUID) ([]Entry, error) {
+	payment, err := s.payments.GetPayment(ctx, paymentID)
+	if err != nil {
+		return nil, err
+	}
+
+	evts, err := s.payments.GetPaymentEvents(ctx, paymentID)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []Entry
+	if len(evts) > 0 {
+		for _, e := range evts {
+			result = append(result, eventEntry(e))
+		}
+	} else {
+		txs, err := s.payments.GetPaymentTransactions(ctx, paymentID)
+		if err != nil {
+			return nil, err
+		}
+		result = stateEntries(payment, txs)
+	}
+
+	if s.webhooks != nil {
+		deliveries, err := s.webhooks.GetDeliveries(ctx, webhook.DeliveryFilter{
+			PaymentID: &paymentID,
+			Since:     payment.CreatedAt,
+			Until:     time.Now().Add(time.Minute),
+			Limit:     maxDeliveries,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get webhook deliveries of payment=%s: %w", paymentID, err)
+		}
+		for _, d := range deliveries {
+			result = append(result, deliveryEntry(d))
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Time.Before(result[j].Time)
+	})
+
+	return result, nil
+}
+
+// eventEntry describes the recorded event.
+func eventEntry(e *payments.PaymentEvent) Entry {
+	entry := Entry{
+		Time:   e.CreatedAt,
+		Kind:   KindEvent,
+		Name:   e.Name,
+		Title:  e.Name,
+		Source: e.Source,
+	}
+
+	var p struct {
+		Reference string `json:"reference"`
+		Status    string `json:"status"`
+		Signature string `json:"signature"`
+		Link      string `json:"link"`
+	}
+	// The payload is informational, the entry is still shown if it can't be decoded.
+	_ = json.Unmarshal(e.Payload, &p)
+
+	switch events.EventName(e.Name) {
+	case events.PaymentCreated:
+		entry.Title = "Payment created"
+	case events.PaymentLinkGenerated:
+		entry.Title = "Payment link generated"
+		entry.Details = p.Link
+	case events.PaymentProcessing:
+		entry.Title = "Payment is being processed"
+	case events.PaymentSucceeded:
+		entry.Title = "Payment succeeded"
+	case events.PaymentFailed:
+		entry.Title = "Payment failed"
+	case events.PaymentCancelled:
+		entry.Title = "Payment cancelled"
+	case events.PaymentExpired:
+		entry.Title = "Payment expired"
+	case events.TransactionCreated:
+		entry.Title = "Transaction built"
+		entry.Details = p.Reference
+	case events.TransactionSubmitted:
+		entry.Title = "Signed transaction submitted"
+		entry.Details = p.Signature
+	case events.TransactionUpdated:
+		entry.Title, entry.Details = transactionTitle(payments.TransactionStatus(p.Status), p.Signature, p.Reference)
+	}
+
+	return entry
+}
+
+// transactionTitle describes the transaction status.
+func transactionTitle(status payments.TransactionStatus, signature, reference string) (title, details string) {
+	switch status {
+	case payments.TransactionStatusCompleted:
+		return "Transaction confirmed on chain", signature
+	case payments.TransactionStatusFailed:
+		return "Transaction failed", signature
+	case payments.TransactionStatusExpired:
+		return "Transaction expired", reference
+	}
+	if signature != "" {
+		return "Transaction sent to the network", signature
+	}
+	return "Transaction pending", reference
+}
+
+// stateEntries builds the timeline from the current state of the payment and its transactions.
+func stateEntries(payment *payments.Payment, txs []*payments.Transaction) []Entry {
+	result := []Entry{{
+		Time:  payment.CreatedAt,
+		Kind:  KindEvent,
+		Name:  string(events.PaymentCreated),
+		Title: "Payment created",
+	}}
+
+	for _, tx := range txs {
+		result = append(result, Entry{
+			Time:    tx.CreatedAt,
+			Kind:    KindEvent,
+			Name:    string(events.TransactionCreated),
+			Title:   "Transaction built",
+			Details: tx.Reference,
+		})
+		if tx.UpdatedAt != nil && tx.Status != payments.TransactionStatusPending {
+			title, details := transactionTitle(tx.Status, tx.Signature, tx.Reference)
+			result = append(result, Entry{
+				Time:    *tx.UpdatedAt,
+				Kind:    KindEvent,
+				Name:    string(events.TransactionUpdated),
+				Title:   title,
+				Details: details,
+			})
+		}
+	}
+
+	if payment.UpdatedAt != nil {
+		if title, name := paymentStatusTitle(payment.Status); title != "" {
+			result = append(result, Entry{
+				Time:  *payment.UpdatedAt,
+				Kind:  KindEvent,
+				Name:  string(name),
+				Title: title,
+			})
+		}
+	}
+
+	return result
+}
+
+// paymentStatusTitle describes the final payment status.
+func paymentStatusTitle(status payments.PaymentStatus) (string, events.EventName) {
+	switch status {
+	case payments.PaymentStatusCompleted:
+		return "Payment succeeded", events.PaymentSucceeded
+	case payments.PaymentStatusFailed:
+		return "Payment failed", events.PaymentFailed
+	case payments.PaymentStatusCanceled:
+		return "Payment cancelled", events.PaymentCancelled
+	case payments.PaymentStatusExpired:
+		return "Payment expired", events.PaymentExpired
+	}
+	return "", ""
+}
+
+// deliveryEntry describes the webhook delivery.
+func deliveryEntry(d *webhook.Delivery) Entry {
+	entry := Entry{
+		Time:  d.CreatedAt,
+		Kind:  KindWebhook,
+		Name:  d.Event,
+		Title: fmt.Sprintf("Webhook %s delivered to %s", d.Event, d.URL),
+	}
+	if d.ReplayOf != nil {
+		entry.Title = fmt.Sprintf("Webhook %s replayed to %s", d.Event, d.URL)
+	}
+	if !d.Succeeded {
+		entry.Title = fmt.Sprintf("Webhook %s failed to deliver to %s", d.Event, d.URL)
+		entry.Details = d.Error
+	} else if d.ResponseStatus != 0 {
+		entry.Details = fmt.Sprintf("HTTP %d in %dms", d.ResponseStatus, d.LatencyMs)
+	}
+
+	return entry
+}
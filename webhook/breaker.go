@@ -0,0 +1,315 @@
+package webhook
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/easypmnt/checkout-api/events"
+	"github.com/easypmnt/checkout-api/repository"
+	"github.com/google/uuid"
+)
+
+// Default circuit breaker settings.
+const (
+	DefaultFailureThreshold = 5                  // consecutive failures to pause the endpoint
+	DefaultPauseDuration    = 5 * time.Minute    // time to wait before flushing the parked events
+	DefaultDisableAfter     = 72 * time.Hour     // time the endpoint can fail before it is disabled
+	flushBatchSize          = 100                // parked events loaded at once
+	DisabledReasonFailures  = "failing_delivery" // the endpoint is disabled by the circuit breaker
+)
+
+// EndpointState represents the health of the webhook endpoint.
+type EndpointState string
+
+// Predefined endpoint states.
+const (
+	EndpointStateHealthy  EndpointState = "healthy"  // the last delivery succeeded
+	EndpointStateFailing  EndpointState = "failing"  // deliveries fail, but the failure threshold is not reached yet
+	EndpointStatePaused   EndpointState = "paused"   // deliveries are paused and the events are parked
+	EndpointStateDisabled EndpointState = "disabled" // the endpoint is disabled manually or by the circuit breaker
+)
+
+type (
+	// circuitBreaker pauses deliveries to the endpoint after the failure threshold is reached
+	// and disables the endpoint if it keeps failing.
+	circuitBreaker struct {
+		failureThreshold int
+		pauseDuration    time.Duration
+		disableAfter     time.Duration
+	}
+
+	// EndpointHealth represents the delivery health of the webhook endpoint.
+	EndpointHealth struct {
+		EndpointID          uuid.UUID     `json:"endpoint_id"`
+		State               EndpointState `json:"state"`
+		ConsecutiveFailures int           `json:"consecutive_failures"`
+		FailingSince        *time.Time    `json:"failing_since,omitempty"`
+		LastSuccessAt       *time.Time    `json:"last_success_at,omitempty"`
+		LastFailureAt       *time.Time    `json:"last_failure_at,omitempty"`
+		PausedUntil         *time.Time    `json:"paused_until,omitempty"`
+		DisabledReason      string        `json:"disabled_reason,omitempty"`
+		ParkedEvents        int64         `json:"parked_events"`
+	}
+
+	// EndpointData is the payload data of the webhook.endpoint.disabled event.
+	EndpointData struct {
+		EndpointID          string    `json:"endpoint_id"`
+		URL                 string    `json:"url"`
+		Reason              string    `json:"reason"`
+		ConsecutiveFailures int       `json:"consecutive_failures"`
+		FailingSince        time.Time `json:"failing_since"`
+	}
+
+	fireEventFunc func(events.EventName, interface{})
+)
+
+// WithCircuitBreaker configures the circuit breaker of the webhook endpoints:
+// deliveries are paused for the given duration after the failure threshold is reached,
+// and the endpoint is disabled if it keeps failing longer than disableAfter.
+// The default endpoint configured with WithWebhookURI has no circuit breaker.
+func WithCircuitBreaker(failureThreshold int, pauseDuration, disableAfter time.Duration) ServiceOption {
+	return func(s *Service) {
+		s.breaker = circuitBreaker{
+			failureThreshold: failureThreshold,
+			pauseDuration:    pauseDuration,
+			disableAfter:     disableAfter,
+		}
+	}
+}
+
+// WithEventEmitter configures the webhook service with the function to fire
+// the webhook.endpoint.disabled event.
+func WithEventEmitter(fn fireEventFunc) ServiceOption {
+	return func(s *Service) {
+		s.fireEvent = fn
+	}
+}
+
+// State returns the delivery state of the endpoint.
+func (e Endpoint) State() EndpointState {
+	switch {
+	case !e.Enabled:
+		return EndpointStateDisabled
+	case e.PausedUntil != nil:
+		return EndpointStatePaused
+	case e.ConsecutiveFailures > 0:
+		return EndpointStateFailing
+	default:
+		return EndpointStateHealthy
+	}
+}
+
+// parksEvents checks if the events for the endpoint must be parked instead of delivered:
+// the circuit is open or the endpoint is disabled by the circuit breaker.
+func (e Endpoint) parksEvents() bool {
+	return e.PausedUntil != nil || e.DisabledReason != ""
+}
+
+// EndpointHealth returns the delivery health of the webhook endpoint with the given ID.
+func (s *Service) EndpointHealth(ctx context.Context, id uuid.UUID) (*EndpointHealth, error) {
+	e, err := s.GetEndpoint(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	parked, err := s.repo.CountWebhookParkedEvents(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count parked webhook events: %w", err)
+	}
+
+	return &EndpointHealth{
+		EndpointID:          e.ID,
+		State:               e.State(),
+		ConsecutiveFailures: e.ConsecutiveFailures,
+		FailingSince:        e.FailingSince,
+		LastSuccessAt:       e.LastSuccessAt,
+		LastFailureAt:       e.LastFailureAt,
+		PausedUntil:         e.PausedUntil,
+		DisabledReason:      e.DisabledReason,
+		ParkedEvents:        parked,
+	}, nil
+}
+
+// park stores the event to deliver it to the endpoint later, in order.
+func (s *Service) park(ctx context.Context, endpoint *Endpoint, event Event) error {
+	payload, err := json.Marshal(FireEventPayload{
+		EndpointID: endpoint.ID,
+		EventID:    event.ID,
+		Event:      event.Name,
+		Payload:    event.Payload,
+		Data:       event.Data,
+		PaymentID:  event.PaymentID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal parked webhook event: %w", err)
+	}
+
+	if err := s.repo.CreateWebhookParkedEvent(ctx, repository.CreateWebhookParkedEventParams{
+		EndpointID: endpoint.ID,
+		EventID:    event.ID,
+		Event:      event.Name,
+		Payload:    payload,
+	}); err != nil {
+		return fmt.Errorf("failed to park webhook event: %w", err)
+	}
+
+	// The circuit could be closed by the flush between loading the endpoint and parking the event,
+	// so the endpoint is paused again to flush the event with the next run.
+	current, err := s.GetEndpoint(ctx, endpoint.ID)
+	if err != nil {
+		return err
+	}
+	if current.Enabled && current.PausedUntil == nil {
+		return s.pause(ctx, endpoint.ID, time.Now())
+	}
+
+	return nil
+}
+
+// pause pauses deliveries to the endpoint until the given time.
+func (s *Service) pause(ctx context.Context, endpointID uuid.UUID, until time.Time) error {
+	if err := s.repo.PauseWebhookEndpoint(ctx, repository.PauseWebhookEndpointParams{
+		ID:          endpointID,
+		PausedUntil: sql.NullTime{Time: until, Valid: true},
+	}); err != nil {
+		return fmt.Errorf("failed to pause webhook endpoint: %w", err)
+	}
+	return nil
+}
+
+// trackHealth updates the health of the endpoint after the delivery.
+// Returns true if the circuit is open after the failed delivery: the endpoint is paused or disabled.
+func (s *Service) trackHealth(ctx context.Context, endpoint *Endpoint, succeeded bool) (bool, error) {
+	if succeeded {
+		if err := s.repo.RecordWebhookEndpointSuccess(ctx, endpoint.ID); err != nil {
+			return false, fmt.Errorf("failed to record webhook endpoint success: %w", err)
+		}
+		return false, nil
+	}
+
+	result, err := s.repo.RecordWebhookEndpointFailure(ctx, endpoint.ID)
+	if err != nil {
+		return false, fmt.Errorf("failed to record webhook endpoint failure: %w", err)
+	}
+	e := castFromRepositoryEndpoint(result)
+	if e.ConsecutiveFailures < s.breaker.failureThreshold {
+		return false, nil
+	}
+
+	if e.FailingSince != nil && time.Since(*e.FailingSince) >= s.breaker.disableAfter {
+		return true, s.disable(ctx, e)
+	}
+
+	return true, s.pause(ctx, e.ID, time.Now().Add(s.breaker.pauseDuration))
+}
+
+// disable disables the failing endpoint and fires the webhook.endpoint.disabled event.
+// The events for the endpoint are parked until it is enabled again.
+func (s *Service) disable(ctx context.Context, e *Endpoint) error {
+	n, err := s.repo.DisableFailingWebhookEndpoint(ctx, repository.DisableFailingWebhookEndpointParams{
+		ID:             e.ID,
+		DisabledReason: sql.NullString{String: DisabledReasonFailures, Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to disable webhook endpoint: %w", err)
+	}
+	if n == 0 || s.fireEvent == nil {
+		// already disabled
+		return nil
+	}
+
+	s.fireEvent(events.WebhookEndpointDisabled, events.WebhookEndpointDisabledPayload{
+		EndpointID:          e.ID.String(),
+		URL:                 e.URL,
+		Reason:              DisabledReasonFailures,
+		ConsecutiveFailures: e.ConsecutiveFailures,
+		FailingSince:        *e.FailingSince,
+	})
+
+	return nil
+}
+
+// FlushParkedEvents delivers the parked events, in order, to the endpoints whose pause is over.
+// The endpoint is paused again on the first failed delivery, the rest of the events stay parked.
+func (s *Service) FlushParkedEvents(ctx context.Context) error {
+	endpoints, err := s.repo.GetWebhookEndpointsToFlush(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get webhook endpoints to flush: %w", err)
+	}
+
+	for _, e := range endpoints {
+		if err := s.flushEndpoint(ctx, castFromRepositoryEndpoint(e)); err != nil {
+			return fmt.Errorf("endpoint %s: %w", e.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// flushEndpoint delivers the parked events to the endpoint and closes the circuit
+// once there are no parked events left.
+func (s *Service) flushEndpoint(ctx context.Context, endpoint *Endpoint) error {
+	for {
+		parked, err := s.repo.GetWebhookParkedEvents(ctx, repository.GetWebhookParkedEventsParams{
+			EndpointID: endpoint.ID,
+			Limit:      flushBatchSize,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to get parked webhook events: %w", err)
+		}
+
+		if len(parked) == 0 {
+			n, err := s.repo.ResumeWebhookEndpoint(ctx, endpoint.ID)
+			if err != nil {
+				return fmt.Errorf("failed to resume webhook endpoint: %w", err)
+			}
+			if n > 0 {
+				return nil
+			}
+			// An event has been parked in the meantime, or the endpoint has been deleted.
+			left, err := s.repo.CountWebhookParkedEvents(ctx, endpoint.ID)
+			if err != nil || left == 0 {
+				return err
+			}
+			continue
+		}
+
+		for _, p := range parked {
+			var task FireEventPayload
+			if err := json.Unmarshal(p.Payload, &task); err != nil {
+				return fmt.Errorf("failed to unmarshal parked webhook event %d: %w", p.ID, err)
+			}
+			event := Event{
+				ID:        task.EventID,
+				Name:      task.Event,
+				Payload:   task.Payload,
+				Data:      task.Data,
+				PaymentID: task.PaymentID,
+			}
+
+			body, err := s.requestBody(endpoint, event)
+			if err != nil {
+				return err
+			}
+			d := s.deliver(ctx, endpoint, event.delivery(body))
+			opened, err := s.trackHealth(ctx, endpoint, d.Succeeded)
+			if err != nil {
+				return err
+			}
+			if !d.Succeeded {
+				if opened {
+					return nil
+				}
+				// The failure counter has been reset on re-enabling, keep the events parked anyway.
+				return s.pause(ctx, endpoint.ID, time.Now().Add(s.breaker.pauseDuration))
+			}
+
+			if err := s.repo.DeleteWebhookParkedEvent(ctx, p.ID); err != nil {
+				return fmt.Errorf("failed to delete parked webhook event %d: %w", p.ID, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package webhook
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/easypmnt/checkout-api/events"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEndpointState(t *testing.T) {
+	now := time.Now()
+
+	e := Endpoint{Enabled: true}
+	require.Equal(t, EndpointStateHealthy, e.State())
+	require.False(t, e.parksEvents())
+
+	e.ConsecutiveFailures = 2
+	e.FailingSince = &now
+	require.Equal(t, EndpointStateFailing, e.State())
+	require.False(t, e.parksEvents())
+
+	e.PausedUntil = &now
+	require.Equal(t, EndpointStatePaused, e.State())
+	require.True(t, e.parksEvents())
+
+	// disabled by the circuit breaker: the events are parked until the endpoint is enabled
+	e = Endpoint{Enabled: false, DisabledReason: DisabledReasonFailures}
+	require.Equal(t, EndpointStateDisabled, e.State())
+	require.True(t, e.parksEvents())
+
+	// disabled manually: the events are dropped
+	e = Endpoint{Enabled: false}
+	require.Equal(t, EndpointStateDisabled, e.State())
+	require.False(t, e.parksEvents())
+}
+
+func TestEndpointDisabledEventData(t *testing.T) {
+	failingSince := time.Now().Add(-DefaultDisableAfter)
+	data, err := NewService().EventData(context.Background(), events.WebhookEndpointDisabled, events.WebhookEndpointDisabledPayload{
+		EndpointID:          "id",
+		URL:                 "https://example.com",
+		Reason:              DisabledReasonFailures,
+		ConsecutiveFailures: DefaultFailureThreshold,
+		FailingSince:        failingSince,
+	})
+	require.NoError(t, err)
+	require.NotNil(t, data.Endpoint)
+	require.Equal(t, "https://example.com", data.Endpoint.URL)
+	require.Equal(t, DefaultFailureThreshold, data.Endpoint.ConsecutiveFailures)
+	require.Equal(t, failingSince, data.Endpoint.FailingSince)
+}
@@ -0,0 +1,95 @@
+package webhook
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/easypmnt/checkout-api/repository"
+	"github.com/google/uuid"
+)
+
+// maxResponseBodySize is the max size of the endpoint response body stored in the delivery log.
+const maxResponseBodySize = 4 << 10
+
+// Delivery statuses to filter the delivery log by.
+const (
+	DeliveryStatusSucceeded = "succeeded"
+	DeliveryStatusFailed    = "failed"
+)
+
+type (
+	// Delivery represents a single attempt to deliver the webhook event to the endpoint.
+	Delivery struct {
+		ID             uuid.UUID       `json:"id"`
+		EndpointID     uuid.UUID       `json:"endpoint_id"` // uuid.Nil for the default endpoint
+		URL            string          `json:"url"`
+		Event          string          `json:"event"`
+		EventID        uuid.UUID       `json:"event_id"`
+		RequestBody    json.RawMessage `json:"request_body"`
+		ResponseStatus int             `json:"response_status,omitempty"`
+		ResponseBody   string          `json:"response_body,omitempty"` // truncated to 4KB
+		LatencyMs      int             `json:"latency_ms"`
+		Error          string          `json:"error,omitempty"`
+		Succeeded      bool            `json:"succeeded"`
+		ReplayOf       *uuid.UUID      `json:"replay_of,omitempty"` // ID of the replayed delivery
+		PaymentID      *uuid.UUID      `json:"payment_id,omitempty"`
+		CreatedAt      time.Time       `json:"created_at"`
+	}
+
+	// DeliveryFilter represents the filter of the delivery log.
+	// Zero values of the optional fields mean no filtering.
+	DeliveryFilter struct {
+		EndpointID *uuid.UUID // uuid.Nil for the default endpoint
+		Event      string
+		EventID    *uuid.UUID
+		PaymentID  *uuid.UUID
+		Status     string // DeliveryStatusSucceeded or DeliveryStatusFailed
+		Since      time.Time
+		Until      time.Time
+		Limit      int32
+		Offset     int32
+	}
+)
+
+// validate checks the delivery filter.
+func (f DeliveryFilter) validate() error {
+	if f.Status != "" && f.Status != DeliveryStatusSucceeded && f.Status != DeliveryStatusFailed {
+		return ErrInvalidDeliveryFilter
+	}
+	if f.Limit <= 0 || f.Offset < 0 || !f.Since.Before(f.Until) {
+		return ErrInvalidDeliveryFilter
+	}
+	return nil
+}
+
+// sanitizeResponseBody makes the response body safe to store in a text column:
+// postgres rejects invalid UTF-8 and NUL characters.
+func sanitizeResponseBody(body []byte) string {
+	return strings.ReplaceAll(strings.ToValidUTF8(string(body), "�"), "\x00", "")
+}
+
+// cast repository.WebhookDelivery to webhook.Delivery
+func castFromRepositoryDelivery(d repository.WebhookDelivery) *Delivery {
+	result := &Delivery{
+		ID:             d.ID,
+		EndpointID:     d.EndpointID,
+		URL:            d.URL,
+		Event:          d.Event,
+		EventID:        d.EventID,
+		RequestBody:    d.RequestBody,
+		ResponseStatus: int(d.ResponseStatus.Int32),
+		ResponseBody:   d.ResponseBody.String,
+		LatencyMs:      int(d.LatencyMs),
+		Error:          d.Error.String,
+		Succeeded:      d.Succeeded,
+		CreatedAt:      d.CreatedAt,
+	}
+	if d.ReplayOf.Valid {
+		result.ReplayOf = &d.ReplayOf.UUID
+	}
+	if d.PaymentID.Valid {
+		result.PaymentID = &d.PaymentID.UUID
+	}
+	return result
+}
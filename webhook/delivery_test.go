@@ -0,0 +1,81 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeliver(t *testing.T) {
+	var received []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received, _ = io.ReadAll(r.Body)
+		require.NotEmpty(t, r.Header.Get(DefaultSignatureHeader))
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(strings.Repeat("x", maxResponseBodySize+100)))
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	s := NewService(WithSignatureSecret([]byte("secret")), WithWebhookURI(srv.URL))
+	eventID := uuid.New()
+
+	t.Run("default endpoint", func(t *testing.T) {
+		err := s.FireEvent(context.Background(), uuid.Nil, Event{
+			ID:      eventID,
+			Name:    EventPaymentSucceeded,
+			Payload: map[string]string{"payment_id": "1"},
+			Data:    &EventData{Payment: &PaymentData{PaymentID: "1"}},
+		})
+		require.NoError(t, err)
+
+		var payload WebhookRequestPayload
+		require.NoError(t, json.Unmarshal(received, &payload))
+		require.Equal(t, EventPaymentSucceeded, payload.Event)
+		require.Equal(t, eventID.String(), payload.EventID)
+		require.Empty(t, payload.WebhookID)
+
+		// the default endpoint is pinned to the legacy version
+		require.Equal(t, APIVersion20230310, payload.APIVersion)
+		require.Equal(t, map[string]interface{}{"payment_id": "1"}, payload.Data)
+	})
+
+	t.Run("failed delivery", func(t *testing.T) {
+		body := []byte(`{"event":"payment.succeeded"}`)
+		d := s.deliver(context.Background(), &Endpoint{URL: srv.URL + "/fail", Secret: "secret"}, &Delivery{
+			Event:       "payment.succeeded",
+			EventID:     eventID,
+			RequestBody: body,
+		})
+		require.False(t, d.Succeeded)
+		require.Equal(t, http.StatusInternalServerError, d.ResponseStatus)
+		require.Len(t, d.ResponseBody, maxResponseBodySize)
+		require.NotEmpty(t, d.Error)
+		require.Equal(t, body, []byte(received))
+	})
+
+	t.Run("unreachable endpoint", func(t *testing.T) {
+		d := s.deliver(context.Background(), &Endpoint{URL: "http://127.0.0.1:1", Secret: "secret"}, &Delivery{
+			Event:       "payment.succeeded",
+			EventID:     eventID,
+			RequestBody: []byte(`{}`),
+		})
+		require.False(t, d.Succeeded)
+		require.Zero(t, d.ResponseStatus)
+		require.NotEmpty(t, d.Error)
+	})
+}
+
+func TestSanitizeResponseBody(t *testing.T) {
+	require.Equal(t, "ab�c", sanitizeResponseBody([]byte("a\x00b\xffc")))
+}
@@ -0,0 +1,169 @@
+package webhook
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/easypmnt/checkout-api/repository"
+	"github.com/google/uuid"
+)
+
+// secretPrefix is the prefix of generated endpoint secrets, so they are easy to recognize.
+const secretPrefix = "whsec_"
+
+// Limits of the previous secret lifetime after the secret rotation.
+const (
+	DefaultPreviousSecretTTL = 24 * time.Hour
+	MaxPreviousSecretTTL     = 7 * 24 * time.Hour
+)
+
+// Endpoint represents the webhook endpoint subscribed to the events.
+type Endpoint struct {
+	ID          uuid.UUID  `json:"id"`
+	URL         string     `json:"url"`
+	Secret      string     `json:"secret"` // payloads sent to the endpoint are signed with this secret
+	Description string     `json:"description,omitempty"`
+	EventTypes  []string   `json:"event_types"` // e.g. "payment.succeeded" or "payment.*"; empty = all events
+	Enabled     bool       `json:"enabled"`
+	APIVersion  string     `json:"api_version"` // the payload version, see APIVersion* constants
+	UpdatedAt   *time.Time `json:"updated_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+
+	// Delivery health, see EndpointState.
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	FailingSince        *time.Time `json:"failing_since,omitempty"`
+	LastSuccessAt       *time.Time `json:"last_success_at,omitempty"`
+	LastFailureAt       *time.Time `json:"last_failure_at,omitempty"`
+	PausedUntil         *time.Time `json:"paused_until,omitempty"`    // the events are parked while the endpoint is paused
+	DisabledReason      string     `json:"disabled_reason,omitempty"` // set if the endpoint is disabled by the circuit breaker
+
+	// The secret replaced by the rotation, payloads are signed with both secrets until it expires.
+	PreviousSecret          string     `json:"-"`
+	PreviousSecretExpiresAt *time.Time `json:"previous_secret_expires_at,omitempty"`
+}
+
+// activeSecrets returns the secrets the payloads must be signed with at the given time:
+// the current one and the previous one until it expires. The previous secret without expiration
+// time is always active, it's used for the default endpoint.
+func (e Endpoint) activeSecrets(now time.Time) [][]byte {
+	secrets := [][]byte{[]byte(e.Secret)}
+	if e.PreviousSecret != "" && (e.PreviousSecretExpiresAt == nil || now.Before(*e.PreviousSecretExpiresAt)) {
+		secrets = append(secrets, []byte(e.PreviousSecret))
+	}
+	return secrets
+}
+
+// Subscribed checks if the endpoint is subscribed to the given event.
+// The event type ending with ".*" matches all the events with the prefix, e.g. "payment.*".
+func (e Endpoint) Subscribed(event string) bool {
+	if len(e.EventTypes) == 0 {
+		return true
+	}
+	for _, t := range e.EventTypes {
+		if t == event {
+			return true
+		}
+		if prefix := strings.TrimSuffix(t, "*"); prefix != t && strings.HasPrefix(event, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalize trims the URL and the API version and removes empty and duplicated event types.
+func (e *Endpoint) normalize() {
+	e.URL = strings.TrimSpace(e.URL)
+	e.APIVersion = strings.TrimSpace(e.APIVersion)
+	types := make([]string, 0, len(e.EventTypes))
+	seen := make(map[string]bool, len(e.EventTypes))
+	for _, t := range e.EventTypes {
+		t = strings.TrimSpace(t)
+		if t == "" || seen[t] {
+			continue
+		}
+		seen[t] = true
+		types = append(types, t)
+	}
+	e.EventTypes = types
+}
+
+// validate checks the endpoint URL and event types.
+func (e Endpoint) validate() error {
+	u, err := url.Parse(e.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http(s) URL", ErrInvalidEndpoint)
+	}
+	if !IsSupportedAPIVersion(e.APIVersion) {
+		return fmt.Errorf("%w: unknown api version %q", ErrInvalidEndpoint, e.APIVersion)
+	}
+	for _, t := range e.EventTypes {
+		if !isKnownEventType(t) {
+			return fmt.Errorf("%w: unknown event type %q", ErrInvalidEndpoint, t)
+		}
+	}
+	return nil
+}
+
+// isKnownEventType checks if the event type matches at least one of the events sent to webhooks.
+func isKnownEventType(t string) bool {
+	probe := Endpoint{EventTypes: []string{t}}
+	for _, e := range webhookEvents {
+		if probe.Subscribed(e) {
+			return true
+		}
+	}
+	return false
+}
+
+// NewSecret generates a random endpoint secret, e.g. "whsec_4f1c...".
+func NewSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate secret: %w", err)
+	}
+	return secretPrefix + hex.EncodeToString(b), nil
+}
+
+// cast repository.WebhookEndpoint to webhook.Endpoint
+func castFromRepositoryEndpoint(e repository.WebhookEndpoint) *Endpoint {
+	result := &Endpoint{
+		ID:          e.ID,
+		URL:         e.URL,
+		Secret:      e.Secret,
+		Description: e.Description.String,
+		EventTypes:  e.EventTypes,
+		Enabled:     e.Enabled,
+		APIVersion:  e.ApiVersion,
+		CreatedAt:   e.CreatedAt,
+
+		ConsecutiveFailures: int(e.ConsecutiveFailures),
+		DisabledReason:      e.DisabledReason.String,
+	}
+	if e.FailingSince.Valid {
+		result.FailingSince = &e.FailingSince.Time
+	}
+	if e.LastSuccessAt.Valid {
+		result.LastSuccessAt = &e.LastSuccessAt.Time
+	}
+	if e.LastFailureAt.Valid {
+		result.LastFailureAt = &e.LastFailureAt.Time
+	}
+	if e.PausedUntil.Valid {
+		result.PausedUntil = &e.PausedUntil.Time
+	}
+	if result.EventTypes == nil {
+		result.EventTypes = []string{}
+	}
+	if e.UpdatedAt.Valid {
+		result.UpdatedAt = &e.UpdatedAt.Time
+	}
+	if e.PreviousSecret.Valid && e.PreviousSecretExpiresAt.Valid {
+		result.PreviousSecret = e.PreviousSecret.String
+		result.PreviousSecretExpiresAt = &e.PreviousSecretExpiresAt.Time
+	}
+	return result
+}
@@ -0,0 +1,87 @@
+package webhook
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEndpointSubscribed(t *testing.T) {
+	all := Endpoint{}
+	require.True(t, all.Subscribed("payment.succeeded"))
+	require.True(t, all.Subscribed("transaction.created"))
+
+	e := Endpoint{EventTypes: []string{"payment.succeeded", "transaction.*"}}
+	require.True(t, e.Subscribed("payment.succeeded"))
+	require.True(t, e.Subscribed("transaction.created"))
+	require.True(t, e.Subscribed("transaction.reference.notification"))
+	require.False(t, e.Subscribed("payment.failed"))
+	require.False(t, e.Subscribed("loyalty.tier.changed"))
+}
+
+func TestEndpointValidate(t *testing.T) {
+	e := Endpoint{
+		URL:        " https://example.com/webhook ",
+		EventTypes: []string{"payment.*", "", "payment.*", "loyalty.tier.changed"},
+		APIVersion: LatestAPIVersion,
+	}
+	e.normalize()
+	require.Equal(t, "https://example.com/webhook", e.URL)
+	require.Equal(t, []string{"payment.*", "loyalty.tier.changed"}, e.EventTypes)
+	require.NoError(t, e.validate())
+
+	e = Endpoint{URL: "ftp://example.com"}
+	e.normalize()
+	require.NotNil(t, e.EventTypes)
+	require.True(t, errors.Is(e.validate(), ErrInvalidEndpoint))
+
+	e = Endpoint{URL: "https://example.com", EventTypes: []string{"payment.unknown"}, APIVersion: LatestAPIVersion}
+	require.True(t, errors.Is(e.validate(), ErrInvalidEndpoint))
+}
+
+func TestNewSecret(t *testing.T) {
+	s1, err := NewSecret()
+	require.NoError(t, err)
+	require.Len(t, s1, len(secretPrefix)+64)
+
+	s2, err := NewSecret()
+	require.NoError(t, err)
+	require.NotEqual(t, s1, s2)
+}
+
+func TestEndpointActiveSecrets(t *testing.T) {
+	now := time.Now()
+	expiresAt := now.Add(time.Hour)
+
+	e := Endpoint{Secret: "new"}
+	require.Equal(t, [][]byte{[]byte("new")}, e.activeSecrets(now))
+
+	e = Endpoint{Secret: "new", PreviousSecret: "old", PreviousSecretExpiresAt: &expiresAt}
+	require.Equal(t, [][]byte{[]byte("new"), []byte("old")}, e.activeSecrets(now))
+	require.Equal(t, [][]byte{[]byte("new")}, e.activeSecrets(expiresAt))
+
+	// the previous secret of the default endpoint never expires
+	e = Endpoint{Secret: "new", PreviousSecret: "old"}
+	require.Equal(t, [][]byte{[]byte("new"), []byte("old")}, e.activeSecrets(now.Add(MaxPreviousSecretTTL)))
+}
+
+func TestEventPayloadVersion(t *testing.T) {
+	data := &EventData{Payment: &PaymentData{PaymentID: "1"}}
+	e := Event{Payload: "raw", Data: data}
+	require.Equal(t, "raw", e.payload(APIVersion20230310))
+	require.Equal(t, data, e.payload(APIVersion20230414))
+
+	// tasks enqueued before the typed payloads were introduced have no data
+	e = Event{Payload: "raw"}
+	require.Equal(t, "raw", e.payload(APIVersion20230414))
+}
+
+func TestEndpointAPIVersion(t *testing.T) {
+	e := Endpoint{URL: "https://example.com", APIVersion: "2020-01-01"}
+	require.True(t, errors.Is(e.validate(), ErrInvalidEndpoint))
+
+	e.APIVersion = LatestAPIVersion
+	require.NoError(t, e.validate())
+}
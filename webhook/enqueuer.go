@@ -3,12 +3,18 @@ package webhook
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/hibiken/asynq"
 )
 
+// fireEventRetention is how long the completed fire event task is kept,
+// so the redelivered event isn't delivered to the endpoint again.
+const fireEventRetention = 24 * time.Hour
+
 type (
 	// Enqueuer is a helper struct for enqueuing email tasks.
 	Enqueuer struct {
@@ -70,13 +76,15 @@ func WithMaxRetry(n int) EnqueuerOption {
 }
 
 // enqueueTask enqueues a task to the queue.
-func (e *Enqueuer) enqueueTask(ctx context.Context, task *asynq.Task) error {
+func (e *Enqueuer) enqueueTask(ctx context.Context, task *asynq.Task, opts ...asynq.Option) error {
 	if _, err := e.client.Enqueue(
 		task,
-		asynq.Queue(e.queueName),
-		asynq.Deadline(time.Now().Add(e.taskDeadline)),
-		asynq.MaxRetry(e.maxRetry),
-		asynq.Unique(e.taskDeadline),
+		append([]asynq.Option{
+			asynq.Queue(e.queueName),
+			asynq.Deadline(time.Now().Add(e.taskDeadline)),
+			asynq.MaxRetry(e.maxRetry),
+			asynq.Unique(e.taskDeadline),
+		}, opts...)...,
 	); err != nil {
 		return fmt.Errorf("failed to enqueue task: %w", err)
 	}
@@ -84,17 +92,34 @@ func (e *Enqueuer) enqueueTask(ctx context.Context, task *asynq.Task) error {
 	return nil
 }
 
-// FireEvent enqueues a task to fire an event.
+// FireEvent enqueues a task to fire an event to the webhook endpoint.
+// uuid.Nil stands for the default endpoint.
+// The event ID must be the same for all the endpoints the event is fanned out to.
+// The task ID is the event ID and the endpoint ID, so the event is enqueued once per endpoint:
+// enqueuing it again, e.g. when the event is redelivered, is a no-op.
 // This function returns an error if the task could not be enqueued.
-func (e *Enqueuer) FireEvent(ctx context.Context, event string, payload interface{}) error {
-	fmt.Println("FireEvent", event, fmt.Sprintf("%+v", payload))
+func (e *Enqueuer) FireEvent(ctx context.Context, endpointID uuid.UUID, event Event) error {
 	task, err := json.Marshal(FireEventPayload{
-		Event:   event,
-		Payload: payload,
+		EndpointID: endpointID,
+		EventID:    event.ID,
+		Event:      event.Name,
+		Payload:    event.Payload,
+		Data:       event.Data,
+		PaymentID:  event.PaymentID,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to marshal task payload: %w", err)
 	}
 
-	return e.enqueueTask(ctx, asynq.NewTask(TaskFireEvent, task))
+	if err := e.enqueueTask(ctx, asynq.NewTask(TaskFireEvent, task),
+		asynq.TaskID(event.ID.String()+":"+endpointID.String()),
+		asynq.Retention(fireEventRetention),
+	); err != nil {
+		if errors.Is(err, asynq.ErrTaskIDConflict) || errors.Is(err, asynq.ErrDuplicateTask) {
+			return nil
+		}
+		return err
+	}
+
+	return nil
 }
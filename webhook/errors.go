@@ -0,0 +1,18 @@
+package webhook
+
+import "errors"
+
+// Predefined package errors.
+var (
+	ErrEndpointNotFound = errors.New("webhook endpoint not found")
+	ErrInvalidEndpoint  = errors.New("invalid webhook endpoint")
+	ErrEndpointDisabled = errors.New("webhook endpoint is disabled")
+
+	ErrDeliveryNotFound      = errors.New("webhook delivery not found")
+	ErrInvalidDeliveryFilter = errors.New("invalid webhook delivery filter")
+
+	ErrMissingSignatureSecret = errors.New("webhook signature secret is not set")
+	ErrInvalidSignatureHeader = errors.New("invalid webhook signature header")
+	ErrSignatureExpired       = errors.New("webhook signature timestamp is outside the tolerance")
+	ErrSignatureMismatch      = errors.New("webhook signature verification failed")
+)
@@ -2,21 +2,84 @@ package webhook
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/easypmnt/checkout-api/events"
+	"github.com/google/uuid"
 )
 
-type webhookEnqueuer interface {
-	FireEvent(ctx context.Context, event string, payload interface{}) error
-}
+type (
+	webhookEnqueuer interface {
+		FireEvent(ctx context.Context, endpointID uuid.UUID, event Event) error
+	}
+
+	// eventRouter returns IDs of the webhook endpoints subscribed to the event
+	// and builds the typed payload data of the event.
+	eventRouter interface {
+		MatchingEndpoints(ctx context.Context, event string) ([]uuid.UUID, error)
+		EventData(ctx context.Context, event events.EventName, payload interface{}) (*EventData, error)
+	}
+)
 
 // TranslateEventsToWebhookEvents translates the events from the events package to the webhook events.
-func TranslateEventsToWebhookEvents(enq webhookEnqueuer) events.Listener {
-	return func(event events.EventName, payload interface{}) error {
+// Each event is fanned out to all the subscribed endpoints as separate tasks,
+// so a failing endpoint doesn't delay or block the delivery to the others.
+// All the tasks share the same event ID and the payload snapshot.
+// The event ID is derived from the ID of the recorded event, so a redelivered event
+// keeps its ID and the tasks already enqueued for it aren't enqueued again.
+// Internal events without a webhook event type are skipped.
+func TranslateEventsToWebhookEvents(enq webhookEnqueuer, router eventRouter) events.Listener {
+	return func(ctx context.Context, event events.EventName, payload interface{}) error {
 		if payload == nil {
 			return nil
 		}
+		name, ok := webhookEvents[event]
+		if !ok {
+			return nil
+		}
+
+		ids, err := router.MatchingEndpoints(ctx, name)
+		if err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		data, err := router.EventData(ctx, event, payload)
+		if err != nil {
+			return fmt.Errorf("failed to build webhook payload of event %s: %w", event, err)
+		}
+
+		e := Event{
+			ID:      eventID(ctx),
+			Name:    name,
+			Payload: payload,
+			Data:    data,
+		}
+		if p, ok := payload.(events.PaymentIDGetter); ok {
+			if pid, err := uuid.Parse(p.GetPaymentID()); err == nil {
+				e.PaymentID = &pid
+			}
+		}
+		for _, id := range ids {
+			if err := enq.FireEvent(ctx, id, e); err != nil {
+				return fmt.Errorf("endpoint %s: %w", id, err)
+			}
+		}
+
+		return nil
+	}
+}
+
+// eventNamespace is the namespace of the webhook event IDs derived from the recorded event IDs.
+var eventNamespace = uuid.MustParse("0c5a3d2e-6f1b-4c8e-9a47-3b2d8e1f6a90")
 
-		return enq.FireEvent(context.Background(), string(event), payload)
+// eventID returns the webhook event ID derived from the ID of the recorded event,
+// or a random one if the event is emitted without being recorded.
+func eventID(ctx context.Context) uuid.UUID {
+	if id := events.EventIDFromContext(ctx); id != "" {
+		return uuid.NewSHA1(eventNamespace, []byte(id))
 	}
+	return uuid.New()
 }
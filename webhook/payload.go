@@ -0,0 +1,269 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/easypmnt/checkout-api/events"
+	"github.com/easypmnt/checkout-api/payments"
+	"github.com/google/uuid"
+)
+
+// Webhook payload API versions. Endpoints are pinned to a version,
+// so breaking changes of the payload don't break the receivers.
+const (
+	// APIVersion20230310 sends the internal event payload as is: mostly IDs only.
+	APIVersion20230310 = "2023-03-10"
+	// APIVersion20230414 sends EventData built from the payment and the transaction.
+	APIVersion20230414 = "2023-04-14"
+
+	// LatestAPIVersion is the version of the new endpoints.
+	LatestAPIVersion = APIVersion20230414
+)
+
+// webhookEvents maps the internal events to the webhook event types.
+// Internal events missing in the map are never sent to the endpoints.
+var webhookEvents = map[events.EventName]string{
+	events.PaymentCreated:       EventPaymentCreated,
+	events.PaymentProcessing:    EventPaymentProcessing,
+	events.PaymentCancelled:     EventPaymentCancelled,
+	events.PaymentFailed:        EventPaymentFailed,
+	events.PaymentExpired:       EventPaymentExpired,
+	events.PaymentSucceeded:     EventPaymentSucceeded,
+	events.PaymentLinkGenerated: EventPaymentLinkGenerated,
+	events.TransactionCreated:   EventTransactionCreated,
+	events.TransactionUpdated:   EventTransactionUpdated,
+	events.LoyaltyTierChanged:   EventLoyaltyTierChanged,
+
+	events.WebhookEndpointDisabled: EventWebhookEndpointDisabled,
+}
+
+// IsSupportedAPIVersion checks if the payload API version is supported.
+func IsSupportedAPIVersion(v string) bool {
+	return v == APIVersion20230310 || v == APIVersion20230414
+}
+
+type (
+	// EventData is the payload data of the webhook events since APIVersion20230414.
+	// Only the objects related to the event are set.
+	EventData struct {
+		Payment     *PaymentData     `json:"payment,omitempty"`
+		Transaction *TransactionData `json:"transaction,omitempty"` // the event transaction or the latest transaction of the payment
+		Link        string           `json:"link,omitempty"`        // payment.link.generated only
+		Loyalty     *LoyaltyData     `json:"loyalty,omitempty"`
+		Endpoint    *EndpointData    `json:"endpoint,omitempty"` // webhook.endpoint.disabled only
+	}
+
+	// TransactionData is the payment transaction payload.
+	// All the amounts are in base units of the mint.
+	TransactionData struct {
+		TransactionID        string     `json:"transaction_id"`
+		PaymentID            string     `json:"payment_id"`
+		Reference            string     `json:"reference"`
+		Status               string     `json:"status"`              // pending, completed, failed or expired
+		Signature            string     `json:"signature,omitempty"` // the transaction signature on-chain
+		SourceWallet         string     `json:"source_wallet"`
+		SourceMint           string     `json:"source_mint"`
+		DestinationWallet    string     `json:"destination_wallet"`
+		DestinationMint      string     `json:"destination_mint"`
+		Amount               uint64     `json:"amount"`
+		DiscountAmount       uint64     `json:"discount_amount"` // paid with bonus tokens
+		PromoDiscountAmount  uint64     `json:"promo_discount_amount"`
+		CouponID             string     `json:"coupon_id,omitempty"`
+		CouponDiscountAmount uint64     `json:"coupon_discount_amount"`
+		GatingDiscountAmount uint64     `json:"gating_discount_amount"`
+		GiftCardAmount       uint64     `json:"gift_card_amount"`
+		TotalAmount          uint64     `json:"total_amount"`
+		AccruedBonusAmount   uint64     `json:"accrued_bonus_amount"`
+		RefCode              string     `json:"ref_code,omitempty"`
+		AffiliateCommission  uint64     `json:"affiliate_commission,omitempty"`
+		Message              string     `json:"message,omitempty"`
+		Memo                 string     `json:"memo,omitempty"`
+		CreatedAt            time.Time  `json:"created_at"`
+		UpdatedAt            *time.Time `json:"updated_at,omitempty"`
+	}
+
+	// LoyaltyData is the loyalty tier change payload.
+	LoyaltyData struct {
+		Wallet       string `json:"wallet"`
+		PreviousTier string `json:"previous_tier,omitempty"`
+		Tier         string `json:"tier"`
+	}
+
+	// paymentSource is the source of the payment data of the webhook payloads.
+	paymentSource interface {
+		GetPayment(ctx context.Context, id uuid.UUID) (*payments.Payment, error)
+		GetTransactionByReference(ctx context.Context, reference string) (*payments.Transaction, error)
+		GetPaymentTransactions(ctx context.Context, paymentID uuid.UUID) ([]*payments.Transaction, error)
+	}
+)
+
+// EventData builds the typed payload data of the event from the payment and the transaction.
+// The data is a snapshot of the moment the event is fanned out to the endpoints.
+func (s *Service) EventData(ctx context.Context, event events.EventName, payload interface{}) (*EventData, error) {
+	switch p := payload.(type) {
+	case events.LoyaltyTierChangedPayload:
+		return &EventData{Loyalty: &LoyaltyData{
+			Wallet:       p.Wallet,
+			PreviousTier: p.PreviousTier,
+			Tier:         p.Tier,
+		}}, nil
+	case events.WebhookEndpointDisabledPayload:
+		return &EventData{Endpoint: &EndpointData{
+			EndpointID:          p.EndpointID,
+			URL:                 p.URL,
+			Reason:              p.Reason,
+			ConsecutiveFailures: p.ConsecutiveFailures,
+			FailingSince:        p.FailingSince,
+		}}, nil
+	case events.TransactionCreatedPayload:
+		return s.transactionEventData(ctx, p.Reference)
+	case events.TransactionUpdatedPayload:
+		data, err := s.transactionEventData(ctx, p.Reference)
+		if err != nil {
+			return nil, err
+		}
+		// The expired transaction is read as pending, the event status is the actual one.
+		data.Transaction.Status = p.Status
+		return data, nil
+	case events.PaymentLinkGeneratedPayload:
+		data, err := s.paymentEventData(ctx, p.GetPaymentID(), "")
+		if err != nil {
+			return nil, err
+		}
+		data.Link = p.Link
+		return data, nil
+	case events.PaymentStatusUpdatedPayload:
+		return s.paymentEventData(ctx, p.GetPaymentID(), p.Status)
+	case events.PaymentIDGetter:
+		return s.paymentEventData(ctx, p.GetPaymentID(), "")
+	}
+
+	return nil, fmt.Errorf("unsupported payload of event %s: %T", event, payload)
+}
+
+// paymentEventData returns the payment and its latest transaction, the completed one is preferred.
+// The status of the event overrides the payment status, so the data is consistent with the event
+// even if the payment has been updated since then.
+func (s *Service) paymentEventData(ctx context.Context, paymentID, status string) (*EventData, error) {
+	if s.payments == nil {
+		return nil, fmt.Errorf("payment source is not set")
+	}
+
+	id, err := uuid.Parse(paymentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse payment id: %w", err)
+	}
+	payment, err := s.payments.GetPayment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if status != "" {
+		payment.Status = payments.PaymentStatus(status)
+	}
+
+	txs, err := s.payments.GetPaymentTransactions(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &EventData{Payment: castPaymentData(payment)}
+	for _, tx := range txs {
+		if tx.Status == payments.TransactionStatusCompleted {
+			data.Transaction = castTransactionData(tx)
+			break
+		}
+	}
+	if data.Transaction == nil && len(txs) > 0 {
+		data.Transaction = castTransactionData(txs[0])
+	}
+
+	return data, nil
+}
+
+// transactionEventData returns the transaction with the given reference and its payment.
+func (s *Service) transactionEventData(ctx context.Context, reference string) (*EventData, error) {
+	if s.payments == nil {
+		return nil, fmt.Errorf("payment source is not set")
+	}
+
+	tx, err := s.payments.GetTransactionByReference(ctx, reference)
+	if err != nil {
+		return nil, err
+	}
+	payment, err := s.payments.GetPayment(ctx, tx.PaymentID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &EventData{
+		Payment:     castPaymentData(payment),
+		Transaction: castTransactionData(tx),
+	}, nil
+}
+
+// cast payments.Payment to webhook.PaymentData
+func castPaymentData(p *payments.Payment) *PaymentData {
+	result := &PaymentData{
+		PaymentID:         p.ID.String(),
+		ExternalID:        p.ExternalID,
+		DestinationWallet: p.DestinationWallet,
+		Mint:              p.DestinationMint,
+		Amount:            p.Amount,
+		Status:            string(p.Status),
+		Message:           p.Message,
+		ReceiptMint:       p.ReceiptMint,
+		ExpiresAt:         p.ExpiresAt,
+		CreatedAt:         p.CreatedAt,
+		UpdatedAt:         p.UpdatedAt,
+	}
+
+	switch p.Status {
+	case payments.PaymentStatusFailed:
+		result.Err = &PaymentError{
+			Code:    "transaction_failed",
+			Message: "The payment transaction failed on-chain",
+		}
+	case payments.PaymentStatusExpired:
+		result.Err = &PaymentError{
+			Code:    "payment_expired",
+			Message: "The payment was not completed before it expired",
+		}
+	}
+
+	return result
+}
+
+// cast payments.Transaction to webhook.TransactionData
+func castTransactionData(tx *payments.Transaction) *TransactionData {
+	result := &TransactionData{
+		TransactionID:        tx.ID.String(),
+		PaymentID:            tx.PaymentID.String(),
+		Reference:            tx.Reference,
+		Status:               string(tx.Status),
+		Signature:            tx.Signature,
+		SourceWallet:         tx.SourceWallet,
+		SourceMint:           tx.SourceMint,
+		DestinationWallet:    tx.DestinationWallet,
+		DestinationMint:      tx.DestinationMint,
+		Amount:               tx.Amount,
+		DiscountAmount:       tx.DiscountAmount,
+		PromoDiscountAmount:  tx.PromoDiscountAmount,
+		CouponDiscountAmount: tx.CouponDiscountAmount,
+		GatingDiscountAmount: tx.GatingDiscountAmount,
+		GiftCardAmount:       tx.GiftCardAmount,
+		TotalAmount:          tx.TotalAmount,
+		AccruedBonusAmount:   tx.AccruedBonusAmount,
+		RefCode:              tx.RefCode,
+		AffiliateCommission:  tx.AffiliateCommission,
+		Message:              tx.Message,
+		Memo:                 tx.Memo,
+		CreatedAt:            tx.CreatedAt,
+		UpdatedAt:            tx.UpdatedAt,
+	}
+	if tx.CouponID != nil {
+		result.CouponID = tx.CouponID.String()
+	}
+	return result
+}
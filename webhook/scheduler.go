@@ -0,0 +1,21 @@
+package webhook
+
+import (
+	"time"
+
+	"github.com/hibiken/asynq"
+)
+
+// Scheduler is a task scheduler for the webhook deliveries.
+type Scheduler struct{}
+
+// NewScheduler creates a new task scheduler for the webhook deliveries.
+func NewScheduler() *Scheduler {
+	return &Scheduler{}
+}
+
+// Schedule tasks for the webhook deliveries.
+// The unique option prevents concurrent flushes, so the parked events are delivered in order.
+func (s *Scheduler) Schedule(scheduler *asynq.Scheduler) {
+	scheduler.Register("@every 1m", asynq.NewTask(TaskFlushParkedEvents, nil), asynq.Unique(10*time.Minute))
+}
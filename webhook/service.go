@@ -2,11 +2,18 @@ package webhook
 
 import (
 	"bytes"
+	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"strings"
 	"time"
+
+	"github.com/easypmnt/checkout-api/repository"
+	"github.com/google/uuid"
 )
 
 type (
@@ -15,11 +22,46 @@ type (
 		client          *http.Client
 		signatureHeader string
 		signatureSecret []byte
+		previousSecret  []byte
 		webhookURI      string
+		apiVersion      string
+		repo            webhookRepository
+		payments        paymentSource
+		breaker         circuitBreaker
+		fireEvent       fireEventFunc
+		log             logger
 	}
 
 	// ServiceOption is a function that configures the webhook service.
 	ServiceOption func(*Service)
+
+	webhookRepository interface {
+		CreateWebhookEndpoint(ctx context.Context, arg repository.CreateWebhookEndpointParams) (repository.WebhookEndpoint, error)
+		GetWebhookEndpoint(ctx context.Context, id uuid.UUID) (repository.WebhookEndpoint, error)
+		GetWebhookEndpoints(ctx context.Context) ([]repository.WebhookEndpoint, error)
+		GetSubscribedWebhookEndpoints(ctx context.Context) ([]repository.WebhookEndpoint, error)
+		UpdateWebhookEndpoint(ctx context.Context, arg repository.UpdateWebhookEndpointParams) (repository.WebhookEndpoint, error)
+		RotateWebhookEndpointSecret(ctx context.Context, arg repository.RotateWebhookEndpointSecretParams) (repository.WebhookEndpoint, error)
+		DeleteWebhookEndpoint(ctx context.Context, id uuid.UUID) (int64, error)
+		RecordWebhookEndpointSuccess(ctx context.Context, id uuid.UUID) error
+		RecordWebhookEndpointFailure(ctx context.Context, id uuid.UUID) (repository.WebhookEndpoint, error)
+		PauseWebhookEndpoint(ctx context.Context, arg repository.PauseWebhookEndpointParams) error
+		ResumeWebhookEndpoint(ctx context.Context, id uuid.UUID) (int64, error)
+		DisableFailingWebhookEndpoint(ctx context.Context, arg repository.DisableFailingWebhookEndpointParams) (int64, error)
+		ReenableWebhookEndpoint(ctx context.Context, id uuid.UUID) error
+		GetWebhookEndpointsToFlush(ctx context.Context) ([]repository.WebhookEndpoint, error)
+		CreateWebhookParkedEvent(ctx context.Context, arg repository.CreateWebhookParkedEventParams) error
+		GetWebhookParkedEvents(ctx context.Context, arg repository.GetWebhookParkedEventsParams) ([]repository.WebhookParkedEvent, error)
+		DeleteWebhookParkedEvent(ctx context.Context, id int64) error
+		CountWebhookParkedEvents(ctx context.Context, endpointID uuid.UUID) (int64, error)
+		CreateWebhookDelivery(ctx context.Context, arg repository.CreateWebhookDeliveryParams) (repository.WebhookDelivery, error)
+		GetWebhookDelivery(ctx context.Context, id uuid.UUID) (repository.WebhookDelivery, error)
+		GetWebhookDeliveries(ctx context.Context, arg repository.GetWebhookDeliveriesParams) ([]repository.WebhookDelivery, error)
+	}
+
+	logger interface {
+		Errorf(format string, args ...interface{})
+	}
 )
 
 // NewService creates a new webhook service.
@@ -29,6 +71,12 @@ func NewService(opts ...ServiceOption) *Service {
 			Timeout: 10 * time.Second,
 		},
 		signatureHeader: DefaultSignatureHeader,
+		apiVersion:      APIVersion20230310,
+		breaker: circuitBreaker{
+			failureThreshold: DefaultFailureThreshold,
+			pauseDuration:    DefaultPauseDuration,
+			disableAfter:     DefaultDisableAfter,
+		},
 	}
 
 	for _, opt := range opts {
@@ -59,13 +107,55 @@ func WithSignatureSecret(secret []byte) ServiceOption {
 	}
 }
 
-// WithWebhookURI configures the webhook service with a custom webhook URI.
+// WithPreviousSignatureSecret configures the webhook service with the previous signature secret
+// of the default endpoint. Payloads are signed with both secrets, so the receiver can switch
+// to the new secret at any time. Remove it once the receiver has switched.
+func WithPreviousSignatureSecret(secret []byte) ServiceOption {
+	return func(s *Service) {
+		s.previousSecret = secret
+	}
+}
+
+// WithWebhookURI configures the webhook service with a default webhook URI.
+// The default endpoint is subscribed to all events and signed with the signature secret.
 func WithWebhookURI(uri string) ServiceOption {
 	return func(s *Service) {
 		s.webhookURI = uri
 	}
 }
 
+// WithAPIVersion configures the payload API version of the default endpoint,
+// APIVersion20230310 by default for backward compatibility.
+func WithAPIVersion(v string) ServiceOption {
+	return func(s *Service) {
+		s.apiVersion = v
+	}
+}
+
+// WithPaymentSource configures the webhook service with the source of the payment data.
+// It's required to build the payloads since APIVersion20230414.
+func WithPaymentSource(ps paymentSource) ServiceOption {
+	return func(s *Service) {
+		s.payments = ps
+	}
+}
+
+// WithRepository configures the webhook service with the repository of webhook endpoints and deliveries.
+// Without it, the events are sent to the default webhook URI only and deliveries are not logged.
+func WithRepository(repo webhookRepository) ServiceOption {
+	return func(s *Service) {
+		s.repo = repo
+	}
+}
+
+// WithLogger configures the webhook service with a logger.
+// It's used to report deliveries that could not be logged.
+func WithLogger(log logger) ServiceOption {
+	return func(s *Service) {
+		s.log = log
+	}
+}
+
 // Send post request to webhook url with payload.
 func (s *Service) Send(url string, payload interface{}) (*http.Response, error) {
 	body, err := json.Marshal(payload)
@@ -73,7 +163,13 @@ func (s *Service) Send(url string, payload interface{}) (*http.Response, error)
 		return nil, fmt.Errorf("failed to marshal webhook payload: %w", err)
 	}
 
-	signature, err := SignPayload(body, s.signatureSecret)
+	return s.post(url, [][]byte{s.signatureSecret}, body)
+}
+
+// post posts the body signed with the given secrets to the webhook url.
+// The body is signed at the time of sending, so retries and replays get a fresh timestamp.
+func (s *Service) post(url string, secrets [][]byte, body []byte) (*http.Response, error) {
+	signature, err := SignPayload(body, time.Now(), secrets...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to sign webhook payload: %w", err)
 	}
@@ -93,28 +189,425 @@ func (s *Service) Send(url string, payload interface{}) (*http.Response, error)
 	return resp, nil
 }
 
-// FireEvent sends a webhook event to the webhook url.
-func (s *Service) FireEvent(event string, payload interface{}) error {
-	if s.webhookURI == "" {
-		return fmt.Errorf("webhook uri is not set")
+// FireEvent sends a webhook event to the endpoint with the given ID and logs the delivery.
+// The payload data depends on the API version the endpoint is pinned to.
+// uuid.Nil stands for the default endpoint configured with WithWebhookURI.
+// All the deliveries of the same event share the event ID, so the receiver can deduplicate them.
+// The event is parked if the endpoint is paused or disabled by the circuit breaker,
+// including the failed delivery which opens the circuit.
+// Returns ErrEndpointNotFound or ErrEndpointDisabled if the endpoint was deleted or disabled
+// after the event had been enqueued.
+func (s *Service) FireEvent(ctx context.Context, endpointID uuid.UUID, event Event) error {
+	endpoint, err := s.resolveEndpoint(ctx, endpointID)
+	if err != nil {
+		return err
+	}
+	if endpointID != uuid.Nil && endpoint.parksEvents() {
+		return s.park(ctx, endpoint, event)
+	}
+	if !endpoint.Enabled {
+		return ErrEndpointDisabled
+	}
+
+	body, err := s.requestBody(endpoint, event)
+	if err != nil {
+		return err
+	}
+
+	d := s.deliver(ctx, endpoint, event.delivery(body))
+	if endpointID == uuid.Nil {
+		if !d.Succeeded {
+			return fmt.Errorf("failed to send webhook event: %s", d.Error)
+		}
+		return nil
+	}
+
+	opened, err := s.trackHealth(ctx, endpoint, d.Succeeded)
+	if d.Succeeded {
+		// The event is delivered, so it must not be retried because of the health tracking error.
+		if err != nil && s.log != nil {
+			s.log.Errorf("webhook endpoint %s: %v", endpoint.ID, err)
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if opened {
+		return s.park(ctx, endpoint, event)
 	}
 
-	return s.fireEvent(event, s.webhookURI, payload)
+	return fmt.Errorf("failed to send webhook event: %s", d.Error)
 }
 
-// fireEvent sends a webhook event to the webhook url.
-func (s *Service) fireEvent(event, url string, payload interface{}) error {
+// requestBody returns the request body of the event for the API version of the endpoint.
+func (s *Service) requestBody(endpoint *Endpoint, event Event) ([]byte, error) {
 	reqData := WebhookRequestPayload{
-		Event: event,
-		Data:  payload,
+		APIVersion: endpoint.APIVersion,
+		Event:      event.Name,
+		EventID:    event.ID.String(),
+		Data:       event.payload(endpoint.APIVersion),
+	}
+	if endpoint.ID != uuid.Nil {
+		reqData.WebhookID = endpoint.ID.String()
+	}
+
+	body, err := json.Marshal(reqData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+
+	return body, nil
+}
+
+// Replay sends the request body of the given delivery to its endpoint again and logs the new delivery.
+// The event ID is kept, so the receiver can deduplicate the event.
+// The returned delivery contains the result, it's not an error if the endpoint failed again.
+func (s *Service) Replay(ctx context.Context, deliveryID uuid.UUID) (*Delivery, error) {
+	d, err := s.GetDelivery(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint, err := s.resolveEndpoint(ctx, d.EndpointID)
+	if err != nil {
+		return nil, err
+	}
+	if !endpoint.Enabled {
+		return nil, ErrEndpointDisabled
+	}
+
+	return s.deliver(ctx, endpoint, &Delivery{
+		Event:       d.Event,
+		EventID:     d.EventID,
+		RequestBody: d.RequestBody,
+		ReplayOf:    &d.ID,
+		PaymentID:   d.PaymentID,
+	}), nil
+}
+
+// resolveEndpoint returns the endpoint with the given ID.
+// uuid.Nil stands for the default endpoint configured with WithWebhookURI.
+func (s *Service) resolveEndpoint(ctx context.Context, endpointID uuid.UUID) (*Endpoint, error) {
+	if endpointID != uuid.Nil {
+		return s.GetEndpoint(ctx, endpointID)
+	}
+	if s.webhookURI == "" {
+		return nil, ErrEndpointNotFound
+	}
+
+	return &Endpoint{
+		URL:            s.webhookURI,
+		Secret:         string(s.signatureSecret),
+		PreviousSecret: string(s.previousSecret),
+		Enabled:        true,
+		APIVersion:     s.apiVersion,
+	}, nil
+}
+
+// deliver posts the request body of the delivery to the endpoint and logs the delivery.
+// Only 200 OK response is considered as a successful delivery.
+func (s *Service) deliver(ctx context.Context, endpoint *Endpoint, d *Delivery) *Delivery {
+	d.EndpointID = endpoint.ID
+	d.URL = endpoint.URL
+
+	start := time.Now()
+	resp, err := s.post(endpoint.URL, endpoint.activeSecrets(start), d.RequestBody)
+	d.LatencyMs = int(time.Since(start).Milliseconds())
+	if err != nil {
+		d.Error = err.Error()
+		return s.logDelivery(ctx, d)
+	}
+	defer resp.Body.Close()
+
+	// The response body is informational, so a failed read doesn't fail the delivery.
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBodySize))
+	d.ResponseStatus = resp.StatusCode
+	d.ResponseBody = sanitizeResponseBody(respBody)
+	if resp.StatusCode == http.StatusOK {
+		d.Succeeded = true
+	} else {
+		d.Error = fmt.Sprintf("unexpected response status: %s", resp.Status)
+	}
+
+	return s.logDelivery(ctx, d)
+}
+
+// logDelivery stores the delivery in the repository if it's set.
+// A delivery that could not be logged is not retried, so the endpoint doesn't receive the event twice.
+func (s *Service) logDelivery(ctx context.Context, d *Delivery) *Delivery {
+	if s.repo == nil {
+		return d
+	}
+
+	params := repository.CreateWebhookDeliveryParams{
+		EndpointID:  d.EndpointID,
+		URL:         d.URL,
+		Event:       d.Event,
+		EventID:     d.EventID,
+		RequestBody: d.RequestBody,
+		ResponseStatus: sql.NullInt32{
+			Int32: int32(d.ResponseStatus),
+			Valid: d.ResponseStatus != 0,
+		},
+		ResponseBody: sql.NullString{
+			String: d.ResponseBody,
+			Valid:  d.ResponseBody != "",
+		},
+		LatencyMs: int32(d.LatencyMs),
+		Error: sql.NullString{
+			String: d.Error,
+			Valid:  d.Error != "",
+		},
+		Succeeded: d.Succeeded,
+	}
+	if d.ReplayOf != nil {
+		params.ReplayOf = uuid.NullUUID{UUID: *d.ReplayOf, Valid: true}
 	}
-	resp, err := s.Send(url, reqData)
+	if d.PaymentID != nil {
+		params.PaymentID = uuid.NullUUID{UUID: *d.PaymentID, Valid: true}
+	}
+
+	result, err := s.repo.CreateWebhookDelivery(ctx, params)
+	if err != nil {
+		if s.log != nil {
+			s.log.Errorf("failed to log webhook delivery of event %s to %s: %v", d.EventID, d.URL, err)
+		}
+		return d
+	}
+
+	return castFromRepositoryDelivery(result)
+}
+
+// GetDelivery returns the webhook delivery with the given ID.
+func (s *Service) GetDelivery(ctx context.Context, id uuid.UUID) (*Delivery, error) {
+	d, err := s.repo.GetWebhookDelivery(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrDeliveryNotFound
+		}
+		return nil, fmt.Errorf("failed to get webhook delivery: %w", err)
+	}
+
+	return castFromRepositoryDelivery(d), nil
+}
+
+// GetDeliveries returns the webhook deliveries matching the filter, newest first.
+func (s *Service) GetDeliveries(ctx context.Context, filter DeliveryFilter) ([]*Delivery, error) {
+	if err := filter.validate(); err != nil {
+		return nil, err
+	}
+
+	params := repository.GetWebhookDeliveriesParams{
+		Since:        filter.Since,
+		Until:        filter.Until,
+		AnyEndpoint:  filter.EndpointID == nil,
+		Event:        filter.Event,
+		AnyEventID:   filter.EventID == nil,
+		AnyPaymentID: filter.PaymentID == nil,
+		Status:       filter.Status,
+		Limit:        filter.Limit,
+		Offset:       filter.Offset,
+	}
+	if filter.EndpointID != nil {
+		params.EndpointID = *filter.EndpointID
+	}
+	if filter.EventID != nil {
+		params.EventID = *filter.EventID
+	}
+	if filter.PaymentID != nil {
+		params.PaymentID = *filter.PaymentID
+	}
+
+	deliveries, err := s.repo.GetWebhookDeliveries(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook deliveries: %w", err)
+	}
+
+	result := make([]*Delivery, 0, len(deliveries))
+	for _, d := range deliveries {
+		result = append(result, castFromRepositoryDelivery(d))
+	}
+
+	return result, nil
+}
+
+// MatchingEndpoints returns IDs of the endpoints subscribed to the given event,
+// including uuid.Nil for the default endpoint if it is configured.
+// The endpoints disabled by the circuit breaker are included, so their events are parked
+// and delivered in order once the endpoint is enabled again.
+func (s *Service) MatchingEndpoints(ctx context.Context, event string) ([]uuid.UUID, error) {
+	var result []uuid.UUID
+	if s.webhookURI != "" {
+		result = append(result, uuid.Nil)
+	}
+	if s.repo == nil {
+		return result, nil
+	}
+
+	endpoints, err := s.repo.GetSubscribedWebhookEndpoints(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook endpoints: %w", err)
+	}
+	for _, e := range endpoints {
+		if castFromRepositoryEndpoint(e).Subscribed(event) {
+			result = append(result, e.ID)
+		}
+	}
+
+	return result, nil
+}
+
+// CreateEndpoint creates a new webhook endpoint.
+// The secret is generated if it's not set, the endpoint is pinned to the latest API version by default.
+func (s *Service) CreateEndpoint(ctx context.Context, endpoint *Endpoint) (*Endpoint, error) {
+	endpoint.normalize()
+	if endpoint.APIVersion == "" {
+		endpoint.APIVersion = LatestAPIVersion
+	}
+	if err := endpoint.validate(); err != nil {
+		return nil, err
+	}
+	if endpoint.Secret == "" {
+		secret, err := NewSecret()
+		if err != nil {
+			return nil, err
+		}
+		endpoint.Secret = secret
+	}
+
+	e, err := s.repo.CreateWebhookEndpoint(ctx, repository.CreateWebhookEndpointParams{
+		URL:    endpoint.URL,
+		Secret: endpoint.Secret,
+		Description: sql.NullString{
+			String: endpoint.Description,
+			Valid:  endpoint.Description != "",
+		},
+		EventTypes: endpoint.EventTypes,
+		Enabled:    endpoint.Enabled,
+		ApiVersion: endpoint.APIVersion,
+	})
 	if err != nil {
-		return fmt.Errorf("failed to send webhook event: %w", err)
+		return nil, fmt.Errorf("failed to create webhook endpoint: %w", err)
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to send webhook event: %s", resp.Status)
+	return castFromRepositoryEndpoint(e), nil
+}
+
+// GetEndpoints returns all the webhook endpoints, newest first.
+func (s *Service) GetEndpoints(ctx context.Context) ([]*Endpoint, error) {
+	endpoints, err := s.repo.GetWebhookEndpoints(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook endpoints: %w", err)
+	}
+
+	result := make([]*Endpoint, 0, len(endpoints))
+	for _, e := range endpoints {
+		result = append(result, castFromRepositoryEndpoint(e))
+	}
+
+	return result, nil
+}
+
+// GetEndpoint returns the webhook endpoint with the given ID.
+func (s *Service) GetEndpoint(ctx context.Context, id uuid.UUID) (*Endpoint, error) {
+	e, err := s.repo.GetWebhookEndpoint(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrEndpointNotFound
+		}
+		return nil, fmt.Errorf("failed to get webhook endpoint: %w", err)
+	}
+
+	return castFromRepositoryEndpoint(e), nil
+}
+
+// UpdateEndpoint updates the URL, description, event types, status and API version of the webhook endpoint.
+// The secret is never changed, the API version is kept if it's not set.
+// Enabling the endpoint disabled by the circuit breaker flushes the parked events in order.
+func (s *Service) UpdateEndpoint(ctx context.Context, endpoint *Endpoint) (*Endpoint, error) {
+	endpoint.normalize()
+	current, err := s.GetEndpoint(ctx, endpoint.ID)
+	if err != nil {
+		return nil, err
+	}
+	if endpoint.APIVersion == "" {
+		endpoint.APIVersion = current.APIVersion
+	}
+	if err := endpoint.validate(); err != nil {
+		return nil, err
+	}
+
+	e, err := s.repo.UpdateWebhookEndpoint(ctx, repository.UpdateWebhookEndpointParams{
+		ID:  endpoint.ID,
+		URL: endpoint.URL,
+		Description: sql.NullString{
+			String: endpoint.Description,
+			Valid:  endpoint.Description != "",
+		},
+		EventTypes: endpoint.EventTypes,
+		Enabled:    endpoint.Enabled,
+		ApiVersion: endpoint.APIVersion,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrEndpointNotFound
+		}
+		return nil, fmt.Errorf("failed to update webhook endpoint: %w", err)
+	}
+
+	if e.Enabled && current.DisabledReason != "" {
+		// The endpoint is paused till now, so the parked events are flushed before the new ones.
+		if err := s.repo.ReenableWebhookEndpoint(ctx, e.ID); err != nil {
+			return nil, fmt.Errorf("failed to re-enable webhook endpoint: %w", err)
+		}
+		return s.GetEndpoint(ctx, e.ID)
+	}
+
+	return castFromRepositoryEndpoint(e), nil
+}
+
+// RotateSecret replaces the secret of the webhook endpoint with a new generated one.
+// Payloads are signed with both secrets until the previous one expires in the given duration,
+// so the receiver can switch to the new secret without missing events.
+// Zero duration expires the previous secret immediately.
+func (s *Service) RotateSecret(ctx context.Context, id uuid.UUID, previousSecretTTL time.Duration) (*Endpoint, error) {
+	if previousSecretTTL < 0 || previousSecretTTL > MaxPreviousSecretTTL {
+		return nil, fmt.Errorf("%w: previous secret lifetime must be between 0 and %s", ErrInvalidEndpoint, MaxPreviousSecretTTL)
+	}
+
+	secret, err := NewSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	e, err := s.repo.RotateWebhookEndpointSecret(ctx, repository.RotateWebhookEndpointSecretParams{
+		ID:     id,
+		Secret: secret,
+		PreviousSecretExpiresAt: sql.NullTime{
+			Time:  time.Now().Add(previousSecretTTL),
+			Valid: true,
+		},
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrEndpointNotFound
+		}
+		return nil, fmt.Errorf("failed to rotate webhook endpoint secret: %w", err)
+	}
+
+	return castFromRepositoryEndpoint(e), nil
+}
+
+// DeleteEndpoint deletes the webhook endpoint with the given ID.
+// Events already enqueued for the endpoint are dropped.
+func (s *Service) DeleteEndpoint(ctx context.Context, id uuid.UUID) error {
+	n, err := s.repo.DeleteWebhookEndpoint(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook endpoint: %w", err)
+	}
+	if n == 0 {
+		return ErrEndpointNotFound
 	}
 
 	return nil
@@ -0,0 +1,203 @@
+package webhook
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/easypmnt/checkout-api/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// memRepo is the in-memory repository of the endpoints and the parked events.
+// The methods not used by the tests panic.
+type memRepo struct {
+	webhookRepository
+
+	endpoints map[uuid.UUID]repository.WebhookEndpoint
+	parked    []repository.WebhookParkedEvent
+}
+
+func (r *memRepo) GetWebhookEndpoint(_ context.Context, id uuid.UUID) (repository.WebhookEndpoint, error) {
+	e, ok := r.endpoints[id]
+	if !ok {
+		return e, sql.ErrNoRows
+	}
+	return e, nil
+}
+
+func (r *memRepo) GetSubscribedWebhookEndpoints(context.Context) ([]repository.WebhookEndpoint, error) {
+	var result []repository.WebhookEndpoint
+	for _, e := range r.endpoints {
+		if e.Enabled || e.DisabledReason.String == DisabledReasonFailures {
+			result = append(result, e)
+		}
+	}
+	return result, nil
+}
+
+func (r *memRepo) UpdateWebhookEndpoint(_ context.Context, arg repository.UpdateWebhookEndpointParams) (repository.WebhookEndpoint, error) {
+	e := r.endpoints[arg.ID]
+	e.URL, e.EventTypes, e.Enabled, e.ApiVersion = arg.URL, arg.EventTypes, arg.Enabled, arg.ApiVersion
+	r.endpoints[arg.ID] = e
+	return e, nil
+}
+
+func (r *memRepo) DisableFailingWebhookEndpoint(_ context.Context, arg repository.DisableFailingWebhookEndpointParams) (int64, error) {
+	e := r.endpoints[arg.ID]
+	if !e.Enabled {
+		return 0, nil
+	}
+	e.Enabled, e.DisabledReason = false, arg.DisabledReason
+	r.endpoints[arg.ID] = e
+	return 1, nil
+}
+
+func (r *memRepo) ReenableWebhookEndpoint(_ context.Context, id uuid.UUID) error {
+	e := r.endpoints[id]
+	e.DisabledReason = sql.NullString{}
+	e.ConsecutiveFailures = 0
+	e.FailingSince = sql.NullTime{}
+	e.PausedUntil = sql.NullTime{Time: time.Now(), Valid: true}
+	r.endpoints[id] = e
+	return nil
+}
+
+func (r *memRepo) ResumeWebhookEndpoint(_ context.Context, id uuid.UUID) (int64, error) {
+	e := r.endpoints[id]
+	e.PausedUntil = sql.NullTime{}
+	r.endpoints[id] = e
+	return 1, nil
+}
+
+func (r *memRepo) RecordWebhookEndpointSuccess(context.Context, uuid.UUID) error {
+	return nil
+}
+
+func (r *memRepo) GetWebhookEndpointsToFlush(context.Context) ([]repository.WebhookEndpoint, error) {
+	var result []repository.WebhookEndpoint
+	for _, e := range r.endpoints {
+		if e.Enabled && e.PausedUntil.Valid && !e.PausedUntil.Time.After(time.Now()) {
+			result = append(result, e)
+		}
+	}
+	return result, nil
+}
+
+func (r *memRepo) CreateWebhookParkedEvent(_ context.Context, arg repository.CreateWebhookParkedEventParams) error {
+	r.parked = append(r.parked, repository.WebhookParkedEvent{
+		ID:         int64(len(r.parked) + 1),
+		EndpointID: arg.EndpointID,
+		EventID:    arg.EventID,
+		Event:      arg.Event,
+		Payload:    arg.Payload,
+	})
+	return nil
+}
+
+func (r *memRepo) GetWebhookParkedEvents(_ context.Context, arg repository.GetWebhookParkedEventsParams) ([]repository.WebhookParkedEvent, error) {
+	var result []repository.WebhookParkedEvent
+	for _, p := range r.parked {
+		if p.EndpointID == arg.EndpointID && len(result) < int(arg.Limit) {
+			result = append(result, p)
+		}
+	}
+	return result, nil
+}
+
+func (r *memRepo) DeleteWebhookParkedEvent(_ context.Context, id int64) error {
+	for i, p := range r.parked {
+		if p.ID == id {
+			r.parked = append(r.parked[:i], r.parked[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (r *memRepo) CountWebhookParkedEvents(_ context.Context, endpointID uuid.UUID) (int64, error) {
+	var n int64
+	for _, p := range r.parked {
+		if p.EndpointID == endpointID {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *memRepo) CreateWebhookDelivery(_ context.Context, arg repository.CreateWebhookDeliveryParams) (repository.WebhookDelivery, error) {
+	return repository.WebhookDelivery{
+		ID:         uuid.New(),
+		EndpointID: arg.EndpointID,
+		Event:      arg.Event,
+		EventID:    arg.EventID,
+		Succeeded:  arg.Succeeded,
+	}, nil
+}
+
+func TestReenableFlushesEventsOfDisabledEndpoint(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		received []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var payload WebhookRequestPayload
+		require.NoError(t, json.Unmarshal(body, &payload))
+		mu.Lock()
+		received = append(received, payload.EventID)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	endpoint := repository.WebhookEndpoint{
+		ID:         uuid.New(),
+		URL:        srv.URL,
+		Secret:     "secret",
+		EventTypes: []string{},
+		Enabled:    true,
+		ApiVersion: APIVersion20230310,
+	}
+	repo := &memRepo{endpoints: map[uuid.UUID]repository.WebhookEndpoint{endpoint.ID: endpoint}}
+	s := NewService(WithRepository(repo))
+
+	require.NoError(t, s.disable(ctx, castFromRepositoryEndpoint(endpoint)))
+
+	// the events emitted while the endpoint is disabled by the circuit breaker are parked
+	var eventIDs []string
+	for i := 0; i < 2; i++ {
+		ids, err := s.MatchingEndpoints(ctx, EventPaymentSucceeded)
+		require.NoError(t, err)
+		require.Equal(t, []uuid.UUID{endpoint.ID}, ids)
+
+		event := Event{ID: uuid.New(), Name: EventPaymentSucceeded, Payload: map[string]string{"payment_id": "1"}}
+		require.NoError(t, s.FireEvent(ctx, endpoint.ID, event))
+		eventIDs = append(eventIDs, event.ID.String())
+	}
+	require.Empty(t, received)
+	require.Len(t, repo.parked, 2)
+
+	// re-enabling flushes them in order
+	_, err := s.UpdateEndpoint(ctx, &Endpoint{ID: endpoint.ID, URL: endpoint.URL, Enabled: true})
+	require.NoError(t, err)
+	require.NoError(t, s.FlushParkedEvents(ctx))
+
+	require.Equal(t, eventIDs, received)
+	require.Empty(t, repo.parked)
+	require.Nil(t, castFromRepositoryEndpoint(repo.endpoints[endpoint.ID]).PausedUntil)
+
+	// the endpoint disabled manually gets no events
+	_, err = s.UpdateEndpoint(ctx, &Endpoint{ID: endpoint.ID, URL: endpoint.URL, Enabled: false})
+	require.NoError(t, err)
+	ids, err := s.MatchingEndpoints(ctx, EventPaymentSucceeded)
+	require.NoError(t, err)
+	require.Empty(t, ids)
+}
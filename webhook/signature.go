@@ -3,39 +3,113 @@ package webhook
 import (
 	"crypto/hmac"
 	"crypto/sha256"
-	"encoding/base64"
-	"errors"
+	"encoding/hex"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultSignatureTolerance is the max difference between the signature timestamp
+// and the current time accepted by VerifySignature.
+const DefaultSignatureTolerance = 5 * time.Minute
 
-	"github.com/easypmnt/checkout-api/internal/utils"
+// Signature header scheme: t=<unix timestamp>,v1=<signature>[,v1=<signature>...]
+const (
+	signatureTimestampKey = "t"
+	signatureV1Key        = "v1"
 )
 
-// SignPayload signs a payload using a secret key and returns the signature as a base64 encoded string
-func SignPayload(payload []byte, secretKey []byte) (string, error) {
-	hash := hmac.New(sha256.New, secretKey)
-	if _, err := hash.Write(payload); err != nil {
-		return "", fmt.Errorf("failed to write payload to hash: %w", err)
+// SignPayload signs the payload with each of the secret keys and returns the signature header value:
+// "t=<unix timestamp>,v1=<hex hmac-sha256 of "<timestamp>.<payload>">", one v1 per secret key.
+// Several secret keys are used while the endpoint secret is rotated,
+// so the receiver can verify the payload with either the old or the new secret.
+func SignPayload(payload []byte, timestamp time.Time, secretKeys ...[]byte) (string, error) {
+	if len(secretKeys) == 0 {
+		return "", ErrMissingSignatureSecret
 	}
 
-	return utils.BytesToBase64(hash.Sum(nil)), nil
+	ts := timestamp.Unix()
+	parts := make([]string, 0, len(secretKeys)+1)
+	parts = append(parts, fmt.Sprintf("%s=%d", signatureTimestampKey, ts))
+	for _, key := range secretKeys {
+		parts = append(parts, fmt.Sprintf("%s=%s", signatureV1Key, hex.EncodeToString(computeSignature(payload, ts, key))))
+	}
+
+	return strings.Join(parts, ","), nil
 }
 
-// VerifySignature verifies a signature against a payload using a secret key
-func VerifySignature(payload []byte, signature string, secretKey []byte) error {
-	expectedSignature, err := base64.StdEncoding.DecodeString(signature)
+// VerifySignature verifies the signature header value against the payload.
+// The header is accepted if the timestamp differs from the current time by no more than the tolerance
+// and at least one of the v1 signatures matches one of the secret keys.
+// Zero tolerance disables the timestamp check, which is not recommended: a captured request can be replayed.
+func VerifySignature(payload []byte, header string, tolerance time.Duration, secretKeys ...[]byte) error {
+	ts, signatures, err := parseSignatureHeader(header)
 	if err != nil {
-		return fmt.Errorf("failed to decode signature: %w", err)
+		return err
 	}
 
-	hash := hmac.New(sha256.New, secretKey)
-	if _, err := hash.Write(payload); err != nil {
-		return fmt.Errorf("failed to write payload to hash: %w", err)
+	if tolerance > 0 {
+		diff := time.Since(time.Unix(ts, 0))
+		if diff < 0 {
+			diff = -diff
+		}
+		if diff > tolerance {
+			return ErrSignatureExpired
+		}
+	}
+
+	for _, key := range secretKeys {
+		expected := computeSignature(payload, ts, key)
+		for _, signature := range signatures {
+			if hmac.Equal(expected, signature) {
+				return nil
+			}
+		}
 	}
 
-	actualSignature := hash.Sum(nil)
-	if !hmac.Equal(expectedSignature, actualSignature) {
-		return errors.New("signature verification failed")
+	return ErrSignatureMismatch
+}
+
+// computeSignature returns hmac-sha256 of "<timestamp>.<payload>".
+func computeSignature(payload []byte, timestamp int64, secretKey []byte) []byte {
+	hash := hmac.New(sha256.New, secretKey)
+	hash.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	hash.Write([]byte("."))
+	hash.Write(payload)
+	return hash.Sum(nil)
+}
+
+// parseSignatureHeader returns the timestamp and v1 signatures from the signature header value.
+// Unknown schemes are ignored, so new ones can be added without breaking the receivers.
+func parseSignatureHeader(header string) (int64, [][]byte, error) {
+	var (
+		ts         int64
+		signatures [][]byte
+	)
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return 0, nil, ErrInvalidSignatureHeader
+		}
+		switch key {
+		case signatureTimestampKey:
+			v, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return 0, nil, ErrInvalidSignatureHeader
+			}
+			ts = v
+		case signatureV1Key:
+			signature, err := hex.DecodeString(value)
+			if err != nil {
+				return 0, nil, ErrInvalidSignatureHeader
+			}
+			signatures = append(signatures, signature)
+		}
+	}
+	if ts == 0 || len(signatures) == 0 {
+		return 0, nil, ErrInvalidSignatureHeader
 	}
 
-	return nil
+	return ts, signatures, nil
 }
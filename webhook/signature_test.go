@@ -1,7 +1,9 @@
 package webhook
 
 import (
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -10,10 +12,68 @@ func TestSignature(t *testing.T) {
 	secretKey := []byte("secret")
 	payload := []byte("payload")
 
-	signature, err := SignPayload(payload, secretKey)
+	signature, err := SignPayload(payload, time.Now(), secretKey)
 	require.NoError(t, err)
 	require.NotEmpty(t, signature)
 
-	err = VerifySignature(payload, signature, secretKey)
+	err = VerifySignature(payload, signature, DefaultSignatureTolerance, secretKey)
 	require.NoError(t, err)
+
+	err = VerifySignature([]byte("tampered"), signature, DefaultSignatureTolerance, secretKey)
+	require.ErrorIs(t, err, ErrSignatureMismatch)
+
+	err = VerifySignature(payload, signature, DefaultSignatureTolerance, []byte("other"))
+	require.ErrorIs(t, err, ErrSignatureMismatch)
+}
+
+func TestSignatureTolerance(t *testing.T) {
+	secretKey := []byte("secret")
+	payload := []byte("payload")
+
+	signature, err := SignPayload(payload, time.Now().Add(-time.Hour), secretKey)
+	require.NoError(t, err)
+
+	err = VerifySignature(payload, signature, DefaultSignatureTolerance, secretKey)
+	require.ErrorIs(t, err, ErrSignatureExpired)
+
+	err = VerifySignature(payload, signature, 0, secretKey)
+	require.NoError(t, err)
+}
+
+func TestSignatureRotation(t *testing.T) {
+	oldKey, newKey := []byte("old"), []byte("new")
+	payload := []byte("payload")
+
+	signature, err := SignPayload(payload, time.Now(), newKey, oldKey)
+	require.NoError(t, err)
+
+	require.NoError(t, VerifySignature(payload, signature, DefaultSignatureTolerance, oldKey))
+	require.NoError(t, VerifySignature(payload, signature, DefaultSignatureTolerance, newKey))
+	require.NoError(t, VerifySignature(payload, signature, DefaultSignatureTolerance, []byte("other"), newKey))
+}
+
+func TestSignatureHeader(t *testing.T) {
+	secretKey := []byte("secret")
+	payload := []byte("payload")
+	now := time.Now()
+
+	_, err := SignPayload(payload, now)
+	require.ErrorIs(t, err, ErrMissingSignatureSecret)
+
+	for _, header := range []string{
+		"",
+		"v1=abcd",
+		fmt.Sprintf("t=%d", now.Unix()),
+		fmt.Sprintf("t=%d,v1=not-hex", now.Unix()),
+		"t=now,v1=abcd",
+		"garbage",
+	} {
+		err := VerifySignature(payload, header, DefaultSignatureTolerance, secretKey)
+		require.ErrorIs(t, err, ErrInvalidSignatureHeader, header)
+	}
+
+	// unknown schemes are ignored
+	signature, err := SignPayload(payload, now, secretKey)
+	require.NoError(t, err)
+	require.NoError(t, VerifySignature(payload, signature+",v0=abcd", DefaultSignatureTolerance, secretKey))
 }
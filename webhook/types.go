@@ -1,5 +1,11 @@
 package webhook
 
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
 const (
 	// ContentTypeJSON is the content type for JSON.
 	ContentTypeJSON = "application/json"
@@ -7,50 +13,100 @@ const (
 	DefaultSignatureHeader = "X-Webhook-Signature"
 )
 
-// Event types
+// Webhook event types, see webhookEvents for the mapping from the internal events.
 const (
-	EventPaymentCreated   = "payment.created"
-	EventPaymentPending   = "payment.pending"
-	EventPaymentCompleted = "payment.completed"
-	EventPaymentFailed    = "payment.failed"
+	EventPaymentCreated       = "payment.created"
+	EventPaymentProcessing    = "payment.processing"
+	EventPaymentSucceeded     = "payment.succeeded"
+	EventPaymentFailed        = "payment.failed"
+	EventPaymentCancelled     = "payment.cancelled"
+	EventPaymentExpired       = "payment.expired"
+	EventPaymentLinkGenerated = "payment.link.generated"
+	EventTransactionCreated   = "transaction.created"
+	EventTransactionUpdated   = "transaction.updated"
+	EventLoyaltyTierChanged   = "loyalty.tier.changed"
+
+	EventWebhookEndpointDisabled = "webhook.endpoint.disabled" // the endpoint is disabled by the circuit breaker
+
+	// Deprecated: use EventPaymentProcessing, "payment.pending" has never been sent.
+	EventPaymentPending = EventPaymentProcessing
+	// Deprecated: use EventPaymentSucceeded, "payment.completed" has never been sent.
+	EventPaymentCompleted = EventPaymentSucceeded
 )
 
 type (
 	// Webhook request payload
 	WebhookRequestPayload struct {
-		Event     string      `json:"event"`                // The name of the event that triggered the webhook
-		EventID   string      `json:"event_id,omitempty"`   // The ID of the event that triggered the webhook
-		WebhookID string      `json:"webhook_id,omitempty"` // The ID of the webhook that triggered the webhook
-		Data      interface{} `json:"data"`                 // The data associated with the event that triggered the webhook
+		APIVersion string      `json:"api_version"`          // The version of the payload, see APIVersion* constants
+		Event      string      `json:"event"`                // The name of the event that triggered the webhook
+		EventID    string      `json:"event_id,omitempty"`   // The ID of the event, the same for all retries and replays: use it to deduplicate
+		WebhookID  string      `json:"webhook_id,omitempty"` // The ID of the webhook endpoint, empty for the default endpoint
+		Data       interface{} `json:"data"`                 // The data associated with the event: EventData since APIVersion20230414
 	}
 
 	// Payment data payload
 	PaymentData struct {
-		PaymentID  string        `json:"payment_id"`      // The ID of the payment
-		ExternalID string        `json:"external_id"`     // The ID of the payment in your system. E.g. the order ID, etc.
-		Amount     uint64        `json:"amount"`          // The amount of the payment in base units (e.g. lamports, etc.)
-		Currency   string        `json:"currency"`        // The currency of the payment: SOL, USDC, or any token mint address.
-		Status     string        `json:"status"`          // The status of the payment: new, pending, completed, or failed.
-		CreatedAt  string        `json:"created_at"`      // The time the payment was created.
-		TxID       string        `json:"tx_id,omitempty"` // The transaction ID of the payment.
-		Err        *PaymentError `json:"error,omitempty"` // The error details if the payment failed.
+		PaymentID         string        `json:"payment_id"`             // The ID of the payment
+		ExternalID        string        `json:"external_id"`            // The ID of the payment in your system. E.g. the order ID, etc.
+		DestinationWallet string        `json:"destination_wallet"`     // The wallet receiving the payment
+		Mint              string        `json:"mint"`                   // The mint address of the payment token
+		Amount            uint64        `json:"amount"`                 // The amount of the payment in base units (e.g. lamports, etc.)
+		Status            string        `json:"status"`                 // The status of the payment: new, pending, completed, failed, canceled or expired.
+		Message           string        `json:"message,omitempty"`      // The message shown to the customer.
+		ReceiptMint       string        `json:"receipt_mint,omitempty"` // The mint address of the receipt NFT sent to the payer.
+		ExpiresAt         *time.Time    `json:"expires_at,omitempty"`   // The time the payment expires.
+		CreatedAt         time.Time     `json:"created_at"`             // The time the payment was created.
+		UpdatedAt         *time.Time    `json:"updated_at,omitempty"`   // The time the payment was updated.
+		Err               *PaymentError `json:"error,omitempty"`        // The error details if the payment failed or expired.
 	}
 
 	// Payment error payload
 	PaymentError struct {
 		Code    string `json:"code"`
 		Message string `json:"message"`
-		Details string `json:"details"`
+		Details string `json:"details,omitempty"`
 	}
 )
 
 // Worker task types
 const (
-	TaskFireEvent = "webhook:fire_event"
+	TaskFireEvent         = "webhook:fire_event"
+	TaskFlushParkedEvents = "webhook:flush_parked_events"
 )
 
 // FireEventPayload is the payload for the webhook:fire_event task.
 type FireEventPayload struct {
-	Event   string      `json:"event"`
-	Payload interface{} `json:"payload"`
+	EndpointID uuid.UUID   `json:"endpoint_id"` // uuid.Nil for the default endpoint
+	EventID    uuid.UUID   `json:"event_id"`    // shared by all the deliveries of the event
+	Event      string      `json:"event"`
+	Payload    interface{} `json:"payload"`        // internal event payload, sent to endpoints pinned to APIVersion20230310
+	Data       *EventData  `json:"data,omitempty"` // typed payload, sent to endpoints pinned to APIVersion20230414
+	PaymentID  *uuid.UUID  `json:"payment_id,omitempty"`
+}
+
+// Event is the webhook event fanned out to the endpoints.
+type Event struct {
+	ID        uuid.UUID
+	Name      string
+	Payload   interface{}
+	Data      *EventData
+	PaymentID *uuid.UUID // the payment the event relates to, if any
+}
+
+// payload returns the payload data for the given API version.
+func (e Event) payload(apiVersion string) interface{} {
+	if apiVersion == APIVersion20230310 || e.Data == nil {
+		return e.Payload
+	}
+	return e.Data
+}
+
+// delivery returns the delivery of the event with the given request body.
+func (e Event) delivery(body []byte) *Delivery {
+	return &Delivery{
+		Event:       e.Name,
+		EventID:     e.ID,
+		RequestBody: body,
+		PaymentID:   e.PaymentID,
+	}
 }
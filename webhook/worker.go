@@ -3,8 +3,10 @@ package webhook
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 
+	"github.com/google/uuid"
 	"github.com/hibiken/asynq"
 )
 
@@ -15,7 +17,8 @@ type (
 	}
 
 	service interface {
-		FireEvent(event string, payload interface{}) error
+		FireEvent(ctx context.Context, endpointID uuid.UUID, event Event) error
+		FlushParkedEvents(ctx context.Context) error
 	}
 )
 
@@ -27,18 +30,38 @@ func NewWorker(svc service) *Worker {
 // Register registers task handlers for email delivery.
 func (w *Worker) Register(mux *asynq.ServeMux) {
 	mux.HandleFunc(TaskFireEvent, w.FireEvent)
+	mux.HandleFunc(TaskFlushParkedEvents, w.FlushParkedEvents)
 }
 
-// FireEvent sends a webhook event to the specified URL.
+// FireEvent sends a webhook event to the endpoint.
+// The task is not retried if the endpoint was deleted or disabled.
 func (w *Worker) FireEvent(ctx context.Context, t *asynq.Task) error {
 	var p FireEventPayload
 	if err := json.Unmarshal(t.Payload(), &p); err != nil {
 		return fmt.Errorf("failed to unmarshal payload: %w", err)
 	}
 
-	if err := w.svc.FireEvent(p.Event, p.Payload); err != nil {
+	if err := w.svc.FireEvent(ctx, p.EndpointID, Event{
+		ID:        p.EventID,
+		Name:      p.Event,
+		Payload:   p.Payload,
+		Data:      p.Data,
+		PaymentID: p.PaymentID,
+	}); err != nil {
+		if errors.Is(err, ErrEndpointNotFound) || errors.Is(err, ErrEndpointDisabled) {
+			return fmt.Errorf("failed to fire webhook event: %v: %w", err, asynq.SkipRetry)
+		}
 		return fmt.Errorf("failed to fire webhook event: %w", err)
 	}
 
 	return nil
 }
+
+// FlushParkedEvents delivers the parked events to the endpoints whose pause is over.
+func (w *Worker) FlushParkedEvents(ctx context.Context, t *asynq.Task) error {
+	if err := w.svc.FlushParkedEvents(ctx); err != nil {
+		return fmt.Errorf("failed to flush parked webhook events: %w", err)
+	}
+
+	return nil
+}
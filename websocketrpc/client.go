@@ -77,7 +77,7 @@ func NewClient(conn *websocket.Conn, opts ...ClientOption) *Client {
 }
 
 // ListenNewTransactions listens for new transactions created event.
-func (c *Client) ListenNewTransactions(event events.EventName, payload interface{}) error {
+func (c *Client) ListenNewTransactions(_ context.Context, event events.EventName, payload interface{}) error {
 	if payload == nil || event != events.TransactionCreated {
 		return nil
 	}
@@ -93,7 +93,7 @@ func (c *Client) ListenNewTransactions(event events.EventName, payload interface
 }
 
 // ListenTransactionUpdates listens for transaction updates.
-func (c *Client) ListenTransactionUpdates(event events.EventName, payload interface{}) error {
+func (c *Client) ListenTransactionUpdates(_ context.Context, event events.EventName, payload interface{}) error {
 	if payload == nil || event != events.TransactionUpdated {
 		return nil
 	}